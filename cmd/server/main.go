@@ -81,6 +81,10 @@ func main() {
 	var noIncognito bool
 	var useIncognito bool
 	var vertexImport string
+	var encryptAuthFiles bool
+	var decryptAuthFiles bool
+	var replayID string
+	var replayOptions cmd.ReplayOptions
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&login, "login", false, "Login Google Account")
@@ -102,6 +106,13 @@ func main() {
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.BoolVar(&encryptAuthFiles, "encrypt-auth-files", false, "Encrypt existing auth files in auth-dir and exit")
+	flag.BoolVar(&decryptAuthFiles, "decrypt-auth-files", false, "Decrypt encrypted auth files in auth-dir and exit")
+	flag.StringVar(&replayID, "replay", "", "Replay a request from the request log of the running server by request ID and diff it against the capture")
+	flag.StringVar(&replayOptions.Model, "replay-model", "", "Model to use for --replay (default: the captured model)")
+	flag.StringVar(&replayOptions.Provider, "replay-provider", "", "Provider to use for --replay (default: normal routing)")
+	flag.StringVar(&replayOptions.AuthIndex, "replay-auth-index", "", "Auth index of the credential to use for --replay")
+	flag.BoolVar(&replayOptions.ShowAll, "replay-full", false, "Show unchanged lines in the --replay diff")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -203,20 +214,47 @@ func main() {
 		NoBrowser: noBrowser,
 	}
 
-	// ALWAYS use the local file store for authentication persistence.
-	// This simplified architecture does not support remote Git/Postgres/S3 stores.
-	fileStore := sdkAuth.NewFileTokenStore()
-	fileStore.SetBaseDir(cfg.AuthDir)
-	sdkAuth.RegisterTokenStore(fileStore)
+	// Credentials are persisted as files in auth-dir, or in a local SQLite database when
+	// storage.backend is "sqlite". Remote Git/Postgres/S3 stores are not supported.
+	if cfg.Storage.UsesSQLite() {
+		sqliteStore, errStore := sdkAuth.NewSQLiteTokenStore(cfg.Storage.SQLitePath)
+		if errStore != nil {
+			log.Errorf("failed to open sqlite token store: %v", errStore)
+			return
+		}
+		sqliteStore.SetBaseDir(cfg.AuthDir)
+		sdkAuth.RegisterTokenStore(sqliteStore)
+	} else {
+		fileStore := sdkAuth.NewFileTokenStore()
+		fileStore.SetBaseDir(cfg.AuthDir)
+		sdkAuth.RegisterTokenStore(fileStore)
+	}
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register()
 
+	if encryptAuthFiles || decryptAuthFiles {
+		cmd.DoMigrateAuthEncryption(cfg, decryptAuthFiles)
+		return
+	}
+	if err = cmd.ConfigureAuthEncryption(cfg); err != nil {
+		log.Errorf("failed to configure auth file encryption: %v", err)
+		return
+	}
+
 	// Handle different command modes based on the provided flags.
 
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
+	} else if replayID != "" {
+		// Replay through the running server; MANAGEMENT_PASSWORD or --password authenticates
+		replayOptions.RequestID = replayID
+		replayOptions.ManagementKey = strings.TrimSpace(os.Getenv("MANAGEMENT_PASSWORD"))
+		if replayOptions.ManagementKey == "" {
+			replayOptions.ManagementKey = password
+		}
+		cmd.DoReplay(cfg, replayOptions)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
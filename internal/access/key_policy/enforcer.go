@@ -0,0 +1,318 @@
+// Package keypolicy enforces per-client API key policies: model allowlists,
+// request rate limits and token budgets. Token consumption is fed back from the
+// usage plugin pipeline, so budgets reflect what upstream providers reported.
+package keypolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cliproxy/internal/util"
+	coreusage "cliproxy/sdk/cliproxy/usage"
+	sdkconfig "cliproxy/sdk/config"
+)
+
+func init() {
+	coreusage.RegisterPlugin(defaultEnforcer)
+}
+
+var defaultEnforcer = NewEnforcer()
+
+// Default returns the shared enforcer used by the API handlers.
+func Default() *Enforcer { return defaultEnforcer }
+
+// Enforcer evaluates API key policies and tracks per-key consumption in memory.
+// Counters are not persisted and reset when the process restarts.
+type Enforcer struct {
+	mu       sync.Mutex
+	policies map[string]sdkconfig.APIKeyPolicy
+	usage    map[string]*keyUsage
+	now      func() time.Time
+}
+
+// keyUsage holds the rolling counters for a single API key.
+type keyUsage struct {
+	requests    []time.Time
+	day         string
+	dayTokens   int64
+	month       string
+	monthTokens int64
+}
+
+// Usage is a read-only view of the counters tracked for a key.
+type Usage struct {
+	APIKey            string `json:"api-key"`
+	RequestsLastMin   int    `json:"requests-last-minute"`
+	TokensToday       int64  `json:"tokens-today"`
+	TokensThisMonth   int64  `json:"tokens-this-month"`
+	RequestsPerMinute int    `json:"requests-per-minute,omitempty"`
+	TokensPerDay      int64  `json:"tokens-per-day,omitempty"`
+	TokensPerMonth    int64  `json:"tokens-per-month,omitempty"`
+}
+
+// NewEnforcer constructs an enforcer without any policies.
+func NewEnforcer() *Enforcer {
+	return &Enforcer{
+		policies: make(map[string]sdkconfig.APIKeyPolicy),
+		usage:    make(map[string]*keyUsage),
+		now:      time.Now,
+	}
+}
+
+// SetPolicies replaces the active policy set. Counters for keys that keep a policy are preserved.
+func (e *Enforcer) SetPolicies(policies []sdkconfig.APIKeyPolicy) {
+	if e == nil {
+		return
+	}
+	next := make(map[string]sdkconfig.APIKeyPolicy, len(policies))
+	for _, policy := range policies {
+		key := strings.TrimSpace(policy.APIKey)
+		if key == "" {
+			continue
+		}
+		if _, exists := next[key]; exists {
+			continue
+		}
+		next[key] = policy
+	}
+	e.mu.Lock()
+	e.policies = next
+	for key := range e.usage {
+		if _, ok := next[key]; !ok {
+			delete(e.usage, key)
+		}
+	}
+	e.mu.Unlock()
+}
+
+// CheckModel verifies that apiKey may request model without consuming rate-limit capacity.
+func (e *Enforcer) CheckModel(apiKey, model string) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	policy, ok := e.policies[apiKey]
+	e.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return checkModel(policy, model)
+}
+
+// Tags returns the credential tags apiKey is pinned to. Requests made with the key are only
+// routed to credentials carrying all of them.
+func (e *Enforcer) Tags(apiKey string) []string {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	policy, ok := e.policies[apiKey]
+	if !ok || len(policy.Tags) == 0 {
+		return nil
+	}
+	return append([]string(nil), policy.Tags...)
+}
+
+// Admit verifies the full policy for apiKey and, when the request is allowed,
+// counts it against the per-minute request limit.
+func (e *Enforcer) Admit(apiKey, model string) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	policy, ok := e.policies[apiKey]
+	if !ok {
+		return nil
+	}
+	if err := checkModel(policy, model); err != nil {
+		return err
+	}
+
+	now := e.now()
+	usage := e.usageFor(apiKey, now)
+	if policy.TokensPerMonth > 0 && usage.monthTokens >= policy.TokensPerMonth {
+		return &Error{
+			Status:     http.StatusTooManyRequests,
+			Code:       "monthly_token_budget_exceeded",
+			Message:    fmt.Sprintf("monthly token budget of %d exhausted", policy.TokensPerMonth),
+			RetryAfter: nextMonth(now).Sub(now),
+		}
+	}
+	if policy.TokensPerDay > 0 && usage.dayTokens >= policy.TokensPerDay {
+		return &Error{
+			Status:     http.StatusTooManyRequests,
+			Code:       "daily_token_budget_exceeded",
+			Message:    fmt.Sprintf("daily token budget of %d exhausted", policy.TokensPerDay),
+			RetryAfter: nextDay(now).Sub(now),
+		}
+	}
+	if policy.RequestsPerMinute > 0 {
+		cutoff := now.Add(-time.Minute)
+		kept := usage.requests[:0]
+		for _, ts := range usage.requests {
+			if ts.After(cutoff) {
+				kept = append(kept, ts)
+			}
+		}
+		usage.requests = kept
+		if len(usage.requests) >= policy.RequestsPerMinute {
+			return &Error{
+				Status:     http.StatusTooManyRequests,
+				Code:       "rate_limit_exceeded",
+				Message:    fmt.Sprintf("rate limit of %d requests per minute exceeded", policy.RequestsPerMinute),
+				RetryAfter: usage.requests[0].Add(time.Minute).Sub(now),
+			}
+		}
+		usage.requests = append(usage.requests, now)
+	}
+	return nil
+}
+
+// HandleUsage implements coreusage.Plugin by charging reported tokens to the client key.
+func (e *Enforcer) HandleUsage(_ context.Context, record coreusage.Record) {
+	if e == nil || record.APIKey == "" {
+		return
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	if tokens <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.policies[record.APIKey]; !ok {
+		return
+	}
+	usage := e.usageFor(record.APIKey, e.now())
+	usage.dayTokens += tokens
+	usage.monthTokens += tokens
+}
+
+// Snapshot returns the current counters for every key with a policy.
+func (e *Enforcer) Snapshot() []Usage {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	cutoff := now.Add(-time.Minute)
+	out := make([]Usage, 0, len(e.policies))
+	for key, policy := range e.policies {
+		usage := e.usageFor(key, now)
+		recent := 0
+		for _, ts := range usage.requests {
+			if ts.After(cutoff) {
+				recent++
+			}
+		}
+		out = append(out, Usage{
+			APIKey:            util.HideAPIKey(key),
+			RequestsLastMin:   recent,
+			TokensToday:       usage.dayTokens,
+			TokensThisMonth:   usage.monthTokens,
+			RequestsPerMinute: policy.RequestsPerMinute,
+			TokensPerDay:      policy.TokensPerDay,
+			TokensPerMonth:    policy.TokensPerMonth,
+		})
+	}
+	return out
+}
+
+// usageFor returns the counters for key, rolling day and month buckets forward. Callers hold e.mu.
+func (e *Enforcer) usageFor(key string, now time.Time) *keyUsage {
+	usage, ok := e.usage[key]
+	if !ok {
+		usage = &keyUsage{}
+		e.usage[key] = usage
+	}
+	if day := now.Format("2006-01-02"); usage.day != day {
+		usage.day = day
+		usage.dayTokens = 0
+	}
+	if month := now.Format("2006-01"); usage.month != month {
+		usage.month = month
+		usage.monthTokens = 0
+	}
+	return usage
+}
+
+func checkModel(policy sdkconfig.APIKeyPolicy, model string) error {
+	if len(policy.AllowedModels) == 0 {
+		return nil
+	}
+	candidate := strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range policy.AllowedModels {
+		if util.MatchWildcard(strings.ToLower(pattern), candidate) {
+			return nil
+		}
+	}
+	return &Error{
+		Status:  http.StatusForbidden,
+		Code:    "model_not_allowed",
+		Message: fmt.Sprintf("model %s is not allowed for this API key", model),
+	}
+}
+
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+}
+
+// Error reports a policy violation. It carries the HTTP status and an optional
+// Retry-After hint for the client.
+type Error struct {
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+// Error renders the violation as an OpenAI-style JSON error body.
+func (e *Error) Error() string {
+	errType := "rate_limit_error"
+	if e.Status == http.StatusForbidden {
+		errType = "permission_error"
+	}
+	payload := map[string]any{
+		"error": map[string]any{
+			"code":    e.Code,
+			"message": e.Message,
+			"type":    errType,
+		},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return e.Message
+	}
+	return string(data)
+}
+
+// StatusCode implements the status accessor used by the API handlers.
+func (e *Error) StatusCode() int { return e.Status }
+
+// Headers exposes Retry-After for throttled requests.
+func (e *Error) Headers() http.Header {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	if e.RetryAfter > 0 {
+		headers.Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	return headers
+}
@@ -0,0 +1,105 @@
+package keypolicy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	coreusage "cliproxy/sdk/cliproxy/usage"
+	sdkconfig "cliproxy/sdk/config"
+)
+
+func newTestEnforcer(now *time.Time, policies ...sdkconfig.APIKeyPolicy) *Enforcer {
+	e := NewEnforcer()
+	e.now = func() time.Time { return *now }
+	e.SetPolicies(policies)
+	return e
+}
+
+func statusOf(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	perr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("unexpected error type %T", err)
+	}
+	return perr.StatusCode()
+}
+
+func TestEnforcer_AllowedModels(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	e := newTestEnforcer(&now, sdkconfig.APIKeyPolicy{APIKey: "k", AllowedModels: []string{"gemini-2.5-*"}})
+
+	if err := e.Admit("k", "Gemini-2.5-Pro"); err != nil {
+		t.Fatalf("expected model to be allowed, got %v", err)
+	}
+	if status := statusOf(t, e.CheckModel("k", "gpt-5")); status != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", status, http.StatusForbidden)
+	}
+	if err := e.Admit("other", "gpt-5"); err != nil {
+		t.Fatalf("keys without a policy must pass, got %v", err)
+	}
+}
+
+func TestEnforcer_RequestsPerMinute(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	e := newTestEnforcer(&now, sdkconfig.APIKeyPolicy{APIKey: "k", RequestsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := e.Admit("k", "m"); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+	}
+	now = now.Add(20 * time.Second)
+	err := e.Admit("k", "m")
+	if status := statusOf(t, err); status != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	if got := err.(*Error).Headers().Get("Retry-After"); got != "40" {
+		t.Fatalf("Retry-After = %q, want 40", got)
+	}
+	now = now.Add(41 * time.Second)
+	if err := e.Admit("k", "m"); err != nil {
+		t.Fatalf("expected window to slide, got %v", err)
+	}
+}
+
+func TestEnforcer_TokenBudgets(t *testing.T) {
+	now := time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+	e := newTestEnforcer(&now, sdkconfig.APIKeyPolicy{APIKey: "k", TokensPerDay: 100, TokensPerMonth: 150})
+
+	e.HandleUsage(context.Background(), coreusage.Record{APIKey: "k", Detail: coreusage.Detail{InputTokens: 60, OutputTokens: 40}})
+	if status := statusOf(t, e.Admit("k", "m")); status != http.StatusTooManyRequests {
+		t.Fatalf("daily budget: status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+
+	// A new day in a new month resets both counters.
+	now = now.Add(2 * time.Hour)
+	if err := e.Admit("k", "m"); err != nil {
+		t.Fatalf("expected budgets to reset, got %v", err)
+	}
+	e.HandleUsage(context.Background(), coreusage.Record{APIKey: "k", Detail: coreusage.Detail{TotalTokens: 90}})
+	now = now.Add(24 * time.Hour)
+	e.HandleUsage(context.Background(), coreusage.Record{APIKey: "k", Detail: coreusage.Detail{TotalTokens: 70}})
+	err := e.Admit("k", "m")
+	if status := statusOf(t, err); status != http.StatusTooManyRequests {
+		t.Fatalf("monthly budget: status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	if code := err.(*Error).Code; code != "monthly_token_budget_exceeded" {
+		t.Fatalf("code = %q, want monthly_token_budget_exceeded", code)
+	}
+}
+
+func TestEnforcer_Tags(t *testing.T) {
+	now := time.Now()
+	e := newTestEnforcer(&now, sdkconfig.APIKeyPolicy{APIKey: "k", Tags: []string{"production"}})
+	if got := e.Tags("k"); len(got) != 1 || got[0] != "production" {
+		t.Fatalf("Tags(k) = %v", got)
+	}
+	if got := e.Tags("other"); got != nil {
+		t.Fatalf("Tags(other) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,122 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	internalBatch "cliproxy/internal/batch"
+	"cliproxy/internal/constant"
+	"cliproxy/internal/interfaces"
+	"cliproxy/internal/logging"
+	"cliproxy/sdk/api/handlers"
+	"cliproxy/sdk/api/handlers/openai"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Executor runs batch request lines through the same handler pipeline as live requests.
+type Executor struct {
+	base      *handlers.BaseAPIHandler
+	chat      *openai.OpenAIAPIHandler
+	responses *openai.OpenAIResponsesAPIHandler
+}
+
+// NewExecutor creates an executor that routes batch lines through base.
+func NewExecutor(base *handlers.BaseAPIHandler) *Executor {
+	return &Executor{
+		base:      base,
+		chat:      openai.NewOpenAIAPIHandler(base),
+		responses: openai.NewOpenAIResponsesAPIHandler(base),
+	}
+}
+
+// Execute runs one line as the batch owner. Handler errors become the line's response, with the
+// same status and body a live request would have received.
+func (e *Executor) Execute(ctx context.Context, b internalBatch.StoredBatch, line internalBatch.RequestLine) internalBatch.Response {
+	requestID := logging.GenerateRequestID()
+	ctx = logging.WithRequestID(ctx, requestID)
+	apiKey, ok := e.principal(b.Owner)
+	c, recorder := detachedContext(ctx, b, apiKey, line.URL)
+	logging.SetGinRequestID(c, requestID)
+	if !ok {
+		e.base.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusUnauthorized, Error: errOwnerKeyRemoved})
+		return internalBatch.Response{StatusCode: recorder.Code, Body: recorder.Body.Bytes(), RequestID: requestID}
+	}
+
+	body, _ := sjson.DeleteBytes(line.Body, "stream")
+	modelName := gjson.GetBytes(body, "model").String()
+
+	var handler interfaces.APIHandler = e.chat
+	if line.URL == internalBatch.EndpointResponses {
+		handler = e.responses
+	}
+	cliCtx, cliCancel := e.base.GetContextWithCancel(handler, c, ctx)
+	defer cliCancel()
+
+	var resp []byte
+	var errMsg *interfaces.ErrorMessage
+	if line.URL == internalBatch.EndpointEmbeddings {
+		resp, errMsg = e.base.ExecuteEmbedWithAuthManager(cliCtx, constant.OpenAIEmbedding, modelName, body)
+	} else {
+		resp, errMsg = e.base.ExecuteWithAuthManager(cliCtx, handler.HandlerType(), modelName, body, "")
+	}
+	if errMsg == nil {
+		return internalBatch.Response{StatusCode: http.StatusOK, Body: resp, RequestID: requestID}
+	}
+
+	e.base.WriteErrorResponse(c, errMsg)
+	out := internalBatch.Response{StatusCode: recorder.Code, Body: recorder.Body.Bytes(), RequestID: requestID}
+	if seconds, err := strconv.Atoi(recorder.Header().Get("Retry-After")); err == nil && seconds > 0 {
+		out.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return out
+}
+
+// systemPrincipal is the API key identity of requests authenticated with the local password.
+const systemPrincipal = "system-internal"
+
+var errOwnerKeyRemoved = errors.New("the API key that created this batch is no longer configured")
+
+// principal returns the configured API key whose OwnerOf is owner. Batches store only the hash,
+// so the key is looked up among the current keys; a removed key can no longer run its batches.
+func (e *Executor) principal(owner string) (string, bool) {
+	if owner == "" {
+		return "", true
+	}
+	candidates := []string{systemPrincipal}
+	if cfg := e.base.Cfg; cfg != nil {
+		candidates = append(candidates, cfg.APIKeys...)
+		for _, provider := range cfg.Access.Providers {
+			candidates = append(candidates, provider.APIKeys...)
+		}
+	}
+	for _, key := range candidates {
+		if key != "" && internalBatch.OwnerOf(key) == owner {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// detachedContext builds a gin context carrying the batch owner's API key, for handler code
+// that reads the API key and request headers from the live request.
+func detachedContext(ctx context.Context, b internalBatch.StoredBatch, apiKey, path string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	req := httptest.NewRequest(http.MethodPost, path, nil).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if b.ProxyTags != "" {
+		req.Header.Set("X-Proxy-Tags", b.ProxyTags)
+	}
+	c.Request = req
+	if apiKey != "" {
+		c.Set("apiKey", apiKey)
+	}
+	return c, recorder
+}
@@ -0,0 +1,259 @@
+// Package batch provides the OpenAI-compatible /v1/files and /v1/batches handlers.
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	internalBatch "cliproxy/internal/batch"
+	"cliproxy/sdk/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxFileSizeMB caps uploaded batch input files when no limit is configured.
+const DefaultMaxFileSizeMB = 100
+
+// Handler serves the Files and Batch API for the client identified by its API key. Clients only
+// see the files and batches they created.
+type Handler struct {
+	store  *internalBatch.Store
+	runner *internalBatch.Runner
+
+	maxFileBytes atomic.Int64
+}
+
+// NewHandler creates a handler backed by store and runner.
+func NewHandler(store *internalBatch.Store, runner *internalBatch.Runner) *Handler {
+	h := &Handler{store: store, runner: runner}
+	h.SetMaxFileSizeMB(0)
+	return h
+}
+
+// SetMaxFileSizeMB changes the upload size limit; values below 1 use the default.
+func (h *Handler) SetMaxFileSizeMB(mb int) {
+	if mb < 1 {
+		mb = DefaultMaxFileSizeMB
+	}
+	h.maxFileBytes.Store(int64(mb) << 20)
+}
+
+// owner returns the stored identity of the requesting client's API key.
+func owner(c *gin.Context) string {
+	return internalBatch.OwnerOf(c.GetString("apiKey"))
+}
+
+func writeError(c *gin.Context, status int, message string) {
+	errType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errType = "server_error"
+	}
+	c.JSON(status, handlers.ErrorResponse{Error: handlers.ErrorDetail{Message: message, Type: errType}})
+}
+
+// UploadFile handles POST /v1/files. Only the "batch" purpose is accepted.
+func (h *Handler) UploadFile(c *gin.Context) {
+	maxBytes := h.maxFileBytes.Load()
+	// Leave room for the multipart envelope; the file itself is checked against maxBytes below.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+	purpose := c.PostForm("purpose")
+	if purpose != internalBatch.PurposeBatch {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid purpose %q: only %q is supported.", purpose, internalBatch.PurposeBatch))
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the %d MB limit.", maxBytes>>20))
+			return
+		}
+		writeError(c, http.StatusBadRequest, "Missing required parameter: 'file'.")
+		return
+	}
+	if header.Size > maxBytes {
+		writeError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the %d MB limit.", maxBytes>>20))
+		return
+	}
+	src, err := header.Open()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Failed to read file: %v", err))
+		return
+	}
+	content, err := io.ReadAll(src)
+	_ = src.Close()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Failed to read file: %v", err))
+		return
+	}
+	file, err := h.store.CreateFile(owner(c), header.Filename, purpose, content)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// ListFiles handles GET /v1/files.
+func (h *Handler) ListFiles(c *gin.Context) {
+	files := h.store.ListFiles(owner(c), c.Query("purpose"))
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": files, "has_more": false})
+}
+
+func (h *Handler) ownFile(c *gin.Context) (internalBatch.StoredFile, bool) {
+	id := c.Param("id")
+	file, ok := h.store.GetFile(id)
+	if !ok || file.Owner != owner(c) {
+		writeError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", id))
+		return internalBatch.StoredFile{}, false
+	}
+	return file, true
+}
+
+// GetFile handles GET /v1/files/:id.
+func (h *Handler) GetFile(c *gin.Context) {
+	if file, ok := h.ownFile(c); ok {
+		c.JSON(http.StatusOK, file.File)
+	}
+}
+
+// GetFileContent handles GET /v1/files/:id/content.
+func (h *Handler) GetFileContent(c *gin.Context) {
+	file, ok := h.ownFile(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "application/jsonl")
+	c.File(h.store.FilePath(file.ID))
+}
+
+// DeleteFile handles DELETE /v1/files/:id.
+func (h *Handler) DeleteFile(c *gin.Context) {
+	file, ok := h.ownFile(c)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteFile(file.ID); err != nil {
+		writeError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": file.ID, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches. The batch runs with the caller's API key and
+// X-Proxy-Tags, so key policies and credential pools apply as for direct requests.
+func (h *Handler) CreateBatch(c *gin.Context) {
+	var req struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	switch req.Endpoint {
+	case internalBatch.EndpointChatCompletions, internalBatch.EndpointResponses, internalBatch.EndpointEmbeddings:
+	default:
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid endpoint %q: supported endpoints are %s, %s and %s.",
+			req.Endpoint, internalBatch.EndpointChatCompletions, internalBatch.EndpointResponses, internalBatch.EndpointEmbeddings))
+		return
+	}
+	if req.CompletionWindow != internalBatch.CompletionWindow {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid completion_window %q: only %q is supported.", req.CompletionWindow, internalBatch.CompletionWindow))
+		return
+	}
+	if len(req.Metadata) > 16 {
+		writeError(c, http.StatusBadRequest, "metadata supports at most 16 keys.")
+		return
+	}
+	file, ok := h.store.GetFile(req.InputFileID)
+	if !ok || file.Owner != owner(c) {
+		writeError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", req.InputFileID))
+		return
+	}
+	if file.Purpose != internalBatch.PurposeBatch {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("File %s has purpose %q; batches need %q.", file.ID, file.Purpose, internalBatch.PurposeBatch))
+		return
+	}
+
+	b, err := h.runner.Create(owner(c), c.GetHeader("X-Proxy-Tags"), file.ID, req.Endpoint, req.Metadata)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, b.Batch)
+}
+
+// ListBatches handles GET /v1/batches with OpenAI's after/limit pagination.
+func (h *Handler) ListBatches(c *gin.Context) {
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			writeError(c, http.StatusBadRequest, "limit must be between 1 and 100.")
+			return
+		}
+		limit = n
+	}
+	list := h.store.ListBatches(owner(c))
+	if after := strings.TrimSpace(c.Query("after")); after != "" {
+		for i := range list {
+			if list[i].ID == after {
+				list = list[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(list) > limit
+	if hasMore {
+		list = list[:limit]
+	}
+	resp := gin.H{"object": "list", "data": list, "first_id": nil, "last_id": nil, "has_more": hasMore}
+	if len(list) > 0 {
+		resp["first_id"] = list[0].ID
+		resp["last_id"] = list[len(list)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ownBatch(c *gin.Context) (internalBatch.StoredBatch, bool) {
+	id := c.Param("id")
+	b, ok := h.store.GetBatch(id)
+	if !ok || b.Owner != owner(c) {
+		writeError(c, http.StatusNotFound, fmt.Sprintf("No such Batch object: %s", id))
+		return internalBatch.StoredBatch{}, false
+	}
+	return b, true
+}
+
+// GetBatch handles GET /v1/batches/:id.
+func (h *Handler) GetBatch(c *gin.Context) {
+	if b, ok := h.ownBatch(c); ok {
+		c.JSON(http.StatusOK, b.Batch)
+	}
+}
+
+// CancelBatch handles POST /v1/batches/:id/cancel.
+func (h *Handler) CancelBatch(c *gin.Context) {
+	b, ok := h.ownBatch(c)
+	if !ok {
+		return
+	}
+	b, err := h.runner.Cancel(b.ID)
+	if errors.Is(err, internalBatch.ErrNotCancellable) {
+		writeError(c, http.StatusConflict, fmt.Sprintf("Cannot cancel a batch with status '%s'.", b.Status))
+		return
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, b.Batch)
+}
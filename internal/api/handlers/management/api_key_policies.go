@@ -0,0 +1,67 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	keypolicy "cliproxy/internal/access/key_policy"
+	"cliproxy/internal/config"
+)
+
+// api-key-policies: []APIKeyPolicy
+func (h *Handler) GetAPIKeyPolicies(c *gin.Context) {
+	c.JSON(200, gin.H{"api-key-policies": h.cfg.APIKeyPolicies})
+}
+
+func (h *Handler) PutAPIKeyPolicies(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.APIKeyPolicy
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.APIKeyPolicy `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.APIKeyPolicies = arr
+	h.cfg.SanitizeAPIKeyPolicies()
+	h.persist(c)
+}
+
+func (h *Handler) DeleteAPIKeyPolicy(c *gin.Context) {
+	if val := c.Query("api-key"); val != "" {
+		out := make([]config.APIKeyPolicy, 0, len(h.cfg.APIKeyPolicies))
+		for _, v := range h.cfg.APIKeyPolicies {
+			if v.APIKey != val {
+				out = append(out, v)
+			}
+		}
+		h.cfg.APIKeyPolicies = out
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, err := fmt.Sscanf(idxStr, "%d", &idx)
+		if err == nil && idx >= 0 && idx < len(h.cfg.APIKeyPolicies) {
+			h.cfg.APIKeyPolicies = append(h.cfg.APIKeyPolicies[:idx], h.cfg.APIKeyPolicies[idx+1:]...)
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing api-key or index"})
+}
+
+// GetAPIKeyPolicyUsage reports the live request and token counters for keys with a policy.
+func (h *Handler) GetAPIKeyPolicyUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"usage": keypolicy.Default().Snapshot()})
+}
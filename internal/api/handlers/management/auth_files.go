@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
@@ -24,6 +25,7 @@ import (
 	geminiAuth "cliproxy/internal/auth/gemini"
 	iflowauth "cliproxy/internal/auth/iflow"
 	"cliproxy/internal/auth/qwen"
+	"cliproxy/internal/authcrypt"
 	"cliproxy/internal/interfaces"
 	"cliproxy/internal/misc"
 	"cliproxy/internal/registry"
@@ -334,7 +336,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 
 			// Read file to get type field
 			full := filepath.Join(h.cfg.AuthDir, name)
-			if data, errRead := os.ReadFile(full); errRead == nil {
+			if data, errRead := authcrypt.ReadFile(full); errRead == nil {
 				typeValue := gjson.GetBytes(data, "type").String()
 				emailValue := gjson.GetBytes(data, "email").String()
 				fileData["type"] = typeValue
@@ -502,7 +504,7 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		return
 	}
 	full := filepath.Join(h.cfg.AuthDir, name)
-	data, err := os.ReadFile(full)
+	data, err := authcrypt.ReadFile(full)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
@@ -534,13 +536,13 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 				dst = abs
 			}
 		}
-		if errSave := c.SaveUploadedFile(file, dst); errSave != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errSave)})
+		data, errRead := readUploadedFile(file)
+		if errRead != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read uploaded file: %v", errRead)})
 			return
 		}
-		data, errRead := os.ReadFile(dst)
-		if errRead != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read saved file: %v", errRead)})
+		if errSave := authcrypt.WriteFile(dst, data, 0o600); errSave != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errSave)})
 			return
 		}
 		if errReg := h.registerAuthFromFile(ctx, dst, data); errReg != nil {
@@ -570,7 +572,7 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			dst = abs
 		}
 	}
-	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+	if errWrite := authcrypt.WriteFile(dst, data, 0o600); errWrite != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
 		return
 	}
@@ -581,6 +583,16 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// readUploadedFile returns the contents of a multipart upload without staging it on disk.
+func readUploadedFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = src.Close() }()
+	return io.ReadAll(src)
+}
+
 // Delete auth files: single by name or all
 func (h *Handler) DeleteAuthFile(c *gin.Context) {
 	if h.authManager == nil {
@@ -675,7 +687,7 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	}
 	if data == nil {
 		var err error
-		data, err = os.ReadFile(path)
+		data, err = authcrypt.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read auth file: %w", err)
 		}
@@ -786,7 +798,46 @@ func (h *Handler) saveTokenRecord(ctx context.Context, record *coreauth.Auth) (s
 	if store == nil {
 		return "", fmt.Errorf("token store unavailable")
 	}
-	return store.Save(ctx, record)
+	savedPath, err := store.Save(ctx, record)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := store.(*sdkAuth.SQLiteTokenStore); ok {
+		// No file reaches auth-dir, so the watcher will not register the new credential.
+		if errRegister := h.registerStoredRecord(ctx, record); errRegister != nil {
+			log.Warnf("failed to register stored credential %s: %v", record.ID, errRegister)
+		}
+	}
+	return savedPath, nil
+}
+
+// registerStoredRecord adds a credential saved to a database-backed token store to the auth manager.
+func (h *Handler) registerStoredRecord(ctx context.Context, record *coreauth.Auth) error {
+	if h.authManager == nil {
+		return nil
+	}
+	auth := record.Clone()
+	auth.Storage = nil
+	if provider, _ := auth.Metadata["type"].(string); provider != "" {
+		auth.Provider = provider
+	}
+	if auth.Label == "" {
+		auth.Label = auth.Provider
+		if email, ok := auth.Metadata["email"].(string); ok && email != "" {
+			auth.Label = email
+		}
+	}
+	auth.Status = coreauth.StatusActive
+	auth.Attributes = map[string]string{"source": "sqlite:" + auth.ID}
+	auth.CreatedAt, auth.UpdatedAt = time.Now(), time.Now()
+	if existing, ok := h.authManager.GetByID(auth.ID); ok {
+		auth.CreatedAt = existing.CreatedAt
+		auth.Runtime = existing.Runtime
+		_, err := h.authManager.Update(ctx, auth)
+		return err
+	}
+	_, err := h.authManager.Register(ctx, auth)
+	return err
 }
 
 func (h *Handler) RequestAnthropicToken(c *gin.Context) {
@@ -2,11 +2,64 @@ package management
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"cliproxy/internal/config"
+	"cliproxy/internal/util"
 )
 
+// nonSecretCredentialKeys lists provider credential keys returned unmasked.
+var nonSecretCredentialKeys = map[string]struct{}{
+	"base_url": {}, "base-url": {}, "name": {}, "project": {}, "project_id": {}, "location": {}, "region": {},
+}
+
+// maskCredential hides a secret credential value for API responses.
+func maskCredential(key, value string) string {
+	if _, ok := nonSecretCredentialKeys[strings.ToLower(key)]; ok {
+		return value
+	}
+	if len(value) <= 2 {
+		return strings.Repeat("*", len(value))
+	}
+	return util.HideAPIKey(value)
+}
+
+// maskedProvider returns a copy of p with secret credentials masked.
+func maskedProvider(p config.UnifiedProvider) config.UnifiedProvider {
+	if len(p.Credentials) > 0 {
+		masked := make(map[string]string, len(p.Credentials))
+		for k, v := range p.Credentials {
+			masked[k] = maskCredential(k, v)
+		}
+		p.Credentials = masked
+	}
+	return p
+}
+
+// restoreMaskedCredentials keeps stored secrets when a client echoes back masked values.
+func restoreMaskedCredentials(incoming *config.UnifiedProvider, existing *config.UnifiedProvider) {
+	if incoming == nil || existing == nil {
+		return
+	}
+	for k, v := range incoming.Credentials {
+		if old, ok := existing.Credentials[k]; ok && v != old && v == maskCredential(k, old) {
+			incoming.Credentials[k] = old
+		}
+	}
+}
+
+// findProvider returns the index of the provider with the given ID, or -1.
+func (h *Handler) findProvider(id string) int {
+	id = strings.TrimSpace(id)
+	for i := range h.cfg.Providers {
+		if h.cfg.Providers[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // GetSchedulingConfig returns the current scheduling strategy configuration.
 func (h *Handler) GetSchedulingConfig(c *gin.Context) {
 	if h.cfg == nil {
@@ -16,42 +69,208 @@ func (h *Handler) GetSchedulingConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, h.cfg.Scheduling)
 }
 
-// PutSchedulingConfig updates the scheduling strategy configuration.
+// PutSchedulingConfig updates the scheduling strategy configuration. The running selector picks
+// up the change through the config reload.
 func (h *Handler) PutSchedulingConfig(c *gin.Context) {
 	var body config.SchedulingConfig
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body", "details": err.Error()})
 		return
 	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	if err := config.ValidateScheduling(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduling config", "details": err.Error()})
+		return
+	}
 
 	h.cfg.Scheduling = body
+	h.cfg.SanitizeProviders()
 	h.persist(c)
 }
 
-// GetUnifiedProviders returns the list of unified providers.
+// GetUnifiedProviders returns the list of unified providers with secrets masked.
 func (h *Handler) GetUnifiedProviders(c *gin.Context) {
 	if h.cfg == nil {
 		c.JSON(http.StatusOK, []config.UnifiedProvider{})
 		return
 	}
-	c.JSON(http.StatusOK, h.cfg.Providers)
+	out := make([]config.UnifiedProvider, 0, len(h.cfg.Providers))
+	for _, p := range h.cfg.Providers {
+		out = append(out, maskedProvider(p))
+	}
+	c.JSON(http.StatusOK, out)
 }
 
-// PutUnifiedProviders updates the list of unified providers.
+// PutUnifiedProviders replaces the list of unified providers after validating every entry.
+// Masked credentials echoed back from GetUnifiedProviders keep their stored values.
 func (h *Handler) PutUnifiedProviders(c *gin.Context) {
 	var body []config.UnifiedProvider
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body, expected array of providers", "details": err.Error()})
 		return
 	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	seen := make(map[string]struct{}, len(body))
+	for i := range body {
+		p := &body[i]
+		p.ID = strings.TrimSpace(p.ID)
+		if p.ID != "" {
+			if _, dup := seen[p.ID]; dup {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate provider id", "id": p.ID})
+				return
+			}
+			seen[p.ID] = struct{}{}
+			if idx := h.findProvider(p.ID); idx >= 0 {
+				restoreMaskedCredentials(p, &h.cfg.Providers[idx])
+			}
+		}
+		if err := config.ValidateUnifiedProvider(p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider", "index": i, "id": p.ID, "details": err.Error()})
+			return
+		}
+	}
 
 	h.cfg.Providers = body
-	h.cfg.SanitizeProviders() // Ensure validation logic runs
+	h.cfg.SanitizeProviders()
+	h.persist(c)
+}
+
+// GetUnifiedProvider returns one provider by ID with secrets masked.
+func (h *Handler) GetUnifiedProvider(c *gin.Context) {
+	idx := h.findProvider(c.Param("id"))
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+	c.JSON(http.StatusOK, maskedProvider(h.cfg.Providers[idx]))
+}
+
+// CreateUnifiedProvider adds a provider. The ID is required so scripts can address it later.
+func (h *Handler) CreateUnifiedProvider(c *gin.Context) {
+	var body config.UnifiedProvider
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body", "details": err.Error()})
+		return
+	}
+	body.ID = strings.TrimSpace(body.ID)
+	if body.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	if h.findProvider(body.ID) >= 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "provider already exists", "id": body.ID})
+		return
+	}
+	if err := config.ValidateUnifiedProvider(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider", "details": err.Error()})
+		return
+	}
+
+	h.cfg.Providers = append(h.cfg.Providers, body)
+	h.cfg.SanitizeProviders()
+	h.persist(c)
+}
+
+// unifiedProviderPatch holds the fields PatchUnifiedProvider may change. A null credential
+// value removes that credential.
+type unifiedProviderPatch struct {
+	Type        *string                     `json:"type"`
+	Enabled     *bool                       `json:"enabled"`
+	Priority    *int                        `json:"priority"`
+	Weight      *int                        `json:"weight"`
+	Tags        *[]string                   `json:"tags"`
+	Prefix      *string                     `json:"prefix"`
+	Credentials map[string]*string          `json:"credentials"`
+	ProxyURL    *string                     `json:"proxy-url"`
+	Models      *config.ProviderModelConfig `json:"models"`
+}
+
+// PatchUnifiedProvider updates selected fields of a provider.
+func (h *Handler) PatchUnifiedProvider(c *gin.Context) {
+	idx := h.findProvider(c.Param("id"))
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+	var body unifiedProviderPatch
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body", "details": err.Error()})
+		return
+	}
+
+	existing := h.cfg.Providers[idx]
+	entry := existing
+	if body.Type != nil {
+		entry.Type = *body.Type
+	}
+	if body.Enabled != nil {
+		enabled := *body.Enabled
+		entry.Enabled = &enabled
+	}
+	if body.Priority != nil {
+		entry.Priority = *body.Priority
+	}
+	if body.Weight != nil {
+		entry.Weight = *body.Weight
+	}
+	if body.Tags != nil {
+		entry.Tags = append([]string(nil), (*body.Tags)...)
+	}
+	if body.Prefix != nil {
+		entry.Prefix = *body.Prefix
+	}
+	if body.ProxyURL != nil {
+		entry.ProxyURL = *body.ProxyURL
+	}
+	if body.Models != nil {
+		entry.Models = *body.Models
+	}
+	if body.Credentials != nil {
+		creds := make(map[string]string, len(existing.Credentials)+len(body.Credentials))
+		for k, v := range existing.Credentials {
+			creds[k] = v
+		}
+		for k, v := range body.Credentials {
+			if v == nil {
+				delete(creds, k)
+				continue
+			}
+			creds[k] = *v
+		}
+		entry.Credentials = creds
+		restoreMaskedCredentials(&entry, &existing)
+	}
+	if err := config.ValidateUnifiedProvider(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider", "details": err.Error()})
+		return
+	}
+
+	h.cfg.Providers[idx] = entry
+	h.cfg.SanitizeProviders()
+	h.persist(c)
+}
+
+// DeleteUnifiedProvider removes a provider by ID.
+func (h *Handler) DeleteUnifiedProvider(c *gin.Context) {
+	idx := h.findProvider(c.Param("id"))
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+	h.cfg.Providers = append(h.cfg.Providers[:idx], h.cfg.Providers[idx+1:]...)
+	h.persist(c)
+}
+
+// EnableUnifiedProvider turns a provider on.
+func (h *Handler) EnableUnifiedProvider(c *gin.Context) { h.setProviderEnabled(c, true) }
+
+// DisableUnifiedProvider turns a provider off without removing it.
+func (h *Handler) DisableUnifiedProvider(c *gin.Context) { h.setProviderEnabled(c, false) }
+
+func (h *Handler) setProviderEnabled(c *gin.Context, enabled bool) {
+	idx := h.findProvider(c.Param("id"))
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+	h.cfg.Providers[idx].Enabled = &enabled
 	h.persist(c)
 }
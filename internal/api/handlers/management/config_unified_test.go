@@ -0,0 +1,103 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cliproxy/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func newUnifiedProviderTestHandler(t *testing.T) (*Handler, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := &config.Config{Providers: []config.UnifiedProvider{{
+		ID:          "main",
+		Type:        "claude",
+		Credentials: map[string]string{"api_key": "sk-ant-secret-value", "base_url": "https://api.anthropic.com"},
+	}}}
+	h := NewHandler(cfg, path, nil)
+	r := gin.New()
+	r.GET("/providers/:id", h.GetUnifiedProvider)
+	r.POST("/providers", h.CreateUnifiedProvider)
+	r.PATCH("/providers/:id", h.PatchUnifiedProvider)
+	r.POST("/providers/:id/disable", h.DisableUnifiedProvider)
+	return h, r
+}
+
+func doUnifiedRequest(r *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUnifiedProviders_MasksSecrets(t *testing.T) {
+	_, r := newUnifiedProviderTestHandler(t)
+
+	rec := doUnifiedRequest(r, http.MethodGet, "/providers/main", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "sk-ant-secret-value") {
+		t.Fatalf("api key leaked: %s", rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "https://api.anthropic.com") {
+		t.Fatalf("base_url should stay visible: %s", rec.Body.String())
+	}
+}
+
+func TestUnifiedProviders_PatchKeepsMaskedSecret(t *testing.T) {
+	h, r := newUnifiedProviderTestHandler(t)
+	masked := maskCredential("api_key", "sk-ant-secret-value")
+
+	rec := doUnifiedRequest(r, http.MethodPatch, "/providers/main", `{"priority":3,"credentials":{"api_key":"`+masked+`"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	p := h.cfg.Providers[0]
+	if p.Priority != 3 {
+		t.Fatalf("priority = %d, want 3", p.Priority)
+	}
+	if p.Credentials["api_key"] != "sk-ant-secret-value" {
+		t.Fatalf("api key = %q, want stored secret", p.Credentials["api_key"])
+	}
+
+	rec = doUnifiedRequest(r, http.MethodPatch, "/providers/main", `{"credentials":{"api_key":null}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("removing a required credential: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestUnifiedProviders_CreateValidatesAndRejectsDuplicates(t *testing.T) {
+	h, r := newUnifiedProviderTestHandler(t)
+
+	if rec := doUnifiedRequest(r, http.MethodPost, "/providers", `{"id":"main","type":"claude","credentials":{"api_key":"x"}}`); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate id: status = %d, want 409", rec.Code)
+	}
+	if rec := doUnifiedRequest(r, http.MethodPost, "/providers", `{"id":"compat","type":"openai-compatibility"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing base_url: status = %d, want 400", rec.Code)
+	}
+	if rec := doUnifiedRequest(r, http.MethodPost, "/providers", `{"id":"compat","type":"openai","credentials":{"base_url":"https://example.com/v1"}}`); rec.Code != http.StatusOK {
+		t.Fatalf("create: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if len(h.cfg.Providers) != 2 || h.cfg.Providers[1].Type != "openai-compatibility" {
+		t.Fatalf("providers = %+v", h.cfg.Providers)
+	}
+
+	if rec := doUnifiedRequest(r, http.MethodPost, "/providers/compat/disable", ""); rec.Code != http.StatusOK {
+		t.Fatalf("disable: status = %d", rec.Code)
+	}
+	if h.cfg.Providers[1].IsEnabled() {
+		t.Fatal("provider should be disabled")
+	}
+}
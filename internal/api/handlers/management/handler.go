@@ -15,6 +15,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"cliproxy/internal/buildinfo"
 	"cliproxy/internal/config"
+	"cliproxy/internal/logging"
+	"cliproxy/internal/replay"
 	"cliproxy/internal/usage"
 	sdkAuth "cliproxy/sdk/auth"
 	coreauth "cliproxy/sdk/cliproxy/auth"
@@ -40,6 +42,8 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	structuredLog       *logging.StructuredRequestLogger
+	replayer            *replay.Replayer
 }
 
 // NewHandler creates a new management handler instance.
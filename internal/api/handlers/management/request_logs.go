@@ -0,0 +1,117 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/logging"
+)
+
+const (
+	defaultRequestLogsLimit = 100
+	maxRequestLogsLimit     = 1000
+)
+
+// SetStructuredRequestLogger wires the structured request log queried by GetRequestLogs.
+func (h *Handler) SetStructuredRequestLogger(logger *logging.StructuredRequestLogger) {
+	h.structuredLog = logger
+}
+
+// GetRequestLogs queries the structured request log, newest first.
+//
+// Query parameters:
+//   - from, to: time range as RFC 3339 or Unix seconds
+//   - model: requested model (case-insensitive)
+//   - status: exact code (e.g. 429), a class (4xx, 5xx) or "error" for every status >= 400
+//   - key: client API key, matched by hash; key_hash matches the logged hash directly
+//   - request_id: a single request
+//   - limit: maximum records returned (default 100, max 1000)
+func (h *Handler) GetRequestLogs(c *gin.Context) {
+	if h == nil || h.structuredLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "structured request log unavailable"})
+		return
+	}
+
+	filter := logging.StructuredRequestFilter{
+		Model:     strings.TrimSpace(c.Query("model")),
+		KeyHash:   strings.TrimSpace(c.Query("key_hash")),
+		RequestID: strings.TrimSpace(c.Query("request_id")),
+		Limit:     defaultRequestLogsLimit,
+	}
+	if key := c.Query("key"); key != "" {
+		filter.KeyHash = logging.HashAPIKey(key)
+	}
+	var err error
+	if filter.From, err = parseRequestLogTime(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", err)})
+		return
+	}
+	if filter.To, err = parseRequestLogTime(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", err)})
+		return
+	}
+	if filter.StatusMin, filter.StatusMax, err = parseStatusFilter(c.Query("status")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid status: %v", err)})
+		return
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	if limit > 0 {
+		filter.Limit = min(limit, maxRequestLogsLimit)
+	}
+
+	records, err := h.structuredLog.Query(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read request logs: %v", err)})
+		return
+	}
+	if records == nil {
+		records = []logging.StructuredRequestRecord{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": h.structuredLog.Enabled(),
+		"records": records,
+		"count":   len(records),
+	})
+}
+
+func parseRequestLogTime(raw string) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be RFC 3339 or Unix seconds")
+	}
+	return t, nil
+}
+
+// parseStatusFilter turns a status query into an inclusive range.
+func parseStatusFilter(raw string) (int, int, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case value == "":
+		return 0, 0, nil
+	case value == "error":
+		return http.StatusBadRequest, 0, nil
+	case len(value) == 3 && strings.HasSuffix(value, "xx") && value[0] >= '1' && value[0] <= '5':
+		class := int(value[0]-'0') * 100
+		return class, class + 99, nil
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("must be a status code, a class such as 5xx, or error")
+	}
+	return code, code, nil
+}
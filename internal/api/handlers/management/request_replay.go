@@ -0,0 +1,61 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/replay"
+)
+
+// SetReplayer wires the replayer used by ReplayRequest.
+func (h *Handler) SetReplayer(r *replay.Replayer) {
+	h.replayer = r
+}
+
+// ReplayRequest re-executes a request captured in the request log, optionally against another
+// model, provider or credential, and returns a side-by-side diff of the translated upstream
+// request and the client response against the capture.
+//
+// Body: {"request_id": "...", "model": "...", "provider": "...", "auth_index": "..."}
+func (h *Handler) ReplayRequest(c *gin.Context) {
+	if h == nil || h.replayer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "request replay unavailable"})
+		return
+	}
+	var body struct {
+		RequestID string `json:"request_id"`
+		replay.Options
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if strings.TrimSpace(body.RequestID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing request_id"})
+		return
+	}
+
+	capture, err := replay.LoadCapture(h.logDirectory(), body.RequestID)
+	if err != nil {
+		if errors.Is(err, replay.ErrCaptureNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "request log not found for the given request ID; enable request-log to capture requests"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to load request log: %v", err)})
+		return
+	}
+
+	result, err := h.replayer.Replay(c.Request.Context(), capture, body.Options)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, replay.ErrUnsupportedEndpoint) || errors.Is(err, replay.ErrInvalidTarget) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
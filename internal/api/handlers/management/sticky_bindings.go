@@ -0,0 +1,54 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// stickySelector returns the running unified selector, or nil when another strategy is active.
+func (h *Handler) stickySelector() *coreauth.UnifiedSelector {
+	if h.authManager == nil {
+		return nil
+	}
+	selector, _ := h.authManager.Selector().(*coreauth.UnifiedSelector)
+	return selector
+}
+
+// GetStickyBindings lists the live session-to-credential bindings of the sticky strategy.
+func (h *Handler) GetStickyBindings(c *gin.Context) {
+	selector := h.stickySelector()
+	if selector == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "bindings": []coreauth.StickyBinding{}})
+		return
+	}
+	bindings := selector.StickyBindings()
+	if authID := strings.TrimSpace(c.Query("auth-id")); authID != "" {
+		filtered := bindings[:0]
+		for _, b := range bindings {
+			if b.AuthID == authID {
+				filtered = append(filtered, b)
+			}
+		}
+		bindings = filtered
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  selector.Strategy() == "sticky",
+		"total":    len(bindings),
+		"bindings": bindings,
+	})
+}
+
+// DeleteStickyBindings clears bindings. The optional auth-id and session query parameters
+// restrict removal to matching bindings; without them every binding is removed.
+func (h *Handler) DeleteStickyBindings(c *gin.Context) {
+	selector := h.stickySelector()
+	if selector == nil {
+		c.JSON(http.StatusOK, gin.H{"removed": 0})
+		return
+	}
+	removed := selector.ClearStickyBindings(strings.TrimSpace(c.Query("auth-id")), strings.TrimSpace(c.Query("session")))
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
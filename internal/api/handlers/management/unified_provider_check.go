@@ -0,0 +1,100 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cliproxy/internal/config"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const providerCheckTimeout = 15 * time.Second
+
+// providerCheckRequest builds the lightweight model-listing request used to verify a provider's
+// credentials and reachability.
+func providerCheckRequest(ctx context.Context, p *config.UnifiedProvider) (*http.Request, error) {
+	key := p.Credential("api_key")
+	base := strings.TrimRight(p.Credential("base_url"), "/")
+	headers := map[string]string{}
+	var endpoint string
+	switch config.NormalizeProviderType(p.Type) {
+	case "gemini", "vertex":
+		if base == "" {
+			base = "https://generativelanguage.googleapis.com"
+		}
+		endpoint = base + "/v1beta/models"
+		headers["x-goog-api-key"] = key
+	case "claude":
+		if base == "" {
+			base = "https://api.anthropic.com"
+		}
+		endpoint = base + "/v1/models"
+		headers["x-api-key"] = key
+		headers["anthropic-version"] = "2023-06-01"
+	case "codex":
+		if base == "" {
+			base = "https://api.openai.com/v1"
+		}
+		endpoint = base + "/models"
+		headers["Authorization"] = "Bearer " + key
+	case "openai-compatibility":
+		endpoint = base + "/models"
+		if key != "" {
+			headers["Authorization"] = "Bearer " + key
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %q", p.Type)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// TestUnifiedProvider checks that a provider's upstream accepts its credentials by listing models.
+func (h *Handler) TestUnifiedProvider(c *gin.Context) {
+	idx := h.findProvider(c.Param("id"))
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+	p := h.cfg.Providers[idx]
+	if err := config.ValidateUnifiedProvider(&p); err != nil {
+		c.JSON(http.StatusOK, gin.H{"ok": false, "error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), providerCheckTimeout)
+	defer cancel()
+	req, err := providerCheckRequest(ctx, &p)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"ok": false, "error": err.Error()})
+		return
+	}
+
+	client := &http.Client{Transport: h.apiCallTransport(&coreauth.Auth{ProxyURL: p.ProxyURL})}
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"ok": false, "latency_ms": latency, "error": err.Error()})
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	result := gin.H{"ok": resp.StatusCode < 300, "status": resp.StatusCode, "latency_ms": latency}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		result["error"] = strings.TrimSpace(string(body))
+	}
+	c.JSON(http.StatusOK, result)
+}
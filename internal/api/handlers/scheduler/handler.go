@@ -1,7 +1,10 @@
 package scheduler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"cliproxy/internal/scheduler"
@@ -9,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/tidwall/sjson"
 )
 
 type Handler struct {
@@ -25,7 +29,45 @@ func NewHandler(store *internalScheduler.Store, engine *internalScheduler.Engine
 
 func (h *Handler) ListTasks(c *gin.Context) {
 	tasks := h.store.GetTasks()
-	c.JSON(http.StatusOK, tasks)
+	views := make([]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		view, err := taskView(task)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode task"})
+			return
+		}
+		views = append(views, view)
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// taskView renders a task for API responses with notification credentials masked. The stored
+// task keeps them, since the store persists tasks with their own MarshalJSON.
+func taskView(task *internalScheduler.Task) (json.RawMessage, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	task.RLock()
+	channels := make([]internalScheduler.NotificationChannel, len(task.Notifications))
+	for i, ch := range task.Notifications {
+		channels[i] = ch.Redacted()
+	}
+	task.RUnlock()
+	if len(channels) == 0 {
+		return data, nil
+	}
+	return sjson.SetBytes(data, "notifications", channels)
+}
+
+// respondTask writes task as the response body with its credentials masked.
+func respondTask(c *gin.Context, status int, task *internalScheduler.Task) {
+	view, err := taskView(task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode task"})
+		return
+	}
+	c.Data(status, "application/json; charset=utf-8", view)
 }
 
 func (h *Handler) GetTask(c *gin.Context) {
@@ -35,19 +77,41 @@ func (h *Handler) GetTask(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 		return
 	}
-	c.JSON(http.StatusOK, task)
+	respondTask(c, http.StatusOK, task)
 }
 
 func (h *Handler) CreateTask(c *gin.Context) {
 	var req struct {
 		Name       string `json:"name" binding:"required"`
-		Type       string `json:"type" binding:"required"` // interval, fixed_time, daily
+		Type       string `json:"type" binding:"required"` // interval, fixed_time, daily, cron
 		Interval   string `json:"interval"`
 		FixedTime  string `json:"fixed_time"` // ISO8601 string
 		DailyTime  string `json:"daily_time"` // e.g. "09:00,18:00"
-		Prompt     string `json:"prompt" binding:"required"`
-		Model      string `json:"model" binding:"required"`
+		Cron       string `json:"cron"`       // e.g. "0 9 * * 1-5"
+		Timezone   string `json:"timezone"`   // IANA zone, e.g. "America/New_York"
+		CatchUp    string `json:"catch_up"`   // skip, once or all
+		Prompt     string `json:"prompt"`
+		Model      string `json:"model"` // Required except for credential tasks, where it is the preferred probe model
 		WebhookURL string `json:"webhook_url"`
+
+		API            string                              `json:"api"` // chat, responses or messages
+		SystemPrompt   string                              `json:"system_prompt"`
+		Messages       []internalScheduler.MessageTemplate `json:"messages"`
+		Parameters     *internalScheduler.GenerationParams `json:"parameters"`
+		Tools          json.RawMessage                     `json:"tools"`
+		ResponseSchema json.RawMessage                     `json:"response_schema"`
+		Metadata       map[string]string                   `json:"metadata"`
+
+		MaxRetries         int    `json:"max_retries"`
+		RetryBackoff       string `json:"retry_backoff"`
+		Timeout            string `json:"timeout"`
+		PauseAfterFailures int    `json:"pause_after_failures"`
+		FailureWebhookURL  string `json:"failure_webhook_url"`
+
+		Notifications []scheduler.NotificationChannel `json:"notifications"`
+
+		Credentials  *scheduler.CredentialFilter `json:"credentials"`
+		ReportWindow string                      `json:"report_window"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -55,15 +119,58 @@ func (h *Handler) CreateTask(c *gin.Context) {
 		return
 	}
 
+	credentialTask := req.Type == string(scheduler.TaskTypeCredentialProbe) || req.Type == string(scheduler.TaskTypeCredentialReport)
+	if req.Model == "" && !credentialTask {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	if req.Prompt == "" && len(req.Messages) == 0 && req.Type != string(scheduler.TaskTypeSystemReport) && !credentialTask {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt or messages is required"})
+		return
+	}
+
 	task := &scheduler.Task{
-		ID:         uuid.New().String(),
-		Name:       req.Name,
-		Type:       scheduler.TaskType(req.Type),
-		Prompt:     req.Prompt,
-		Model:      req.Model,
-		WebhookURL: req.WebhookURL,
-		Status:     scheduler.TaskStatusActive, // Default active
-		CreatedAt:  time.Now(),
+		ID:             uuid.New().String(),
+		Name:           req.Name,
+		Type:           scheduler.TaskType(req.Type),
+		Prompt:         req.Prompt,
+		Model:          req.Model,
+		WebhookURL:     req.WebhookURL,
+		API:            scheduler.APISurface(req.API),
+		SystemPrompt:   req.SystemPrompt,
+		Messages:       req.Messages,
+		Parameters:     req.Parameters,
+		Tools:          req.Tools,
+		ResponseSchema: req.ResponseSchema,
+		Metadata:       req.Metadata,
+		MaxRetries:     req.MaxRetries,
+		RetryBackoff:   req.RetryBackoff,
+		Timeout:        req.Timeout,
+		PauseAfter:     req.PauseAfterFailures,
+		FailureWebhook: req.FailureWebhookURL,
+		Notifications:  req.Notifications,
+		Credentials:    req.Credentials,
+		ReportWindow:   req.ReportWindow,
+		Timezone:       req.Timezone,
+		CatchUp:        scheduler.CatchUpPolicy(req.CatchUp),
+		Status:         scheduler.TaskStatusActive, // Default active
+		CreatedAt:      time.Now(),
+	}
+	if err := validateScheduleOptions(task.Timezone, task.CatchUp); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := scheduler.ValidateRequestFields(task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := scheduler.ValidateRetryFields(task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := scheduler.ValidateNotifications(task.Notifications); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	switch task.Type {
@@ -90,6 +197,22 @@ func (h *Handler) CreateTask(c *gin.Context) {
 			return
 		}
 		task.DailyTime = req.DailyTime
+	case scheduler.TaskTypeCron:
+		if req.Cron == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cron is required for cron tasks"})
+			return
+		}
+		if _, err := scheduler.ParseCron(req.Cron); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.Cron = req.Cron
+	case scheduler.TaskTypeCredentialProbe, scheduler.TaskTypeCredentialReport:
+		task.Interval, task.Cron, task.DailyTime = req.Interval, req.Cron, req.DailyTime
+		if err := scheduler.ValidateCredentialTask(task); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task type"})
 		return
@@ -100,7 +223,7 @@ func (h *Handler) CreateTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	respondTask(c, http.StatusCreated, task)
 }
 
 func (h *Handler) UpdateTask(c *gin.Context) {
@@ -120,7 +243,29 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 		Interval   *string `json:"interval"`
 		DailyTime  *string `json:"daily_time"`
 		FixedTime  *string `json:"fixed_time"`
+		Cron       *string `json:"cron"`
+		Timezone   *string `json:"timezone"`
+		CatchUp    *string `json:"catch_up"`
 		WebhookURL *string `json:"webhook_url"`
+
+		API            *string                              `json:"api"`
+		SystemPrompt   *string                              `json:"system_prompt"`
+		Messages       *[]internalScheduler.MessageTemplate `json:"messages"`
+		Parameters     *internalScheduler.GenerationParams  `json:"parameters"`
+		Tools          json.RawMessage                      `json:"tools"`
+		ResponseSchema json.RawMessage                      `json:"response_schema"`
+		Metadata       *map[string]string                   `json:"metadata"`
+
+		MaxRetries         *int    `json:"max_retries"`
+		RetryBackoff       *string `json:"retry_backoff"`
+		Timeout            *string `json:"timeout"`
+		PauseAfterFailures *int    `json:"pause_after_failures"`
+		FailureWebhookURL  *string `json:"failure_webhook_url"`
+
+		Notifications *[]internalScheduler.NotificationChannel `json:"notifications"`
+
+		Credentials  *internalScheduler.CredentialFilter `json:"credentials"`
+		ReportWindow *string                             `json:"report_window"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -147,7 +292,8 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 	if req.Type != nil {
 		taskType := internalScheduler.TaskType(*req.Type)
 		// Validation
-		if taskType == internalScheduler.TaskTypeInterval || taskType == internalScheduler.TaskTypeFixedTime || taskType == internalScheduler.TaskTypeDaily || taskType == internalScheduler.TaskTypeSystemReport {
+		if taskType == internalScheduler.TaskTypeInterval || taskType == internalScheduler.TaskTypeFixedTime || taskType == internalScheduler.TaskTypeDaily || taskType == internalScheduler.TaskTypeSystemReport || taskType == internalScheduler.TaskTypeCron ||
+			taskType == internalScheduler.TaskTypeCredentialProbe || taskType == internalScheduler.TaskTypeCredentialReport {
 			task.Type = taskType
 		} else {
 			task.Unlock()
@@ -179,6 +325,128 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 		}
 		task.FixedTime = &t
 	}
+	if req.Cron != nil {
+		if _, err := internalScheduler.ParseCron(*req.Cron); err != nil {
+			task.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.Cron = *req.Cron
+	}
+	if req.Timezone != nil || req.CatchUp != nil {
+		timezone, catchUp := task.Timezone, task.CatchUp
+		if req.Timezone != nil {
+			timezone = *req.Timezone
+		}
+		if req.CatchUp != nil {
+			catchUp = internalScheduler.CatchUpPolicy(*req.CatchUp)
+		}
+		if err := validateScheduleOptions(timezone, catchUp); err != nil {
+			task.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.Timezone, task.CatchUp = timezone, catchUp
+	}
+
+	if req.API != nil || req.SystemPrompt != nil || req.Messages != nil || req.Parameters != nil ||
+		req.Tools != nil || req.ResponseSchema != nil || req.Metadata != nil || req.Prompt != nil {
+		candidate := &internalScheduler.Task{
+			API:            task.API,
+			SystemPrompt:   task.SystemPrompt,
+			Messages:       task.Messages,
+			Prompt:         task.Prompt,
+			Tools:          task.Tools,
+			ResponseSchema: task.ResponseSchema,
+		}
+		if req.API != nil {
+			candidate.API = internalScheduler.APISurface(*req.API)
+		}
+		if req.SystemPrompt != nil {
+			candidate.SystemPrompt = *req.SystemPrompt
+		}
+		if req.Messages != nil {
+			candidate.Messages = *req.Messages
+		}
+		if req.Tools != nil {
+			candidate.Tools = req.Tools
+		}
+		if req.ResponseSchema != nil {
+			candidate.ResponseSchema = req.ResponseSchema
+		}
+		if err := internalScheduler.ValidateRequestFields(candidate); err != nil {
+			task.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.API = candidate.API
+		task.SystemPrompt = candidate.SystemPrompt
+		task.Messages = candidate.Messages
+		task.Tools = candidate.Tools
+		task.ResponseSchema = candidate.ResponseSchema
+		if req.Parameters != nil {
+			task.Parameters = req.Parameters
+		}
+		if req.Metadata != nil {
+			task.Metadata = *req.Metadata
+		}
+	}
+
+	if req.MaxRetries != nil || req.RetryBackoff != nil || req.Timeout != nil || req.PauseAfterFailures != nil {
+		candidate := &internalScheduler.Task{
+			MaxRetries:   task.MaxRetries,
+			RetryBackoff: task.RetryBackoff,
+			Timeout:      task.Timeout,
+			PauseAfter:   task.PauseAfter,
+		}
+		if req.MaxRetries != nil {
+			candidate.MaxRetries = *req.MaxRetries
+		}
+		if req.RetryBackoff != nil {
+			candidate.RetryBackoff = *req.RetryBackoff
+		}
+		if req.Timeout != nil {
+			candidate.Timeout = *req.Timeout
+		}
+		if req.PauseAfterFailures != nil {
+			candidate.PauseAfter = *req.PauseAfterFailures
+		}
+		if err := internalScheduler.ValidateRetryFields(candidate); err != nil {
+			task.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.MaxRetries = candidate.MaxRetries
+		task.RetryBackoff = candidate.RetryBackoff
+		task.Timeout = candidate.Timeout
+		task.PauseAfter = candidate.PauseAfter
+	}
+	if req.FailureWebhookURL != nil {
+		task.FailureWebhook = *req.FailureWebhookURL
+	}
+	if req.Notifications != nil {
+		internalScheduler.RestoreSecrets(*req.Notifications, task.Notifications)
+		if err := internalScheduler.ValidateNotifications(*req.Notifications); err != nil {
+			task.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.Notifications = *req.Notifications
+	}
+
+	if req.Credentials != nil {
+		task.Credentials = req.Credentials
+	}
+	if req.ReportWindow != nil {
+		task.ReportWindow = *req.ReportWindow
+	}
+	if task.Type == internalScheduler.TaskTypeCredentialProbe || task.Type == internalScheduler.TaskTypeCredentialReport {
+		if err := internalScheduler.ValidateCredentialTask(task); err != nil {
+			task.Unlock()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	// Reset next run calculation if schedule params changed?
 	// For simplicity, let the engine handle it on next tick (it updates if NextRunAt is wrong/past).
@@ -192,7 +460,22 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	respondTask(c, http.StatusOK, task)
+}
+
+// validateScheduleOptions checks a task's time zone and catch-up policy.
+func validateScheduleOptions(timezone string, catchUp internalScheduler.CatchUpPolicy) error {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", timezone)
+		}
+	}
+	switch catchUp {
+	case "", internalScheduler.CatchUpSkip, internalScheduler.CatchUpOnce, internalScheduler.CatchUpAll:
+		return nil
+	default:
+		return fmt.Errorf("invalid catch_up %q (expected skip, once or all)", catchUp)
+	}
 }
 
 func (h *Handler) DeleteTask(c *gin.Context) {
@@ -204,9 +487,27 @@ func (h *Handler) DeleteTask(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// GetLogs lists execution logs, newest first, optionally filtered by ?task_id=, ?since= (RFC3339)
+// and ?limit=.
 func (h *Handler) GetLogs(c *gin.Context) {
-	logs := h.store.GetLogs()
-	c.JSON(http.StatusOK, logs)
+	filter := internalScheduler.LogFilter{TaskID: c.Query("task_id")}
+	if raw := c.Query("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since: expected RFC3339 timestamp"})
+			return
+		}
+		filter.Since = since
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+	c.JSON(http.StatusOK, h.store.QueryLogs(filter))
 }
 
 func (h *Handler) RunTask(c *gin.Context) {
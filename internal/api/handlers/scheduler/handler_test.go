@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	internalScheduler "cliproxy/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+func TestTaskResponsesRedactNotificationSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := internalScheduler.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	task := &internalScheduler.Task{
+		ID: "t1", Name: "report", Type: internalScheduler.TaskTypeInterval, Interval: "1h",
+		Notifications: []internalScheduler.NotificationChannel{
+			{Type: internalScheduler.NotifierTelegram, BotToken: "123:bot-token", ChatID: "1"},
+		},
+	}
+	if err = store.AddTask(task); err != nil {
+		t.Fatalf("add task: %v", err)
+	}
+
+	h := NewHandler(store, nil)
+	router := gin.New()
+	router.GET("/tasks", h.ListTasks)
+	router.GET("/tasks/:id", h.GetTask)
+	router.PUT("/tasks/:id", h.UpdateTask)
+
+	for _, path := range []string{"/tasks", "/tasks/t1"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d", path, rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "bot-token") {
+			t.Fatalf("GET %s leaked the bot token: %s", path, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/t1", nil))
+	notifications := gjson.Get(rec.Body.String(), "notifications").Raw
+	body := `{"name":"renamed","notifications":` + notifications + `}`
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/tasks/t1", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := gjson.Get(rec.Body.String(), "notifications.0.bot_token").String(); got != internalScheduler.RedactedSecret {
+		t.Fatalf("PUT response bot_token = %q", got)
+	}
+	stored, _ := store.GetTask("t1")
+	if stored.Notifications[0].BotToken != "123:bot-token" {
+		t.Fatalf("stored bot token = %q, want it kept", stored.Notifications[0].BotToken)
+	}
+}
@@ -0,0 +1,147 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the structured request logging middleware that appends one JSON
+// record per proxied request to the JSONL request log.
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/interfaces"
+	"cliproxy/internal/logging"
+	"cliproxy/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// StructuredRequestLogMiddleware records each proxied request in the structured request log.
+// The model, provider, credential and token usage come from the usage record the executor
+// stores in the Gin context; requests that never reached an executor fall back to the model
+// named in the request body.
+func StructuredRequestLogMiddleware(logger *logging.StructuredRequestLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if logger == nil || !logger.Enabled() || c.Request.Method == http.MethodGet || !shouldLogRequest(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		if c.Request.Body != nil {
+			data, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.Next()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(data))
+			body = data
+		}
+		capture := &responseCaptureWriter{ResponseWriter: c.Writer, limit: logger.MaxBodyBytes()}
+		c.Writer = capture
+
+		c.Next()
+
+		status := capture.Status()
+		if !logger.Sampled(status) {
+			return
+		}
+		record := &logging.StructuredRequestRecord{
+			Timestamp:      start.UTC(),
+			RequestID:      logging.GetGinRequestID(c),
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			KeyHash:        logging.HashAPIKey(c.GetString("apiKey")),
+			Model:          gjson.GetBytes(body, "model").String(),
+			Status:         status,
+			LatencyMs:      time.Since(start).Milliseconds(),
+			Stream:         strings.Contains(capture.Header().Get("Content-Type"), "text/event-stream"),
+			RequestHeaders: logger.RedactHeaders(c.Request.Header),
+		}
+		if value, ok := c.Get("API_USAGE"); ok {
+			if rec, okRecord := value.(usage.Record); okRecord {
+				if record.Model == "" {
+					record.Model = rec.Model
+				}
+				record.Provider = rec.Provider
+				record.AuthIndex = rec.AuthIndex
+				if !rec.Failed {
+					record.Usage = &logging.StructuredRequestUsage{
+						InputTokens:     rec.Detail.InputTokens,
+						OutputTokens:    rec.Detail.OutputTokens,
+						ReasoningTokens: rec.Detail.ReasoningTokens,
+						CachedTokens:    rec.Detail.CachedTokens,
+						TotalTokens:     rec.Detail.TotalTokens,
+					}
+				}
+			}
+		}
+		if status >= http.StatusBadRequest {
+			record.Error = requestErrorMessage(c, capture.body.Bytes())
+		}
+		record.RequestBody, record.RequestBodyTruncated = logger.FormatBody(body)
+		record.ResponseBody, record.ResponseBodyTruncated = logger.FormatBody(capture.body.Bytes())
+		record.ResponseBodyTruncated = record.ResponseBodyTruncated || capture.truncated
+
+		if err := logger.Write(record); err != nil {
+			log.Debugf("structured request log: failed to write record: %v", err)
+		}
+	}
+}
+
+// requestErrorMessage returns the upstream error recorded by the handlers, or the message of an
+// OpenAI-style error body.
+func requestErrorMessage(c *gin.Context, response []byte) string {
+	if value, ok := c.Get("API_RESPONSE_ERROR"); ok {
+		if errs, okErrs := value.([]*interfaces.ErrorMessage); okErrs {
+			for _, e := range errs {
+				if e != nil && e.Error != nil {
+					return e.Error.Error()
+				}
+			}
+		}
+	}
+	if msg := gjson.GetBytes(response, "error.message"); msg.Exists() {
+		return msg.String()
+	}
+	return ""
+}
+
+// responseCaptureWriter keeps the first limit bytes of the response body for the structured
+// request log.
+type responseCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *responseCaptureWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.capture(data[:n])
+	return n, err
+}
+
+func (w *responseCaptureWriter) WriteString(data string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(data)
+	w.capture([]byte(data[:n]))
+	return n, err
+}
+
+func (w *responseCaptureWriter) capture(data []byte) {
+	if w.limit < 0 {
+		return
+	}
+	room := w.limit - w.body.Len()
+	if room < len(data) {
+		w.truncated = true
+		if room <= 0 {
+			return
+		}
+		data = data[:room]
+	}
+	w.body.Write(data)
+}
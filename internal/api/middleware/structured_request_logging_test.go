@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/config"
+	"cliproxy/internal/logging"
+	"cliproxy/internal/registry"
+	"cliproxy/internal/runtime/executor"
+	_ "cliproxy/internal/translator"
+	"cliproxy/sdk/api/handlers"
+	"cliproxy/sdk/api/handlers/openai"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	sdkconfig "cliproxy/sdk/config"
+)
+
+func TestStructuredRequestLogMiddlewareRecordsExecutorUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":3,"totalTokenCount":10},"modelVersion":"structured-log-model"}`))
+	}))
+	defer upstream.Close()
+
+	const model = "structured-log-model"
+	auth := &coreauth.Auth{ID: "structured-log-auth", Provider: "gemini", Attributes: map[string]string{"api_key": "k", "base_url": upstream.URL}}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "gemini", []*registry.ModelInfo{{ID: model, Object: "model", OwnedBy: "google"}})
+	defer registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+
+	manager := coreauth.NewManager(nil, &coreauth.FillFirstSelector{}, nil)
+	manager.RegisterExecutor(executor.NewGeminiExecutor(&config.Config{}))
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+
+	logger := logging.NewStructuredRequestLogger(t.TempDir(), config.StructuredRequestLogConfig{Enable: true})
+	defer func() { _ = logger.Close() }()
+
+	router := gin.New()
+	router.Use(StructuredRequestLogMiddleware(logger))
+	h := openai.NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager, nil))
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"`+model+`","messages":[{"role":"user","content":"hello"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	records, err := logger.Query(logging.StructuredRequestFilter{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %d, want 1", len(records))
+	}
+	got := records[0]
+	if got.Provider != "gemini" || got.AuthIndex == "" || got.Model != model {
+		t.Fatalf("unexpected record: provider=%q auth_index=%q model=%q", got.Provider, got.AuthIndex, got.Model)
+	}
+	if got.Usage == nil || got.Usage.InputTokens != 7 || got.Usage.OutputTokens != 3 {
+		t.Fatalf("unexpected usage: %+v", got.Usage)
+	}
+}
@@ -12,21 +12,26 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"cliproxy/internal/access"
+	batchHandlers "cliproxy/internal/api/handlers/batch"
 	managementHandlers "cliproxy/internal/api/handlers/management"
 	schedulerHandlers "cliproxy/internal/api/handlers/scheduler"
 	"cliproxy/internal/api/middleware"
 	"cliproxy/internal/api/modules"
 	ampmodule "cliproxy/internal/api/modules/amp"
+	"cliproxy/internal/batch"
 	"cliproxy/internal/config"
 	"cliproxy/internal/logging"
 	"cliproxy/internal/managementasset"
+	"cliproxy/internal/metrics"
 	"cliproxy/internal/registry"
+	"cliproxy/internal/replay"
 	"cliproxy/internal/router"
 	"cliproxy/internal/scheduler"
 	"cliproxy/internal/usage"
@@ -142,6 +147,9 @@ type Server struct {
 	requestLogger logging.RequestLogger
 	loggerToggle  func(bool)
 
+	// structuredLog appends JSONL request records queried through the management API.
+	structuredLog *logging.StructuredRequestLogger
+
 	// configFilePath is the absolute path to the YAML config file for persistence.
 	configFilePath string
 
@@ -180,6 +188,10 @@ type Server struct {
 	schedulerStore   *scheduler.Store
 	schedulerEngine  *scheduler.Engine
 	schedulerHandler *schedulerHandlers.Handler
+
+	// batch components
+	batchRunner  *batch.Runner
+	batchHandler *batchHandlers.Handler
 }
 
 // NewServer creates and initializes a new API server instance.
@@ -221,6 +233,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Resolve logs directory relative to the configuration file directory.
 	var requestLogger logging.RequestLogger
 	var toggle func(bool)
+	var structuredLog *logging.StructuredRequestLogger
 	if !cfg.CommercialMode {
 		if optionState.requestLoggerFactory != nil {
 			requestLogger = optionState.requestLoggerFactory(cfg, configFilePath)
@@ -231,6 +244,12 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 				toggle = setter.SetEnabled
 			}
 		}
+		structuredLogDir := "logs"
+		if base := util.WritablePath(); base != "" {
+			structuredLogDir = filepath.Join(base, "logs")
+		}
+		structuredLog = logging.NewStructuredRequestLogger(structuredLogDir, cfg.StructuredRequestLog)
+		engine.Use(middleware.StructuredRequestLogMiddleware(structuredLog))
 	}
 
 	engine.Use(corsMiddleware())
@@ -254,6 +273,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		accessManager:       accessManager,
 		requestLogger:       requestLogger,
 		loggerToggle:        toggle,
+		structuredLog:       structuredLog,
 		configFilePath:      configFilePath,
 		currentPath:         wd,
 		envManagementSecret: envManagementSecret,
@@ -275,6 +295,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		logDir = filepath.Join(base, "logs")
 	}
 	s.mgmt.SetLogDirectory(logDir)
+	s.mgmt.SetStructuredRequestLogger(structuredLog)
+	s.mgmt.SetReplayer(replay.NewReplayer(s.handlers))
 
 	internalLocalPassword := optionState.localPassword
 	if internalLocalPassword == "" {
@@ -289,18 +311,41 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		schedulerDataDir = filepath.Join(base, "data", "scheduler")
 	}
 	var schedulerErr error
-	s.schedulerStore, schedulerErr = scheduler.NewStore(schedulerDataDir)
+	if cfg.Storage.UsesSQLite() {
+		s.schedulerStore, schedulerErr = scheduler.NewSQLiteStore(cfg.Storage.SQLitePath)
+	} else {
+		s.schedulerStore, schedulerErr = scheduler.NewStore(schedulerDataDir)
+	}
 	if schedulerErr != nil {
 		log.Warnf("failed to initialize scheduler store: %v", schedulerErr)
 	} else {
 		baseURL := fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
 		executor := scheduler.NewLoopbackExecutor(baseURL, s.localPassword, s.schedulerStore)
+		if authManager != nil {
+			executor.SetCredentialManager(authManager)
+		}
 		s.schedulerEngine = scheduler.NewEngine(s.schedulerStore, executor)
 		s.schedulerHandler = schedulerHandlers.NewHandler(s.schedulerStore, s.schedulerEngine)
 		// Start scheduler engine in background
 		go s.schedulerEngine.Start()
 	}
 
+	// Initialize the Files and Batch API
+	batchDataDir := filepath.Join(s.currentPath, "data", "batches")
+	if base := util.WritablePath(); base != "" {
+		batchDataDir = filepath.Join(base, "data", "batches")
+	}
+	if batchStore, batchErr := batch.NewStore(batchDataDir); batchErr != nil {
+		log.Warnf("failed to initialize batch store: %v", batchErr)
+	} else {
+		s.batchRunner = batch.NewRunner(batchStore, batchHandlers.NewExecutor(s.handlers), cfg.Batch.Concurrency)
+		s.batchRunner.SetMaxRequests(cfg.Batch.MaxRequests)
+		s.batchHandler = batchHandlers.NewHandler(batchStore, s.batchRunner)
+		s.batchHandler.SetMaxFileSizeMB(cfg.Batch.MaxFileSizeMB)
+		// Resume batches interrupted by the previous shutdown
+		s.batchRunner.Start()
+	}
+
 	// Setup routes
 	s.setupRoutes()
 
@@ -366,9 +411,25 @@ func (s *Server) setupRoutes() {
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/tokenize", s.handlers.Tokenize)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
+		v1.GET("/responses/:id/input_items", openaiResponsesHandlers.ListResponseInputItems)
+		if s.batchHandler != nil {
+			v1.POST("/files", s.batchHandler.UploadFile)
+			v1.GET("/files", s.batchHandler.ListFiles)
+			v1.GET("/files/:id", s.batchHandler.GetFile)
+			v1.DELETE("/files/:id", s.batchHandler.DeleteFile)
+			v1.GET("/files/:id/content", s.batchHandler.GetFileContent)
+			v1.POST("/batches", s.batchHandler.CreateBatch)
+			v1.GET("/batches", s.batchHandler.ListBatches)
+			v1.GET("/batches/:id", s.batchHandler.GetBatch)
+			v1.POST("/batches/:id/cancel", s.batchHandler.CancelBatch)
+		}
 	}
 
 	// Gemini compatible API routes
@@ -387,12 +448,18 @@ func (s *Server) setupRoutes() {
 			"endpoints": []string{
 				"POST /v1/chat/completions",
 				"POST /v1/completions",
+				"POST /v1/embeddings",
+				"POST /v1/files",
+				"POST /v1/batches",
 				"GET /v1/models",
 			},
 		})
 	})
 	s.engine.POST("/v1internal:method", geminiCLIHandlers.CLIHandler)
 
+	// Prometheus metrics, guarded by its own access settings under 'metrics'.
+	s.engine.GET("/metrics", s.handleMetrics)
+
 	// OAuth callback endpoints (reuse main server port)
 	// These endpoints receive provider redirects and persist
 	// the short-lived code/state for the waiting goroutine.
@@ -563,6 +630,28 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
 
+		mgmt.GET("/api-key-policies", s.mgmt.GetAPIKeyPolicies)
+		mgmt.PUT("/api-key-policies", s.mgmt.PutAPIKeyPolicies)
+		mgmt.DELETE("/api-key-policies", s.mgmt.DeleteAPIKeyPolicy)
+		mgmt.GET("/api-key-policies/usage", s.mgmt.GetAPIKeyPolicyUsage)
+
+		mgmt.GET("/sticky-bindings", s.mgmt.GetStickyBindings)
+		mgmt.DELETE("/sticky-bindings", s.mgmt.DeleteStickyBindings)
+
+		mgmt.GET("/scheduling", s.mgmt.GetSchedulingConfig)
+		mgmt.PUT("/scheduling", s.mgmt.PutSchedulingConfig)
+		mgmt.PATCH("/scheduling", s.mgmt.PutSchedulingConfig)
+
+		mgmt.GET("/providers", s.mgmt.GetUnifiedProviders)
+		mgmt.PUT("/providers", s.mgmt.PutUnifiedProviders)
+		mgmt.POST("/providers", s.mgmt.CreateUnifiedProvider)
+		mgmt.GET("/providers/:id", s.mgmt.GetUnifiedProvider)
+		mgmt.PATCH("/providers/:id", s.mgmt.PatchUnifiedProvider)
+		mgmt.DELETE("/providers/:id", s.mgmt.DeleteUnifiedProvider)
+		mgmt.POST("/providers/:id/enable", s.mgmt.EnableUnifiedProvider)
+		mgmt.POST("/providers/:id/disable", s.mgmt.DisableUnifiedProvider)
+		mgmt.POST("/providers/:id/test", s.mgmt.TestUnifiedProvider)
+
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
 		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
@@ -573,7 +662,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.POST("/request-replay", s.mgmt.ReplayRequest)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
+		mgmt.GET("/request-logs", s.mgmt.GetRequestLogs)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
 		mgmt.GET("/ws-auth", s.mgmt.GetWebsocketAuth)
@@ -749,6 +840,43 @@ func (s *Server) handleKeepAlive(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// handleMetrics serves usage and credential metrics in the Prometheus text format.
+// The endpoint returns 404 unless metrics are enabled, only accepts localhost scrapes
+// unless allow-remote is set, and requires the configured bearer token when present.
+func (s *Server) handleMetrics(c *gin.Context) {
+	cfg := s.cfg
+	if cfg == nil || !cfg.Metrics.Enable {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	clientIP := c.ClientIP()
+	if !cfg.Metrics.AllowRemote && clientIP != "127.0.0.1" && clientIP != "::1" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote metrics access disabled"})
+		return
+	}
+	if token := cfg.Metrics.BearerToken; token != "" {
+		provided := strings.TrimSpace(c.GetHeader("Authorization"))
+		parts := strings.SplitN(provided, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+			provided = strings.TrimSpace(parts[1])
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+	}
+
+	var auths []*auth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := metrics.Default().WriteTo(c.Writer, auths); err != nil {
+		log.Debugf("metrics: failed to write response: %v", err)
+	}
+}
+
 func (s *Server) signalKeepAlive() {
 	if !s.keepAliveEnabled {
 		return
@@ -862,12 +990,28 @@ func (s *Server) Stop(ctx context.Context) error {
 	if s.schedulerEngine != nil {
 		s.schedulerEngine.Stop()
 	}
+	if s.schedulerStore != nil {
+		if errClose := s.schedulerStore.Close(); errClose != nil {
+			log.Warnf("failed to close scheduler store: %v", errClose)
+		}
+	}
+
+	// Interrupt running batches; they resume on the next start
+	if s.batchRunner != nil {
+		s.batchRunner.Stop()
+	}
 
 	// Shutdown the HTTP server.
 	if err := s.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	if s.structuredLog != nil {
+		if err := s.structuredLog.Close(); err != nil {
+			log.Debugf("failed to close structured request log: %v", err)
+		}
+	}
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -932,6 +1076,11 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if s.structuredLog != nil && (oldCfg == nil || !reflect.DeepEqual(oldCfg.StructuredRequestLog, cfg.StructuredRequestLog)) {
+		s.structuredLog.Configure(cfg.StructuredRequestLog)
+		log.Debugf("structured request log updated (enabled=%t)", cfg.StructuredRequestLog.Enable)
+	}
+
 	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB {
 		if err := logging.ConfigureLogOutput(cfg); err != nil {
 			log.Errorf("failed to reconfigure log output: %v", err)
@@ -966,6 +1115,14 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 			log.Debugf("disable_cooling toggled to %t", cfg.DisableCooling)
 		}
 	}
+	if s.batchRunner != nil && (oldCfg == nil || oldCfg.Batch != cfg.Batch) {
+		s.batchRunner.SetConcurrency(cfg.Batch.Concurrency)
+		s.batchRunner.SetMaxRequests(cfg.Batch.MaxRequests)
+		s.batchHandler.SetMaxFileSizeMB(cfg.Batch.MaxFileSizeMB)
+		if oldCfg != nil {
+			log.Debugf("batch settings updated: concurrency %d, max file size %d MB, max requests %d", cfg.Batch.Concurrency, cfg.Batch.MaxFileSizeMB, cfg.Batch.MaxRequests)
+		}
+	}
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
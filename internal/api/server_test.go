@@ -109,3 +109,37 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsEndpointAccess(t *testing.T) {
+	server := newTestServer(t)
+
+	get := func(remoteAddr, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = remoteAddr
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("127.0.0.1:1234", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("disabled metrics: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	server.cfg.Metrics = proxyconfig.MetricsConfig{Enable: true, BearerToken: "scrape"}
+	if rr := get("10.0.0.5:1234", "Bearer scrape"); rr.Code != http.StatusForbidden {
+		t.Fatalf("remote scrape: status = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+	if rr := get("127.0.0.1:1234", "Bearer wrong"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	rr := get("127.0.0.1:1234", "Bearer scrape")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("valid scrape: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "# TYPE cliproxy_requests_total counter") {
+		t.Fatalf("unexpected metrics body: %s", rr.Body.String())
+	}
+}
@@ -71,3 +71,9 @@ func (ts *ClaudeTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (ts *ClaudeTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "claude"
+	return json.Marshal(ts)
+}
@@ -64,3 +64,9 @@ func (ts *CodexTokenStorage) SaveTokenToFile(authFilePath string) error {
 	return nil
 
 }
+
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (ts *CodexTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "codex"
+	return json.Marshal(ts)
+}
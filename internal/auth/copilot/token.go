@@ -91,3 +91,9 @@ func (ts *CopilotTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (ts *CopilotTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "github-copilot"
+	return json.Marshal(ts)
+}
@@ -69,6 +69,12 @@ func (ts *GeminiTokenStorage) SaveTokenToFile(authFilePath string) error {
 	return nil
 }
 
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (ts *GeminiTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "gemini"
+	return json.Marshal(ts)
+}
+
 // CredentialFileName returns the filename used to persist Gemini CLI credentials.
 // When projectID represents multiple projects (comma-separated or literal ALL),
 // the suffix is normalized to "all" and a "gemini-" prefix is enforced to keep
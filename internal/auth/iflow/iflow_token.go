@@ -42,3 +42,9 @@ func (ts *IFlowTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (ts *IFlowTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "iflow"
+	return json.Marshal(ts)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"cliproxy/internal/authcrypt"
 )
 
 // KiroTokenStorage holds the persistent token data for Kiro authentication.
@@ -44,9 +46,14 @@ func (s *KiroTokenStorage) SaveTokenToFile(authFilePath string) error {
 	return nil
 }
 
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (s *KiroTokenStorage) MarshalToken() ([]byte, error) {
+	return json.Marshal(s)
+}
+
 // LoadFromFile loads token storage from the specified file path.
 func LoadFromFile(authFilePath string) (*KiroTokenStorage, error) {
-	data, err := os.ReadFile(authFilePath)
+	data, err := authcrypt.ReadFile(authFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read token file: %w", err)
 	}
@@ -15,3 +15,11 @@ type TokenStorage interface {
 	//   - error: An error if the save operation fails, nil otherwise
 	SaveTokenToFile(authFilePath string) error
 }
+
+// TokenMarshaler is implemented by token storages that can serialise their tokens in memory,
+// producing the same JSON document SaveTokenToFile writes. Stores that encrypt credentials use it
+// so a plaintext copy never reaches the disk.
+type TokenMarshaler interface {
+	// MarshalToken returns the JSON encoding of the stored tokens.
+	MarshalToken() ([]byte, error)
+}
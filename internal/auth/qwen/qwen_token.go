@@ -61,3 +61,9 @@ func (ts *QwenTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// MarshalToken returns the JSON document SaveTokenToFile writes, without touching the disk.
+func (ts *QwenTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "qwen"
+	return json.Marshal(ts)
+}
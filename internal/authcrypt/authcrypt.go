@@ -0,0 +1,235 @@
+// Package authcrypt encrypts credential files in the auth directory at rest.
+// Encrypted files stay valid JSON: a small envelope carrying an AES-256-GCM nonce
+// and ciphertext. Readers use Decode or ReadFile, which pass plaintext files through
+// unchanged so a directory can be migrated incrementally.
+package authcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// KeyEnvVar names the environment variable holding the encryption key.
+const KeyEnvVar = "CLIPROXY_AUTH_ENCRYPTION_KEY"
+
+const (
+	envelopeVersion   = 1
+	envelopeAlgorithm = "AES-256-GCM"
+	keySize           = 32
+)
+
+// ErrNoKey is returned when an encrypted file is read without a configured cipher.
+var ErrNoKey = errors.New("authcrypt: file is encrypted but no encryption key is configured")
+
+// envelope is the on-disk representation of an encrypted auth file.
+type envelope struct {
+	Encrypted int    `json:"cliproxy_encrypted"`
+	Algorithm string `json:"alg"`
+	Nonce     string `json:"nonce"`
+	Data      string `json:"data"`
+}
+
+// Cipher encrypts and decrypts auth file contents with AES-256-GCM.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher constructs a cipher from a 32-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("authcrypt: key must be %d bytes, got %d", keySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey decodes a 32-byte key given as base64 or hex.
+func ParseKey(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("authcrypt: key is empty")
+	}
+	if len(raw) == hex.EncodedLen(keySize) {
+		if key, err := hex.DecodeString(raw); err == nil {
+			return key, nil
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(raw); err == nil && len(key) == keySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("authcrypt: key must be %d bytes encoded as base64 or hex", keySize)
+}
+
+// LoadKey reads the key from KeyEnvVar, falling back to keyFile. A key file may hold the
+// encoded key or the raw 32 key bytes.
+func LoadKey(keyFile string) ([]byte, error) {
+	if raw := strings.TrimSpace(os.Getenv(KeyEnvVar)); raw != "" {
+		return ParseKey(raw)
+	}
+	keyFile = strings.TrimSpace(keyFile)
+	if keyFile == "" {
+		return nil, fmt.Errorf("authcrypt: set %s or configure a key file", KeyEnvVar)
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: read key file: %w", err)
+	}
+	if len(data) == keySize {
+		return data, nil
+	}
+	return ParseKey(string(data))
+}
+
+// Encrypt seals plaintext into an envelope using a fresh random nonce.
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nil, nonce, plaintext, nil)
+	return json.Marshal(envelope{
+		Encrypted: envelopeVersion,
+		Algorithm: envelopeAlgorithm,
+		Nonce:     base64.StdEncoding.EncodeToString(nonce),
+		Data:      base64.StdEncoding.EncodeToString(sealed),
+	})
+}
+
+// Decrypt opens an envelope produced by Encrypt.
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return nil, fmt.Errorf("authcrypt: not an encrypted auth file")
+	}
+	if env.Algorithm != envelopeAlgorithm {
+		return nil, fmt.Errorf("authcrypt: unsupported algorithm %q", env.Algorithm)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil || len(nonce) != c.aead.NonceSize() {
+		return nil, fmt.Errorf("authcrypt: invalid nonce")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: invalid ciphertext encoding")
+	}
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decrypt failed (wrong key or corrupted file)")
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether data is an encrypted auth file envelope.
+func IsEncrypted(data []byte) bool {
+	_, ok := parseEnvelope(data)
+	return ok
+}
+
+func parseEnvelope(data []byte) (envelope, bool) {
+	var env envelope
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") || !strings.Contains(trimmed, `"cliproxy_encrypted"`) {
+		return env, false
+	}
+	if err := json.Unmarshal([]byte(trimmed), &env); err != nil || env.Encrypted == 0 {
+		return env, false
+	}
+	return env, true
+}
+
+var defaultCipher atomic.Pointer[Cipher]
+
+// SetDefault installs the process-wide cipher used by Encode, Decode, ReadFile and WriteFile.
+// Passing nil disables encryption of newly written files.
+func SetDefault(c *Cipher) { defaultCipher.Store(c) }
+
+// Default returns the process-wide cipher, or nil when encryption is disabled.
+func Default() *Cipher { return defaultCipher.Load() }
+
+// Enabled reports whether newly written auth files are encrypted.
+func Enabled() bool { return Default() != nil }
+
+// Encode encrypts plaintext with the default cipher, or returns it unchanged when
+// encryption is disabled.
+func Encode(plaintext []byte) ([]byte, error) {
+	c := Default()
+	if c == nil {
+		return plaintext, nil
+	}
+	return c.Encrypt(plaintext)
+}
+
+// Decode returns the plaintext of an auth file. Plaintext files are returned unchanged.
+func Decode(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	c := Default()
+	if c == nil {
+		return nil, ErrNoKey
+	}
+	return c.Decrypt(data)
+}
+
+// ReadFile reads an auth file and returns its plaintext.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// WriteFile atomically writes plaintext to path, encrypting it when a default cipher is set.
+func WriteFile(path string, plaintext []byte, perm os.FileMode) error {
+	data, err := Encode(plaintext)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, data, perm)
+}
+
+func writeAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err = os.Chmod(tmpName, perm); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package authcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testCipher(t *testing.T, fill byte) *Cipher {
+	t.Helper()
+	c, err := NewCipher(bytes.Repeat([]byte{fill}, keySize))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	return c
+}
+
+func TestCipher_RoundTripAndWrongKey(t *testing.T) {
+	c := testCipher(t, 1)
+	plain := []byte(`{"type":"claude","refresh_token":"secret"}`)
+	sealed, err := c.Encrypt(plain)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) || !IsEncrypted(sealed) {
+		t.Fatalf("ciphertext leaks plaintext or is not detected: %s", sealed)
+	}
+	again, _ := c.Encrypt(plain)
+	if bytes.Equal(sealed, again) {
+		t.Fatal("expected a fresh nonce per encryption")
+	}
+	opened, err := c.Decrypt(sealed)
+	if err != nil || !bytes.Equal(opened, plain) {
+		t.Fatalf("Decrypt = %s, %v", opened, err)
+	}
+	if _, err = testCipher(t, 2).Decrypt(sealed); err == nil {
+		t.Fatal("expected decryption with the wrong key to fail")
+	}
+}
+
+func TestDecode_PassesPlaintextThrough(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	c := testCipher(t, 3)
+	plain := []byte(`{"type":"gemini"}`)
+	sealed, _ := c.Encrypt(plain)
+
+	SetDefault(nil)
+	if got, err := Decode(plain); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("plaintext Decode = %s, %v", got, err)
+	}
+	if _, err := Decode(sealed); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+
+	SetDefault(c)
+	if got, err := Decode(sealed); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("encrypted Decode = %s, %v", got, err)
+	}
+}
+
+func TestParseAndLoadKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, keySize)
+	for _, raw := range []string{base64.StdEncoding.EncodeToString(key), "0707070707070707070707070707070707070707070707070707070707070707"} {
+		got, err := ParseKey(raw)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Fatalf("ParseKey(%q) = %x, %v", raw, got, err)
+		}
+	}
+	if _, err := ParseKey("too-short"); err == nil {
+		t.Fatal("expected short key to be rejected")
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "auth.key")
+	if err := os.WriteFile(keyFile, key, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(KeyEnvVar, "")
+	if got, err := LoadKey(keyFile); err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("LoadKey(file) = %x, %v", got, err)
+	}
+	t.Setenv(KeyEnvVar, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{9}, keySize)))
+	if got, err := LoadKey(keyFile); err != nil || got[0] != 9 {
+		t.Fatalf("env var should take precedence, got %x, %v", got, err)
+	}
+}
+
+func TestMigrateDir(t *testing.T) {
+	dir := t.TempDir()
+	c := testCipher(t, 4)
+	plain := []byte(`{"type":"codex","api_key":"sk"}`)
+	path := filepath.Join(dir, "codex.json")
+	if err := os.WriteFile(path, plain, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := MigrateDir(dir, c, false)
+	if err != nil || res.Converted != 1 || len(res.Failed) != 0 {
+		t.Fatalf("encrypt migration = %+v, %v", res, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !IsEncrypted(data) {
+		t.Fatalf("file not encrypted: %s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if res, _ = MigrateDir(dir, c, false); res.Converted != 0 || res.Skipped != 1 {
+		t.Fatalf("re-run should skip encrypted files: %+v", res)
+	}
+
+	if res, err = MigrateDir(dir, c, true); err != nil || res.Converted != 1 {
+		t.Fatalf("decrypt migration = %+v, %v", res, err)
+	}
+	if data, _ = os.ReadFile(path); !bytes.Equal(data, plain) {
+		t.Fatalf("decrypted file = %s", data)
+	}
+}
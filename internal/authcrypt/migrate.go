@@ -0,0 +1,64 @@
+package authcrypt
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MigrateResult summarises a directory migration.
+type MigrateResult struct {
+	Converted int
+	Skipped   int
+	Failed    []string
+}
+
+// MigrateDir rewrites every auth JSON file under dir. With decrypt false plaintext files are
+// encrypted with c; with decrypt true encrypted files are written back as plaintext. Files
+// already in the target form are left untouched so the command can be re-run safely.
+func MigrateDir(dir string, c *Cipher, decrypt bool) (MigrateResult, error) {
+	var result MigrateResult
+	if c == nil {
+		return result, fmt.Errorf("authcrypt: cipher is nil")
+	}
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return result, fmt.Errorf("authcrypt: auth directory is empty")
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			result.Failed = append(result.Failed, path)
+			return nil
+		}
+		if len(data) == 0 || IsEncrypted(data) != decrypt {
+			result.Skipped++
+			return nil
+		}
+		var out []byte
+		var errConvert error
+		if decrypt {
+			out, errConvert = c.Decrypt(data)
+		} else {
+			out, errConvert = c.Encrypt(data)
+		}
+		if errConvert == nil {
+			errConvert = writeAtomic(path, out, 0o600)
+		}
+		if errConvert != nil {
+			result.Failed = append(result.Failed, path)
+			return nil
+		}
+		result.Converted++
+		return nil
+	})
+	return result, err
+}
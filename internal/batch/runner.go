@@ -0,0 +1,540 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// DefaultConcurrency is the number of batch requests in flight across all batches.
+	DefaultConcurrency = 4
+	// DefaultMaxRequests is the per-batch request limit, as in the OpenAI Batch API.
+	DefaultMaxRequests = 50000
+
+	maxLineBytes       = 32 << 20
+	maxReportedErrors  = 100
+	maxCooldownRetries = 10
+	maxCooldownWait    = 5 * time.Minute
+)
+
+// cooldownBackoff is the wait before retrying a request rejected with 429 and no Retry-After,
+// doubled per retry.
+var cooldownBackoff = 5 * time.Second
+
+var (
+	errCancelled = errors.New("batch cancelled")
+	errShutdown  = errors.New("batch runner stopped")
+)
+
+// ErrNotCancellable is returned when cancelling a batch that already finished.
+var ErrNotCancellable = errors.New("batch has already finished")
+
+// Executor runs one request line of a batch on behalf of the client that created it.
+type Executor interface {
+	Execute(ctx context.Context, b StoredBatch, line RequestLine) Response
+}
+
+// Runner executes batches in the background. A shared limit caps the number of requests in
+// flight across all batches, and a batch whose requests are rejected because every credential
+// is cooling down pauses until the cooldown ends instead of failing them.
+type Runner struct {
+	store *Store
+	exec  Executor
+	limit *limiter
+
+	mu          sync.Mutex
+	maxRequests int
+	running     map[string]context.CancelCauseFunc
+	wg          sync.WaitGroup
+	ctx         context.Context
+	stop        context.CancelCauseFunc
+}
+
+// NewRunner creates a runner for the batches in store.
+func NewRunner(store *Store, exec Executor, concurrency int) *Runner {
+	ctx, stop := context.WithCancelCause(context.Background())
+	r := &Runner{
+		store:       store,
+		exec:        exec,
+		limit:       &limiter{wake: make(chan struct{})},
+		maxRequests: DefaultMaxRequests,
+		running:     make(map[string]context.CancelCauseFunc),
+		ctx:         ctx,
+		stop:        stop,
+	}
+	r.SetConcurrency(concurrency)
+	return r
+}
+
+// SetConcurrency changes the number of requests in flight; values below 1 use the default.
+func (r *Runner) SetConcurrency(n int) {
+	if n < 1 {
+		n = DefaultConcurrency
+	}
+	r.limit.setLimit(n)
+}
+
+// SetMaxRequests changes the per-batch request limit; values below 1 use the default.
+func (r *Runner) SetMaxRequests(n int) {
+	if n < 1 {
+		n = DefaultMaxRequests
+	}
+	r.mu.Lock()
+	r.maxRequests = n
+	r.mu.Unlock()
+}
+
+// Start resumes batches that were still running when the process stopped.
+func (r *Runner) Start() {
+	for _, b := range r.store.pendingBatches() {
+		log.Infof("Resuming batch %s (%s)", b.ID, b.Status)
+		r.Submit(b.ID)
+	}
+}
+
+// Stop interrupts running batches without changing their state, so they resume on the next
+// Start, and waits for them to wind down.
+func (r *Runner) Stop() {
+	r.stop(errShutdown)
+	r.wg.Wait()
+}
+
+// Create stores a new batch for an input file and starts executing it. The batch's requests
+// run as owner with the given X-Proxy-Tags.
+func (r *Runner) Create(owner, proxyTags, inputFileID, endpoint string, metadata map[string]string) (StoredBatch, error) {
+	now := time.Now()
+	expiresAt := now.Add(24 * time.Hour).Unix()
+	b := StoredBatch{
+		Batch: Batch{
+			ID:               newID("batch_"),
+			Object:           "batch",
+			Endpoint:         endpoint,
+			InputFileID:      inputFileID,
+			CompletionWindow: CompletionWindow,
+			Status:           StatusValidating,
+			CreatedAt:        now.Unix(),
+			ExpiresAt:        &expiresAt,
+			Metadata:         metadata,
+		},
+		Owner:     owner,
+		ProxyTags: proxyTags,
+	}
+	stored := b
+	if err := r.store.AddBatch(&stored); err != nil {
+		return StoredBatch{}, err
+	}
+	r.Submit(b.ID)
+	return b, nil
+}
+
+// Submit starts executing a batch in the background.
+func (r *Runner) Submit(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.running[id]; ok || r.ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancelCause(r.ctx)
+	r.running[id] = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, id)
+			r.mu.Unlock()
+			cancel(nil)
+		}()
+		r.run(ctx, id)
+	}()
+}
+
+// Cancel moves a batch to cancelling. Requests in flight are abandoned, results written so far
+// are kept, and the batch ends as cancelled.
+func (r *Runner) Cancel(id string) (StoredBatch, error) {
+	var notCancellable bool
+	b, err := r.store.UpdateBatch(id, func(b *StoredBatch) {
+		switch {
+		case b.Status.Terminal():
+			notCancellable = true
+		case b.Status != StatusCancelling:
+			b.setStatus(StatusCancelling, time.Now())
+		}
+	})
+	if err != nil {
+		return b, err
+	}
+	if notCancellable {
+		return b, ErrNotCancellable
+	}
+	r.mu.Lock()
+	cancel, running := r.running[id]
+	r.mu.Unlock()
+	if running {
+		cancel(errCancelled)
+	} else {
+		r.Submit(id)
+	}
+	return b, nil
+}
+
+func (r *Runner) run(ctx context.Context, id string) {
+	b, ok := r.store.GetBatch(id)
+	if !ok {
+		return
+	}
+	if b.Status == StatusCancelling {
+		r.finish(id, StatusCancelled)
+		return
+	}
+
+	r.mu.Lock()
+	maxRequests := r.maxRequests
+	r.mu.Unlock()
+	lines, lineErrs, err := readInput(r.store.FilePath(b.InputFileID), b.Endpoint, maxRequests)
+	if err != nil {
+		lineErrs = []LineError{{Code: "invalid_file", Message: err.Error()}}
+	}
+	if len(lineErrs) > 0 {
+		r.fail(id, lineErrs)
+		return
+	}
+
+	done, completed, failed := r.store.finishedResults(id)
+	b, err = r.store.UpdateBatch(id, func(b *StoredBatch) {
+		if b.Status == StatusValidating {
+			b.setStatus(StatusInProgress, time.Now())
+		}
+		b.RequestCounts = RequestCounts{Total: len(lines), Completed: completed, Failed: failed}
+	})
+	if err != nil {
+		log.Errorf("batch %s: %v", id, err)
+		return
+	}
+
+	expiresAt := time.Unix(b.CreatedAt, 0).Add(24 * time.Hour)
+	if b.ExpiresAt != nil {
+		expiresAt = time.Unix(*b.ExpiresAt, 0)
+	}
+	runCtx, cancelRun := context.WithDeadline(ctx, expiresAt)
+	defer cancelRun()
+
+	pause := &pacer{}
+	var wg sync.WaitGroup
+	for _, line := range lines {
+		if _, ok := done[line.CustomID]; ok {
+			continue
+		}
+		if pause.wait(runCtx) != nil || r.limit.acquire(runCtx) != nil {
+			break
+		}
+		wg.Add(1)
+		go func(line RequestLine) {
+			defer wg.Done()
+			defer r.limit.release()
+			r.execute(runCtx, b, line, pause)
+		}(line)
+	}
+	wg.Wait()
+
+	switch cause := context.Cause(runCtx); {
+	case errors.Is(cause, errShutdown):
+		log.Infof("Batch %s interrupted; it will resume on restart", id)
+	case errors.Is(cause, errCancelled):
+		r.finish(id, StatusCancelled)
+	case errors.Is(cause, context.DeadlineExceeded):
+		r.expireRemaining(id, lines)
+		r.finish(id, StatusExpired)
+	default:
+		r.finish(id, StatusCompleted)
+	}
+}
+
+// execute runs one line and records its result. Requests rejected because credentials are
+// cooling down are retried after the cooldown, pausing the whole batch meanwhile. Requests
+// interrupted by cancellation, expiry or shutdown are not recorded.
+func (r *Runner) execute(ctx context.Context, b StoredBatch, line RequestLine, pause *pacer) {
+	var resp Response
+	for attempt := 0; ; attempt++ {
+		resp = r.exec.Execute(ctx, b, line)
+		if ctx.Err() != nil {
+			return
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= maxCooldownRetries {
+			break
+		}
+		wait := resp.RetryAfter
+		if wait <= 0 {
+			wait = cooldownBackoff << attempt
+		}
+		pause.extend(min(wait, maxCooldownWait))
+		if pause.wait(ctx) != nil {
+			return
+		}
+	}
+
+	failed := resp.StatusCode < 200 || resp.StatusCode >= 300
+	body := resp.Body
+	if !json.Valid(body) {
+		body, _ = json.Marshal(map[string]any{"error": map[string]any{"message": string(resp.Body), "type": "server_error"}})
+	}
+	requestID := resp.RequestID
+	if requestID == "" {
+		requestID = newID("req_")
+	}
+	r.record(b.ID, line.CustomID, failed, &resultResponse{StatusCode: resp.StatusCode, RequestID: requestID, Body: body}, nil)
+}
+
+// expireRemaining records every line without a result as expired.
+func (r *Runner) expireRemaining(id string, lines []RequestLine) {
+	done, _, _ := r.store.finishedResults(id)
+	for _, line := range lines {
+		if _, ok := done[line.CustomID]; ok {
+			continue
+		}
+		r.record(id, line.CustomID, true, nil, &resultError{
+			Code:    "batch_expired",
+			Message: "This request could not be executed before the completion window expired.",
+		})
+	}
+}
+
+type resultResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type resultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type resultLine struct {
+	ID       string          `json:"id"`
+	CustomID string          `json:"custom_id"`
+	Response *resultResponse `json:"response"`
+	Error    *resultError    `json:"error"`
+}
+
+func (r *Runner) record(id, customID string, failed bool, resp *resultResponse, resErr *resultError) {
+	data, err := json.Marshal(resultLine{ID: newID("batch_req_"), CustomID: customID, Response: resp, Error: resErr})
+	if err == nil {
+		err = r.store.appendResult(id, failed, data)
+	}
+	if err != nil {
+		log.Errorf("batch %s: failed to record result for %s: %v", id, customID, err)
+		return
+	}
+	r.store.countResult(id, failed)
+}
+
+// finish publishes the result files and moves the batch to its final status.
+func (r *Runner) finish(id string, status Status) {
+	b, err := r.store.UpdateBatch(id, func(b *StoredBatch) {
+		if status == StatusCompleted {
+			b.setStatus(StatusFinalizing, time.Now())
+		}
+	})
+	if err != nil {
+		log.Errorf("batch %s: %v", id, err)
+		return
+	}
+	outputID, errorID, errPublish := r.store.publishResults(id, b.Owner)
+	if errPublish != nil {
+		log.Errorf("batch %s: failed to publish results: %v", id, errPublish)
+	}
+	_, completed, failed := r.store.finishedResults(id)
+	b, err = r.store.UpdateBatch(id, func(b *StoredBatch) {
+		b.OutputFileID, b.ErrorFileID = outputID, errorID
+		if completed+failed > 0 {
+			// Results not yet published (e.g. after a publish error) are still on disk.
+			b.RequestCounts.Completed, b.RequestCounts.Failed = completed, failed
+		}
+		b.setStatus(status, time.Now())
+	})
+	if err != nil {
+		log.Errorf("batch %s: %v", id, err)
+		return
+	}
+	log.Infof("Batch %s %s: %d completed, %d failed of %d", id, b.Status, b.RequestCounts.Completed, b.RequestCounts.Failed, b.RequestCounts.Total)
+}
+
+func (r *Runner) fail(id string, lineErrs []LineError) {
+	_, err := r.store.UpdateBatch(id, func(b *StoredBatch) {
+		b.Errors = &Errors{Object: "list", Data: lineErrs}
+		b.setStatus(StatusFailed, time.Now())
+	})
+	if err != nil {
+		log.Errorf("batch %s: %v", id, err)
+		return
+	}
+	log.Warnf("Batch %s failed validation: %s", id, lineErrs[0].Message)
+}
+
+// readInput parses and validates a batch input file. Validation problems are returned as line
+// errors, in the form OpenAI reports them on a failed batch.
+func readInput(path, endpoint string, maxRequests int) ([]RequestLine, []LineError, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("input file not found")
+		}
+		return nil, nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var lines []RequestLine
+	var lineErrs []LineError
+	addErr := func(line int, code, param, message string) {
+		if len(lineErrs) >= maxReportedErrors {
+			return
+		}
+		e := LineError{Code: code, Message: message, Line: &line}
+		if param != "" {
+			e.Param = &param
+		}
+		lineErrs = append(lineErrs, e)
+	}
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		if !gjson.Valid(raw) || !gjson.Parse(raw).IsObject() {
+			addErr(lineNo, "invalid_json_line", "", "This line is not parseable as valid JSON.")
+			continue
+		}
+		parsed := gjson.Parse(raw)
+		customID := parsed.Get("custom_id").String()
+		body := parsed.Get("body")
+		switch {
+		case customID == "":
+			addErr(lineNo, "missing_required_parameter", "custom_id", "Missing required parameter: 'custom_id'.")
+			continue
+		case !strings.EqualFold(parsed.Get("method").String(), http.MethodPost):
+			addErr(lineNo, "invalid_value", "method", "Invalid value for 'method': only POST is supported.")
+			continue
+		case parsed.Get("url").String() != endpoint:
+			addErr(lineNo, "mismatched_endpoint", "url", fmt.Sprintf("The url %q does not match the batch endpoint %q.", parsed.Get("url").String(), endpoint))
+			continue
+		case !body.IsObject():
+			addErr(lineNo, "missing_required_parameter", "body", "Missing required parameter: 'body'.")
+			continue
+		}
+		if _, dup := seen[customID]; dup {
+			addErr(lineNo, "duplicate_custom_id", "custom_id", fmt.Sprintf("The custom_id %q is used by more than one request.", customID))
+			continue
+		}
+		seen[customID] = struct{}{}
+		lines = append(lines, RequestLine{CustomID: customID, Method: http.MethodPost, URL: endpoint, Body: []byte(body.Raw), Line: lineNo})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	if len(lineErrs) == 0 && len(lines) == 0 {
+		lineErrs = append(lineErrs, LineError{Code: "empty_file", Message: "The input file contains no requests."})
+	}
+	if len(lines) > maxRequests {
+		lineErrs = append(lineErrs, LineError{Code: "too_many_requests", Message: fmt.Sprintf("The input file contains %d requests; the limit is %d.", len(lines), maxRequests)})
+	}
+	return lines, lineErrs, nil
+}
+
+func newID(prefix string) string {
+	return prefix + strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// pacer holds back a batch's dispatching while credentials are cooling down.
+type pacer struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (p *pacer) extend(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until := time.Now().Add(d); until.After(p.until) {
+		p.until = until
+	}
+}
+
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	d := time.Until(p.until)
+	p.mu.Unlock()
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limiter is a counting semaphore whose size can change while it is in use.
+type limiter struct {
+	mu     sync.Mutex
+	limit  int
+	active int
+	wake   chan struct{}
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.broadcast()
+	l.mu.Unlock()
+}
+
+func (l *limiter) setLimit(n int) {
+	l.mu.Lock()
+	l.limit = n
+	l.broadcast()
+	l.mu.Unlock()
+}
+
+// broadcast wakes all waiters. The caller must hold l.mu.
+func (l *limiter) broadcast() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
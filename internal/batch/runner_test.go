@@ -0,0 +1,300 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type executorFunc func(ctx context.Context, b StoredBatch, line RequestLine) Response
+
+func (f executorFunc) Execute(ctx context.Context, b StoredBatch, line RequestLine) Response {
+	return f(ctx, b, line)
+}
+
+func newTestRunner(t *testing.T, exec Executor) (*Runner, *Store) {
+	t.Helper()
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	runner := NewRunner(store, exec, 2)
+	t.Cleanup(runner.Stop)
+	return runner, store
+}
+
+func createBatch(t *testing.T, runner *Runner, store *Store, endpoint string, lines ...string) StoredBatch {
+	t.Helper()
+	file, err := store.CreateFile("key-1", "input.jsonl", PurposeBatch, []byte(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	b, err := runner.Create("key-1", "", file.ID, endpoint, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return b
+}
+
+func chatLine(customID string) string {
+	return `{"custom_id":"` + customID + `","method":"POST","url":"/v1/chat/completions","body":{"model":"m","messages":[]}}`
+}
+
+func waitForStatus(t *testing.T, store *Store, id string, want Status) StoredBatch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, _ := store.GetBatch(id)
+		if b.Status == want {
+			return b
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch status = %s, want %s", b.Status, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func readResults(t *testing.T, store *Store, fileID *string) map[string]gjson.Result {
+	t.Helper()
+	if fileID == nil {
+		t.Fatal("result file id is nil")
+	}
+	f, err := os.Open(store.FilePath(*fileID))
+	if err != nil {
+		t.Fatalf("open result file: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	results := make(map[string]gjson.Result)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := gjson.Parse(scanner.Text())
+		results[line.Get("custom_id").String()] = line
+	}
+	return results
+}
+
+func TestRunnerWritesOutputAndErrorFiles(t *testing.T) {
+	runner, store := newTestRunner(t, executorFunc(func(_ context.Context, _ StoredBatch, line RequestLine) Response {
+		if line.CustomID == "bad" {
+			return Response{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":{"message":"bad request"}}`)}
+		}
+		return Response{StatusCode: http.StatusOK, Body: []byte(`{"id":"chatcmpl-1"}`), RequestID: "req-" + line.CustomID}
+	}))
+	b := createBatch(t, runner, store, EndpointChatCompletions, chatLine("a"), chatLine("b"), chatLine("bad"))
+
+	b = waitForStatus(t, store, b.ID, StatusCompleted)
+	if b.RequestCounts != (RequestCounts{Total: 3, Completed: 2, Failed: 1}) {
+		t.Fatalf("request counts = %+v", b.RequestCounts)
+	}
+	if b.InProgressAt == nil || b.FinalizingAt == nil || b.CompletedAt == nil {
+		t.Fatalf("missing status timestamps: %+v", b.Batch)
+	}
+
+	output := readResults(t, store, b.OutputFileID)
+	if len(output) != 2 {
+		t.Fatalf("output lines = %d, want 2", len(output))
+	}
+	a := output["a"]
+	if a.Get("response.status_code").Int() != 200 || a.Get("response.body.id").String() != "chatcmpl-1" || a.Get("response.request_id").String() != "req-a" {
+		t.Fatalf("unexpected output line: %s", a.Raw)
+	}
+	if !strings.HasPrefix(a.Get("id").String(), "batch_req_") || a.Get("error").Type != gjson.Null {
+		t.Fatalf("unexpected output line: %s", a.Raw)
+	}
+
+	errorsOut := readResults(t, store, b.ErrorFileID)
+	if bad := errorsOut["bad"]; bad.Get("response.status_code").Int() != 400 || bad.Get("response.body.error.message").String() != "bad request" {
+		t.Fatalf("unexpected error line: %s", bad.Raw)
+	}
+	if file, _ := store.GetFile(*b.OutputFileID); file.Owner != "key-1" || file.Purpose != PurposeBatchOutput {
+		t.Fatalf("output file = %+v", file)
+	}
+}
+
+func TestRunnerRejectsInvalidInput(t *testing.T) {
+	runner, store := newTestRunner(t, executorFunc(func(context.Context, StoredBatch, RequestLine) Response {
+		t.Error("executor called for an invalid batch")
+		return Response{}
+	}))
+	b := createBatch(t, runner, store, EndpointChatCompletions,
+		chatLine("a"),
+		`{"custom_id":"b","method":"POST","url":"/v1/embeddings","body":{}}`,
+		chatLine("a"),
+		`not json`,
+	)
+
+	b = waitForStatus(t, store, b.ID, StatusFailed)
+	if b.Errors == nil || len(b.Errors.Data) != 3 {
+		t.Fatalf("errors = %+v", b.Errors)
+	}
+	codes := []string{b.Errors.Data[0].Code, b.Errors.Data[1].Code, b.Errors.Data[2].Code}
+	if strings.Join(codes, ",") != "mismatched_endpoint,duplicate_custom_id,invalid_json_line" {
+		t.Fatalf("error codes = %v", codes)
+	}
+	if line := b.Errors.Data[0].Line; line == nil || *line != 2 {
+		t.Fatalf("error line = %v, want 2", line)
+	}
+}
+
+func TestRunnerWaitsOutCooldowns(t *testing.T) {
+	var calls atomic.Int32
+	runner, store := newTestRunner(t, executorFunc(func(context.Context, StoredBatch, RequestLine) Response {
+		if calls.Add(1) <= 2 {
+			return Response{StatusCode: http.StatusTooManyRequests, Body: []byte(`{"error":{"message":"cooling down"}}`), RetryAfter: 10 * time.Millisecond}
+		}
+		return Response{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+	}))
+	b := createBatch(t, runner, store, EndpointChatCompletions, chatLine("a"))
+
+	b = waitForStatus(t, store, b.ID, StatusCompleted)
+	if b.RequestCounts.Completed != 1 || b.RequestCounts.Failed != 0 || b.ErrorFileID != nil {
+		t.Fatalf("batch = %+v", b.Batch)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("executor calls = %d, want 3", got)
+	}
+}
+
+func TestRunnerCancel(t *testing.T) {
+	var once sync.Once
+	started := make(chan struct{})
+	runner, store := newTestRunner(t, executorFunc(func(ctx context.Context, _ StoredBatch, line RequestLine) Response {
+		if line.CustomID == "a" {
+			return Response{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+		}
+		once.Do(func() { close(started) })
+		<-ctx.Done()
+		return Response{StatusCode: http.StatusInternalServerError, Body: []byte(`{}`)}
+	}))
+	runner.SetConcurrency(1)
+	b := createBatch(t, runner, store, EndpointChatCompletions, chatLine("a"), chatLine("b"), chatLine("c"))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch did not start")
+	}
+	if _, err := runner.Cancel(b.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	b = waitForStatus(t, store, b.ID, StatusCancelled)
+	if b.CancellingAt == nil || b.CancelledAt == nil {
+		t.Fatalf("missing cancel timestamps: %+v", b.Batch)
+	}
+	if output := readResults(t, store, b.OutputFileID); len(output) != 1 {
+		t.Fatalf("output lines = %d, want 1", len(output))
+	}
+	if b.ErrorFileID != nil {
+		t.Fatalf("interrupted requests should not be recorded, got error file %s", *b.ErrorFileID)
+	}
+	if _, err := runner.Cancel(b.ID); err != ErrNotCancellable {
+		t.Fatalf("second Cancel error = %v, want ErrNotCancellable", err)
+	}
+}
+
+func TestRunnerResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	file, _ := store.CreateFile("key-1", "input.jsonl", PurposeBatch, []byte(chatLine("a")+"\n"+chatLine("b")+"\n"))
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	if err = store.AddBatch(&StoredBatch{Batch: Batch{
+		ID: "batch_resume", Object: "batch", Endpoint: EndpointChatCompletions, InputFileID: file.ID,
+		Status: StatusInProgress, CreatedAt: time.Now().Unix(), ExpiresAt: &expiresAt,
+	}, Owner: "key-1"}); err != nil {
+		t.Fatalf("AddBatch: %v", err)
+	}
+	if err = store.appendResult("batch_resume", false, []byte(`{"id":"batch_req_1","custom_id":"a","response":{"status_code":200,"request_id":"x","body":{}},"error":null}`)); err != nil {
+		t.Fatalf("appendResult: %v", err)
+	}
+
+	store, err = NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	var executed []string
+	var mu sync.Mutex
+	runner := NewRunner(store, executorFunc(func(_ context.Context, _ StoredBatch, line RequestLine) Response {
+		mu.Lock()
+		executed = append(executed, line.CustomID)
+		mu.Unlock()
+		return Response{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+	}), 2)
+	t.Cleanup(runner.Stop)
+	runner.Start()
+
+	b := waitForStatus(t, store, "batch_resume", StatusCompleted)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(executed) != 1 || executed[0] != "b" {
+		t.Fatalf("executed = %v, want [b]", executed)
+	}
+	if b.RequestCounts != (RequestCounts{Total: 2, Completed: 2}) {
+		t.Fatalf("request counts = %+v", b.RequestCounts)
+	}
+}
+
+func TestLimiterResize(t *testing.T) {
+	l := &limiter{wake: make(chan struct{})}
+	l.setLimit(1)
+	ctx := context.Background()
+	if err := l.acquire(ctx); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	acquired := make(chan struct{})
+	go func() {
+		_ = l.acquire(ctx)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	l.setLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("raising the limit did not wake the waiter")
+	}
+}
+
+func TestStoreHashesLegacyOwners(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "files.json"), []byte(`{"file-1":{"id":"file-1","purpose":"batch","owner":"sk-raw-key"}}`), 0644); err != nil {
+		t.Fatalf("write files.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "batches.json"), []byte(`{"batch_1":{"id":"batch_1","status":"completed","owner":"sk-raw-key"}}`), 0644); err != nil {
+		t.Fatalf("write batches.json: %v", err)
+	}
+
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	want := OwnerOf("sk-raw-key")
+	if file, _ := store.GetFile("file-1"); file.Owner != want {
+		t.Fatalf("file owner = %q, want %q", file.Owner, want)
+	}
+	if b, _ := store.GetBatch("batch_1"); b.Owner != want {
+		t.Fatalf("batch owner = %q, want %q", b.Owner, want)
+	}
+	for _, name := range []string{"files.json", "batches.json"} {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		if strings.Contains(string(data), "sk-raw-key") {
+			t.Fatalf("%s still holds the raw API key", name)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"cliproxy/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// countsSaveInterval throttles persisting request counts while a batch runs. Counts are
+// rebuilt from the result files on restart, so a lost update is harmless.
+const countsSaveInterval = time.Second
+
+// Store persists files, batches and per-batch results under a data directory:
+//
+//	files.json, batches.json   metadata
+//	files/<file-id>            file contents
+//	results/<batch-id>/        output.jsonl and errors.jsonl of running batches
+type Store struct {
+	mu          sync.RWMutex
+	dir         string
+	filesPath   string
+	batchesPath string
+	files       map[string]*StoredFile
+	batches     map[string]*StoredBatch
+	lastSave    time.Time
+
+	resultsMu sync.Mutex
+}
+
+// NewStore initializes a Store in dataDir and loads existing metadata.
+func NewStore(dataDir string) (*Store, error) {
+	for _, dir := range []string{dataDir, filepath.Join(dataDir, "files"), filepath.Join(dataDir, "results")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create batch data directory: %w", err)
+		}
+	}
+	s := &Store{
+		dir:         dataDir,
+		filesPath:   filepath.Join(dataDir, "files.json"),
+		batchesPath: filepath.Join(dataDir, "batches.json"),
+		files:       make(map[string]*StoredFile),
+		batches:     make(map[string]*StoredBatch),
+	}
+	for path, target := range map[string]any{s.filesPath: &s.files, s.batchesPath: &s.batches} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		if err := json.Unmarshal(data, target); err != nil {
+			log.Warnf("failed to unmarshal %s: %v", filepath.Base(path), err)
+		}
+	}
+	if s.hashLegacyOwners() {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// OwnerOf returns the owner identity stored for a client API key. Only a hash of the key is
+// persisted, so the metadata files never hold a usable credential.
+func OwnerOf(apiKey string) string {
+	return logging.HashAPIKey(apiKey)
+}
+
+// isOwnerHash reports whether owner already has the OwnerOf form: 16 lowercase hex digits.
+func isOwnerHash(owner string) bool {
+	if len(owner) != 16 {
+		return false
+	}
+	for _, r := range owner {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// hashLegacyOwners replaces raw API keys stored as owners by earlier versions with their
+// OwnerOf form and reports whether anything changed.
+func (s *Store) hashLegacyOwners() bool {
+	changed := false
+	for _, file := range s.files {
+		if file.Owner != "" && !isOwnerHash(file.Owner) {
+			file.Owner, changed = OwnerOf(file.Owner), true
+		}
+	}
+	for _, b := range s.batches {
+		if b.Owner != "" && !isOwnerHash(b.Owner) {
+			b.Owner, changed = OwnerOf(b.Owner), true
+		}
+	}
+	return changed
+}
+
+// save persists all metadata. The caller must hold s.mu.
+func (s *Store) save() error {
+	s.lastSave = time.Now()
+	data, err := json.MarshalIndent(s.files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal files: %w", err)
+	}
+	if err := writeFileAtomic(s.filesPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write files: %w", err)
+	}
+	data, err = json.MarshalIndent(s.batches, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batches: %w", err)
+	}
+	if err := writeFileAtomic(s.batchesPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write batches: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over
+// path, so a crash mid-write leaves the previous contents intact.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+	}
+	return err
+}
+
+// FilePath returns where the contents of file id are stored.
+func (s *Store) FilePath(id string) string {
+	return filepath.Join(s.dir, "files", id)
+}
+
+// CreateFile stores content as a new file owned by owner.
+func (s *Store) CreateFile(owner, filename, purpose string, content []byte) (File, error) {
+	id := newID("file-")
+	if err := writeFileAtomic(s.FilePath(id), content, 0644); err != nil {
+		return File{}, fmt.Errorf("failed to write file: %w", err)
+	}
+	return s.addFile(id, owner, filename, purpose, int64(len(content)))
+}
+
+// adoptFile moves the file at src into the store as a new file owned by owner.
+func (s *Store) adoptFile(owner, filename, purpose, src string) (File, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return File{}, err
+	}
+	id := newID("file-")
+	if err := os.Rename(src, s.FilePath(id)); err != nil {
+		return File{}, fmt.Errorf("failed to move file: %w", err)
+	}
+	return s.addFile(id, owner, filename, purpose, info.Size())
+}
+
+func (s *Store) addFile(id, owner, filename, purpose string, size int64) (File, error) {
+	file := &StoredFile{
+		File: File{
+			ID:        id,
+			Object:    "file",
+			Bytes:     size,
+			CreatedAt: time.Now().Unix(),
+			Filename:  filename,
+			Purpose:   purpose,
+			Status:    "processed",
+		},
+		Owner: owner,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[id] = file
+	return file.File, s.save()
+}
+
+// GetFile returns the file with the given id.
+func (s *Store) GetFile(id string) (StoredFile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[id]
+	if !ok {
+		return StoredFile{}, false
+	}
+	return *file, true
+}
+
+// ListFiles returns the files owned by owner, newest first, optionally filtered by purpose.
+func (s *Store) ListFiles(owner, purpose string) []File {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]File, 0, len(s.files))
+	for _, file := range s.files {
+		if file.Owner == owner && (purpose == "" || file.Purpose == purpose) {
+			list = append(list, file.File)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt > list[j].CreatedAt
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list
+}
+
+// DeleteFile removes a file and its contents.
+func (s *Store) DeleteFile(id string) error {
+	s.mu.Lock()
+	delete(s.files, id)
+	err := s.save()
+	s.mu.Unlock()
+	if errRemove := os.Remove(s.FilePath(id)); errRemove != nil && !os.IsNotExist(errRemove) && err == nil {
+		err = errRemove
+	}
+	return err
+}
+
+// AddBatch stores a new batch.
+func (s *Store) AddBatch(b *StoredBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches[b.ID] = b
+	return s.save()
+}
+
+// GetBatch returns a copy of the batch with the given id.
+func (s *Store) GetBatch(id string) (StoredBatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.batches[id]
+	if !ok {
+		return StoredBatch{}, false
+	}
+	return *b, true
+}
+
+// ListBatches returns the batches owned by owner, newest first.
+func (s *Store) ListBatches(owner string) []Batch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Batch, 0, len(s.batches))
+	for _, b := range s.batches {
+		if b.Owner == owner {
+			list = append(list, b.Batch)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt > list[j].CreatedAt
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list
+}
+
+// pendingBatches returns the batches that have not reached a terminal state.
+func (s *Store) pendingBatches() []StoredBatch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var list []StoredBatch
+	for _, b := range s.batches {
+		if !b.Status.Terminal() {
+			list = append(list, *b)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+	return list
+}
+
+// UpdateBatch applies fn to the stored batch, persists it and returns the updated copy.
+func (s *Store) UpdateBatch(id string, fn func(*StoredBatch)) (StoredBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.batches[id]
+	if !ok {
+		return StoredBatch{}, fmt.Errorf("batch %s not found", id)
+	}
+	fn(b)
+	return *b, s.save()
+}
+
+// countResult tallies a finished request, persisting at most once per countsSaveInterval.
+func (s *Store) countResult(id string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.batches[id]
+	if !ok {
+		return
+	}
+	if failed {
+		b.RequestCounts.Failed++
+	} else {
+		b.RequestCounts.Completed++
+	}
+	if time.Since(s.lastSave) >= countsSaveInterval {
+		if err := s.save(); err != nil {
+			log.Warnf("batch %s: failed to persist request counts: %v", id, err)
+		}
+	}
+}
+
+func (s *Store) resultPath(id string, failed bool) string {
+	name := "output.jsonl"
+	if failed {
+		name = "errors.jsonl"
+	}
+	return filepath.Join(s.dir, "results", id, name)
+}
+
+// appendResult appends one JSONL line to the batch's output or error results.
+func (s *Store) appendResult(id string, failed bool, line []byte) error {
+	s.resultsMu.Lock()
+	defer s.resultsMu.Unlock()
+	path := s.resultPath(id, failed)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// finishedResults returns the custom IDs already written for a batch, and how many of them
+// succeeded and failed, so an interrupted batch can resume where it stopped.
+func (s *Store) finishedResults(id string) (done map[string]struct{}, completed, failed int) {
+	s.resultsMu.Lock()
+	defer s.resultsMu.Unlock()
+	done = make(map[string]struct{})
+	for _, isError := range []bool{false, true} {
+		f, err := os.Open(s.resultPath(id, isError))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+		for scanner.Scan() {
+			var line struct {
+				CustomID string `json:"custom_id"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &line) != nil || line.CustomID == "" {
+				continue
+			}
+			done[line.CustomID] = struct{}{}
+			if isError {
+				failed++
+			} else {
+				completed++
+			}
+		}
+		_ = f.Close()
+	}
+	return done, completed, failed
+}
+
+// publishResults turns the batch's result files into output files owned by owner and returns
+// their IDs (nil when a file is empty), then removes the results directory.
+func (s *Store) publishResults(id, owner string) (outputID, errorID *string, err error) {
+	s.resultsMu.Lock()
+	defer s.resultsMu.Unlock()
+	for _, isError := range []bool{false, true} {
+		path := s.resultPath(id, isError)
+		if info, errStat := os.Stat(path); errStat != nil || info.Size() == 0 {
+			continue
+		}
+		name := id + "_output.jsonl"
+		if isError {
+			name = id + "_error.jsonl"
+		}
+		file, errAdopt := s.adoptFile(owner, name, PurposeBatchOutput, path)
+		if errAdopt != nil {
+			return outputID, errorID, errAdopt
+		}
+		fileID := file.ID
+		if isError {
+			errorID = &fileID
+		} else {
+			outputID = &fileID
+		}
+	}
+	_ = os.RemoveAll(filepath.Join(s.dir, "results", id))
+	return outputID, errorID, nil
+}
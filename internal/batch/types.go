@@ -0,0 +1,155 @@
+// Package batch emulates the OpenAI Files and Batch APIs. Uploaded JSONL files are persisted in
+// the data directory and batches execute their lines in the background through the proxy's own
+// request pipeline, producing output and error files in OpenAI's format.
+package batch
+
+import "time"
+
+// Status is the lifecycle state of a batch, as defined by the OpenAI Batch API.
+type Status string
+
+const (
+	StatusValidating Status = "validating"
+	StatusFailed     Status = "failed"
+	StatusInProgress Status = "in_progress"
+	StatusFinalizing Status = "finalizing"
+	StatusCompleted  Status = "completed"
+	StatusExpired    Status = "expired"
+	StatusCancelling Status = "cancelling"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Terminal reports whether the batch will not change state again.
+func (s Status) Terminal() bool {
+	switch s {
+	case StatusFailed, StatusCompleted, StatusExpired, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// File purposes.
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+)
+
+// Supported batch endpoints.
+const (
+	EndpointChatCompletions = "/v1/chat/completions"
+	EndpointResponses       = "/v1/responses"
+	EndpointEmbeddings      = "/v1/embeddings"
+)
+
+// CompletionWindow is the only completion window OpenAI accepts.
+const CompletionWindow = "24h"
+
+// File is an OpenAI file object.
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status"`
+}
+
+// StoredFile is a file as persisted, with the owner identity (see OwnerOf) of the API key that
+// uploaded it.
+type StoredFile struct {
+	File
+	Owner string `json:"owner,omitempty"`
+}
+
+// RequestCounts tallies the requests of a batch.
+type RequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// LineError describes why a batch failed validation.
+type LineError struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Param   *string `json:"param"`
+	Line    *int    `json:"line"`
+}
+
+// Errors is the list of validation errors of a failed batch.
+type Errors struct {
+	Object string      `json:"object"`
+	Data   []LineError `json:"data"`
+}
+
+// Batch is an OpenAI batch object.
+type Batch struct {
+	ID               string            `json:"id"`
+	Object           string            `json:"object"`
+	Endpoint         string            `json:"endpoint"`
+	Errors           *Errors           `json:"errors"`
+	InputFileID      string            `json:"input_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           Status            `json:"status"`
+	OutputFileID     *string           `json:"output_file_id"`
+	ErrorFileID      *string           `json:"error_file_id"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     *int64            `json:"in_progress_at"`
+	ExpiresAt        *int64            `json:"expires_at"`
+	FinalizingAt     *int64            `json:"finalizing_at"`
+	CompletedAt      *int64            `json:"completed_at"`
+	FailedAt         *int64            `json:"failed_at"`
+	ExpiredAt        *int64            `json:"expired_at"`
+	CancellingAt     *int64            `json:"cancelling_at"`
+	CancelledAt      *int64            `json:"cancelled_at"`
+	RequestCounts    RequestCounts     `json:"request_counts"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// StoredBatch is a batch as persisted, with the client identity its requests run as.
+type StoredBatch struct {
+	Batch
+	Owner     string `json:"owner,omitempty"`      // OwnerOf the API key that created the batch
+	ProxyTags string `json:"proxy_tags,omitempty"` // X-Proxy-Tags of the creating request
+}
+
+// setStatus moves the batch to status and stamps the matching timestamp.
+func (b *Batch) setStatus(status Status, now time.Time) {
+	b.Status = status
+	ts := now.Unix()
+	switch status {
+	case StatusInProgress:
+		b.InProgressAt = &ts
+	case StatusFinalizing:
+		b.FinalizingAt = &ts
+	case StatusCompleted:
+		b.CompletedAt = &ts
+	case StatusFailed:
+		b.FailedAt = &ts
+	case StatusExpired:
+		b.ExpiredAt = &ts
+	case StatusCancelling:
+		b.CancellingAt = &ts
+	case StatusCancelled:
+		b.CancelledAt = &ts
+	}
+}
+
+// RequestLine is one line of a batch input file.
+type RequestLine struct {
+	CustomID string `json:"custom_id"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Body     []byte `json:"-"`
+	Line     int    `json:"-"`
+}
+
+// Response is the result of executing one request line.
+type Response struct {
+	StatusCode int
+	Body       []byte
+	RequestID  string
+	// RetryAfter is set when the request was rejected because credentials are cooling down.
+	RetryAfter time.Duration
+}
@@ -0,0 +1,69 @@
+// Package cmd contains CLI helpers. This file configures encryption at rest for auth
+// files and implements the one-shot migration of an existing auth directory.
+package cmd
+
+import (
+	"fmt"
+
+	"cliproxy/internal/authcrypt"
+	"cliproxy/internal/config"
+	"cliproxy/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// ConfigureAuthEncryption installs the process-wide auth file cipher when auth-encryption is
+// enabled. It fails rather than silently writing plaintext when no usable key is available.
+func ConfigureAuthEncryption(cfg *config.Config) error {
+	if cfg == nil || !cfg.AuthEncryption.Enable {
+		authcrypt.SetDefault(nil)
+		return nil
+	}
+	c, err := loadAuthCipher(cfg)
+	if err != nil {
+		return err
+	}
+	authcrypt.SetDefault(c)
+	log.Info("auth file encryption enabled")
+	return nil
+}
+
+// DoMigrateAuthEncryption rewrites every auth file in auth-dir, encrypting plaintext files or,
+// when decrypt is true, restoring encrypted files to plaintext. The key is loaded the same way
+// as at startup, regardless of auth-encryption.enable.
+func DoMigrateAuthEncryption(cfg *config.Config, decrypt bool) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	c, err := loadAuthCipher(cfg)
+	if err != nil {
+		log.Errorf("auth encryption migration: %v", err)
+		return
+	}
+	action := "encrypted"
+	if decrypt {
+		action = "decrypted"
+	}
+	result, err := authcrypt.MigrateDir(cfg.AuthDir, c, decrypt)
+	if err != nil {
+		log.Errorf("auth encryption migration: %v", err)
+		return
+	}
+	for _, path := range result.Failed {
+		log.Errorf("auth encryption migration: failed to convert %s", path)
+	}
+	fmt.Printf("Auth files %s: %d, unchanged: %d, failed: %d\n", action, result.Converted, result.Skipped, len(result.Failed))
+	if !decrypt && len(result.Failed) == 0 && !cfg.AuthEncryption.Enable {
+		fmt.Println("Set auth-encryption.enable: true in the config so new credentials are stored encrypted.")
+	}
+}
+
+func loadAuthCipher(cfg *config.Config) (*authcrypt.Cipher, error) {
+	key, err := authcrypt.LoadKey(cfg.AuthEncryption.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return authcrypt.NewCipher(key)
+}
@@ -11,6 +11,8 @@ import (
 
 	"cliproxy/internal/auth/iflow"
 	"cliproxy/internal/config"
+	sdkAuth "cliproxy/sdk/auth"
+	coreauth "cliproxy/sdk/cliproxy/auth"
 )
 
 // DoIFlowCookieAuth performs the iFlow cookie-based authentication.
@@ -62,11 +64,30 @@ func DoIFlowCookieAuth(cfg *config.Config, options *LoginOptions) {
 	// Create token storage
 	tokenStorage := auth.CreateCookieTokenStorage(tokenData)
 
-	// Get auth file path using email in filename
-	authFilePath := getAuthFilePath(cfg, "iflow", tokenData.Email)
-
-	// Save token to file
-	if err := tokenStorage.SaveTokenToFile(authFilePath); err != nil {
+	// Save through the token store so auth-dir encryption applies like for the other logins
+	fileName := authFileName("iflow", tokenData.Email)
+	record := &coreauth.Auth{
+		ID:       fileName,
+		Provider: "iflow",
+		FileName: fileName,
+		Storage:  tokenStorage,
+		Metadata: map[string]any{
+			"email":   tokenStorage.Email,
+			"api_key": tokenStorage.APIKey,
+			"cookie":  tokenStorage.Cookie,
+			"expired": tokenStorage.Expire,
+			"type":    "iflow",
+		},
+		Attributes: map[string]string{
+			"api_key": tokenStorage.APIKey,
+		},
+	}
+	store := sdkAuth.GetTokenStore()
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	authFilePath, err := store.Save(ctx, record)
+	if err != nil {
 		fmt.Printf("Failed to save authentication: %v\n", err)
 		return
 	}
@@ -91,8 +112,8 @@ func promptForCookie(promptFn func(string) (string, error)) (string, error) {
 	return cookie, nil
 }
 
-// getAuthFilePath returns the auth file path for the given provider and email
-func getAuthFilePath(cfg *config.Config, provider, email string) string {
+// authFileName returns the auth file name for the given provider and email
+func authFileName(provider, email string) string {
 	fileName := iflow.SanitizeIFlowFileName(email)
-	return fmt.Sprintf("%s/%s-%s-%d.json", cfg.AuthDir, provider, fileName, time.Now().Unix())
+	return fmt.Sprintf("%s-%s-%d.json", provider, fileName, time.Now().Unix())
 }
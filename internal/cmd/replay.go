@@ -0,0 +1,168 @@
+// Package cmd contains CLI helpers. This file implements replaying a captured request through
+// the management API of a running server and printing the diff against the capture.
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cliproxy/internal/config"
+	"cliproxy/internal/replay"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReplayOptions selects the captured request to replay and where it runs.
+type ReplayOptions struct {
+	RequestID string
+	replay.Options
+	// ManagementKey authenticates against the management API of the running server.
+	ManagementKey string
+	// ShowAll prints unchanged lines instead of collapsing them.
+	ShowAll bool
+}
+
+// DoReplay asks the server configured in cfg to replay a request from its request log and
+// prints a side-by-side diff of the upstream request and the response. The server must be
+// running with request-log enabled when the original request was made.
+func DoReplay(cfg *config.Config, opts ReplayOptions) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if strings.TrimSpace(opts.RequestID) == "" {
+		log.Errorf("replay: missing request ID")
+		return
+	}
+	payload, _ := json.Marshal(struct {
+		RequestID string `json:"request_id"`
+		replay.Options
+	}{RequestID: strings.TrimSpace(opts.RequestID), Options: opts.Options})
+
+	endpoint := managementBaseURL(cfg) + "/v0/management/request-replay"
+	req, errReq := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if errReq != nil {
+		log.Errorf("replay: %v", errReq)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.ManagementKey != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.ManagementKey)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	if cfg.TLS.Enable && isLoopbackHost(req.URL.Hostname()) {
+		// A certificate rarely names the loopback address; any other host is verified as usual.
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		log.Errorf("replay: request to %s failed: %v", endpoint, errDo)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		log.Errorf("replay: read response failed: %v", errRead)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			log.Errorf("replay: %s (HTTP %d)", apiErr.Error, resp.StatusCode)
+		} else {
+			log.Errorf("replay: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+		return
+	}
+
+	var result replay.Result
+	if errUnmarshal := json.Unmarshal(data, &result); errUnmarshal != nil {
+		log.Errorf("replay: invalid response: %v", errUnmarshal)
+		return
+	}
+	printReplayResult(os.Stdout, &result, opts.ShowAll)
+}
+
+// managementBaseURL returns the URL of the server described by cfg, using loopback when it
+// listens on every interface.
+func managementBaseURL(cfg *config.Config) string {
+	scheme := "http"
+	if cfg.TLS.Enable {
+		scheme = "https"
+	}
+	host := strings.TrimSpace(cfg.Host)
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 8317
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// isLoopbackHost reports whether host names the local machine.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func printReplayResult(w io.Writer, result *replay.Result, showAll bool) {
+	width := 78
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 40 {
+		width = (cols - 3) / 2
+	}
+	context := 3
+	if showAll {
+		context = -1
+	}
+
+	header := func(title string) {
+		_, _ = fmt.Fprintf(w, "\n== %s ==\n", title)
+		_, _ = fmt.Fprintf(w, "%-*s   %s\n", width, "original "+result.Original.RequestID, "replay "+result.Replay.RequestID)
+	}
+	row := func(label, left, right string) {
+		if left == "" && right == "" {
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%-*s   %s\n", width, label+": "+left, label+": "+right)
+	}
+
+	header("Summary")
+	row("model", result.Original.Model, result.Replay.Model)
+	row("upstream", result.Original.UpstreamURL, result.Replay.UpstreamURL)
+	row("auth", result.Original.Auth, result.Replay.Auth)
+	row("attempts", strconv.Itoa(result.Original.Attempts), strconv.Itoa(result.Replay.Attempts))
+	row("upstream status", statusText(result.Original.UpstreamStatus), statusText(result.Replay.UpstreamStatus))
+	row("upstream error", result.Original.UpstreamError, result.Replay.UpstreamError)
+	row("status", statusText(result.Original.Status), statusText(result.Replay.Status))
+
+	header("Upstream request")
+	_ = replay.WriteSideBySide(w, result.UpstreamRequestDiff, width, context)
+	header("Response")
+	_ = replay.WriteSideBySide(w, result.ResponseDiff, width, context)
+
+	if !result.Changed {
+		_, _ = fmt.Fprintln(w, "\nNo differences.")
+	}
+}
+
+func statusText(status int) string {
+	if status == 0 {
+		return ""
+	}
+	return strconv.Itoa(status)
+}
@@ -27,6 +27,18 @@ type Config struct {
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
+	// Metrics configures the Prometheus /metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics" json:"-"`
+
+	// AuthEncryption configures encryption at rest for credential files in auth-dir.
+	AuthEncryption AuthEncryptionConfig `yaml:"auth-encryption" json:"-"`
+
+	// Storage selects where credentials and scheduler data are persisted.
+	Storage StorageConfig `yaml:"storage" json:"-"`
+
+	// Batch configures the OpenAI-compatible /v1/files and /v1/batches endpoints.
+	Batch BatchConfig `yaml:"batch" json:"batch"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
@@ -40,6 +52,9 @@ type Config struct {
 	// When exceeded, the oldest log files are deleted until within the limit. Set to 0 to disable.
 	LogsMaxTotalSizeMB int `yaml:"logs-max-total-size-mb" json:"logs-max-total-size-mb"`
 
+	// StructuredRequestLog configures the JSONL request log served by /v0/management/request-logs.
+	StructuredRequestLog StructuredRequestLogConfig `yaml:"structured-request-log" json:"structured-request-log"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
@@ -89,6 +104,71 @@ type RemoteManagement struct {
 	DisableControlPanel bool `yaml:"disable-control-panel"`
 }
 
+// MetricsConfig holds Prometheus endpoint configuration under 'metrics'.
+type MetricsConfig struct {
+	// Enable exposes GET /metrics.
+	Enable bool `yaml:"enable"`
+	// AllowRemote toggles remote (non-localhost) scraping.
+	AllowRemote bool `yaml:"allow-remote"`
+	// BearerToken, when set, must be presented as "Authorization: Bearer <token>".
+	BearerToken string `yaml:"bearer-token"`
+}
+
+// BatchConfig holds batch execution settings under 'batch'.
+type BatchConfig struct {
+	// Concurrency caps the batch requests in flight across all batches (default 4).
+	Concurrency int `yaml:"concurrency" json:"concurrency"`
+	// MaxFileSizeMB caps the size of uploaded batch input files (default 100).
+	MaxFileSizeMB int `yaml:"max-file-size-mb" json:"max-file-size-mb"`
+	// MaxRequests caps the number of requests in one batch (default 50000).
+	MaxRequests int `yaml:"max-requests" json:"max-requests"`
+}
+
+// StructuredRequestLogConfig holds JSONL request log settings under 'structured-request-log'.
+type StructuredRequestLogConfig struct {
+	// Enable appends one JSON record per proxied request to requests.jsonl in the logs directory.
+	Enable bool `yaml:"enable" json:"enable"`
+	// SampleRate is the fraction (0-1) of successful requests recorded (default 1).
+	SampleRate *float64 `yaml:"sample-rate,omitempty" json:"sample-rate,omitempty"`
+	// ErrorSampleRate is the fraction (0-1) of failed requests (status >= 400) recorded (default 1).
+	ErrorSampleRate *float64 `yaml:"error-sample-rate,omitempty" json:"error-sample-rate,omitempty"`
+	// MaxBodyBytes truncates the logged request and response bodies (default 4096, -1 omits them).
+	MaxBodyBytes int `yaml:"max-body-bytes" json:"max-body-bytes"`
+	// RedactHeaders lists request headers whose values are replaced, in addition to the
+	// credential headers that are always redacted.
+	RedactHeaders []string `yaml:"redact-headers,omitempty" json:"redact-headers,omitempty"`
+	// RedactBodyFields lists JSON keys whose values are replaced wherever they appear in bodies.
+	RedactBodyFields []string `yaml:"redact-body-fields,omitempty" json:"redact-body-fields,omitempty"`
+	// MaxFileSizeMB rotates requests.jsonl once it grows past this size (default 100).
+	MaxFileSizeMB int `yaml:"max-file-size-mb" json:"max-file-size-mb"`
+	// MaxFiles is the number of rotated files kept besides the active one (default 5).
+	MaxFiles int `yaml:"max-files" json:"max-files"`
+}
+
+// AuthEncryptionConfig holds auth file encryption settings under 'auth-encryption'.
+// The key is read from the CLIPROXY_AUTH_ENCRYPTION_KEY environment variable when set,
+// otherwise from KeyFile. Changes take effect on restart.
+type AuthEncryptionConfig struct {
+	// Enable encrypts auth files written by the proxy with AES-256-GCM.
+	Enable bool `yaml:"enable"`
+	// KeyFile points to a file holding a 32-byte key (base64, hex or raw bytes).
+	KeyFile string `yaml:"key-file"`
+}
+
+// StorageConfig holds persistence settings under 'storage'.
+type StorageConfig struct {
+	// Backend is "file" (default: one JSON file per credential in auth-dir, JSON files for the
+	// scheduler) or "sqlite" (one database with a row per credential, task and log entry).
+	Backend string `yaml:"backend"`
+	// SQLitePath is the database file of the sqlite backend (default data/cliproxy.db).
+	SQLitePath string `yaml:"sqlite-path"`
+}
+
+// UsesSQLite reports whether the sqlite storage backend is selected.
+func (c StorageConfig) UsesSQLite() bool {
+	return strings.EqualFold(strings.TrimSpace(c.Backend), "sqlite")
+}
+
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
 // It provides configuration options for automatic failover mechanisms.
 type QuotaExceeded struct {
@@ -108,6 +188,12 @@ type ClientOverride = sdkconfig.ClientOverride
 // RoutingRule defines a routing rule.
 type RoutingRule = sdkconfig.RoutingRule
 
+// APIKeyPolicy restricts what a single client API key may do.
+type APIKeyPolicy = sdkconfig.APIKeyPolicy
+
+// ResponseCacheConfig controls the completion response cache.
+type ResponseCacheConfig = sdkconfig.ResponseCacheConfig
+
 // ModelNameMapping defines a model ID mapping for a specific channel.
 type ModelNameMapping = sdkconfig.ModelNameMapping
 
@@ -243,6 +329,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sync request authentication providers with inline API keys for backwards compatibility.
 	syncInlineAccessProvider(&cfg)
 
+	// Normalize per-key client policies.
+	cfg.SanitizeAPIKeyPolicies()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -264,6 +353,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize global OAuth model name mappings.
 	cfg.SanitizeOAuthModelMappings()
 
+	// Normalize unified providers and scheduling settings.
+	cfg.SanitizeProviders()
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -367,6 +459,39 @@ func (cfg *Config) SanitizeClaudeKeys() {
 	}
 }
 
+// SanitizeAPIKeyPolicies trims keys, normalizes model patterns, clamps negative limits
+// and keeps only the first policy for each key.
+func (cfg *Config) SanitizeAPIKeyPolicies() {
+	if cfg == nil || len(cfg.APIKeyPolicies) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.APIKeyPolicies))
+	out := make([]APIKeyPolicy, 0, len(cfg.APIKeyPolicies))
+	for i := range cfg.APIKeyPolicies {
+		entry := cfg.APIKeyPolicies[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		if _, exists := seen[entry.APIKey]; exists {
+			continue
+		}
+		seen[entry.APIKey] = struct{}{}
+		entry.AllowedModels = NormalizeExcludedModels(entry.AllowedModels)
+		if entry.RequestsPerMinute < 0 {
+			entry.RequestsPerMinute = 0
+		}
+		if entry.TokensPerDay < 0 {
+			entry.TokensPerDay = 0
+		}
+		if entry.TokensPerMonth < 0 {
+			entry.TokensPerMonth = 0
+		}
+		out = append(out, entry)
+	}
+	cfg.APIKeyPolicies = out
+}
+
 // SanitizeGeminiKeys deduplicates and normalizes Gemini credentials.
 func (cfg *Config) SanitizeGeminiKeys() {
 	if cfg == nil {
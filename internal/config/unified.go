@@ -1,11 +1,17 @@
 package config
 
-import "strings"
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
 
 // SchedulingConfig defines the global scheduling strategy for unified providers.
 type SchedulingConfig struct {
 	// Strategy defines how to select a provider when multiple are available.
-	// Options: "priority" (default), "load-balance", "round-robin", "sticky".
+	// Options: "priority", "load-balance", "round-robin", "sticky". When empty, routing.strategy
+	// selects the credential; an explicit routing.strategy also takes precedence.
 	Strategy string `yaml:"strategy" json:"strategy"`
 
 	// Retry defines the number of retries for failed requests.
@@ -13,6 +19,25 @@ type SchedulingConfig struct {
 
 	// Fallback enables automatic failover to the next available provider.
 	Fallback bool `yaml:"fallback" json:"fallback"`
+
+	// Sticky bounds the session bindings kept by the "sticky" strategy.
+	Sticky StickyConfig `yaml:"sticky,omitempty" json:"sticky,omitempty"`
+}
+
+// StickyConfig bounds and persists sticky session bindings.
+type StickyConfig struct {
+	// TTLSeconds expires bindings idle for longer than this. Default 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the number of bindings; least recently used are evicted. Default 10000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// RebalanceFactor rebinds a session when its credential holds more than this multiple
+	// of its fair share of bindings. 0 disables rebalancing.
+	RebalanceFactor float64 `yaml:"rebalance-factor,omitempty" json:"rebalance-factor,omitempty"`
+
+	// PersistFile, when set, saves bindings on shutdown and restores them on start.
+	PersistFile string `yaml:"persist-file,omitempty" json:"persist-file,omitempty"`
 }
 
 // UnifiedProvider defines a standard configuration for any AI provider.
@@ -54,11 +79,11 @@ type UnifiedProvider struct {
 
 // ProviderModelConfig controls model visibility and aliasing for a provider.
 type ProviderModelConfig struct {
-	// Include is a list of glob patterns for models to explicit allow.
+	// Include is a list of model patterns, with "*" wildcards, to explicitly allow.
 	// If empty, all models are allowed (subject to Exclude).
 	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
 
-	// Exclude is a list of glob patterns for models to hide.
+	// Exclude is a list of model patterns, with "*" wildcards, to hide.
 	Exclude []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
 
 	// Alias maps client-facing model names to upstream model names.
@@ -72,22 +97,31 @@ func (cfg *Config) SanitizeProviders() {
 		return
 	}
 
-	// Normalize Scheduling defaults
+	// Normalize Scheduling defaults. An empty strategy is left empty so routing.strategy
+	// (round-robin by default) stays in charge unless a unified strategy is chosen explicitly.
 	cfg.Scheduling.Strategy = strings.ToLower(strings.TrimSpace(cfg.Scheduling.Strategy))
-	if cfg.Scheduling.Strategy == "" {
-		cfg.Scheduling.Strategy = "priority"
-	}
 	if cfg.Scheduling.Retry < 0 {
 		cfg.Scheduling.Retry = 3 // Default retry count
 	}
+	if cfg.Scheduling.Sticky.TTLSeconds < 0 {
+		cfg.Scheduling.Sticky.TTLSeconds = 0
+	}
+	if cfg.Scheduling.Sticky.MaxEntries < 0 {
+		cfg.Scheduling.Sticky.MaxEntries = 0
+	}
+	if cfg.Scheduling.Sticky.RebalanceFactor < 0 {
+		cfg.Scheduling.Sticky.RebalanceFactor = 0
+	}
+	cfg.Scheduling.Sticky.PersistFile = strings.TrimSpace(cfg.Scheduling.Sticky.PersistFile)
 
 	// Filter and normalize providers
 	validProviders := make([]UnifiedProvider, 0, len(cfg.Providers))
+	seenIDs := make(map[string]struct{}, len(cfg.Providers))
 	for i := range cfg.Providers {
 		p := cfg.Providers[i]
 
 		// Normalize Type
-		p.Type = strings.ToLower(strings.TrimSpace(p.Type))
+		p.Type = NormalizeProviderType(p.Type)
 		if p.Type == "" {
 			continue // Skip providers with no type
 		}
@@ -106,6 +140,13 @@ func (cfg *Config) SanitizeProviders() {
 		}
 
 		p.ID = strings.TrimSpace(p.ID)
+		if p.ID == "" {
+			p.ID = fmt.Sprintf("%s-%d", p.Type, i)
+		}
+		if _, dup := seenIDs[p.ID]; dup {
+			p.ID = fmt.Sprintf("%s-%d", p.ID, i)
+		}
+		seenIDs[p.ID] = struct{}{}
 		p.Prefix = normalizeModelPrefix(p.Prefix)
 		p.ProxyURL = strings.TrimSpace(p.ProxyURL)
 
@@ -113,3 +154,136 @@ func (cfg *Config) SanitizeProviders() {
 	}
 	cfg.Providers = validProviders
 }
+
+// unifiedProviderTypeAliases maps accepted spellings to canonical provider types.
+var unifiedProviderTypeAliases = map[string]string{
+	"openai":            "openai-compatibility",
+	"openai-compat":     "openai-compatibility",
+	"openai-compatible": "openai-compatibility",
+	"vertex-api-key":    "vertex",
+}
+
+// unifiedProviderCredentials lists the credential keys each supported provider type requires.
+var unifiedProviderCredentials = map[string][]string{
+	"gemini":               {"api_key"},
+	"claude":               {"api_key"},
+	"codex":                {"api_key"},
+	"vertex":               {"api_key", "base_url"},
+	"openai-compatibility": {"base_url"},
+}
+
+// NormalizeProviderType lowercases a provider type and resolves accepted aliases.
+func NormalizeProviderType(providerType string) string {
+	providerType = strings.ToLower(strings.TrimSpace(providerType))
+	if canonical, ok := unifiedProviderTypeAliases[providerType]; ok {
+		return canonical
+	}
+	return providerType
+}
+
+// SupportedProviderTypes returns the provider types that can be turned into credentials.
+func SupportedProviderTypes() []string {
+	out := make([]string, 0, len(unifiedProviderCredentials))
+	for t := range unifiedProviderCredentials {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RequiredProviderCredentials returns the credential keys required by a provider type and
+// whether the type is supported.
+func RequiredProviderCredentials(providerType string) ([]string, bool) {
+	keys, ok := unifiedProviderCredentials[NormalizeProviderType(providerType)]
+	return keys, ok
+}
+
+// IsEnabled reports whether the provider is active. Providers are enabled unless set otherwise.
+func (p *UnifiedProvider) IsEnabled() bool {
+	return p != nil && (p.Enabled == nil || *p.Enabled)
+}
+
+// Credential returns a trimmed credential value. Keys may be written with underscores or
+// dashes ("api_key" or "api-key").
+func (p *UnifiedProvider) Credential(key string) string {
+	if p == nil || len(p.Credentials) == 0 {
+		return ""
+	}
+	if v := strings.TrimSpace(p.Credentials[key]); v != "" {
+		return v
+	}
+	return strings.TrimSpace(p.Credentials[strings.ReplaceAll(key, "_", "-")])
+}
+
+// ValidateUnifiedProvider checks that a provider entry has a supported type, the credentials
+// its type requires, well-formed model patterns and aliases, and a usable proxy URL.
+func ValidateUnifiedProvider(p *UnifiedProvider) error {
+	if p == nil {
+		return fmt.Errorf("provider is empty")
+	}
+	var problems []string
+	required, supported := RequiredProviderCredentials(p.Type)
+	if !supported {
+		problems = append(problems, fmt.Sprintf("unsupported type %q (supported: %s)", p.Type, strings.Join(SupportedProviderTypes(), ", ")))
+	}
+	for _, key := range required {
+		if p.Credential(key) == "" {
+			problems = append(problems, fmt.Sprintf("credentials.%s is required for type %s", key, NormalizeProviderType(p.Type)))
+		}
+	}
+	if base := p.Credential("base_url"); base != "" {
+		if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("credentials.base_url %q is not an absolute URL", base))
+		}
+	}
+	if proxyURL := strings.TrimSpace(p.ProxyURL); proxyURL != "" {
+		if u, err := url.Parse(proxyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("proxy-url %q is not a valid URL", proxyURL))
+		}
+	}
+	if p.Priority < 0 {
+		problems = append(problems, "priority must not be negative")
+	}
+	if p.Weight < 0 {
+		problems = append(problems, "weight must not be negative")
+	}
+	for field, patterns := range map[string][]string{"include": p.Models.Include, "exclude": p.Models.Exclude} {
+		for _, pattern := range patterns {
+			trimmed := strings.TrimSpace(pattern)
+			if trimmed == "" {
+				problems = append(problems, fmt.Sprintf("models.%s contains an empty pattern", field))
+				continue
+			}
+			// Patterns are matched with util.MatchWildcard, which only understands "*".
+			if strings.ContainsAny(trimmed, "?[]") {
+				problems = append(problems, fmt.Sprintf("models.%s pattern %q is malformed: only \"*\" wildcards are supported", field, trimmed))
+			}
+		}
+	}
+	for client, upstream := range p.Models.Alias {
+		if strings.TrimSpace(client) == "" || strings.TrimSpace(upstream) == "" {
+			problems = append(problems, fmt.Sprintf("models.alias %q -> %q must name both models", client, upstream))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// ValidateScheduling checks the scheduling strategy and sticky bounds.
+func ValidateScheduling(s *SchedulingConfig) error {
+	if s == nil {
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(s.Strategy)) {
+	case "", "priority", "load-balance", "weight", "round-robin", "sticky":
+	default:
+		return fmt.Errorf("unsupported strategy %q", s.Strategy)
+	}
+	if s.Sticky.TTLSeconds < 0 || s.Sticky.MaxEntries < 0 || s.Sticky.RebalanceFactor < 0 {
+		return fmt.Errorf("sticky settings must not be negative")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -15,8 +16,7 @@ func TestSanitizeProviders(t *testing.T) {
 			input: &Config{},
 			expected: &Config{
 				Scheduling: SchedulingConfig{
-					Strategy: "priority",
-					Retry:    0,
+					Retry: 0,
 				},
 				Providers: []UnifiedProvider{},
 			},
@@ -44,8 +44,7 @@ func TestSanitizeProviders(t *testing.T) {
 			},
 			expected: &Config{
 				Scheduling: SchedulingConfig{
-					Strategy: "priority",
-					Retry:    0,
+					Retry: 0,
 				},
 				Providers: []UnifiedProvider{},
 			},
@@ -70,8 +69,7 @@ func TestSanitizeProviders(t *testing.T) {
 			},
 			expected: &Config{
 				Scheduling: SchedulingConfig{
-					Strategy: "priority",
-					Retry:    0,
+					Retry: 0,
 				},
 				Providers: []UnifiedProvider{
 					{
@@ -101,8 +99,7 @@ func TestSanitizeProviders(t *testing.T) {
 			},
 			expected: &Config{
 				Scheduling: SchedulingConfig{
-					Strategy: "priority",
-					Retry:    0,
+					Retry: 0,
 				},
 				Providers: []UnifiedProvider{},
 			},
@@ -116,8 +113,7 @@ func TestSanitizeProviders(t *testing.T) {
 			},
 			expected: &Config{
 				Scheduling: SchedulingConfig{
-					Strategy: "priority",
-					Retry:    3,
+					Retry: 3,
 				},
 				Providers: []UnifiedProvider{},
 			},
@@ -170,4 +166,73 @@ func TestSanitizeProviders(t *testing.T) {
 
 func boolPtr(b bool) *bool {
 	return &b
-}
\ No newline at end of file
+}
+func TestValidateUnifiedProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   UnifiedProvider
+		wantErr string
+	}{
+		{
+			name:  "valid gemini",
+			input: UnifiedProvider{Type: "gemini", Credentials: map[string]string{"api_key": "k"}, Models: ProviderModelConfig{Include: []string{"gemini-*"}}},
+		},
+		{
+			name:    "unsupported type",
+			input:   UnifiedProvider{Type: "bogus"},
+			wantErr: "unsupported type",
+		},
+		{
+			name:    "missing api key",
+			input:   UnifiedProvider{Type: "claude"},
+			wantErr: "credentials.api_key is required",
+		},
+		{
+			name:    "openai compatibility needs base url",
+			input:   UnifiedProvider{Type: "openai-compatibility", Credentials: map[string]string{"base_url": "not a url"}},
+			wantErr: "not an absolute URL",
+		},
+		{
+			name:    "malformed glob",
+			input:   UnifiedProvider{Type: "codex", Credentials: map[string]string{"api_key": "k"}, Models: ProviderModelConfig{Exclude: []string{"gpt-[4"}}},
+			wantErr: "is malformed",
+		},
+		{
+			name:    "glob syntax beyond star",
+			input:   UnifiedProvider{Type: "codex", Credentials: map[string]string{"api_key": "k"}, Models: ProviderModelConfig{Include: []string{"gpt-5*", "gpt-4?"}}},
+			wantErr: `models.include pattern "gpt-4?" is malformed`,
+		},
+		{
+			name:    "negative weight",
+			input:   UnifiedProvider{Type: "codex", Credentials: map[string]string{"api-key": "k"}, Weight: -1},
+			wantErr: "weight must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUnifiedProvider(&tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateScheduling(t *testing.T) {
+	if err := ValidateScheduling(&SchedulingConfig{Strategy: "sticky"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ValidateScheduling(&SchedulingConfig{Strategy: "random"}); err == nil {
+		t.Fatal("expected error for unsupported strategy")
+	}
+	if err := ValidateScheduling(&SchedulingConfig{Sticky: StickyConfig{TTLSeconds: -1}}); err == nil {
+		t.Fatal("expected error for negative sticky ttl")
+	}
+}
@@ -19,6 +19,7 @@ import (
 var aiAPIPrefixes = []string{
 	"/v1/chat/completions",
 	"/v1/completions",
+	"/v1/embeddings",
 	"/v1/messages",
 	"/v1/responses",
 	"/v1beta/models/",
@@ -0,0 +1,397 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"cliproxy/internal/config"
+	"cliproxy/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	structuredLogFileName          = "requests.jsonl"
+	defaultStructuredMaxBodyBytes  = 4096
+	defaultStructuredMaxFileSizeMB = 100
+	defaultStructuredMaxFiles      = 5
+	structuredLogMaxLineBytes      = 16 << 20
+
+	redactedValue = "[REDACTED]"
+)
+
+// StructuredRequestRecord is one line of the JSONL request log.
+type StructuredRequestRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	// KeyHash identifies the client API key without revealing it; see HashAPIKey.
+	KeyHash   string                  `json:"key_hash,omitempty"`
+	Model     string                  `json:"model,omitempty"`
+	Provider  string                  `json:"provider,omitempty"`
+	AuthIndex string                  `json:"auth_index,omitempty"`
+	Status    int                     `json:"status"`
+	LatencyMs int64                   `json:"latency_ms"`
+	Stream    bool                    `json:"stream,omitempty"`
+	Usage     *StructuredRequestUsage `json:"usage,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+
+	RequestHeaders        map[string]string `json:"request_headers,omitempty"`
+	RequestBody           string            `json:"request_body,omitempty"`
+	RequestBodyTruncated  bool              `json:"request_body_truncated,omitempty"`
+	ResponseBody          string            `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool              `json:"response_body_truncated,omitempty"`
+}
+
+// StructuredRequestUsage is the token usage reported for a request.
+type StructuredRequestUsage struct {
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	ReasoningTokens int64 `json:"reasoning_tokens,omitempty"`
+	CachedTokens    int64 `json:"cached_tokens,omitempty"`
+	TotalTokens     int64 `json:"total_tokens"`
+}
+
+// StructuredRequestFilter selects records in StructuredRequestLogger.Query. Zero fields match
+// everything.
+type StructuredRequestFilter struct {
+	From, To time.Time
+	// Model matches case-insensitively.
+	Model string
+	// StatusMin and StatusMax bound the status code inclusively.
+	StatusMin, StatusMax int
+	KeyHash              string
+	RequestID            string
+	// Limit caps the number of records returned, newest first.
+	Limit int
+}
+
+func (f StructuredRequestFilter) match(r *StructuredRequestRecord) bool {
+	switch {
+	case !f.From.IsZero() && r.Timestamp.Before(f.From):
+		return false
+	case !f.To.IsZero() && r.Timestamp.After(f.To):
+		return false
+	case f.Model != "" && !strings.EqualFold(f.Model, r.Model):
+		return false
+	case f.StatusMin > 0 && r.Status < f.StatusMin:
+		return false
+	case f.StatusMax > 0 && r.Status > f.StatusMax:
+		return false
+	case f.KeyHash != "" && f.KeyHash != r.KeyHash:
+		return false
+	case f.RequestID != "" && f.RequestID != r.RequestID:
+		return false
+	}
+	return true
+}
+
+// StructuredRequestLogger appends StructuredRequestRecord lines to requests.jsonl in the logs
+// directory, rotating the file by size. Sampling and redaction follow the
+// structured-request-log configuration, which can be swapped at runtime.
+type StructuredRequestLogger struct {
+	mu     sync.RWMutex
+	dir    string
+	cfg    config.StructuredRequestLogConfig
+	writer *lumberjack.Logger
+	// redactBody matches redacted body fields in payloads that are not a single JSON document.
+	redactBody *regexp.Regexp
+	random     func() float64
+}
+
+// NewStructuredRequestLogger creates a logger writing to logsDir.
+func NewStructuredRequestLogger(logsDir string, cfg config.StructuredRequestLogConfig) *StructuredRequestLogger {
+	l := &StructuredRequestLogger{dir: logsDir, random: rand.Float64}
+	l.Configure(cfg)
+	return l
+}
+
+// Configure applies a new configuration.
+func (l *StructuredRequestLogger) Configure(cfg config.StructuredRequestLogConfig) {
+	if l == nil {
+		return
+	}
+	var pattern *regexp.Regexp
+	if len(cfg.RedactBodyFields) > 0 {
+		quoted := make([]string, 0, len(cfg.RedactBodyFields))
+		for _, field := range cfg.RedactBodyFields {
+			quoted = append(quoted, regexp.QuoteMeta(field))
+		}
+		pattern = regexp.MustCompile(`("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|[^,}\]\s]+)`)
+	}
+
+	maxSize := cfg.MaxFileSizeMB
+	if maxSize <= 0 {
+		maxSize = defaultStructuredMaxFileSizeMB
+	}
+	maxBackups := cfg.MaxFiles
+	if maxBackups <= 0 {
+		maxBackups = defaultStructuredMaxFiles
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.redactBody = pattern
+	if l.writer == nil || l.writer.MaxSize != maxSize || l.writer.MaxBackups != maxBackups {
+		if l.writer != nil {
+			_ = l.writer.Close()
+		}
+		l.writer = &lumberjack.Logger{
+			Filename:   filepath.Join(l.dir, structuredLogFileName),
+			MaxSize:    maxSize,
+			MaxBackups: maxBackups,
+		}
+	}
+}
+
+// Enabled reports whether records are written.
+func (l *StructuredRequestLogger) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cfg.Enable
+}
+
+// Sampled decides whether a request that finished with status is recorded.
+func (l *StructuredRequestLogger) Sampled(status int) bool {
+	l.mu.RLock()
+	rate := l.cfg.SampleRate
+	if status >= 400 {
+		rate = l.cfg.ErrorSampleRate
+	}
+	l.mu.RUnlock()
+	if rate == nil || *rate >= 1 {
+		return true
+	}
+	return *rate > 0 && l.random() < *rate
+}
+
+// MaxBodyBytes returns the body size kept per record; negative means bodies are omitted.
+func (l *StructuredRequestLogger) MaxBodyBytes() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.cfg.MaxBodyBytes == 0 {
+		return defaultStructuredMaxBodyBytes
+	}
+	return l.cfg.MaxBodyBytes
+}
+
+// RedactHeaders flattens headers for a record, replacing credential headers and the configured
+// ones with a placeholder.
+func (l *StructuredRequestLogger) RedactHeaders(headers map[string][]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	l.mu.RLock()
+	extra := l.cfg.RedactHeaders
+	l.mu.RUnlock()
+	out := make(map[string]string, len(headers))
+	for name, values := range headers {
+		value := strings.Join(values, ", ")
+		if isCredentialHeader(name) || containsFold(extra, name) {
+			value = redactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// FormatBody redacts the configured fields in body and truncates it to MaxBodyBytes, reporting
+// whether it was cut.
+func (l *StructuredRequestLogger) FormatBody(body []byte) (string, bool) {
+	limit := l.MaxBodyBytes()
+	if limit < 0 || len(body) == 0 {
+		return "", false
+	}
+	l.mu.RLock()
+	fields, pattern := l.cfg.RedactBodyFields, l.redactBody
+	l.mu.RUnlock()
+
+	text := string(body)
+	if len(fields) > 0 {
+		if gjson.Valid(text) {
+			text = redactJSONFields(text, fields)
+		} else {
+			// SSE streams and cut-off payloads: only scalar values can be matched reliably.
+			text = pattern.ReplaceAllString(text, `${1}"`+redactedValue+`"`)
+		}
+	}
+	if len(text) <= limit {
+		return text, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut], true
+}
+
+// Write appends record to the log.
+func (l *StructuredRequestLogger) Write(record *StructuredRequestRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, err = l.writer.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the active log file.
+func (l *StructuredRequestLogger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.writer.Close()
+}
+
+// Query returns the records matching filter, newest first, reading the active file and then
+// the rotated ones.
+func (l *StructuredRequestLogger) Query(filter StructuredRequestFilter) ([]StructuredRequestRecord, error) {
+	files, err := l.files()
+	if err != nil {
+		return nil, err
+	}
+	var results []StructuredRequestRecord
+	for _, path := range files {
+		need := 0
+		if filter.Limit > 0 {
+			need = filter.Limit - len(results)
+			if need <= 0 {
+				break
+			}
+		}
+		matches, errScan := scanStructuredLog(path, filter, need)
+		if errScan != nil {
+			if os.IsNotExist(errScan) {
+				continue
+			}
+			return nil, errScan
+		}
+		for i := len(matches) - 1; i >= 0; i-- {
+			results = append(results, matches[i])
+		}
+	}
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// files lists the active log followed by the rotated ones, newest first. Rotated names carry
+// a sortable timestamp.
+func (l *StructuredRequestLogger) files() ([]string, error) {
+	ext := filepath.Ext(structuredLogFileName)
+	prefix := strings.TrimSuffix(structuredLogFileName, ext) + "-"
+	rotated, err := filepath.Glob(filepath.Join(l.dir, prefix+"*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(rotated)))
+	return append([]string{filepath.Join(l.dir, structuredLogFileName)}, rotated...), nil
+}
+
+// scanStructuredLog returns the matching records of one file in file order, keeping only the
+// last keep of them when keep is positive.
+func scanStructuredLog(path string, filter StructuredRequestFilter, keep int) ([]StructuredRequestRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var matches []StructuredRequestRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), structuredLogMaxLineBytes)
+	for scanner.Scan() {
+		var record StructuredRequestRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || !filter.match(&record) {
+			continue
+		}
+		matches = append(matches, record)
+		if keep > 0 && len(matches) > 2*keep {
+			matches = append(matches[:0], matches[len(matches)-keep:]...)
+		}
+	}
+	if keep > 0 && len(matches) > keep {
+		matches = matches[len(matches)-keep:]
+	}
+	return matches, scanner.Err()
+}
+
+// HashAPIKey returns the identifier logged for a client API key: a truncated SHA-256 digest,
+// stable across restarts so records can be filtered by key.
+func HashAPIKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// redactJSONFields replaces the values of every key in fields within the JSON document text.
+func redactJSONFields(text string, fields []string) string {
+	root := gjson.Parse(text)
+	var paths []string
+	for _, field := range fields {
+		util.Walk(root, "", field, &paths)
+	}
+	// Shorter paths first, so values nested inside a redacted field are skipped.
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+	var done []string
+	for _, path := range paths {
+		if hasPathPrefix(path, done) {
+			continue
+		}
+		text, _ = sjson.Set(text, path, redactedValue)
+		done = append(done, path)
+	}
+	return text
+}
+
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isCredentialHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, marker := range []string{"authorization", "api-key", "apikey", "token", "secret", "cookie"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
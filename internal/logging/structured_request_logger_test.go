@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cliproxy/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestStructuredRequestLoggerRedaction(t *testing.T) {
+	logger := NewStructuredRequestLogger(t.TempDir(), config.StructuredRequestLogConfig{
+		Enable:           true,
+		MaxBodyBytes:     128,
+		RedactHeaders:    []string{"X-Session"},
+		RedactBodyFields: []string{"secret"},
+	})
+	defer func() { _ = logger.Close() }()
+
+	headers := logger.RedactHeaders(map[string][]string{
+		"Authorization": {"Bearer sk-1"},
+		"X-Session":     {"abc"},
+		"Content-Type":  {"application/json"},
+	})
+	if headers["Authorization"] != redactedValue || headers["X-Session"] != redactedValue || headers["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+
+	body, truncated := logger.FormatBody([]byte(`{"secret":{"a":1},"items":[{"secret":"x"}],"ok":true}`))
+	if truncated || gjson.Get(body, "secret").String() != redactedValue || gjson.Get(body, "items.0.secret").String() != redactedValue || !gjson.Get(body, "ok").Bool() {
+		t.Fatalf("unexpected JSON body: %s", body)
+	}
+	body, _ = logger.FormatBody([]byte(`data: {"secret":"x","n":1}` + "\n"))
+	if strings.Contains(body, `"x"`) {
+		t.Fatalf("stream body not redacted: %s", body)
+	}
+
+	body, truncated = logger.FormatBody([]byte("a" + strings.Repeat("é", 70)))
+	if !truncated || len(body) != 127 {
+		t.Fatalf("expected truncation at a rune boundary, got %d bytes (truncated=%t)", len(body), truncated)
+	}
+}
+
+func TestStructuredRequestLoggerSampling(t *testing.T) {
+	zero, half := 0.0, 0.5
+	logger := NewStructuredRequestLogger(t.TempDir(), config.StructuredRequestLogConfig{
+		Enable:     true,
+		SampleRate: &half,
+	})
+	defer func() { _ = logger.Close() }()
+	logger.random = func() float64 { return 0.7 }
+
+	if logger.Sampled(200) {
+		t.Fatal("expected success to be dropped above the sample rate")
+	}
+	if !logger.Sampled(500) {
+		t.Fatal("expected errors to be kept when error-sample-rate is unset")
+	}
+
+	logger.Configure(config.StructuredRequestLogConfig{Enable: true, ErrorSampleRate: &zero})
+	if !logger.Sampled(200) || logger.Sampled(502) {
+		t.Fatal("unexpected sampling after reconfiguration")
+	}
+}
+
+func TestStructuredRequestLoggerQuery(t *testing.T) {
+	logger := NewStructuredRequestLogger(t.TempDir(), config.StructuredRequestLogConfig{Enable: true})
+	defer func() { _ = logger.Close() }()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []StructuredRequestRecord{
+		{RequestID: "a", Model: "gpt-5", Status: 200, KeyHash: HashAPIKey("k1")},
+		{RequestID: "b", Model: "gpt-5", Status: 429, KeyHash: HashAPIKey("k2")},
+		{RequestID: "c", Model: "claude", Status: 500, KeyHash: HashAPIKey("k1")},
+		{RequestID: "d", Model: "GPT-5", Status: 200, KeyHash: HashAPIKey("k1")},
+	}
+	for i := range records {
+		records[i].Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if err := logger.Write(&records[i]); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		filter StructuredRequestFilter
+		want   string
+	}{
+		{"all newest first", StructuredRequestFilter{}, "dcba"},
+		{"limit", StructuredRequestFilter{Limit: 2}, "dc"},
+		{"model", StructuredRequestFilter{Model: "gpt-5"}, "dba"},
+		{"errors", StructuredRequestFilter{StatusMin: 400}, "cb"},
+		{"key", StructuredRequestFilter{KeyHash: HashAPIKey("k1"), StatusMax: 299}, "da"},
+		{"time range", StructuredRequestFilter{From: base.Add(time.Minute), To: base.Add(2 * time.Minute)}, "cb"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := logger.Query(tc.filter)
+			if err != nil {
+				t.Fatalf("query: %v", err)
+			}
+			var ids strings.Builder
+			for _, r := range got {
+				ids.WriteString(r.RequestID)
+			}
+			if ids.String() != tc.want {
+				t.Fatalf("got %q, want %q", ids.String(), tc.want)
+			}
+		})
+	}
+}
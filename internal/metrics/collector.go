@@ -0,0 +1,99 @@
+// Package metrics exposes proxy usage and credential health in the Prometheus text
+// exposition format. Request and token counters are fed from the usage plugin
+// pipeline; credential cooldown gauges are computed from the auth manager at scrape time.
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	coreusage "cliproxy/sdk/cliproxy/usage"
+)
+
+func init() {
+	coreusage.RegisterPlugin(defaultCollector)
+}
+
+var defaultCollector = NewCollector()
+
+// Default returns the collector registered with the usage pipeline.
+func Default() *Collector { return defaultCollector }
+
+// latencyBuckets are the upper bounds, in seconds, of the request duration histogram.
+var latencyBuckets = []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// seriesKey identifies one label set of the per-request metrics.
+type seriesKey struct {
+	provider  string
+	model     string
+	authIndex string
+}
+
+// series accumulates counters and the latency histogram for one label set.
+type series struct {
+	success         uint64
+	failure         uint64
+	inputTokens     int64
+	outputTokens    int64
+	reasoningTokens int64
+	cachedTokens    int64
+	buckets         []uint64
+	latencyCount    uint64
+	latencySum      float64
+}
+
+// Collector aggregates usage records into Prometheus counters and histograms.
+type Collector struct {
+	mu     sync.Mutex
+	series map[seriesKey]*series
+	now    func() time.Time
+}
+
+// NewCollector constructs an empty collector.
+func NewCollector() *Collector {
+	return &Collector{
+		series: make(map[seriesKey]*series),
+		now:    time.Now,
+	}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (c *Collector) HandleUsage(_ context.Context, record coreusage.Record) {
+	if c == nil {
+		return
+	}
+	key := seriesKey{provider: record.Provider, model: record.Model, authIndex: record.AuthIndex}
+	latency := -1.0
+	if d := record.Latency(); d > 0 {
+		latency = d.Seconds()
+	} else if !record.RequestedAt.IsZero() {
+		latency = c.now().Sub(record.RequestedAt).Seconds()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(latencyBuckets))}
+		c.series[key] = s
+	}
+	if record.Failed {
+		s.failure++
+	} else {
+		s.success++
+	}
+	s.inputTokens += record.Detail.InputTokens
+	s.outputTokens += record.Detail.OutputTokens
+	s.reasoningTokens += record.Detail.ReasoningTokens
+	s.cachedTokens += record.Detail.CachedTokens
+	if latency >= 0 {
+		s.latencyCount++
+		s.latencySum += latency
+		for i, bound := range latencyBuckets {
+			if latency <= bound {
+				s.buckets[i]++
+			}
+		}
+	}
+}
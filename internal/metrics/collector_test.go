@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	coreusage "cliproxy/sdk/cliproxy/usage"
+)
+
+func TestCollector_WriteTo(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector()
+	c.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	c.HandleUsage(ctx, coreusage.Record{
+		Provider: "gemini", Model: "gemini-2.5-pro", AuthIndex: "abc",
+		RequestedAt: now.Add(-3 * time.Second),
+		Detail:      coreusage.Detail{InputTokens: 10, OutputTokens: 20, ReasoningTokens: 5, CachedTokens: 2},
+	})
+	c.HandleUsage(ctx, coreusage.Record{
+		Provider: "gemini", Model: "gemini-2.5-pro", AuthIndex: "abc",
+		RequestedAt: now.Add(-400 * time.Millisecond),
+		Failed:      true,
+	})
+
+	auths := []*coreauth.Auth{{
+		ID:       "a1",
+		Provider: "gemini",
+		ModelStates: map[string]*coreauth.ModelState{
+			"gemini-2.5-pro": {Unavailable: true, NextRetryAfter: now.Add(90 * time.Second), Quota: coreauth.QuotaState{Exceeded: true}},
+		},
+	}}
+
+	idx := auths[0].EnsureIndex()
+
+	var buf bytes.Buffer
+	if err := c.WriteTo(&buf, auths); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`cliproxy_requests_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",status="success"} 1`,
+		`cliproxy_requests_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",status="failure"} 1`,
+		`cliproxy_tokens_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",type="reasoning"} 5`,
+		`cliproxy_request_duration_seconds_bucket{provider="gemini",model="gemini-2.5-pro",auth_index="abc",le="0.5"} 1`,
+		`cliproxy_request_duration_seconds_bucket{provider="gemini",model="gemini-2.5-pro",auth_index="abc",le="5"} 2`,
+		`cliproxy_request_duration_seconds_count{provider="gemini",model="gemini-2.5-pro",auth_index="abc"} 2`,
+		`cliproxy_auth_available{provider="gemini",auth_index="` + idx + `"} 1`,
+		`cliproxy_auth_model_cooldown{provider="gemini",auth_index="` + idx + `",model="gemini-2.5-pro"} 1`,
+		`cliproxy_auth_model_cooldown_remaining_seconds{provider="gemini",auth_index="` + idx + `",model="gemini-2.5-pro"} 90`,
+		`cliproxy_auth_model_quota_exceeded{provider="gemini",auth_index="` + idx + `",model="gemini-2.5-pro"} 1`,
+		"# TYPE cliproxy_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("escapeLabel = %q", got)
+	}
+}
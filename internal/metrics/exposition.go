@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteTo renders all collected metrics plus credential gauges derived from auths.
+func (c *Collector) WriteTo(w io.Writer, auths []*coreauth.Auth) error {
+	bw := bufio.NewWriter(w)
+	c.writeUsage(bw)
+	writeAuths(bw, auths, c.now())
+	return bw.Flush()
+}
+
+func (c *Collector) writeUsage(w *bufio.Writer) {
+	c.mu.Lock()
+	keys := make([]seriesKey, 0, len(c.series))
+	snapshot := make(map[seriesKey]series, len(c.series))
+	for key, s := range c.series {
+		keys = append(keys, key)
+		copied := *s
+		copied.buckets = append([]uint64(nil), s.buckets...)
+		snapshot[key] = copied
+	}
+	c.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		if keys[i].model != keys[j].model {
+			return keys[i].model < keys[j].model
+		}
+		return keys[i].authIndex < keys[j].authIndex
+	})
+
+	writeHeader(w, "cliproxy_requests_total", "counter", "Upstream requests by outcome.")
+	for _, key := range keys {
+		s := snapshot[key]
+		writeSample(w, "cliproxy_requests_total", labelsFor(key, "status", "success"), float64(s.success))
+		writeSample(w, "cliproxy_requests_total", labelsFor(key, "status", "failure"), float64(s.failure))
+	}
+
+	writeHeader(w, "cliproxy_tokens_total", "counter", "Tokens reported by upstream providers.")
+	for _, key := range keys {
+		s := snapshot[key]
+		writeSample(w, "cliproxy_tokens_total", labelsFor(key, "type", "input"), float64(s.inputTokens))
+		writeSample(w, "cliproxy_tokens_total", labelsFor(key, "type", "output"), float64(s.outputTokens))
+		writeSample(w, "cliproxy_tokens_total", labelsFor(key, "type", "reasoning"), float64(s.reasoningTokens))
+		writeSample(w, "cliproxy_tokens_total", labelsFor(key, "type", "cached"), float64(s.cachedTokens))
+	}
+
+	writeHeader(w, "cliproxy_request_duration_seconds", "histogram", "Upstream request duration.")
+	for _, key := range keys {
+		s := snapshot[key]
+		for i, bound := range latencyBuckets {
+			writeSample(w, "cliproxy_request_duration_seconds_bucket", labelsFor(key, "le", formatFloat(bound)), float64(s.buckets[i]))
+		}
+		writeSample(w, "cliproxy_request_duration_seconds_bucket", labelsFor(key, "le", "+Inf"), float64(s.latencyCount))
+		writeSample(w, "cliproxy_request_duration_seconds_sum", labelsFor(key), s.latencySum)
+		writeSample(w, "cliproxy_request_duration_seconds_count", labelsFor(key), float64(s.latencyCount))
+	}
+}
+
+func writeAuths(w *bufio.Writer, auths []*coreauth.Auth, now time.Time) {
+	sorted := make([]*coreauth.Auth, 0, len(auths))
+	for _, a := range auths {
+		if a != nil {
+			sorted = append(sorted, a)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	writeHeader(w, "cliproxy_auth_available", "gauge", "Whether a credential is enabled and not cooling down (1) or not (0).")
+	for _, a := range sorted {
+		available := !a.Disabled && !(a.Unavailable && a.NextRetryAfter.After(now))
+		writeSample(w, "cliproxy_auth_available", authLabels(a), boolValue(available))
+	}
+
+	type modelRow struct {
+		auth  *coreauth.Auth
+		model string
+		state *coreauth.ModelState
+	}
+	var rows []modelRow
+	for _, a := range sorted {
+		models := make([]string, 0, len(a.ModelStates))
+		for model := range a.ModelStates {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			if state := a.ModelStates[model]; state != nil {
+				rows = append(rows, modelRow{auth: a, model: model, state: state})
+			}
+		}
+	}
+
+	writeHeader(w, "cliproxy_auth_model_cooldown", "gauge", "Whether a credential is cooling down for a model (1) or not (0).")
+	for _, row := range rows {
+		cooling := row.state.Unavailable && row.state.NextRetryAfter.After(now)
+		writeSample(w, "cliproxy_auth_model_cooldown", authLabels(row.auth, "model", row.model), boolValue(cooling))
+	}
+	writeHeader(w, "cliproxy_auth_model_cooldown_remaining_seconds", "gauge", "Seconds until a cooling credential may serve the model again.")
+	for _, row := range rows {
+		remaining := 0.0
+		if row.state.Unavailable && row.state.NextRetryAfter.After(now) {
+			remaining = row.state.NextRetryAfter.Sub(now).Seconds()
+		}
+		writeSample(w, "cliproxy_auth_model_cooldown_remaining_seconds", authLabels(row.auth, "model", row.model), remaining)
+	}
+	writeHeader(w, "cliproxy_auth_model_quota_exceeded", "gauge", "Whether the last failure for a credential and model was a quota error (1) or not (0).")
+	for _, row := range rows {
+		writeSample(w, "cliproxy_auth_model_quota_exceeded", authLabels(row.auth, "model", row.model), boolValue(row.state.Quota.Exceeded))
+	}
+}
+
+func labelsFor(key seriesKey, extra ...string) []string {
+	return append([]string{"provider", key.provider, "model", key.model, "auth_index", key.authIndex}, extra...)
+}
+
+func authLabels(a *coreauth.Auth, extra ...string) []string {
+	return append([]string{"provider", a.Provider, "auth_index", a.EnsureIndex()}, extra...)
+}
+
+func writeHeader(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes one sample line; labels alternate name, value.
+func writeSample(w *bufio.Writer, name string, labels []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i])
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(labels[i+1]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+func formatFloat(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
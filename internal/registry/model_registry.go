@@ -1085,6 +1085,9 @@ func (r *ModelRegistry) GetFirstAvailableModel(handlerType string) (string, erro
 	// Find the first model with available clients
 	for _, model := range models {
 		if modelID, ok := model["id"].(string); ok {
+			if IsEmbeddingModel(modelID) {
+				continue
+			}
 			if count := r.GetModelCount(modelID); count > 0 {
 				return modelID, nil
 			}
@@ -1094,6 +1097,20 @@ func (r *ModelRegistry) GetFirstAvailableModel(handlerType string) (string, erro
 	return "", fmt.Errorf("no available clients for any model in handler type: %s", handlerType)
 }
 
+// IsEmbeddingModel reports whether modelID is an embedding model, which cannot serve
+// generation requests and is therefore never chosen for "auto".
+func IsEmbeddingModel(modelID string) bool {
+	if info := LookupStaticModelInfo(modelID); info != nil {
+		for _, method := range info.SupportedGenerationMethods {
+			if method == "embedContent" {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(strings.ToLower(modelID), "embedding")
+}
+
 // GetModelsForClient returns the models registered for a specific client.
 // Parameters:
 //   - clientID: The client identifier (typically auth file name or auth ID)
@@ -0,0 +1,341 @@
+// Package replay re-executes requests captured in the request log and diffs the upstream
+// traffic and the client response against the original capture.
+package replay
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCaptureNotFound is returned when no request log exists for a request ID.
+var ErrCaptureNotFound = errors.New("request log not found")
+
+// Capture is a request parsed from a request log file.
+type Capture struct {
+	RequestID string
+	URL       string
+	Method    string
+	Timestamp time.Time
+	// Headers are the client request headers; credentials were masked when logged.
+	Headers http.Header
+	Body    []byte
+	// Upstream lists the calls made to providers, one per attempt.
+	Upstream        []Exchange
+	Status          int
+	ResponseHeaders http.Header
+	Response        []byte
+}
+
+// Exchange is one upstream attempt: the translated request sent to a provider and what came back.
+type Exchange struct {
+	URL             string
+	Method          string
+	Auth            string
+	RequestHeaders  http.Header
+	RequestBody     []byte
+	Status          int
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+	Error           string
+}
+
+// LastUpstream returns the final upstream attempt, the one whose outcome reached the client.
+func (c *Capture) LastUpstream() *Exchange {
+	if c == nil || len(c.Upstream) == 0 {
+		return nil
+	}
+	return &c.Upstream[len(c.Upstream)-1]
+}
+
+var sectionHeader = regexp.MustCompile(`^=== (REQUEST INFO|HEADERS|REQUEST BODY|API REQUEST(?: \d+)?|API ERROR RESPONSE|API RESPONSE(?: \d+)?|RESPONSE) ===$`)
+
+type section struct {
+	name string
+	body string
+}
+
+// splitSections cuts log text into its "=== NAME ===" sections.
+func splitSections(text string) []section {
+	var sections []section
+	var current *section
+	var body strings.Builder
+	flush := func() {
+		if current != nil {
+			current.body = body.String()
+			sections = append(sections, *current)
+		}
+		body.Reset()
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if m := sectionHeader.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil {
+			flush()
+			current = &section{name: m[1]}
+			continue
+		}
+		body.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// FindCapture returns the path of the request log written for requestID in dir.
+func FindCapture(dir, requestID string) (string, error) {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" || strings.ContainsAny(requestID, `/\`) {
+		return "", fmt.Errorf("invalid request ID %q", requestID)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrCaptureNotFound
+		}
+		return "", err
+	}
+	suffix := "-" + requestID + ".log"
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+			return filepath.Join(dir, entry.Name()), nil
+		}
+	}
+	return "", ErrCaptureNotFound
+}
+
+// LoadCapture finds and parses the request log of requestID in dir.
+func LoadCapture(dir, requestID string) (*Capture, error) {
+	path, err := FindCapture(dir, requestID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	capture, err := ParseCapture(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	capture.RequestID = strings.TrimSpace(requestID)
+	return capture, nil
+}
+
+// ParseCapture parses a request log file.
+func ParseCapture(data []byte) (*Capture, error) {
+	capture := &Capture{Headers: http.Header{}}
+	var requests, responses []section
+	var errorsSeen []string
+	for _, s := range splitSections(string(data)) {
+		switch {
+		case s.name == "REQUEST INFO":
+			fields, _ := parseFields(s.body)
+			capture.URL = fields["URL"]
+			capture.Method = fields["Method"]
+			capture.Timestamp, _ = time.Parse(time.RFC3339Nano, fields["Timestamp"])
+		case s.name == "HEADERS":
+			capture.Headers = parseHeaderLines(s.body)
+		case s.name == "REQUEST BODY":
+			capture.Body = []byte(trimBlock(s.body))
+		case strings.HasPrefix(s.name, "API REQUEST"):
+			requests = append(requests, s)
+		case strings.HasPrefix(s.name, "API RESPONSE"):
+			responses = append(responses, s)
+		case s.name == "API ERROR RESPONSE":
+			errorsSeen = append(errorsSeen, trimBlock(s.body))
+		case s.name == "RESPONSE":
+			capture.Status, capture.ResponseHeaders, capture.Response = parseResponse(s.body)
+		}
+	}
+	if capture.URL == "" {
+		return nil, errors.New("not a request log: missing REQUEST INFO")
+	}
+	capture.Upstream = exchanges(requests, responses)
+	if last := capture.LastUpstream(); last != nil && last.Error == "" && last.Status == 0 && len(errorsSeen) > 0 {
+		last.Error = errorsSeen[len(errorsSeen)-1]
+	}
+	return capture, nil
+}
+
+// ParseExchanges parses the upstream request and response logs the executors record for a
+// request ("=== API REQUEST n ===" and "=== API RESPONSE n ===" sections).
+func ParseExchanges(apiRequest, apiResponse []byte) []Exchange {
+	var requests, responses []section
+	for _, s := range splitSections(string(apiRequest)) {
+		if strings.HasPrefix(s.name, "API REQUEST") {
+			requests = append(requests, s)
+		}
+	}
+	for _, s := range splitSections(string(apiResponse)) {
+		if strings.HasPrefix(s.name, "API RESPONSE") {
+			responses = append(responses, s)
+		}
+	}
+	return exchanges(requests, responses)
+}
+
+// exchanges pairs request and response sections by attempt number, falling back to their order
+// for unnumbered sections.
+func exchanges(requests, responses []section) []Exchange {
+	out := make([]Exchange, 0, len(requests))
+	index := make(map[string]int, len(requests))
+	for i, s := range requests {
+		out = append(out, parseUpstreamRequest(s.body))
+		index[attemptNumber(s.name, i)] = i
+	}
+	for i, s := range responses {
+		pos, ok := index[attemptNumber(s.name, i)]
+		if !ok {
+			out = append(out, Exchange{})
+			pos = len(out) - 1
+		}
+		parseUpstreamResponse(s.body, &out[pos])
+	}
+	return out
+}
+
+func attemptNumber(name string, position int) string {
+	if fields := strings.Fields(name); len(fields) == 3 {
+		return fields[2]
+	}
+	return strconv.Itoa(position + 1)
+}
+
+// parseUpstreamRequest parses the body of an "API REQUEST" section: metadata lines, then
+// "Headers:" and "Body:" blocks.
+func parseUpstreamRequest(text string) Exchange {
+	fields, rest := parseFields(text)
+	ex := Exchange{URL: fields["Upstream URL"], Method: fields["HTTP Method"], Auth: fields["Auth"]}
+	if ex.URL == "<unknown>" {
+		ex.URL = ""
+	}
+	ex.RequestHeaders, ex.RequestBody = parseHeadersAndBody(rest)
+	return ex
+}
+
+// parseUpstreamResponse parses the body of an "API RESPONSE" section into ex: a status line,
+// a "Headers:" block and a "Body:" block, or "Error:" lines when no response arrived.
+func parseUpstreamResponse(text string, ex *Exchange) {
+	_, rest := parseFields(text) // Timestamp
+	var errs []string
+	inHeaders := false
+	ex.ResponseHeaders = http.Header{}
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		switch {
+		case inHeaders && line == "":
+			inHeaders = false
+		case inHeaders:
+			if key, value, ok := strings.Cut(line, ":"); ok && key != "" {
+				ex.ResponseHeaders.Add(key, strings.TrimSpace(value))
+			}
+		case line == "Headers:":
+			inHeaders = true
+		case line == "Body:":
+			ex.ResponseBody = []byte(trimBlock(rest))
+			rest = ""
+		case strings.HasPrefix(line, "Status: "):
+			ex.Status, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Status: ")))
+		case strings.HasPrefix(line, "Error: "):
+			errs = append(errs, strings.TrimPrefix(line, "Error: "))
+		}
+	}
+	if len(errs) > 0 {
+		ex.Error = strings.Join(errs, "\n")
+	}
+}
+
+// parseResponse parses the "RESPONSE" section: an optional status line, headers up to the first
+// blank line, then the body.
+func parseResponse(text string) (int, http.Header, []byte) {
+	status := 0
+	headers := http.Header{}
+	rest := text
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "Status: "); ok && status == 0 {
+			status, _ = strconv.Atoi(strings.TrimSpace(value))
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			headers.Add(key, value)
+		}
+	}
+	return status, headers, []byte(trimBlock(rest))
+}
+
+// parseFields reads "Key: value" lines up to the first blank line and returns the remainder.
+func parseFields(text string) (map[string]string, string) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	consumed := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		consumed += len(line) + 1
+		if strings.TrimSpace(line) == "" {
+			if len(fields) == 0 {
+				continue
+			}
+			break
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			consumed -= len(line) + 1
+			break
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	if consumed > len(text) {
+		consumed = len(text)
+	}
+	return fields, text[consumed:]
+}
+
+// parseHeadersAndBody reads the "Headers:" and "Body:" blocks of an upstream request section.
+func parseHeadersAndBody(text string) (http.Header, []byte) {
+	headers := http.Header{}
+	rest := strings.TrimLeft(text, "\n")
+	if after, ok := strings.CutPrefix(rest, "Headers:\n"); ok {
+		block, tail, _ := strings.Cut(after, "\n\n")
+		headers = parseHeaderLines(block)
+		rest = strings.TrimLeft(tail, "\n")
+	}
+	after, ok := strings.CutPrefix(rest, "Body:\n")
+	if !ok {
+		return headers, nil
+	}
+	body := trimBlock(after)
+	if body == "<empty>" {
+		body = ""
+	}
+	return headers, []byte(body)
+}
+
+func parseHeaderLines(text string) http.Header {
+	headers := http.Header{}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "<none>" {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok && key != "" {
+			headers.Add(key, strings.TrimSpace(value))
+		}
+	}
+	return headers
+}
+
+// trimBlock drops the blank lines the logger writes between sections.
+func trimBlock(text string) string {
+	return strings.TrimRight(text, "\r\n")
+}
@@ -0,0 +1,257 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Diff row operations, read from the original capture (left) to the replay (right).
+const (
+	DiffEqual  = "equal"
+	DiffChange = "change"
+	DiffDelete = "delete"
+	DiffInsert = "insert"
+)
+
+// maxDiffCells bounds the LCS table; larger inputs are reported as one changed block.
+const maxDiffCells = 4_000_000
+
+// DiffRow is one line of a side-by-side diff.
+type DiffRow struct {
+	Op    string `json:"op"`
+	Left  string `json:"left,omitempty"`
+	Right string `json:"right,omitempty"`
+}
+
+// Diff compares two texts line by line and returns side-by-side rows. Runs of removed and added
+// lines are paired into changed rows.
+func Diff(left, right string) []DiffRow {
+	a, b := splitLines(left), splitLines(right)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	rows := make([]DiffRow, 0, max(len(a), len(b)))
+	for _, line := range a[:prefix] {
+		rows = append(rows, DiffRow{Op: DiffEqual, Left: line, Right: line})
+	}
+	rows = append(rows, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		rows = append(rows, DiffRow{Op: DiffEqual, Left: line, Right: line})
+	}
+	return rows
+}
+
+// Changed reports whether rows contain any difference.
+func Changed(rows []DiffRow) bool {
+	for _, row := range rows {
+		if row.Op != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func diffMiddle(a, b []string) []DiffRow {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	if len(a)*len(b) > maxDiffCells {
+		return pairRuns(nil, a, b)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	var rows []DiffRow
+	var deleted, inserted []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			rows = pairRuns(rows, deleted, inserted)
+			deleted, inserted = deleted[:0], inserted[:0]
+			rows = append(rows, DiffRow{Op: DiffEqual, Left: a[i], Right: b[j]})
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+			deleted = append(deleted, a[i])
+			i++
+		default:
+			inserted = append(inserted, b[j])
+			j++
+		}
+	}
+	return pairRuns(rows, deleted, inserted)
+}
+
+// pairRuns appends a run of removed and added lines as changed rows, followed by the unpaired
+// remainder.
+func pairRuns(rows []DiffRow, deleted, inserted []string) []DiffRow {
+	n := min(len(deleted), len(inserted))
+	for k := 0; k < n; k++ {
+		rows = append(rows, DiffRow{Op: DiffChange, Left: deleted[k], Right: inserted[k]})
+	}
+	for _, line := range deleted[n:] {
+		rows = append(rows, DiffRow{Op: DiffDelete, Left: line})
+	}
+	for _, line := range inserted[n:] {
+		rows = append(rows, DiffRow{Op: DiffInsert, Right: line})
+	}
+	return rows
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// WriteSideBySide renders rows in two columns of width characters, marking changed lines with
+// "|", lines only in the original with "<" and lines only in the replay with ">". When context
+// is not negative, unchanged runs are collapsed to context lines around each difference.
+func WriteSideBySide(w io.Writer, rows []DiffRow, width, context int) error {
+	if width < 10 {
+		width = 10
+	}
+	keep := make([]bool, len(rows))
+	for i, row := range rows {
+		if context < 0 || row.Op != DiffEqual {
+			for k := max(0, i-max(context, 0)); k <= min(len(rows)-1, i+max(context, 0)); k++ {
+				keep[k] = true
+			}
+		}
+	}
+	skipped := 0
+	for i, row := range rows {
+		if !keep[i] {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			if _, err := fmt.Fprintf(w, "%s\n", center(fmt.Sprintf("... %d unchanged lines ...", skipped), 2*width+3)); err != nil {
+				return err
+			}
+			skipped = 0
+		}
+		marker := " "
+		switch row.Op {
+		case DiffChange:
+			marker = "|"
+		case DiffDelete:
+			marker = "<"
+		case DiffInsert:
+			marker = ">"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", pad(row.Left, width), marker, clip(row.Right, width)); err != nil {
+			return err
+		}
+	}
+	if skipped > 0 {
+		if _, err := fmt.Fprintf(w, "%s\n", center(fmt.Sprintf("... %d unchanged lines ...", skipped), 2*width+3)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func clip(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	s = clip(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+func center(s string, width int) string {
+	if n := (width - utf8.RuneCountInString(s)) / 2; n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// upstreamText renders an upstream request for diffing: request line, sorted headers and the
+// normalized body.
+func upstreamText(ex *Exchange) string {
+	if ex == nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", ex.Method, ex.URL)
+	writeSortedHeaders(&b, ex.RequestHeaders)
+	b.WriteString("\n")
+	b.WriteString(normalizeBody(ex.RequestBody))
+	return b.String()
+}
+
+// responseText renders a response for diffing: status line and the normalized body.
+func responseText(status int, body []byte) string {
+	return fmt.Sprintf("Status: %d\n\n%s", status, normalizeBody(body))
+}
+
+func writeSortedHeaders(b *strings.Builder, headers http.Header) {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			fmt.Fprintf(b, "%s: %s\n", key, value)
+		}
+	}
+}
+
+// normalizeBody indents JSON documents, and every JSON event of an SSE stream, so diffs point
+// at individual fields.
+func normalizeBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if json.Valid(trimmed) {
+		return indentJSON(trimmed)
+	}
+	lines := strings.Split(string(trimmed), "\n")
+	for i, line := range lines {
+		if payload, ok := strings.CutPrefix(line, "data: "); ok && json.Valid([]byte(payload)) {
+			lines[i] = "data: " + indentJSON([]byte(payload))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indentJSON(data []byte) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return out.String()
+}
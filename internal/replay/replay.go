@@ -0,0 +1,288 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"cliproxy/internal/logging"
+	"cliproxy/internal/util"
+	"cliproxy/sdk/api/handlers"
+	"cliproxy/sdk/api/handlers/claude"
+	"cliproxy/sdk/api/handlers/gemini"
+	"cliproxy/sdk/api/handlers/openai"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+var (
+	// ErrUnsupportedEndpoint is returned for captures of endpoints that cannot be replayed.
+	ErrUnsupportedEndpoint = errors.New("endpoint cannot be replayed")
+	// ErrInvalidTarget is returned when the provider and auth index options cannot be honoured.
+	ErrInvalidTarget = errors.New("invalid replay target")
+)
+
+// Options choose where a captured request is replayed. Zero fields keep the original routing.
+type Options struct {
+	// Model replaces the requested model.
+	Model string `json:"model,omitempty"`
+	// Provider sends the request to this provider instead of the ones routing picks.
+	Provider string `json:"provider,omitempty"`
+	// AuthIndex pins the request to one credential, identified by its auth index.
+	AuthIndex string `json:"auth_index,omitempty"`
+}
+
+// Summary describes one side of a replay comparison.
+type Summary struct {
+	RequestID      string `json:"request_id"`
+	Model          string `json:"model,omitempty"`
+	UpstreamURL    string `json:"upstream_url,omitempty"`
+	Auth           string `json:"auth,omitempty"`
+	Attempts       int    `json:"attempts"`
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+	UpstreamError  string `json:"upstream_error,omitempty"`
+	Status         int    `json:"status"`
+}
+
+// Result compares a replay with the original capture. The diffs cover the last upstream attempt
+// of each side and the response returned to the client.
+type Result struct {
+	Options             Options   `json:"options"`
+	Original            Summary   `json:"original"`
+	Replay              Summary   `json:"replay"`
+	Changed             bool      `json:"changed"`
+	UpstreamRequestDiff []DiffRow `json:"upstream_request_diff"`
+	ResponseDiff        []DiffRow `json:"response_diff"`
+}
+
+// Replayer re-executes captured requests through the same API handlers as live traffic.
+type Replayer struct {
+	base   *handlers.BaseAPIHandler
+	engine *gin.Engine
+}
+
+type replayStateKey struct{}
+
+// replayState carries a replay's settings into the handler pipeline and its upstream capture out.
+type replayState struct {
+	requestID   string
+	target      handlers.ExecutionTarget
+	routed      bool
+	apiRequest  []byte
+	apiResponse []byte
+}
+
+// NewReplayer creates a replayer executing through base.
+func NewReplayer(base *handlers.BaseAPIHandler) *Replayer {
+	r := &Replayer{base: base, engine: gin.New()}
+	chat := openai.NewOpenAIAPIHandler(base)
+	responses := openai.NewOpenAIResponsesAPIHandler(base)
+	claudeCode := claude.NewClaudeCodeAPIHandler(base)
+	geminiAPI := gemini.NewGeminiAPIHandler(base)
+
+	v1 := r.engine.Group("/v1", r.track)
+	v1.POST("/chat/completions", chat.ChatCompletions)
+	v1.POST("/completions", chat.Completions)
+	v1.POST("/embeddings", chat.Embeddings)
+	v1.POST("/messages", claudeCode.ClaudeMessages)
+	v1.POST("/messages/count_tokens", claudeCode.ClaudeCountTokens)
+	v1.POST("/responses", responses.Responses)
+	r.engine.Group("/v1beta", r.track).POST("/models/*action", geminiAPI.GeminiHandler)
+	return r
+}
+
+// track prepares the Gin context of a replayed request and collects the upstream capture once
+// the handler has finished.
+func (r *Replayer) track(c *gin.Context) {
+	state, _ := c.Request.Context().Value(replayStateKey{}).(*replayState)
+	if state == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	state.routed = true
+	logging.SetGinRequestID(c, state.requestID)
+	c.Set("API_CAPTURE", true)
+	if state.target != (handlers.ExecutionTarget{}) {
+		handlers.SetExecutionTarget(c, state.target)
+	}
+
+	c.Next()
+
+	if value, ok := c.Get("API_REQUEST"); ok {
+		state.apiRequest, _ = value.([]byte)
+	}
+	if value, ok := c.Get("API_RESPONSE"); ok {
+		state.apiResponse, _ = value.([]byte)
+	}
+}
+
+// Replay re-executes capture with opts and compares the outcome with the capture.
+func (r *Replayer) Replay(ctx context.Context, capture *Capture, opts Options) (*Result, error) {
+	if capture == nil {
+		return nil, ErrCaptureNotFound
+	}
+	target, err := r.resolveTarget(opts)
+	if err != nil {
+		return nil, err
+	}
+	requestURL, body, err := rewriteModel(capture, opts.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	requestID := logging.GenerateRequestID()
+	state := &replayState{requestID: requestID, target: target}
+	ctx = context.WithValue(logging.WithRequestID(ctx, requestID), replayStateKey{}, state)
+	method := capture.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedEndpoint, err)
+	}
+	for name, values := range capture.Headers {
+		if replayHeader(name) {
+			req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+	// Replays always reach the provider.
+	req.Header.Set("Cache-Control", "no-cache")
+
+	recorder := httptest.NewRecorder()
+	r.engine.ServeHTTP(recorder, req)
+	if !state.routed {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEndpoint, req.URL.Path)
+	}
+
+	replayed := &Capture{
+		RequestID:       requestID,
+		URL:             req.URL.RequestURI(),
+		Method:          method,
+		Headers:         req.Header,
+		Body:            body,
+		Upstream:        ParseExchanges(state.apiRequest, state.apiResponse),
+		Status:          recorder.Code,
+		ResponseHeaders: recorder.Header(),
+		Response:        recorder.Body.Bytes(),
+	}
+	return compare(capture, replayed, opts), nil
+}
+
+// resolveTarget turns the provider and auth index options into an execution target.
+func (r *Replayer) resolveTarget(opts Options) (handlers.ExecutionTarget, error) {
+	target := handlers.ExecutionTarget{Provider: strings.TrimSpace(opts.Provider)}
+	index := strings.TrimSpace(opts.AuthIndex)
+	if index == "" {
+		return target, nil
+	}
+	if r.base == nil || r.base.AuthManager == nil {
+		return target, fmt.Errorf("%w: no credential has auth index %s", ErrInvalidTarget, index)
+	}
+	for _, auth := range r.base.AuthManager.List() {
+		if auth.EnsureIndex() != index {
+			continue
+		}
+		if target.Provider != "" && !strings.EqualFold(target.Provider, auth.Provider) {
+			return target, fmt.Errorf("%w: auth index %s belongs to provider %s, not %s", ErrInvalidTarget, index, auth.Provider, target.Provider)
+		}
+		target.Provider = auth.Provider
+		target.AuthID = auth.ID
+		return target, nil
+	}
+	return target, fmt.Errorf("%w: no credential has auth index %s", ErrInvalidTarget, index)
+}
+
+// rewriteModel returns the request URL, without credential query parameters, and body of the
+// replay, swapping in model when set. Gemini requests name the model in the path.
+func rewriteModel(capture *Capture, model string) (string, []byte, error) {
+	u, err := url.Parse(capture.URL)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrUnsupportedEndpoint, err)
+	}
+	query := u.Query()
+	for key := range query {
+		if util.IsSensitiveQueryParam(key) {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	body := bytes.Clone(capture.Body)
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return u.String(), body, nil
+	}
+	if rest, ok := strings.CutPrefix(u.Path, "/v1beta/models/"); ok {
+		_, method, found := strings.Cut(rest, ":")
+		if !found {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedEndpoint, u.Path)
+		}
+		u.Path = "/v1beta/models/" + model + ":" + method
+		return u.String(), body, nil
+	}
+	body, err = sjson.SetBytes(body, "model", model)
+	if err != nil {
+		return "", nil, fmt.Errorf("set model: %w", err)
+	}
+	return u.String(), body, nil
+}
+
+// replayHeader reports whether a captured client header is sent with the replay. Credentials were
+// masked in the log, and transport headers belong to the original connection.
+func replayHeader(name string) bool {
+	lower := strings.ToLower(name)
+	switch lower {
+	case "content-length", "accept-encoding", "connection", "host", "cookie", "x-forwarded-for":
+		return false
+	}
+	for _, marker := range []string{"authorization", "api-key", "apikey", "token", "secret"} {
+		if strings.Contains(lower, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestModel returns the model a captured request asked for.
+func requestModel(c *Capture) string {
+	if model := gjson.GetBytes(c.Body, "model").String(); model != "" {
+		return model
+	}
+	path, _, _ := strings.Cut(c.URL, "?")
+	if rest, ok := strings.CutPrefix(path, "/v1beta/models/"); ok {
+		model, _, _ := strings.Cut(rest, ":")
+		return model
+	}
+	return ""
+}
+
+func summarize(c *Capture) Summary {
+	s := Summary{RequestID: c.RequestID, Model: requestModel(c), Attempts: len(c.Upstream), Status: c.Status}
+	if last := c.LastUpstream(); last != nil {
+		s.UpstreamURL = last.URL
+		s.Auth = last.Auth
+		s.UpstreamStatus = last.Status
+		s.UpstreamError = last.Error
+	}
+	return s
+}
+
+func compare(original, replayed *Capture, opts Options) *Result {
+	result := &Result{
+		Options:             opts,
+		Original:            summarize(original),
+		Replay:              summarize(replayed),
+		UpstreamRequestDiff: Diff(upstreamText(original.LastUpstream()), upstreamText(replayed.LastUpstream())),
+		ResponseDiff:        Diff(responseText(original.Status, original.Response), responseText(replayed.Status, replayed.Response)),
+	}
+	result.Changed = Changed(result.UpstreamRequestDiff) || Changed(result.ResponseDiff)
+	return result
+}
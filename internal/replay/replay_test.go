@@ -0,0 +1,209 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"cliproxy/internal/logging"
+	"cliproxy/internal/registry"
+	"cliproxy/sdk/api/handlers"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	sdkconfig "cliproxy/sdk/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const capturedUpstream = `=== API REQUEST 1 ===
+Timestamp: 2025-01-01T00:00:00Z
+Upstream URL: https://upstream.test/replay-a
+HTTP Method: POST
+Auth: provider=replay-test, auth_id=replay-a
+
+Headers:
+Content-Type: application/json
+
+Body:
+{"model":"replay-model","attempt":1}
+
+=== API REQUEST 2 ===
+Timestamp: 2025-01-01T00:00:01Z
+Upstream URL: https://upstream.test/replay-a
+HTTP Method: POST
+Auth: provider=replay-test, auth_id=replay-a
+
+Headers:
+Content-Type: application/json
+
+Body:
+{"model":"replay-model","attempt":2}
+
+`
+
+const capturedUpstreamResponse = `=== API RESPONSE 1 ===
+Timestamp: 2025-01-01T00:00:00Z
+
+Error: connection reset
+
+=== API RESPONSE 2 ===
+Timestamp: 2025-01-01T00:00:01Z
+
+Status: 200
+Headers:
+Content-Type: application/json
+
+Body:
+{"id":"up-1"}
+`
+
+func writeCapture(t *testing.T, dir string) {
+	t.Helper()
+	logger := logging.NewFileRequestLogger(true, dir, dir)
+	headers := map[string][]string{"Content-Type": {"application/json"}, "Authorization": {"Bearer sk-secret"}}
+	body := []byte(`{"model":"replay-model","messages":[{"role":"user","content":"hi"}]}`)
+	err := logger.LogRequest("/v1/chat/completions?key=secret", http.MethodPost, headers, body, http.StatusOK,
+		map[string][]string{"Content-Type": {"application/json"}}, []byte(`{"id":"chat-1","model":"replay-model"}`),
+		[]byte(capturedUpstream), []byte(capturedUpstreamResponse), nil, "req-1")
+	if err != nil {
+		t.Fatalf("LogRequest: %v", err)
+	}
+}
+
+func TestLoadCaptureParsesRequestLog(t *testing.T) {
+	dir := t.TempDir()
+	writeCapture(t, dir)
+
+	capture, err := LoadCapture(dir, "req-1")
+	if err != nil {
+		t.Fatalf("LoadCapture: %v", err)
+	}
+	if capture.URL != "/v1/chat/completions?key=secret" || capture.Method != http.MethodPost || capture.Status != http.StatusOK {
+		t.Fatalf("capture = %s %s -> %d", capture.Method, capture.URL, capture.Status)
+	}
+	if gjson.GetBytes(capture.Body, "messages.0.content").String() != "hi" || string(capture.Response) != `{"id":"chat-1","model":"replay-model"}` {
+		t.Fatalf("body = %s, response = %s", capture.Body, capture.Response)
+	}
+	if len(capture.Upstream) != 2 || capture.Upstream[0].Error != "connection reset" {
+		t.Fatalf("upstream = %+v", capture.Upstream)
+	}
+	last := capture.LastUpstream()
+	if last.URL != "https://upstream.test/replay-a" || last.Status != http.StatusOK || string(last.RequestBody) != `{"model":"replay-model","attempt":2}` || string(last.ResponseBody) != `{"id":"up-1"}` {
+		t.Fatalf("last attempt = %+v", last)
+	}
+	if last.RequestHeaders.Get("Content-Type") != "application/json" || !strings.Contains(last.Auth, "auth_id=replay-a") {
+		t.Fatalf("last attempt headers = %v, auth = %q", last.RequestHeaders, last.Auth)
+	}
+
+	if _, err = LoadCapture(dir, "missing"); err != ErrCaptureNotFound {
+		t.Fatalf("missing capture: err = %v", err)
+	}
+}
+
+func TestDiffSideBySide(t *testing.T) {
+	rows := Diff("a\nb\nc\nd\n", "a\nB\nc\nd\ne")
+	var ops []string
+	for _, row := range rows {
+		ops = append(ops, row.Op)
+	}
+	if got := strings.Join(ops, ","); got != "equal,change,equal,equal,insert" {
+		t.Fatalf("ops = %s", got)
+	}
+	if !Changed(rows) || Changed(Diff("x\ny", "x\ny\n")) {
+		t.Fatal("unexpected Changed result")
+	}
+
+	var out bytes.Buffer
+	if err := WriteSideBySide(&out, Diff("1\n2\n3\n4\n5\n6\n7", "1\n2\n3\n4\n5\n6\nseven"), 12, 1); err != nil {
+		t.Fatalf("WriteSideBySide: %v", err)
+	}
+	want := " ... 5 unchanged lines ...\n" +
+		"6              6\n" +
+		"7            | seven\n"
+	if out.String() != want {
+		t.Fatalf("side-by-side output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+// captureExecutor answers chat requests and records the upstream call the way the real
+// executors do when capture is requested.
+type captureExecutor struct{}
+
+func (captureExecutor) Identifier() string { return "replay-test" }
+
+func (captureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if c, ok := ctx.Value("gin").(*gin.Context); ok && c.GetBool("API_CAPTURE") {
+		c.Set("API_REQUEST", []byte(fmt.Sprintf("=== API REQUEST 1 ===\nUpstream URL: https://upstream.test/%s\nHTTP Method: POST\nAuth: provider=replay-test, auth_id=%s\n\nHeaders:\nContent-Type: application/json\n\nBody:\n{\"model\":%q,\"attempt\":2}\n\n", auth.ID, auth.ID, req.Model)))
+	}
+	return cliproxyexecutor.Response{Payload: []byte(fmt.Sprintf(`{"id":"chat-1","model":%q}`, req.Model))}, nil
+}
+
+func (captureExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (captureExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (captureExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestReplayPinsCredentialAndModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models := []*registry.ModelInfo{{ID: "replay-model"}, {ID: "replay-model-2"}}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("replay-a", "replay-test", models)
+	reg.RegisterClient("replay-b", "replay-test", models)
+	t.Cleanup(func() {
+		reg.UnregisterClient("replay-a")
+		reg.UnregisterClient("replay-b")
+	})
+
+	manager := coreauth.NewManager(nil, &coreauth.FillFirstSelector{}, nil)
+	manager.RegisterExecutor(captureExecutor{})
+	ctx := context.Background()
+	for _, id := range []string{"replay-a", "replay-b"} {
+		if _, err := manager.Register(ctx, &coreauth.Auth{ID: id, Provider: "replay-test", Status: coreauth.StatusActive}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	authB, _ := manager.GetByID("replay-b")
+
+	dir := t.TempDir()
+	writeCapture(t, dir)
+	capture, err := LoadCapture(dir, "req-1")
+	if err != nil {
+		t.Fatalf("LoadCapture: %v", err)
+	}
+
+	replayer := NewReplayer(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager, nil))
+
+	same, err := replayer.Replay(ctx, capture, Options{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if same.Changed || same.Replay.Status != http.StatusOK || same.Replay.UpstreamURL != "https://upstream.test/replay-a" {
+		t.Fatalf("unchanged replay = %+v, upstream diff = %+v", same.Replay, same.UpstreamRequestDiff)
+	}
+
+	result, err := replayer.Replay(ctx, capture, Options{Model: "replay-model-2", AuthIndex: authB.EnsureIndex()})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if result.Replay.UpstreamURL != "https://upstream.test/replay-b" || result.Replay.Model != "replay-model-2" || !result.Changed {
+		t.Fatalf("pinned replay = %+v", result.Replay)
+	}
+	if !strings.Contains(fmt.Sprint(result.UpstreamRequestDiff), "replay-model-2") || !strings.Contains(fmt.Sprint(result.ResponseDiff), "replay-model-2") {
+		t.Fatalf("diffs miss the model change: %+v %+v", result.UpstreamRequestDiff, result.ResponseDiff)
+	}
+
+	if _, err = replayer.Replay(ctx, capture, Options{AuthIndex: "nope"}); err == nil || !strings.Contains(err.Error(), ErrInvalidTarget.Error()) {
+		t.Fatalf("unknown auth index: err = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+// Package responsecache caches completion responses for repeated identical requests.
+// Requests are keyed by a normalised hash of the client's own request body minus transport and
+// bookkeeping fields, together with the client format and whether a stream was requested, so
+// equivalent requests hit the same entry regardless of key order or fields such as metadata.
+package responsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cliproxy/internal/util"
+	sdkconfig "cliproxy/sdk/config"
+	sdktranslator "cliproxy/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultTTL        = time.Hour
+	defaultMaxEntries = 1000
+
+	backendMemory = "memory"
+	backendDisk   = "disk"
+)
+
+// ignoredFields lists the request fields that do not influence the generated response.
+// Every other field, including ones added to the API later, is part of the key.
+var ignoredFields = []string{"stream", "stream_options", "user", "metadata", "store"}
+
+// Entry is a cached upstream response in the client format that produced it.
+// ServedModel names the fallback model that answered, if one did.
+type Entry struct {
+	Format      string    `json:"format"`
+	Model       string    `json:"model"`
+	ServedModel string    `json:"served_model,omitempty"`
+	Stream      bool      `json:"stream"`
+	Payload     []byte    `json:"payload,omitempty"`
+	Chunks      [][]byte  `json:"chunks,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Store persists cache entries. Implementations must be safe for concurrent use.
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Put(key string, entry *Entry)
+	Delete(key string)
+	Len() int
+}
+
+// Cache fronts a Store with TTL handling and request key derivation.
+// A nil or disabled Cache never returns hits.
+type Cache struct {
+	mu     sync.RWMutex
+	cfg    sdkconfig.ResponseCacheConfig
+	dir    string
+	store  Store
+	ttl    time.Duration
+	shared bool
+	now    func() time.Time
+}
+
+// New creates a cache configured from cfg. The cache is disabled when cfg is nil
+// or response-cache.enable is false.
+func New(cfg *sdkconfig.SDKConfig) *Cache {
+	c := &Cache{now: time.Now}
+	c.Configure(cfg)
+	return c
+}
+
+// Configure applies a new configuration. The underlying store is rebuilt only when
+// the backend, directory or size limit changes, so existing entries survive reloads.
+func (c *Cache) Configure(cfg *sdkconfig.SDKConfig) {
+	if c == nil {
+		return
+	}
+	var next sdkconfig.ResponseCacheConfig
+	if cfg != nil {
+		next = cfg.ResponseCache
+	}
+	next.Backend = strings.ToLower(strings.TrimSpace(next.Backend))
+	if next.Backend == "" {
+		next.Backend = backendMemory
+	}
+	if next.MaxEntries <= 0 {
+		next.MaxEntries = defaultMaxEntries
+	}
+	ttl := defaultTTL
+	if next.TTLSeconds > 0 {
+		ttl = time.Duration(next.TTLSeconds) * time.Second
+	}
+	dir := strings.TrimSpace(next.Dir)
+	if next.Backend == backendDisk && dir == "" {
+		// Keep entries out of auth-dir: the token store and watcher treat its JSON files as credentials.
+		dir = filepath.Join("data", "response-cache")
+		if base := util.WritablePath(); base != "" {
+			dir = filepath.Join(base, "data", "response-cache")
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	c.shared = next.SharedAcrossKeys
+	if !next.Enable {
+		c.cfg = next
+		c.store = nil
+		return
+	}
+	if c.store != nil && c.cfg.Enable && c.cfg.Backend == next.Backend && c.cfg.MaxEntries == next.MaxEntries && c.dir == dir {
+		c.cfg = next
+		return
+	}
+	c.cfg = next
+	c.dir = dir
+	switch next.Backend {
+	case backendDisk:
+		store, err := NewDiskStore(dir, next.MaxEntries)
+		if err != nil {
+			log.Errorf("response cache: failed to open disk store at %s, falling back to memory: %v", dir, err)
+			c.store = NewMemoryStore(next.MaxEntries)
+			return
+		}
+		c.store = store
+	default:
+		if next.Backend != backendMemory {
+			log.Warnf("response cache: unknown backend %q, using memory", next.Backend)
+		}
+		c.store = NewMemoryStore(next.MaxEntries)
+	}
+}
+
+// Enabled reports whether lookups and stores are active.
+func (c *Cache) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.store != nil
+}
+
+// Key derives the cache key for a request. The request is hashed in its source format, since a
+// translation may drop fields that still change the answer, and stripped of the fields that do
+// not affect the response. Streaming and non-streaming requests get separate keys.
+// apiKey scopes the entry to a single client unless shared-across-keys is enabled.
+func (c *Cache) Key(apiKey string, source sdktranslator.Format, model string, rawJSON []byte, stream bool) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.RLock()
+	shared := c.shared
+	c.mu.RUnlock()
+	if shared {
+		apiKey = ""
+	}
+
+	normalized := bytes.Clone(rawJSON)
+	for _, field := range ignoredFields {
+		normalized, _ = sjson.DeleteBytes(normalized, field)
+	}
+	normalized, _ = sjson.SetBytes(normalized, "model", model)
+	normalized = canonicalJSON(normalized)
+
+	h := sha256.New()
+	h.Write([]byte(apiKey))
+	h.Write([]byte{0})
+	h.Write([]byte(source.String()))
+	h.Write([]byte{0})
+	if stream {
+		h.Write([]byte("stream"))
+	}
+	h.Write([]byte{0})
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the entry stored under key when it exists and has not expired.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	c.mu.RLock()
+	store, ttl := c.store, c.ttl
+	c.mu.RUnlock()
+	if store == nil {
+		return nil, false
+	}
+	entry, ok := store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(entry.CreatedAt) > ttl {
+		store.Delete(key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores entry under key, stamping its creation time.
+func (c *Cache) Put(key string, entry *Entry) {
+	if c == nil || key == "" || entry == nil {
+		return
+	}
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if store == nil {
+		return
+	}
+	entry.CreatedAt = c.now()
+	store.Put(key, entry)
+}
+
+// canonicalJSON re-encodes data with object keys in sorted order so semantically
+// identical payloads hash identically.
+func canonicalJSON(data []byte) []byte {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package responsecache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	_ "cliproxy/internal/translator"
+	sdkconfig "cliproxy/sdk/config"
+	sdktranslator "cliproxy/sdk/translator"
+)
+
+func enabledConfig(rc sdkconfig.ResponseCacheConfig) *sdkconfig.SDKConfig {
+	rc.Enable = true
+	return &sdkconfig.SDKConfig{ResponseCache: rc}
+}
+
+func TestCacheKey_Normalisation(t *testing.T) {
+	c := New(enabledConfig(sdkconfig.ResponseCacheConfig{}))
+	base := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":0}`)
+	reordered := []byte(`{"temperature":0,"stream":true,"user":"ci","messages":[{"content":"hi","role":"user"}],"model":"m"}`)
+	hotter := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":1}`)
+
+	key := c.Key("k", sdktranslator.FormatOpenAI, "m", base, false)
+	if got := c.Key("k", sdktranslator.FormatOpenAI, "m", reordered, false); got != key {
+		t.Fatalf("equivalent requests produced different keys")
+	}
+	if got := c.Key("k", sdktranslator.FormatOpenAI, "m", hotter, false); got == key {
+		t.Fatalf("different temperature produced the same key")
+	}
+	for _, field := range []string{`"seed":1`, `"presence_penalty":0.5`, `"logit_bias":{"50256":-100}`, `"parallel_tool_calls":false`, `"top_logprobs":2`} {
+		variant := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":0,` + field + `}`)
+		if got := c.Key("k", sdktranslator.FormatOpenAI, "m", variant, false); got == key {
+			t.Fatalf("request with %s produced the same key", field)
+		}
+	}
+	if got := c.Key("other", sdktranslator.FormatOpenAI, "m", base, false); got == key {
+		t.Fatalf("keys must be scoped to the client API key by default")
+	}
+	if got := c.Key("k", sdktranslator.FormatOpenAI, "m", base, true); got == key {
+		t.Fatalf("streaming and non-streaming requests must not share a key")
+	}
+
+	// Fields a translation to chat form would drop still separate the requests.
+	claude := []byte(`{"model":"m","max_tokens":16,"messages":[{"role":"user","content":"hi"}],"top_k":5}`)
+	claudeKey := c.Key("k", sdktranslator.FormatClaude, "m", claude, false)
+	if got := c.Key("k", sdktranslator.FormatClaude, "m", []byte(`{"model":"m","max_tokens":16,"messages":[{"role":"user","content":"hi"}],"top_k":40}`), false); got == claudeKey {
+		t.Fatalf("claude requests differing in top_k produced the same key")
+	}
+
+	shared := New(enabledConfig(sdkconfig.ResponseCacheConfig{SharedAcrossKeys: true}))
+	if shared.Key("a", sdktranslator.FormatOpenAI, "m", base, false) != shared.Key("b", sdktranslator.FormatOpenAI, "m", base, false) {
+		t.Fatalf("shared-across-keys should ignore the client API key")
+	}
+}
+
+func TestCache_TTLAndDisabled(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(enabledConfig(sdkconfig.ResponseCacheConfig{TTLSeconds: 60}))
+	c.now = func() time.Time { return now }
+
+	c.Put("k", &Entry{Format: "openai", Payload: []byte(`{}`)})
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("expected hit before expiry")
+	}
+	now = now.Add(61 * time.Second)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("expected entry to expire")
+	}
+
+	c.Configure(&sdkconfig.SDKConfig{})
+	if c.Enabled() {
+		t.Fatal("cache should be disabled")
+	}
+	var nilCache *Cache
+	if _, ok := nilCache.Get("k"); ok || nilCache.Enabled() {
+		t.Fatal("nil cache must be inert")
+	}
+}
+
+func TestMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryStore(2)
+	s.Put("a", &Entry{})
+	s.Put("b", &Entry{})
+	s.Get("a")
+	s.Put("c", &Entry{})
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Fatal("expected a to survive")
+	}
+	if s.Len() != 2 {
+		t.Fatalf("len = %d, want 2", s.Len())
+	}
+}
+
+func TestDiskStore_PersistsAndEvicts(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir, 2)
+	if err != nil {
+		t.Fatalf("NewDiskStore: %v", err)
+	}
+	s.Put("a", &Entry{Format: "claude", Payload: []byte(`{"id":"a"}`)})
+
+	reopened, err := NewDiskStore(dir, 2)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	entry, ok := reopened.Get("a")
+	if !ok || string(entry.Payload) != `{"id":"a"}` || entry.Format != "claude" {
+		t.Fatalf("entry = %+v, ok = %v", entry, ok)
+	}
+
+	reopened.Put("b", &Entry{})
+	reopened.Put("c", &Entry{})
+	if reopened.Len() != 2 {
+		t.Fatalf("len = %d, want 2", reopened.Len())
+	}
+}
+
+func TestEntry_StreamReplayAcrossFormats(t *testing.T) {
+	entry := &Entry{
+		Format: "openai",
+		Stream: true,
+		Chunks: [][]byte{
+			[]byte(`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`),
+			[]byte(`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`),
+		},
+	}
+	request := []byte(`{"model":"m","max_tokens":16,"messages":[{"role":"user","content":"hi"}],"stream":true}`)
+
+	same, ok := entry.StreamChunks(context.Background(), sdktranslator.FormatOpenAI, "m", request)
+	if !ok || len(same) != 2 {
+		t.Fatalf("same-format replay = %d chunks, ok = %v", len(same), ok)
+	}
+
+	claude, ok := entry.StreamChunks(context.Background(), sdktranslator.FormatClaude, "m", request)
+	if !ok {
+		t.Fatal("expected openai entry to replay for a claude client")
+	}
+	joined := ""
+	for _, chunk := range claude {
+		joined += string(chunk)
+	}
+	for _, want := range []string{"message_start", "Hello", "message_stop"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("claude replay missing %q:\n%s", want, joined)
+		}
+	}
+
+	if _, ok = entry.NonStreamPayload(context.Background(), sdktranslator.FormatOpenAI, "m", request); ok {
+		t.Fatal("stream entries must not serve non-streaming requests")
+	}
+}
@@ -0,0 +1,75 @@
+package responsecache
+
+import (
+	"bytes"
+	"context"
+
+	sdktranslator "cliproxy/sdk/translator"
+)
+
+// NonStreamPayload renders a cached non-streaming response for a client speaking target.
+// Entries recorded in another format are converted through the translator registry;
+// ok is false when the entry cannot serve this request.
+func (e *Entry) NonStreamPayload(ctx context.Context, target sdktranslator.Format, model string, originalRequest []byte) ([]byte, bool) {
+	if e == nil || e.Stream || len(e.Payload) == 0 {
+		return nil, false
+	}
+	source := sdktranslator.FromString(e.Format)
+	if source == target {
+		return bytes.Clone(e.Payload), true
+	}
+	if !sdktranslator.HasResponseTransformer(target, source) {
+		return nil, false
+	}
+	translatedReq := sdktranslator.TranslateRequest(target, source, model, originalRequest, false)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, source, target, model, originalRequest, translatedReq, e.Payload, &param)
+	return []byte(out), out != ""
+}
+
+// StreamChunks renders a cached streaming response for a client speaking target. Chunks
+// recorded in the client's own format are replayed verbatim; otherwise they are re-framed
+// as SSE data lines and fed through the registered stream translator.
+func (e *Entry) StreamChunks(ctx context.Context, target sdktranslator.Format, model string, originalRequest []byte) ([][]byte, bool) {
+	if e == nil || !e.Stream || len(e.Chunks) == 0 {
+		return nil, false
+	}
+	source := sdktranslator.FromString(e.Format)
+	if source == target {
+		out := make([][]byte, 0, len(e.Chunks))
+		for _, chunk := range e.Chunks {
+			out = append(out, bytes.Clone(chunk))
+		}
+		return out, true
+	}
+	if !sdktranslator.HasResponseTransformer(target, source) {
+		return nil, false
+	}
+	translatedReq := sdktranslator.TranslateRequest(target, source, model, originalRequest, true)
+	var param any
+	var out [][]byte
+	emit := func(line []byte) {
+		for _, piece := range sdktranslator.TranslateStream(ctx, source, target, model, originalRequest, translatedReq, line, &param) {
+			if piece != "" {
+				out = append(out, []byte(piece))
+			}
+		}
+	}
+	for _, chunk := range e.Chunks {
+		for _, line := range bytes.Split(chunk, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 || line[0] == ':' || bytes.HasPrefix(line, []byte("event:")) {
+				continue
+			}
+			if !bytes.HasPrefix(line, []byte("data:")) {
+				line = append([]byte("data: "), line...)
+			}
+			emit(line)
+		}
+	}
+	if source == sdktranslator.FormatOpenAI {
+		// OpenAI chunks are stored without the terminal marker; translators flush on it.
+		emit([]byte("data: [DONE]"))
+	}
+	return out, len(out) > 0
+}
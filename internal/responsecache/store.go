@@ -0,0 +1,200 @@
+package responsecache
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MemoryStore is an in-process LRU store bounded by entry count.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStore creates an LRU store holding at most maxEntries entries.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryItem).entry, true
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(key string, entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		elem.Value.(*memoryItem).entry = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.items[key] = s.order.PushFront(&memoryItem{key: key, entry: entry})
+	for s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		s.order.Remove(elem)
+		delete(s.items, key)
+	}
+}
+
+// Len implements Store.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// DiskStore keeps one JSON file per entry in a directory. File modification times
+// track recency so the least recently used files are evicted once maxEntries is exceeded.
+type DiskStore struct {
+	mu         sync.Mutex
+	dir        string
+	maxEntries int
+}
+
+// NewDiskStore creates the cache directory if needed and returns a store rooted there.
+func NewDiskStore(dir string, maxEntries int) (*DiskStore, error) {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create response cache dir: %w", err)
+	}
+	return &DiskStore{dir: dir, maxEntries: maxEntries}, nil
+}
+
+func (s *DiskStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// Get implements Store.
+func (s *DiskStore) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		log.Debugf("response cache: dropping unreadable entry %s: %v", path, err)
+		_ = os.Remove(path)
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &entry, true
+}
+
+// Put implements Store.
+func (s *DiskStore) Put(key string, entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(key)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Warnf("response cache: failed to write %s: %v", tmp, err)
+		return
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		log.Warnf("response cache: failed to store %s: %v", path, err)
+		return
+	}
+	s.evictLocked()
+}
+
+// Delete implements Store.
+func (s *DiskStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = os.Remove(s.path(key))
+}
+
+// Len implements Store.
+func (s *DiskStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.listLocked())
+}
+
+type diskFile struct {
+	path    string
+	modTime time.Time
+}
+
+func (s *DiskStore) listLocked() []diskFile {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	files := make([]diskFile, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, errInfo := e.Info()
+		if errInfo != nil {
+			continue
+		}
+		files = append(files, diskFile{path: filepath.Join(s.dir, e.Name()), modTime: info.ModTime()})
+	}
+	return files
+}
+
+func (s *DiskStore) evictLocked() {
+	files := s.listLocked()
+	if len(files) <= s.maxEntries {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files[:len(files)-s.maxEntries] {
+		_ = os.Remove(f.path)
+	}
+}
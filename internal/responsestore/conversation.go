@@ -0,0 +1,132 @@
+package responsestore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// InputItems returns the input of a Responses request as a list of items. A string input
+// becomes a single user message.
+func InputItems(rawJSON []byte) []string {
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case input.IsArray():
+		items := make([]string, 0, len(input.Array()))
+		for _, item := range input.Array() {
+			items = append(items, item.Raw)
+		}
+		return items
+	case input.Type == gjson.String:
+		item := `{"type":"message","role":"user","content":[{"type":"input_text","text":""}]}`
+		item, _ = sjson.Set(item, "content.0.text", input.String())
+		return []string{item}
+	}
+	return nil
+}
+
+// History returns the conversation of chain, as returned by Store.Conversation, as input
+// items for a follow-up request: each turn's input followed by the model's output. Item IDs
+// are dropped because backends that do not persist items would reject references to them,
+// and reasoning items are only kept when they carry encrypted content a backend can resume from.
+func History(chain []*Record) []string {
+	var items []string
+	for _, record := range chain {
+		for _, item := range gjson.ParseBytes(record.Input).Array() {
+			items = append(items, withoutID(item.Raw))
+		}
+		for _, item := range gjson.GetBytes(record.Response, "output").Array() {
+			if item.Get("type").String() == "reasoning" && item.Get("encrypted_content").String() == "" {
+				continue
+			}
+			items = append(items, withoutID(item.Raw))
+		}
+	}
+	return items
+}
+
+// ConversationInput returns every input item the last response of chain saw, with IDs: the
+// input and output of the earlier turns followed by the last turn's own input.
+func ConversationInput(chain []*Record) []string {
+	var items []string
+	for i, record := range chain {
+		for _, item := range gjson.ParseBytes(record.Input).Array() {
+			items = append(items, item.Raw)
+		}
+		if i == len(chain)-1 {
+			break
+		}
+		for _, item := range gjson.GetBytes(record.Response, "output").Array() {
+			items = append(items, item.Raw)
+		}
+	}
+	return items
+}
+
+// ExpandRequest rewrites a Responses request that continues chain so its input carries the
+// whole conversation. previous_response_id is kept so translators can echo it back.
+func ExpandRequest(chain []*Record, rawJSON []byte) []byte {
+	items := append(History(chain), InputItems(rawJSON)...)
+	out, err := sjson.SetRawBytes(rawJSON, "input", []byte(joinItems(items)))
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
+
+// NewRecord builds the record for response, produced for the request rawJSON as the client
+// sent it, before ExpandRequest, on behalf of the API key owner, of which only the OwnerOf hash
+// is kept. Only the turn's own input is stored; earlier turns are reached through
+// previous_response_id. It returns nil when the response has no ID.
+func NewRecord(owner string, rawJSON, response []byte) *Record {
+	id := gjson.GetBytes(response, "id").String()
+	if id == "" {
+		return nil
+	}
+	items := InputItems(rawJSON)
+	for i, item := range items {
+		if gjson.Get(item, "id").String() != "" {
+			continue
+		}
+		prefix := "item_"
+		if t := gjson.Get(item, "type").String(); t == "message" || (t == "" && gjson.Get(item, "role").Exists()) {
+			prefix = "msg_"
+		}
+		items[i], _ = sjson.Set(item, "id", prefix+randomHex())
+	}
+	return &Record{
+		ID:         id,
+		Owner:      OwnerOf(owner),
+		PreviousID: gjson.GetBytes(rawJSON, "previous_response_id").String(),
+		Input:      []byte(joinItems(items)),
+		Response:   append([]byte(nil), response...),
+	}
+}
+
+// ShouldStore reports whether a Responses request asked for its response to be stored;
+// like OpenAI, the default is true.
+func ShouldStore(rawJSON []byte) bool {
+	store := gjson.GetBytes(rawJSON, "store")
+	return !store.Exists() || store.Type != gjson.False
+}
+
+func withoutID(item string) string {
+	out, err := sjson.Delete(item, "id")
+	if err != nil {
+		return item
+	}
+	return out
+}
+
+func joinItems(items []string) string {
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+func randomHex() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,374 @@
+// Package responsestore keeps OpenAI Responses API results so the proxy can serve stateful
+// Responses features for every backend: previous_response_id is expanded into the full
+// conversation before translation, and stored responses can be retrieved, listed and deleted.
+// Each record holds a single turn; conversations are rebuilt by following previous_response_id.
+package responsestore
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cliproxy/internal/logging"
+	"cliproxy/internal/util"
+	sdkconfig "cliproxy/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTTL        = 30 * 24 * time.Hour
+	defaultMaxEntries = 1000
+	defaultMaxBytes   = 256 << 20
+
+	backendMemory = "memory"
+	backendDisk   = "disk"
+)
+
+// validID limits IDs to characters that are safe as file names.
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Record is a stored response with the input of the turn that produced it.
+type Record struct {
+	ID string `json:"id"`
+	// Owner is the OwnerOf the API key that created the response.
+	Owner string `json:"owner,omitempty"`
+	// PreviousID is the response this turn continued, if any.
+	PreviousID string `json:"previous_response_id,omitempty"`
+	// Input holds the input items sent with this turn, each with an ID. Items inherited through
+	// previous_response_id live in the earlier records.
+	Input json.RawMessage `json:"input"`
+	// Response is the Responses API response object returned to the client.
+	Response  json.RawMessage `json:"response"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Store holds records in memory or on disk, bounded by entry count, total size and age.
+// A nil or disabled Store holds nothing.
+type Store struct {
+	mu         sync.Mutex
+	enabled    bool
+	backend    string
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	bytes      int64                    // total size of the stored records
+	order      *list.List               // most recently used first
+	items      map[string]*list.Element // id -> element holding *Record (nil payload on disk)
+	now        func() time.Time
+}
+
+type storeItem struct {
+	id     string
+	size   int64
+	record *Record
+}
+
+// New creates a store configured from cfg.
+func New(cfg *sdkconfig.SDKConfig) *Store {
+	s := &Store{now: time.Now, order: list.New(), items: make(map[string]*list.Element)}
+	s.Configure(cfg)
+	return s
+}
+
+// Configure applies a new configuration. Existing records survive reloads unless the backend
+// or directory changes.
+func (s *Store) Configure(cfg *sdkconfig.SDKConfig) {
+	if s == nil {
+		return
+	}
+	var next sdkconfig.ResponsesStoreConfig
+	if cfg != nil {
+		next = cfg.ResponsesStore
+	}
+	backend := strings.ToLower(strings.TrimSpace(next.Backend))
+	switch backend {
+	case "":
+		backend = backendMemory
+	case backendMemory, backendDisk:
+	default:
+		log.Warnf("responses store: unknown backend %q, using memory", backend)
+		backend = backendMemory
+	}
+	maxEntries := next.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	maxBytes := next.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	ttl := defaultTTL
+	if next.TTLSeconds > 0 {
+		ttl = time.Duration(next.TTLSeconds) * time.Second
+	}
+	dir := ""
+	if backend == backendDisk {
+		dir = strings.TrimSpace(next.Dir)
+		if dir == "" {
+			// Keep records out of auth-dir: the token store and watcher treat its JSON files as credentials.
+			dir = filepath.Join("data", "responses")
+			if base := util.WritablePath(); base != "" {
+				dir = filepath.Join(base, "data", "responses")
+			}
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			log.Errorf("responses store: failed to create %s, falling back to memory: %v", dir, err)
+			backend, dir = backendMemory, ""
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = !next.Disable
+	s.ttl = ttl
+	s.maxEntries = maxEntries
+	s.maxBytes = maxBytes
+	if backend != s.backend || dir != s.dir {
+		s.backend, s.dir = backend, dir
+		s.order.Init()
+		s.items = make(map[string]*list.Element)
+		s.bytes = 0
+		if dir != "" {
+			s.loadIndexLocked()
+		}
+	}
+	s.evictLocked()
+}
+
+// Enabled reports whether responses are stored and previous_response_id is expanded.
+func (s *Store) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled
+}
+
+// OwnerOf returns the owner identity stored for a client API key. Only a hash of the key is
+// kept, so disk records never hold a usable credential.
+func OwnerOf(apiKey string) string {
+	return logging.HashAPIKey(apiKey)
+}
+
+// isOwnerHash reports whether owner already has the OwnerOf form: 16 lowercase hex digits.
+func isOwnerHash(owner string) bool {
+	if len(owner) != 16 {
+		return false
+	}
+	for _, r := range owner {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the record with id when it belongs to the API key owner and has not expired.
+func (s *Store) Get(owner, id string) (*Record, bool) {
+	if s == nil || !validID.MatchString(id) {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return nil, false
+	}
+	elem, ok := s.items[id]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*storeItem)
+	record := item.record
+	if record == nil {
+		var err error
+		if record, err = s.readLocked(id); err != nil {
+			log.Debugf("responses store: dropping unreadable record %s: %v", id, err)
+			s.removeLocked(elem)
+			return nil, false
+		}
+	}
+	if s.now().Sub(record.CreatedAt) > s.ttl {
+		s.removeLocked(elem)
+		return nil, false
+	}
+	if hashed := OwnerOf(owner); record.Owner != hashed {
+		if record.Owner != owner || isOwnerHash(record.Owner) {
+			return nil, false
+		}
+		// Written before owners were hashed: upgrade the record in place.
+		record.Owner = hashed
+		if s.dir != "" {
+			if data, err := json.Marshal(record); err == nil && s.writeLocked(id, data) == nil {
+				s.bytes += int64(len(data)) - item.size
+				item.size = int64(len(data))
+			}
+		}
+	}
+	s.order.MoveToFront(elem)
+	if s.dir != "" {
+		now := s.now()
+		_ = os.Chtimes(s.path(id), now, now)
+	}
+	return record, true
+}
+
+// Conversation returns the record with id followed back through previous_response_id,
+// oldest first. It reports false when any record of the chain is missing, expired or owned
+// by another API key.
+func (s *Store) Conversation(owner, id string) ([]*Record, bool) {
+	var chain []*Record
+	seen := make(map[string]bool)
+	for id != "" {
+		if seen[id] {
+			break
+		}
+		seen[id] = true
+		record, ok := s.Get(owner, id)
+		if !ok {
+			return nil, false
+		}
+		chain = append(chain, record)
+		id = record.PreviousID
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, len(chain) > 0
+}
+
+// Put stores record, stamping its creation time. Records larger than the store's byte limit
+// are not kept.
+func (s *Store) Put(record *Record) {
+	if s == nil || record == nil || !validID.MatchString(record.ID) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return
+	}
+	record.CreatedAt = s.now()
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Warnf("responses store: failed to encode %s: %v", record.ID, err)
+		return
+	}
+	size := int64(len(data))
+	if size > s.maxBytes {
+		log.Warnf("responses store: not storing %s, its %d bytes exceed max-bytes", record.ID, size)
+		return
+	}
+	item := &storeItem{id: record.ID, size: size, record: record}
+	if s.dir != "" {
+		if err = s.writeLocked(record.ID, data); err != nil {
+			log.Warnf("responses store: failed to store %s: %v", record.ID, err)
+			return
+		}
+		item.record = nil
+	}
+	if elem, ok := s.items[record.ID]; ok {
+		s.bytes -= elem.Value.(*storeItem).size
+		elem.Value = item
+		s.order.MoveToFront(elem)
+	} else {
+		s.items[record.ID] = s.order.PushFront(item)
+	}
+	s.bytes += size
+	s.evictLocked()
+}
+
+// Delete removes the record with id when it belongs to the API key owner.
+func (s *Store) Delete(owner, id string) bool {
+	if _, ok := s.Get(owner, id); !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[id]; ok {
+		s.removeLocked(elem)
+	}
+	return true
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) readLocked(id string) (*Record, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err = json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *Store) writeLocked(id string, data []byte) error {
+	path := s.path(id)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) removeLocked(elem *list.Element) {
+	item := elem.Value.(*storeItem)
+	id := item.id
+	s.order.Remove(elem)
+	delete(s.items, id)
+	s.bytes -= item.size
+	if s.dir != "" {
+		_ = os.Remove(s.path(id))
+	}
+}
+
+func (s *Store) evictLocked() {
+	for s.order.Len() > s.maxEntries || (s.bytes > s.maxBytes && s.order.Len() > 0) {
+		s.removeLocked(s.order.Back())
+	}
+}
+
+// loadIndexLocked indexes the records already on disk, most recently used first.
+func (s *Store) loadIndexLocked() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	type diskFile struct {
+		id      string
+		size    int64
+		modTime time.Time
+	}
+	files := make([]diskFile, 0, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok || !validID.MatchString(id) {
+			continue
+		}
+		if info, errInfo := e.Info(); errInfo == nil {
+			files = append(files, diskFile{id: id, size: info.Size(), modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, f := range files {
+		s.items[f.id] = s.order.PushBack(&storeItem{id: f.id, size: f.size})
+		s.bytes += f.size
+	}
+}
@@ -0,0 +1,169 @@
+package responsestore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sdkconfig "cliproxy/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestExpandRequest_ChainsConversation(t *testing.T) {
+	s := New(nil)
+
+	first := []byte(`{"model":"m","input":"hi","instructions":"be brief"}`)
+	firstResp := []byte(`{"id":"resp_1","object":"response","output":[` +
+		`{"id":"rs_1","type":"reasoning","summary":[]},` +
+		`{"id":"msg_a","type":"message","role":"assistant","content":[{"type":"output_text","text":"hello"}]}]}`)
+	s.Put(NewRecord("key", first, firstResp))
+
+	second := []byte(`{"model":"m","previous_response_id":"resp_1","input":[{"role":"user","content":"again"}]}`)
+	chain, ok := s.Conversation("key", "resp_1")
+	if !ok {
+		t.Fatalf("stored response not found")
+	}
+	expanded := ExpandRequest(chain, second)
+
+	input := gjson.GetBytes(expanded, "input").Array()
+	if len(input) != 3 {
+		t.Fatalf("expanded input has %d items, want 3: %s", len(input), expanded)
+	}
+	if input[0].Get("content.0.text").String() != "hi" || input[0].Get("id").Exists() {
+		t.Fatalf("first item = %s, want the original user message without id", input[0].Raw)
+	}
+	if input[1].Get("role").String() != "assistant" || input[1].Get("id").Exists() {
+		t.Fatalf("second item = %s, want the assistant output without id", input[1].Raw)
+	}
+	if input[2].Get("content").String() != "again" {
+		t.Fatalf("third item = %s, want the new user message", input[2].Raw)
+	}
+	if gjson.GetBytes(expanded, "previous_response_id").String() != "resp_1" {
+		t.Fatalf("previous_response_id should be kept for translators to echo")
+	}
+
+	// The follow-up's record holds only its own turn and links back to the first one.
+	s.Put(NewRecord("key", second, []byte(`{"id":"resp_2","output":[{"id":"msg_b","type":"message","role":"assistant","content":[]}]}`)))
+	record, _ := s.Get("key", "resp_2")
+	items := gjson.ParseBytes(record.Input).Array()
+	if len(items) != 1 || items[0].Get("id").String() == "" || record.PreviousID != "resp_1" {
+		t.Fatalf("record = %+v, input = %s", record, record.Input)
+	}
+
+	third := []byte(`{"model":"m","previous_response_id":"resp_2","input":"more"}`)
+	chain, ok = s.Conversation("key", "resp_2")
+	if !ok || len(chain) != 2 {
+		t.Fatalf("conversation = %d records, ok = %v", len(chain), ok)
+	}
+	if n := len(gjson.GetBytes(ExpandRequest(chain, third), "input").Array()); n != 5 {
+		t.Fatalf("third turn expanded to %d items, want 5", n)
+	}
+	if n := len(ConversationInput(chain)); n != 4 {
+		t.Fatalf("conversation input has %d items, want the first turn, its 2 outputs and the second turn", n)
+	}
+
+	s.Delete("key", "resp_1")
+	if _, ok = s.Conversation("key", "resp_2"); ok {
+		t.Fatalf("a conversation with a missing turn must not be continued")
+	}
+}
+
+func TestStore_BoundsTotalBytes(t *testing.T) {
+	s := New(&sdkconfig.SDKConfig{ResponsesStore: sdkconfig.ResponsesStoreConfig{MaxBytes: 400}})
+	payload := []byte(`{"text":"` + strings.Repeat("x", 100) + `"}`)
+	for _, id := range []string{"resp_a", "resp_b", "resp_c"} {
+		s.Put(&Record{ID: id, Owner: "key", Input: []byte(`[]`), Response: payload})
+	}
+	if _, ok := s.Get("key", "resp_a"); ok {
+		t.Fatalf("oldest record should have been evicted to stay under max-bytes")
+	}
+	if _, ok := s.Get("key", "resp_c"); !ok {
+		t.Fatalf("newest record missing")
+	}
+	s.Put(&Record{ID: "resp_big", Owner: "key", Input: []byte(`[]`), Response: []byte(`{"text":"` + strings.Repeat("x", 500) + `"}`)})
+	if _, ok := s.Get("key", "resp_big"); ok {
+		t.Fatalf("a record larger than max-bytes must not be stored")
+	}
+	if _, ok := s.Get("key", "resp_c"); !ok {
+		t.Fatalf("an oversized record must not evict the others")
+	}
+}
+
+func TestStore_ScopesAndLimits(t *testing.T) {
+	s := New(&sdkconfig.SDKConfig{ResponsesStore: sdkconfig.ResponsesStoreConfig{MaxEntries: 2, TTLSeconds: 60}})
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for _, id := range []string{"resp_a", "resp_b", "resp_c"} {
+		s.Put(&Record{ID: id, Owner: "key", Input: []byte(`[]`), Response: []byte(`{}`)})
+	}
+	if _, ok := s.Get("key", "resp_a"); ok {
+		t.Fatalf("oldest record should have been evicted")
+	}
+	if _, ok := s.Get("other", "resp_b"); ok {
+		t.Fatalf("records must not be visible to other API keys")
+	}
+	if _, ok := s.Get("key", "../resp_b"); ok {
+		t.Fatalf("invalid ids must be rejected")
+	}
+	if !s.Delete("key", "resp_b") {
+		t.Fatalf("delete failed")
+	}
+	if _, ok := s.Get("key", "resp_b"); ok {
+		t.Fatalf("deleted record still present")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := s.Get("key", "resp_c"); ok {
+		t.Fatalf("expired record still present")
+	}
+}
+
+func TestStore_DiskBackendSurvivesRestart(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{ResponsesStore: sdkconfig.ResponsesStoreConfig{Backend: "disk", Dir: t.TempDir()}}
+	New(cfg).Put(&Record{ID: "resp_disk", Owner: "key", Input: []byte(`[]`), Response: []byte(`{"id":"resp_disk"}`)})
+
+	record, ok := New(cfg).Get("key", "resp_disk")
+	if !ok || gjson.GetBytes(record.Response, "id").String() != "resp_disk" {
+		t.Fatalf("record not restored from disk")
+	}
+}
+
+func TestStore_DiskRecordsHoldOwnerHash(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &sdkconfig.SDKConfig{ResponsesStore: sdkconfig.ResponsesStoreConfig{Backend: "disk", Dir: dir}}
+	s := New(cfg)
+	s.Put(NewRecord("sk-raw-key", []byte(`{"input":"hi"}`), []byte(`{"id":"resp_hashed"}`)))
+	// A record written before owners were hashed is upgraded on first access.
+	s.Put(&Record{ID: "resp_legacy", Owner: "sk-raw-key", Input: []byte(`[]`), Response: []byte(`{}`)})
+	if _, ok := s.Get("sk-raw-key", "resp_legacy"); !ok {
+		t.Fatalf("legacy record not visible to its API key")
+	}
+
+	for _, id := range []string{"resp_hashed", "resp_legacy"} {
+		data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+		if err != nil {
+			t.Fatalf("read %s: %v", id, err)
+		}
+		if strings.Contains(string(data), "sk-raw-key") || gjson.GetBytes(data, "owner").String() != OwnerOf("sk-raw-key") {
+			t.Fatalf("%s does not hold the owner hash: %s", id, data)
+		}
+	}
+	if _, ok := New(cfg).Get("sk-raw-key", "resp_hashed"); !ok {
+		t.Fatalf("hashed record not visible to its API key after restart")
+	}
+	if _, ok := s.Get(OwnerOf("sk-raw-key"), "resp_hashed"); ok {
+		t.Fatalf("the stored hash must not work as an API key")
+	}
+}
+
+func TestShouldStore(t *testing.T) {
+	if !ShouldStore([]byte(`{"model":"m"}`)) || !ShouldStore([]byte(`{"store":true}`)) {
+		t.Fatalf("responses are stored by default")
+	}
+	if ShouldStore([]byte(`{"store":false}`)) {
+		t.Fatalf("store:false must not be stored")
+	}
+}
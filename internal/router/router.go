@@ -71,7 +71,8 @@ func (r *Router) ParseModelID(modelID string) *ParsedModel {
 // RouteResult contains the chosen providers and the true model ID.
 type RouteResult struct {
 	Providers []string
-	ModelID   string // The ID recognized by the provider
+	ModelID   string   // The ID recognized by the provider
+	Tags      []string // Credential tags required by matching rules
 }
 
 // Resolve identifies the target providers and normalized model ID for a request.
@@ -84,6 +85,7 @@ func (r *Router) Resolve(ctx context.Context, modelID string, userAgent string)
 					return &RouteResult{
 						Providers: []string{override.ForceProvider},
 						ModelID:   modelID,
+						Tags:      r.ruleTags(r.ParseModelID(modelID).CleanID),
 					}, nil
 				}
 			}
@@ -96,6 +98,7 @@ func (r *Router) Resolve(ctx context.Context, modelID string, userAgent string)
 		return &RouteResult{
 			Providers: []string{parsed.ProviderFilter},
 			ModelID:   parsed.CleanID,
+			Tags:      r.ruleTags(parsed.CleanID),
 		}, nil
 	}
 
@@ -105,17 +108,7 @@ func (r *Router) Resolve(ctx context.Context, modelID string, userAgent string)
 	// 4. Apply priority rules if configured
 	if r.cfg != nil {
 		for _, rule := range r.cfg.Rules {
-			match := false
-			if strings.Contains(rule.Model, "*") {
-				pattern := strings.TrimSuffix(rule.Model, "*")
-				if strings.HasPrefix(parsed.CleanID, pattern) {
-					match = true
-				}
-			} else if rule.Model == parsed.CleanID {
-				match = true
-			}
-
-			if match && len(rule.Priority) > 0 {
+			if matchRule(rule, parsed.CleanID) && len(rule.Priority) > 0 {
 				// Reorder providers based on priority
 				providers = mungeProviders(providers, rule.Priority)
 			}
@@ -125,9 +118,33 @@ func (r *Router) Resolve(ctx context.Context, modelID string, userAgent string)
 	return &RouteResult{
 		Providers: providers,
 		ModelID:   parsed.CleanID,
+		Tags:      r.ruleTags(parsed.CleanID),
 	}, nil
 }
 
+// ruleTags collects the credential tags of every rule matching modelID. Tags apply on every
+// routing path so a direct provider prefix cannot bypass them.
+func (r *Router) ruleTags(modelID string) []string {
+	if r.cfg == nil {
+		return nil
+	}
+	var tags []string
+	for _, rule := range r.cfg.Rules {
+		if matchRule(rule, modelID) {
+			tags = append(tags, rule.Tags...)
+		}
+	}
+	return tags
+}
+
+// matchRule reports whether rule applies to modelID. A trailing "*" matches by prefix.
+func matchRule(rule config.RoutingRule, modelID string) bool {
+	if strings.Contains(rule.Model, "*") {
+		return strings.HasPrefix(modelID, strings.TrimSuffix(rule.Model, "*"))
+	}
+	return rule.Model == modelID
+}
+
 // mungeProviders reorders the slice based on preference.
 func mungeProviders(available []string, priority []string) []string {
 	if len(priority) == 0 {
@@ -187,7 +187,7 @@ func (e *AIStudioExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth
 		return nil, statusErr{code: firstEvent.Status, msg: body.String()}
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
-	stream = out
+	stream = reporter.trackStream(out)
 	go func(first wsrelay.StreamEvent) {
 		defer close(out)
 		var param any
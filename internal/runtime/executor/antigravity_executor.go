@@ -606,7 +606,7 @@ func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 		}
 
 		out := make(chan cliproxyexecutor.StreamChunk)
-		stream = out
+		stream = reporter.trackStream(out)
 		go func(resp *http.Response) {
 			defer close(out)
 			defer func() {
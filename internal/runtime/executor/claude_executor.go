@@ -244,7 +244,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		return nil, err
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
-	stream = out
+	stream = reporter.trackStream(out)
 	go func() {
 		defer close(out)
 		defer func() {
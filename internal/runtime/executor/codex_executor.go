@@ -223,7 +223,7 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		return nil, err
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
-	stream = out
+	stream = reporter.trackStream(out)
 	go func() {
 		defer close(out)
 		defer func() {
@@ -0,0 +1,192 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cliproxy/internal/config"
+	cliproxyauth "cliproxy/sdk/cliproxy/auth"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	"cliproxy/sdk/cliproxy/usage"
+	sdktranslator "cliproxy/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// errTokenEmbeddingInput is returned when an OpenAI embeddings request passes token arrays to a
+// provider that only accepts text.
+var errTokenEmbeddingInput = statusErr{code: http.StatusBadRequest, msg: "token array inputs are only supported by OpenAI-compatible embedding providers"}
+
+// hasTokenEmbeddingInput reports whether an OpenAI embeddings request uses token arrays
+// ([1, 2] or [[1, 2], [3]]) instead of strings.
+func hasTokenEmbeddingInput(opts cliproxyexecutor.Options, payload []byte) bool {
+	if opts.SourceFormat != sdktranslator.FormatOpenAIEmbedding {
+		return false
+	}
+	input := gjson.GetBytes(payload, "input")
+	if !input.IsArray() {
+		return false
+	}
+	for _, item := range input.Array() {
+		if item.Type == gjson.Number || item.IsArray() {
+			return true
+		}
+	}
+	return false
+}
+
+// setGeminiEmbeddingModel points every entry of a batchEmbedContents request at model, which
+// the Gemini API requires to match the model in the URL.
+func setGeminiEmbeddingModel(payload []byte, model string) []byte {
+	count := len(gjson.GetBytes(payload, "requests").Array())
+	for i := 0; i < count; i++ {
+		payload, _ = sjson.SetBytes(payload, "requests."+strconv.Itoa(i)+".model", "models/"+model)
+	}
+	return payload
+}
+
+// geminiEmbeddingTexts returns the text of each entry in a batchEmbedContents request, joining
+// multi-part contents with newlines.
+func geminiEmbeddingTexts(payload []byte) []string {
+	requests := gjson.GetBytes(payload, "requests").Array()
+	texts := make([]string, 0, len(requests))
+	for _, request := range requests {
+		texts = append(texts, geminiEmbeddingText(request))
+	}
+	return texts
+}
+
+func geminiEmbeddingText(request gjson.Result) string {
+	var parts []string
+	for _, part := range request.Get("content.parts").Array() {
+		if text := part.Get("text"); text.Exists() {
+			parts = append(parts, text.String())
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// estimateEmbeddingTokens approximates input tokens for providers whose embedding responses
+// carry no usage. Errors yield zero, which still records the request.
+func estimateEmbeddingTokens(model string, texts []string) int64 {
+	enc, err := tokenizerForModel(model)
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, text := range texts {
+		if n, errCount := enc.Count(text); errCount == nil {
+			total += int64(n)
+		}
+	}
+	return total
+}
+
+// publishEmbeddingUsage records an embedding request; embeddings only consume input tokens.
+func publishEmbeddingUsage(ctx context.Context, reporter *usageReporter, inputTokens int64) {
+	reporter.publish(ctx, usage.Detail{InputTokens: inputTokens, TotalTokens: inputTokens})
+	reporter.ensurePublished(ctx)
+}
+
+// vertexPredictRequest converts batchEmbedContents entries into a Vertex AI predict request.
+func vertexPredictRequest(requests []gjson.Result) []byte {
+	out := []byte(`{"instances":[]}`)
+	for _, request := range requests {
+		instance := []byte(`{"content":""}`)
+		instance, _ = sjson.SetBytes(instance, "content", geminiEmbeddingText(request))
+		if taskType := request.Get("taskType").String(); taskType != "" {
+			instance, _ = sjson.SetBytes(instance, "task_type", taskType)
+		}
+		if title := request.Get("title").String(); title != "" {
+			instance, _ = sjson.SetBytes(instance, "title", title)
+		}
+		out, _ = sjson.SetRawBytes(out, "instances.-1", instance)
+	}
+	if len(requests) > 0 {
+		if dimensions := requests[0].Get("outputDimensionality"); dimensions.Exists() {
+			out, _ = sjson.SetBytes(out, "parameters.outputDimensionality", dimensions.Int())
+		}
+	}
+	return out
+}
+
+// vertexPredictBatchSize is the number of instances sent per predict call. Gemini embedding
+// models on Vertex accept a single instance per request; text-embedding models accept 250.
+func vertexPredictBatchSize(model string) int {
+	if strings.HasPrefix(strings.ToLower(model), "gemini-embedding") {
+		return 1
+	}
+	return 250
+}
+
+// appendVertexPredictions appends the vectors of a Vertex predict response to a
+// batchEmbedContents response and returns the billed token count.
+func appendVertexPredictions(out, data []byte) ([]byte, int64) {
+	var tokens int64
+	for _, prediction := range gjson.GetBytes(data, "predictions").Array() {
+		entry := []byte(`{"values":[]}`)
+		if values := prediction.Get("embeddings.values"); values.IsArray() {
+			entry, _ = sjson.SetRawBytes(entry, "values", []byte(values.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "embeddings.-1", entry)
+		tokens += prediction.Get("embeddings.statistics.token_count").Int()
+	}
+	return out, tokens
+}
+
+// postEmbeddingRequest sends an embedding request upstream with request logging and returns the
+// response body, or a statusErr for non-2xx responses.
+func postEmbeddingRequest(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider, url string, body []byte, authorize func(*http.Request)) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	authorize(httpReq)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close response body error: %v", provider, errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	appendAPIResponseChunk(ctx, cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
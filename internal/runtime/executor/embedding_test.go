@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cliproxy/internal/config"
+	_ "cliproxy/internal/translator"
+	cliproxyauth "cliproxy/sdk/cliproxy/auth"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	sdktranslator "cliproxy/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiExecutorEmbed_TranslatesOpenAIRequest(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotKey = r.URL.Path, r.Header.Get("x-goog-api-key")
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.5,-1]},{"values":[0.25,2]}]}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "g1", Provider: "gemini", Attributes: map[string]string{"api_key": "k", "base_url": server.URL}}
+	payload := []byte(`{"model":"gemini-embedding-001","input":["hello","world"],"dimensions":2}`)
+	resp, err := NewGeminiExecutor(&config.Config{}).Embed(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIEmbedding, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Embed error: %v", err)
+	}
+
+	if gotPath != "/v1beta/models/gemini-embedding-001:batchEmbedContents" || gotKey != "k" {
+		t.Fatalf("upstream path %q key %q", gotPath, gotKey)
+	}
+	req := gjson.ParseBytes(gotBody)
+	if n := len(req.Get("requests").Array()); n != 2 {
+		t.Fatalf("upstream requests = %d, want 2: %s", n, gotBody)
+	}
+	if req.Get("requests.1.content.parts.0.text").String() != "world" || req.Get("requests.0.model").String() != "models/gemini-embedding-001" || req.Get("requests.0.outputDimensionality").Int() != 2 {
+		t.Fatalf("unexpected upstream request: %s", gotBody)
+	}
+
+	out := gjson.ParseBytes(resp.Payload)
+	if out.Get("object").String() != "list" || out.Get("data.1.index").Int() != 1 || out.Get("data.1.embedding.1").Float() != 2 {
+		t.Fatalf("unexpected response: %s", resp.Payload)
+	}
+	if out.Get("usage.prompt_tokens").Int() == 0 {
+		t.Fatalf("expected estimated prompt tokens: %s", resp.Payload)
+	}
+
+	_, err = NewGeminiExecutor(&config.Config{}).Embed(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: []byte(`{"input":[[1,2,3]]}`)},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIEmbedding})
+	if se, ok := err.(statusErr); !ok || se.code != http.StatusBadRequest {
+		t.Fatalf("token input err = %v, want 400", err)
+	}
+}
+
+func TestVertexExecutorEmbed_SplitsGeminiEmbeddingBatches(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if !strings.HasSuffix(r.URL.Path, "/publishers/google/models/gemini-embedding-001:predict") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if n := len(gjson.GetBytes(body, "instances").Array()); n != 1 {
+			t.Errorf("instances per call = %d, want 1", n)
+		}
+		_, _ = w.Write([]byte(`{"predictions":[{"embeddings":{"values":[1,0],"statistics":{"token_count":3}}}]}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "v1", Provider: "vertex", Attributes: map[string]string{"api_key": "k", "base_url": server.URL}}
+	payload := []byte(`{"model":"gemini-embedding-001","input":["a","b"],"encoding_format":"base64"}`)
+	resp, err := NewGeminiVertexExecutor(&config.Config{}).Embed(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIEmbedding, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Embed error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("predict calls = %d, want 2", calls)
+	}
+	out := gjson.ParseBytes(resp.Payload)
+	if out.Get("usage.prompt_tokens").Int() != 6 {
+		t.Fatalf("prompt tokens = %d, want 6", out.Get("usage.prompt_tokens").Int())
+	}
+	raw, errDecode := base64.StdEncoding.DecodeString(out.Get("data.1.embedding").String())
+	if errDecode != nil || len(raw) != 8 {
+		t.Fatalf("base64 embedding = %q (%v), want 2 float32 values", out.Get("data.1.embedding").String(), errDecode)
+	}
+}
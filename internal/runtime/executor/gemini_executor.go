@@ -259,7 +259,7 @@ func (e *GeminiExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		return nil, err
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
-	stream = out
+	stream = reporter.trackStream(out)
 	go func() {
 		defer close(out)
 		defer func() {
@@ -379,6 +379,48 @@ func (e *GeminiExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
 }
 
+// Embed creates embeddings through the Gemini batchEmbedContents endpoint. The endpoint reports
+// no token usage, so input tokens are estimated locally for usage statistics.
+func (e *GeminiExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	apiKey, bearer := geminiCreds(auth)
+
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	if hasTokenEmbeddingInput(opts, req.Payload) {
+		return resp, errTokenEmbeddingInput
+	}
+	model := req.Model
+	if override := e.resolveUpstreamModel(model, auth); override != "" {
+		model = override
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbedding
+	translatedReq := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), false)
+	translatedReq = setGeminiEmbeddingModel(translatedReq, model)
+
+	url := fmt.Sprintf("%s/%s/models/%s:%s", resolveGeminiBaseURL(auth), glAPIVersion, model, "batchEmbedContents")
+	data, err := postEmbeddingRequest(ctx, e.cfg, auth, e.Identifier(), url, translatedReq, func(httpReq *http.Request) {
+		if apiKey != "" {
+			httpReq.Header.Set("x-goog-api-key", apiKey)
+		} else if bearer != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		applyGeminiHeaders(httpReq, auth)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	tokens := estimateEmbeddingTokens(model, geminiEmbeddingTexts(translatedReq))
+	publishEmbeddingUsage(ctx, reporter, tokens)
+	data, _ = sjson.SetBytes(data, "usageMetadata.promptTokenCount", tokens)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translatedReq, data, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
 // Refresh refreshes the authentication credentials (no-op for Gemini API key).
 func (e *GeminiExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
 	return auth, nil
@@ -109,6 +109,77 @@ func (e *GeminiVertexExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 	return e.countTokensWithAPIKey(ctx, auth, req, opts, apiKey, baseURL)
 }
 
+// Embed creates embeddings through the Vertex AI predict endpoint, using the same credential
+// selection as Execute. Requests are split into as many predict calls as the model's instance
+// limit requires, and token usage is taken from the prediction statistics.
+func (e *GeminiVertexExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	if hasTokenEmbeddingInput(opts, req.Payload) {
+		return resp, errTokenEmbeddingInput
+	}
+
+	model := req.Model
+	var endpoint string
+	var authorize func(*http.Request)
+	apiKey, baseURL := vertexAPICreds(auth)
+	if apiKey == "" {
+		projectID, location, saJSON, errCreds := vertexCreds(auth)
+		if errCreds != nil {
+			return resp, errCreds
+		}
+		token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+		if errTok != nil {
+			log.Errorf("vertex executor: access token error: %v", errTok)
+			return resp, statusErr{code: 500, msg: "internal server error"}
+		}
+		endpoint = fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", vertexBaseURL(location), vertexAPIVersion, projectID, location, model, "predict")
+		authorize = func(httpReq *http.Request) {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+			applyGeminiHeaders(httpReq, auth)
+		}
+	} else {
+		if override := e.resolveUpstreamModel(req.Model, auth); override != "" {
+			model = override
+		}
+		// For API key auth, use simpler URL format without project/location
+		if baseURL == "" {
+			baseURL = "https://generativelanguage.googleapis.com"
+		}
+		endpoint = fmt.Sprintf("%s/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, model, "predict")
+		authorize = func(httpReq *http.Request) {
+			httpReq.Header.Set("x-goog-api-key", apiKey)
+			applyGeminiHeaders(httpReq, auth)
+		}
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbedding
+	translatedReq := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), false)
+	requests := gjson.GetBytes(translatedReq, "requests").Array()
+
+	data := []byte(`{"embeddings":[]}`)
+	var tokens int64
+	batchSize := vertexPredictBatchSize(model)
+	for start := 0; start < len(requests); start += batchSize {
+		end := min(start+batchSize, len(requests))
+		body, errPost := postEmbeddingRequest(ctx, e.cfg, auth, e.Identifier(), endpoint, vertexPredictRequest(requests[start:end]), authorize)
+		if errPost != nil {
+			return resp, errPost
+		}
+		var n int64
+		data, n = appendVertexPredictions(data, body)
+		tokens += n
+	}
+
+	publishEmbeddingUsage(ctx, reporter, tokens)
+	data, _ = sjson.SetBytes(data, "usageMetadata.promptTokenCount", tokens)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), translatedReq, data, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
 // Refresh refreshes the authentication credentials (no-op for Vertex).
 func (e *GeminiVertexExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
 	return auth, nil
@@ -412,7 +483,7 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
-	stream = out
+	stream = reporter.trackStream(out)
 	go func() {
 		defer close(out)
 		defer func() {
@@ -537,7 +608,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
-	stream = out
+	stream = reporter.trackStream(out)
 	go func() {
 		defer close(out)
 		defer func() {
@@ -774,7 +774,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				e.streamToChannel(ctx, resp.Body, out, from, req.Model, opts.OriginalRequest, body, reporter)
 			}(httpResp)
 
-			return out, nil
+			return reporter.trackStream(out), nil
 		}
 		// Inner retry loop exhausted for this endpoint, try next endpoint
 		// Note: This code is unreachable because all paths in the inner loop
@@ -2705,48 +2705,13 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 // NOTE: Claude SSE event builders moved to internal/translator/kiro/claude/kiro_claude_stream.go
 // The executor now uses kiroclaude.BuildClaude*Event() functions instead
 
-// CountTokens counts tokens locally using tiktoken since Kiro API doesn't expose a token counting endpoint.
-// This provides approximate token counts for client requests.
+// CountTokens counts tokens locally since the Kiro API doesn't expose a token counting endpoint.
 func (e *KiroExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
-	// Use tiktoken for local token counting
-	enc, err := tokenizerForModel(req.Model)
+	resp, err := countTokensLocalResponse(ctx, req, opts)
 	if err != nil {
-		log.Warnf("kiro: CountTokens failed to get tokenizer: %v, falling back to estimate", err)
-		// Fallback: estimate from payload size (roughly 4 chars per token)
-		estimatedTokens := len(req.Payload) / 4
-		if estimatedTokens == 0 && len(req.Payload) > 0 {
-			estimatedTokens = 1
-		}
-		return cliproxyexecutor.Response{
-			Payload: []byte(fmt.Sprintf(`{"count":%d}`, estimatedTokens)),
-		}, nil
-	}
-
-	// Try to count tokens from the request payload
-	var totalTokens int64
-
-	// Try OpenAI chat format first
-	if tokens, countErr := countOpenAIChatTokens(enc, req.Payload); countErr == nil && tokens > 0 {
-		totalTokens = tokens
-		log.Debugf("kiro: CountTokens counted %d tokens using OpenAI chat format", totalTokens)
-	} else {
-		// Fallback: count raw payload tokens
-		if tokenCount, countErr := enc.Count(string(req.Payload)); countErr == nil {
-			totalTokens = int64(tokenCount)
-			log.Debugf("kiro: CountTokens counted %d tokens from raw payload", totalTokens)
-		} else {
-			// Final fallback: estimate from payload size
-			totalTokens = int64(len(req.Payload) / 4)
-			if totalTokens == 0 && len(req.Payload) > 0 {
-				totalTokens = 1
-			}
-			log.Debugf("kiro: CountTokens estimated %d tokens from payload size", totalTokens)
-		}
+		return cliproxyexecutor.Response{}, fmt.Errorf("kiro executor: token counting failed: %w", err)
 	}
-
-	return cliproxyexecutor.Response{
-		Payload: []byte(fmt.Sprintf(`{"count":%d}`, totalTokens)),
-	}, nil
+	return resp, nil
 }
 
 // Refresh refreshes the Kiro OAuth token.
@@ -19,6 +19,10 @@ const (
 	apiAttemptsKey = "API_UPSTREAM_ATTEMPTS"
 	apiRequestKey  = "API_REQUEST"
 	apiResponseKey = "API_RESPONSE"
+	apiUsageKey    = "API_USAGE"
+	// apiCaptureKey makes the executors record upstream traffic for a request even when request
+	// logging is off; request replay sets it to diff the upstream calls.
+	apiCaptureKey = "API_CAPTURE"
 )
 
 // upstreamRequestLog captures the outbound upstream request details for logging.
@@ -48,11 +52,8 @@ type upstreamAttempt struct {
 
 // recordAPIRequest stores the upstream request metadata in Gin context for request logging.
 func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
-		return
-	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx == nil || !captureEnabled(ginCtx, cfg) {
 		return
 	}
 
@@ -95,11 +96,8 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 
 // recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
-	if cfg == nil || !cfg.RequestLog {
-		return
-	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx == nil || !captureEnabled(ginCtx, cfg) {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -121,11 +119,11 @@ func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 
 // recordAPIResponseError adds an error entry for the latest attempt when no HTTP response is available.
 func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx == nil || !captureEnabled(ginCtx, cfg) {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -146,15 +144,12 @@ func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // appendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
-	if cfg == nil || !cfg.RequestLog {
-		return
-	}
 	data := bytes.TrimSpace(bytes.Clone(chunk))
 	if len(data) == 0 {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx == nil || !captureEnabled(ginCtx, cfg) {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -179,7 +174,15 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	updateAggregatedResponse(ginCtx, attempts)
 }
 
+// captureEnabled reports whether upstream traffic is recorded for the request.
+func captureEnabled(ginCtx *gin.Context, cfg *config.Config) bool {
+	return (cfg != nil && cfg.RequestLog) || ginCtx.GetBool(apiCaptureKey)
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
+	if ctx == nil {
+		return nil
+	}
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
 }
@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	sdktranslator "cliproxy/sdk/translator"
+)
+
+// CountTokensLocally estimates the prompt tokens of payload, a request body in the given
+// client format, with a local tokenizer. It backs count_tokens for providers whose upstream
+// has no counting endpoint and the provider-independent tokenize endpoint.
+func CountTokensLocally(format sdktranslator.Format, model string, payload []byte) (int64, error) {
+	enc, err := tokenizerForModel(model)
+	if err != nil {
+		return 0, fmt.Errorf("tokenizer init failed: %w", err)
+	}
+	switch format {
+	case sdktranslator.FormatClaude:
+		return countClaudeChatTokens(enc, payload)
+	case sdktranslator.FormatGemini, sdktranslator.FormatGeminiCLI:
+		return countGeminiTokens(enc, payload)
+	case sdktranslator.FormatOpenAIResponse, sdktranslator.FormatCodex:
+		return countOpenAIResponsesTokens(enc, payload)
+	default:
+		return countOpenAIChatTokens(enc, payload)
+	}
+}
+
+// countTokensLocalResponse counts a request locally and shapes the result for the client's
+// format, e.g. {"input_tokens":N} for Claude and {"totalTokens":N} for Gemini.
+func countTokensLocalResponse(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	count, err := CountTokensLocally(from, req.Model, req.Payload)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	to := sdktranslator.FormatOpenAI
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	_ "cliproxy/internal/translator"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	sdktranslator "cliproxy/sdk/translator"
+)
+
+func TestCountTokensLocally_Formats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  sdktranslator.Format
+		payload string
+		min     int64
+	}{
+		{
+			name:    "claude with system, tools and image",
+			format:  sdktranslator.FormatClaude,
+			payload: `{"model":"claude-sonnet-4","system":[{"type":"text","text":"You are terse."}],"messages":[{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"` + strings.Repeat("A", 4000) + `"}}]}],"tools":[{"name":"lookup","description":"find things","input_schema":{"type":"object","properties":{"q":{"type":"string"}}}}]}`,
+			min:     claudeImageTokens + 10,
+		},
+		{
+			name:    "gemini with inline image and function declarations",
+			format:  sdktranslator.FormatGemini,
+			payload: `{"systemInstruction":{"parts":[{"text":"be brief"}]},"contents":[{"role":"user","parts":[{"text":"what is this"},{"inlineData":{"mimeType":"image/jpeg","data":"` + strings.Repeat("B", 4000) + `"}}]}],"tools":[{"functionDeclarations":[{"name":"search","parameters":{"type":"object"}}]}]}`,
+			min:     geminiImageTokens + 5,
+		},
+		{
+			name:    "responses with instructions and input image",
+			format:  sdktranslator.FormatOpenAIResponse,
+			payload: `{"model":"gpt-4o","instructions":"answer in french","input":[{"role":"user","content":[{"type":"input_text","text":"hello"},{"type":"input_image","image_url":"data:image/png;base64,` + strings.Repeat("C", 4000) + `"}]}]}`,
+			min:     openAIImageTokens + 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := CountTokensLocally(tt.format, "", []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count < tt.min {
+				t.Fatalf("count = %d, want at least %d", count, tt.min)
+			}
+			// Base64 image data must not be tokenized as text.
+			if count > tt.min+200 {
+				t.Fatalf("count = %d, image data appears to be counted as text", count)
+			}
+		})
+	}
+}
+
+func TestCountTokensLocalResponse_ClaudeShape(t *testing.T) {
+	req := cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: []byte(`{"messages":[{"role":"user","content":"hi there"}]}`)}
+	resp, err := countTokensLocalResponse(context.Background(), req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatClaude})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(resp.Payload), `"input_tokens":`) {
+		t.Fatalf("payload = %s, want input_tokens", resp.Payload)
+	}
+}
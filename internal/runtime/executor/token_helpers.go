@@ -57,16 +57,7 @@ func countOpenAIChatTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
 	addIfNotEmpty(&segments, root.Get("input").String())
 	addIfNotEmpty(&segments, root.Get("prompt").String())
 
-	joined := strings.TrimSpace(strings.Join(segments, "\n"))
-	if joined == "" {
-		return 0, nil
-	}
-
-	count, err := enc.Count(joined)
-	if err != nil {
-		return 0, err
-	}
-	return int64(count), nil
+	return countSegments(enc, segments, countImages(root.Get("messages"))*openAIImageTokens)
 }
 
 // buildOpenAIUsageJSON returns a minimal usage structure understood by downstream translators.
@@ -102,8 +93,8 @@ func collectOpenAIContent(content gjson.Result, segments *[]string) {
 			switch partType {
 			case "text", "input_text", "output_text":
 				addIfNotEmpty(segments, part.Get("text").String())
-			case "image_url":
-				addIfNotEmpty(segments, part.Get("image_url.url").String())
+			case "image_url", "input_image", "image":
+				// Images are priced per image by countImages, not by their URL or data.
 			case "input_audio", "output_audio", "audio":
 				addIfNotEmpty(segments, part.Get("id").String())
 			case "tool_result":
@@ -235,17 +226,237 @@ func addIfNotEmpty(segments *[]string, value string) {
 	}
 }
 
-// countClaudeChatTokens approximates prompt tokens for Claude messages payload.
+// Fixed per-image estimates used because payloads rarely carry image dimensions. They match
+// each vendor's documented cost of a typical ~1024px image.
+const (
+	openAIImageTokens = 765
+	claudeImageTokens = 1600
+	geminiImageTokens = 258
+)
+
+// countSegments tokenizes the collected text segments and adds extra fixed-cost tokens.
+func countSegments(enc tokenizer.Codec, segments []string, extra int) (int64, error) {
+	joined := strings.TrimSpace(strings.Join(segments, "\n"))
+	if joined == "" {
+		return int64(extra), nil
+	}
+	count, err := enc.Count(joined)
+	if err != nil {
+		return 0, err
+	}
+	return int64(count + extra), nil
+}
+
+// countImages walks node and returns the number of image parts in any supported format.
+func countImages(node gjson.Result) int {
+	if !node.IsObject() && !node.IsArray() {
+		return 0
+	}
+	if node.IsObject() {
+		switch node.Get("type").String() {
+		case "image", "image_url", "input_image":
+			return 1
+		}
+		for _, key := range []string{"inlineData", "inline_data", "fileData", "file_data"} {
+			if data := node.Get(key); data.Exists() {
+				mime := data.Get("mimeType").String()
+				if mime == "" {
+					mime = data.Get("mime_type").String()
+				}
+				if strings.HasPrefix(strings.ToLower(mime), "image/") {
+					return 1
+				}
+				return 0
+			}
+		}
+	}
+	total := 0
+	node.ForEach(func(_, child gjson.Result) bool {
+		total += countImages(child)
+		return true
+	})
+	return total
+}
+
+// countClaudeChatTokens approximates prompt tokens for Claude messages payloads, covering the
+// system prompt, content blocks, tool calls and results, and tool schemas.
 func countClaudeChatTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
 	if len(payload) == 0 {
 		return 0, nil
 	}
-	// Simple approximation: simply count tokens of the JSON dump.
-	// A more accurate way would be parsing messages/system fields.
-	// For now, this suffices to satisfy the build and provide reasonable estimation.
-	count, err := enc.Count(string(payload))
-	if err != nil {
-		return 0, err
+
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+
+	collectClaudeContent(root.Get("system"), &segments)
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		addIfNotEmpty(&segments, message.Get("role").String())
+		collectClaudeContent(message.Get("content"), &segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		addIfNotEmpty(&segments, tool.Get("name").String())
+		addIfNotEmpty(&segments, tool.Get("description").String())
+		if schema := tool.Get("input_schema"); schema.Exists() {
+			addIfNotEmpty(&segments, schema.Raw)
+		}
+		return true
+	})
+	if choice := root.Get("tool_choice"); choice.Exists() {
+		addIfNotEmpty(&segments, choice.Raw)
+	}
+
+	images := countImages(root.Get("messages")) + countImages(root.Get("system"))
+	return countSegments(enc, segments, images*claudeImageTokens)
+}
+
+func collectClaudeContent(content gjson.Result, segments *[]string) {
+	if !content.Exists() {
+		return
+	}
+	if content.Type == gjson.String {
+		addIfNotEmpty(segments, content.String())
+		return
+	}
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			addIfNotEmpty(segments, block.Get("text").String())
+		case "thinking":
+			addIfNotEmpty(segments, block.Get("thinking").String())
+		case "tool_use", "server_tool_use":
+			addIfNotEmpty(segments, block.Get("name").String())
+			if input := block.Get("input"); input.Exists() {
+				addIfNotEmpty(segments, input.Raw)
+			}
+		case "tool_result":
+			collectClaudeContent(block.Get("content"), segments)
+		case "document":
+			if block.Get("source.type").String() == "text" {
+				addIfNotEmpty(segments, block.Get("source.data").String())
+			}
+		case "image", "redacted_thinking":
+			// Images are priced by countImages; redacted thinking is opaque.
+		default:
+			addIfNotEmpty(segments, block.Get("text").String())
+		}
+		return true
+	})
+}
+
+// countGeminiTokens approximates prompt tokens for Gemini generateContent payloads, including
+// the Gemini CLI envelope that nests the request under "request".
+func countGeminiTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	if inner := root.Get("request"); inner.IsObject() {
+		root = inner
+	}
+	segments := make([]string, 0, 32)
+
+	for _, key := range []string{"systemInstruction", "system_instruction"} {
+		collectGeminiParts(root.Get(key+".parts"), &segments)
+	}
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		addIfNotEmpty(&segments, content.Get("role").String())
+		collectGeminiParts(content.Get("parts"), &segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		for _, key := range []string{"functionDeclarations", "function_declarations"} {
+			tool.Get(key).ForEach(func(_, decl gjson.Result) bool {
+				addIfNotEmpty(&segments, decl.Get("name").String())
+				addIfNotEmpty(&segments, decl.Get("description").String())
+				for _, schemaKey := range []string{"parameters", "parametersJsonSchema"} {
+					if schema := decl.Get(schemaKey); schema.Exists() {
+						addIfNotEmpty(&segments, schema.Raw)
+					}
+				}
+				return true
+			})
+		}
+		return true
+	})
+
+	return countSegments(enc, segments, countImages(root.Get("contents"))*geminiImageTokens)
+}
+
+func collectGeminiParts(parts gjson.Result, segments *[]string) {
+	parts.ForEach(func(_, part gjson.Result) bool {
+		addIfNotEmpty(segments, part.Get("text").String())
+		for _, key := range []string{"functionCall", "function_call"} {
+			if call := part.Get(key); call.Exists() {
+				addIfNotEmpty(segments, call.Get("name").String())
+				if args := call.Get("args"); args.Exists() {
+					addIfNotEmpty(segments, args.Raw)
+				}
+			}
+		}
+		for _, key := range []string{"functionResponse", "function_response"} {
+			if resp := part.Get(key); resp.Exists() {
+				addIfNotEmpty(segments, resp.Get("name").String())
+				if body := resp.Get("response"); body.Exists() {
+					addIfNotEmpty(segments, body.Raw)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// countOpenAIResponsesTokens approximates prompt tokens for OpenAI Responses API payloads.
+func countOpenAIResponsesTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
 	}
-	return int64(count), nil
+
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+
+	addIfNotEmpty(&segments, root.Get("instructions").String())
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		addIfNotEmpty(&segments, input.String())
+	}
+	input.ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "function_call":
+			addIfNotEmpty(&segments, item.Get("name").String())
+			addIfNotEmpty(&segments, item.Get("arguments").String())
+		case "function_call_output":
+			addIfNotEmpty(&segments, item.Get("output").String())
+		case "reasoning":
+			item.Get("summary").ForEach(func(_, summary gjson.Result) bool {
+				addIfNotEmpty(&segments, summary.Get("text").String())
+				return true
+			})
+		default:
+			addIfNotEmpty(&segments, item.Get("role").String())
+			collectOpenAIContent(item.Get("content"), &segments)
+		}
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		appendToolPayload(tool, &segments)
+		if params := tool.Get("parameters"); params.Exists() {
+			addIfNotEmpty(&segments, params.Raw)
+		}
+		return true
+	})
+	collectOpenAIToolChoice(root.Get("tool_choice"), &segments)
+	collectOpenAIResponseFormat(root.Get("text.format"), &segments)
+
+	return countSegments(enc, segments, countImages(input)*openAIImageTokens)
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	cliproxyauth "cliproxy/sdk/cliproxy/auth"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
 	"cliproxy/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -24,6 +25,9 @@ type usageReporter struct {
 	source      string
 	requestedAt time.Time
 	once        sync.Once
+
+	firstChunkMu sync.Mutex
+	firstChunkAt time.Time
 }
 
 func newUsageReporter(ctx context.Context, provider, model string, auth *cliproxyauth.Auth) *usageReporter {
@@ -73,17 +77,7 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			RequestedAt: r.requestedAt,
-			Failed:      failed,
-			Detail:      detail,
-		})
+		r.publishRecord(ctx, detail, failed)
 	})
 }
 
@@ -96,20 +90,68 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			RequestedAt: r.requestedAt,
-			Failed:      false,
-			Detail:      usage.Detail{},
-		})
+		r.publishRecord(ctx, usage.Detail{}, false)
 	})
 }
 
+// publishRecord emits the usage record and keeps a copy in the Gin context so the structured
+// request log can attribute the request to its provider, credential and token usage.
+func (r *usageReporter) publishRecord(ctx context.Context, detail usage.Detail, failed bool) {
+	record := usage.Record{
+		Provider:     r.provider,
+		Model:        r.model,
+		Source:       r.source,
+		APIKey:       r.apiKey,
+		AuthID:       r.authID,
+		AuthIndex:    r.authIndex,
+		RequestedAt:  r.requestedAt,
+		FirstChunkAt: r.firstChunkTime(),
+		CompletedAt:  time.Now(),
+		Failed:       failed,
+		Detail:       detail,
+	}
+	usage.PublishRecord(ctx, record)
+	if ctx == nil {
+		return
+	}
+	if ginCtx := ginContextFrom(ctx); ginCtx != nil {
+		ginCtx.Set(apiUsageKey, record)
+	}
+}
+
+// trackStream forwards chunks from in and records when the first payload was emitted,
+// so the published usage record carries time-to-first-chunk.
+func (r *usageReporter) trackStream(in <-chan cliproxyexecutor.StreamChunk) <-chan cliproxyexecutor.StreamChunk {
+	if r == nil || in == nil {
+		return in
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		for chunk := range in {
+			if chunk.Err == nil && len(chunk.Payload) > 0 {
+				r.markFirstChunk()
+			}
+			out <- chunk
+		}
+	}()
+	return out
+}
+
+func (r *usageReporter) markFirstChunk() {
+	r.firstChunkMu.Lock()
+	if r.firstChunkAt.IsZero() {
+		r.firstChunkAt = time.Now()
+	}
+	r.firstChunkMu.Unlock()
+}
+
+func (r *usageReporter) firstChunkTime() time.Time {
+	r.firstChunkMu.Lock()
+	defer r.firstChunkMu.Unlock()
+	return r.firstChunkAt
+}
+
 func apiKeyFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "weekdays skip the weekend",
+			expr: "0 9 * * 1-5",
+			from: time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC), // Friday
+			want: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekday names and step",
+			expr: "*/15 8 * * MON,WED",
+			from: time.Date(2025, 1, 6, 8, 20, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 6, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "six fields with seconds",
+			expr: "30 0 12 * * *",
+			from: time.Date(2025, 1, 1, 12, 0, 30, 0, time.UTC),
+			want: time.Date(2025, 1, 2, 12, 0, 30, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 1 * 0",
+			from: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), // Sunday comes before Feb 1
+		},
+		{
+			name: "monthly macro",
+			expr: "@monthly",
+			from: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "evaluated in the given zone",
+			expr: "0 9 * * *",
+			from: time.Date(2025, 3, 29, 12, 0, 0, 0, berlin),
+			want: time.Date(2025, 3, 30, 9, 0, 0, 0, berlin), // across the DST change
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(schedule.Next(tt.from)), "got %s, want %s", schedule.Next(tt.from), tt.want)
+		})
+	}
+}
+
+func TestCronSchedule_NextNeverFires(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
@@ -14,7 +15,7 @@ import (
 
 // Executor defines the interface for running AI tasks.
 type Executor interface {
-	Execute(ctx context.Context, task *Task) (string, error)
+	Execute(ctx context.Context, task *Task) (ExecutionResult, error)
 }
 
 // Engine manages the scheduling and execution of tasks.
@@ -36,8 +37,13 @@ func NewEngine(store *Store, executor Executor) *Engine {
 	}
 }
 
-// Start begins the scheduling loop.
+// maxCatchUpRuns bounds how many missed runs the "all" catch-up policy replays for one task.
+const maxCatchUpRuns = 50
+
+// Start applies each task's catch-up policy to runs missed while the process was down, then
+// begins the scheduling loop.
 func (e *Engine) Start() {
+	e.catchUp(time.Now())
 	e.wg.Add(1)
 	go e.runLoop()
 	log.Info("Scheduler engine started")
@@ -50,6 +56,57 @@ func (e *Engine) Stop() {
 	log.Info("Scheduler engine stopped")
 }
 
+// catchUp handles active tasks whose next run passed while the scheduler was not running.
+func (e *Engine) catchUp(now time.Time) {
+	for _, task := range e.store.GetTasks() {
+		task.RLock()
+		active := task.Status == TaskStatusActive
+		nextRun := task.NextRunAt
+		policy := task.CatchUp
+		taskType := task.Type
+		task.RUnlock()
+		if !active || nextRun == nil || nextRun.After(now) {
+			continue
+		}
+
+		switch policy {
+		case CatchUpOnce, CatchUpAll:
+			runs := 1
+			if policy == CatchUpAll && taskType != TaskTypeFixedTime {
+				runs = e.countMissedRuns(task, *nextRun, now)
+			}
+			if _, loaded := e.runningTasks.LoadOrStore(task.ID, true); loaded {
+				continue
+			}
+			log.Infof("Task %s missed runs since %s, catching up with %d run(s)", task.ID, nextRun.Format(time.RFC3339), runs)
+			go e.executeMissedRuns(task, runs)
+		default:
+			log.Infof("Task %s missed runs since %s, skipping to the next run", task.ID, nextRun.Format(time.RFC3339))
+			if taskType == TaskTypeFixedTime {
+				task.Lock()
+				task.Status = TaskStatusFinished
+				task.Unlock()
+			}
+			e.updateNextRun(task, now, true)
+		}
+	}
+}
+
+// countMissedRuns counts the scheduled runs from first up to now, capped at maxCatchUpRuns.
+func (e *Engine) countMissedRuns(task *Task, first, now time.Time) int {
+	task.RLock()
+	defer task.RUnlock()
+	runs := 0
+	for t := first; !t.After(now) && runs < maxCatchUpRuns; runs++ {
+		next, err := nextOccurrence(task, t)
+		if err != nil {
+			return runs + 1
+		}
+		t = next
+	}
+	return runs
+}
+
 func (e *Engine) runLoop() {
 	defer e.wg.Done()
 	ticker := time.NewTicker(5 * time.Second) // Check every 5 seconds
@@ -163,114 +220,274 @@ func (e *Engine) updateNextRun(task *Task, baseTime time.Time, forcePersist bool
 		task.NextRunAt = &next
 		shouldPersist = true
 
-	case TaskTypeDaily, TaskTypeSystemReport: // System report uses daily scheduling for now
-		dailyTime := task.DailyTime
-		if dailyTime == "" {
-			log.Errorf("DailyTime is empty for task %s", task.ID)
+	case TaskTypeDaily, TaskTypeSystemReport, TaskTypeCron, TaskTypeCredentialProbe, TaskTypeCredentialReport: // System report uses daily scheduling for now
+		var err error
+		next, err = nextOccurrence(task, baseTime)
+		if err != nil {
+			log.Errorf("Invalid schedule for task %s: %v", task.ID, err)
 			task.Status = TaskStatusPaused
 			shouldPersist = true
 			return
 		}
+		task.NextRunAt = &next
+		shouldPersist = true
 
-		timePoints := strings.Split(dailyTime, ",")
-		var candidates []time.Time
+	default:
+		log.Warnf("Unknown task type %s for task %s, pausing", task.Type, task.ID)
+		task.Status = TaskStatusPaused
+		task.NextRunAt = nil
+		shouldPersist = true
+		return
+	}
+}
 
-		for _, p := range timePoints {
-			p = strings.TrimSpace(p)
-			if p == "" {
-				continue
-			}
+// nextOccurrence returns the first scheduled time strictly after "after" for recurring tasks,
+// evaluated in the task's time zone. Callers must hold the task lock.
+func nextOccurrence(task *Task, after time.Time) (time.Time, error) {
+	switch scheduleType(task) {
+	case TaskTypeInterval:
+		duration, err := time.ParseDuration(task.Interval)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if duration <= 0 {
+			return time.Time{}, fmt.Errorf("interval must be positive")
+		}
+		return after.Add(duration), nil
+	case TaskTypeDaily, TaskTypeSystemReport:
+		loc, err := task.Location()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return nextDailyRun(task.DailyTime, after.In(loc))
+	case TaskTypeCron:
+		loc, err := task.Location()
+		if err != nil {
+			return time.Time{}, err
+		}
+		schedule, err := ParseCron(task.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		next := schedule.Next(after.In(loc))
+		if next.IsZero() {
+			return time.Time{}, fmt.Errorf("cron expression %q never fires", task.Cron)
+		}
+		return next, nil
+	default:
+		return time.Time{}, fmt.Errorf("task type %s is not recurring", task.Type)
+	}
+}
 
-			parsedTime, err := time.Parse("15:04", p)
-			if err != nil {
-				log.Errorf("Invalid time format %s for task %s", p, task.ID)
-				continue
-			}
+// scheduleType returns the schedule a task follows. Credential tasks use whichever of interval,
+// cron and daily_time is set, in that order.
+func scheduleType(task *Task) TaskType {
+	if !isCredentialTask(task.Type) {
+		return task.Type
+	}
+	switch {
+	case task.Interval != "":
+		return TaskTypeInterval
+	case task.Cron != "":
+		return TaskTypeCron
+	default:
+		return TaskTypeDaily
+	}
+}
 
-			// Create candidate for today
-			candidate := time.Date(baseTime.Year(), baseTime.Month(), baseTime.Day(),
-				parsedTime.Hour(), parsedTime.Minute(), 0, 0, baseTime.Location())
+// nextDailyRun returns the earliest of the comma-separated HH:MM times after baseTime, in
+// baseTime's location.
+func nextDailyRun(dailyTime string, baseTime time.Time) (time.Time, error) {
+	if dailyTime == "" {
+		return time.Time{}, fmt.Errorf("daily_time is empty")
+	}
 
-			// If already passed today, scheduled for tomorrow
-			if !candidate.After(baseTime) {
-				candidate = candidate.AddDate(0, 0, 1)
-			}
-			candidates = append(candidates, candidate)
+	var candidates []time.Time
+	for _, p := range strings.Split(dailyTime, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
 		}
 
-		if len(candidates) == 0 {
-			log.Errorf("No valid time points for task %s", task.ID)
-			task.Status = TaskStatusPaused
-			shouldPersist = true
-			return
+		parsedTime, err := time.Parse("15:04", p)
+		if err != nil {
+			log.Errorf("Invalid time format %s", p)
+			continue
 		}
 
-		// Sort and pick the earliest one
-		sort.Slice(candidates, func(i, j int) bool {
-			return candidates[i].Before(candidates[j])
-		})
-		next = candidates[0]
-		task.NextRunAt = &next
-		shouldPersist = true
+		// Create candidate for today
+		candidate := time.Date(baseTime.Year(), baseTime.Month(), baseTime.Day(),
+			parsedTime.Hour(), parsedTime.Minute(), 0, 0, baseTime.Location())
 
-	default:
-		log.Warnf("Unknown task type %s for task %s, pausing", task.Type, task.ID)
-		task.Status = TaskStatusPaused
-		task.NextRunAt = nil
-		shouldPersist = true
-		return
+		// If already passed today, scheduled for tomorrow
+		if !candidate.After(baseTime) {
+			candidate = time.Date(baseTime.Year(), baseTime.Month(), baseTime.Day()+1,
+				parsedTime.Hour(), parsedTime.Minute(), 0, 0, baseTime.Location())
+		}
+		candidates = append(candidates, candidate)
 	}
+
+	if len(candidates) == 0 {
+		return time.Time{}, fmt.Errorf("no valid time points in %q", dailyTime)
+	}
+
+	// Sort and pick the earliest one
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+	return candidates[0], nil
 }
 
 func (e *Engine) executeTask(task *Task) {
 	defer e.runningTasks.Delete(task.ID)
-	// Double check status inside goroutine?
-	// e.mu.Lock()... no, task passed by ref might change, but fine for now.
+	e.runTask(task)
+	// Persist changes is handled by updateNextRun which saves the full task state (including LastRunAt updates)
+	e.updateNextRun(task, time.Now(), true) // This will acquire its own lock and persist the next run time
+}
 
-	log.Infof("Executing task: %s (%s)", task.Name, task.ID)
-	start := time.Now()
+// executeMissedRuns runs a task once per missed run, in order, then schedules the next run.
+func (e *Engine) executeMissedRuns(task *Task, runs int) {
+	defer e.runningTasks.Delete(task.ID)
+	for i := 0; i < runs; i++ {
+		select {
+		case <-e.stopChan:
+			return
+		default:
+		}
+		e.runTask(task)
+	}
+	e.updateNextRun(task, time.Now(), true)
+}
 
-	// Call Executor
-	output, err := e.executor.Execute(context.Background(), task)
-	duration := time.Since(start)
+// maxLoggedBodyBytes caps the request and response bodies kept in each execution log. The
+// file backend rewrites every retained log on save, so the bodies stay small.
+const maxLoggedBodyBytes = 4 << 10
 
-	success := err == nil
-	outputStr := output
-	if err != nil {
-		outputStr = fmt.Sprintf("Error: %v", err)
+// truncateLogged cuts body to maxLoggedBodyBytes without splitting a UTF-8 sequence.
+func truncateLogged(body string) string {
+	if len(body) <= maxLoggedBodyBytes {
+		return body
 	}
+	cut := maxLoggedBodyBytes
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return body[:cut] + "...(truncated)"
+}
+
+// runTask runs a task, retrying failed attempts per its retry policy, and records every attempt.
+// It does not schedule the next run.
+func (e *Engine) runTask(task *Task) {
+	policy := retryPolicyFor(task)
+	runID := uuid.New().String()
+
+	for attempt := 1; ; attempt++ {
+		log.Infof("Executing task: %s (%s), attempt %d/%d", task.Name, task.ID, attempt, policy.attempts)
+		start := time.Now()
+
+		// Call Executor
+		ctx, cancel := context.WithTimeout(context.Background(), policy.timeout)
+		result, err := e.executor.Execute(ctx, task)
+		cancel()
+		duration := time.Since(start)
 
-	// Log result
-	entry := &ExecutionLog{
-		ID:         uuid.New().String(),
-		TaskID:     task.ID,
-		TaskName:   task.Name,
-		ExecutedAt: start,
-		DurationMs: duration.Milliseconds(),
-		Success:    success,
-		Output:     outputStr,
+		outputStr := result.Output
+		if err != nil {
+			outputStr = fmt.Sprintf("Error: %v", err)
+		}
+		entry := &ExecutionLog{
+			ID:         uuid.New().String(),
+			RunID:      runID,
+			Attempt:    attempt,
+			WillRetry:  err != nil && attempt < policy.attempts,
+			TaskID:     task.ID,
+			TaskName:   task.Name,
+			ExecutedAt: start,
+			DurationMs: duration.Milliseconds(),
+			Success:    err == nil,
+			Output:     outputStr,
+			Probes:     result.Probes,
+			Request:    truncateLogged(result.Request),
+			Response:   truncateLogged(result.Response),
+		}
+		if !entry.WillRetry {
+			e.finishRun(task, entry, err, attempt, policy)
+			return
+		}
+		_ = e.store.AddLog(entry)
+
+		delay := policy.delay(attempt)
+		log.Warnf("Task %s attempt %d failed: %v; retrying in %s", task.ID, attempt, err, delay)
+		if !e.sleep(delay) {
+			// Shutting down: record the run as failed without further attempts.
+			e.finishRun(task, nil, err, attempt, policy)
+			return
+		}
 	}
-	_ = e.store.AddLog(entry)
+}
 
+// finishRun updates the task after its final attempt, pausing it when configured, notifies the
+// task's channels and stores the final attempt's log entry (if any).
+func (e *Engine) finishRun(task *Task, entry *ExecutionLog, runErr error, attempts int, policy retryPolicy) {
 	// Update Task State
 	task.Lock()
 	now := time.Now()
 	task.LastRunAt = &now
-	if !success {
+	if runErr != nil {
 		task.FailureCount++
 	} else {
 		task.FailureCount = 0
 	}
+	failures := task.FailureCount
+	paused := false
+	if runErr != nil && policy.pauseAfter > 0 && failures >= policy.pauseAfter && task.Status == TaskStatusActive {
+		task.Status = TaskStatusPaused
+		paused = true
+	}
 
 	// Special handling for FixedTime: finish it
 	if task.Type == TaskTypeFixedTime {
 		task.Status = TaskStatusFinished
 		task.NextRunAt = nil
 	}
+	n := Notification{
+		Event:               NotifyOnSuccess,
+		TaskID:              task.ID,
+		TaskName:            task.Name,
+		TaskType:            task.Type,
+		Model:               task.Model,
+		Success:             runErr == nil,
+		Attempts:            attempts,
+		ConsecutiveFailures: failures,
+		Paused:              paused,
+		ExecutedAt:          now,
+		Metadata:            task.Metadata,
+	}
 	task.Unlock()
 
-	// Persist changes is handled by updateNextRun which saves the full task state (including LastRunAt updates)
-	e.updateNextRun(task, now, true) // This will acquire its own lock and persist the next run time
+	if paused {
+		log.Warnf("Task %s paused after %d consecutive failures", task.ID, failures)
+	}
+	if runErr != nil {
+		n.Event, n.Error = NotifyOnFailure, runErr.Error()
+	}
+	if entry != nil {
+		n.ExecutedAt, n.DurationMs = entry.ExecutedAt, entry.DurationMs
+		if runErr == nil {
+			n.Output = entry.Output
+		}
+	}
+	deliveries := e.notify(taskChannels(task), n)
+	if entry != nil {
+		entry.Notifications = deliveries
+		for _, d := range deliveries {
+			if d.Status != 0 {
+				entry.WebhookStatus = d.Status
+				break
+			}
+		}
+		_ = e.store.AddLog(entry)
+	}
 }
 
 // RunTaskNow manually triggers a task execution asynchronously.
@@ -3,8 +3,10 @@ package scheduler
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,8 +14,8 @@ import (
 // NoOpExecutor
 type NoOpExecutor struct{}
 
-func (e *NoOpExecutor) Execute(ctx context.Context, task *Task) (string, error) {
-	return "done", nil
+func (e *NoOpExecutor) Execute(ctx context.Context, task *Task) (ExecutionResult, error) {
+	return ExecutionResult{Output: "done"}, nil
 }
 
 func TestEngine_UpdateNextRun_AppliesForcePersist(t *testing.T) {
@@ -110,3 +112,72 @@ func TestEngine_UpdateNextRun_ForcePersist_UpdatesLastRun(t *testing.T) {
 	// Time comparison might have small location/monotonic diffs, compare Unix() or String()
 	assert.Equal(t, lastRun.Unix(), loaded.LastRunAt.Unix())
 }
+
+type countingExecutor struct {
+	runs chan string
+}
+
+func (e *countingExecutor) Execute(ctx context.Context, task *Task) (ExecutionResult, error) {
+	e.runs <- task.ID
+	return ExecutionResult{Output: "done"}, nil
+}
+
+func TestEngine_CatchUpPolicies(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := &countingExecutor{runs: make(chan string, 100)}
+	engine := NewEngine(store, exec)
+
+	now := time.Now()
+	missed := now.Add(-3*time.Hour - 30*time.Minute)
+	for _, task := range []*Task{
+		{ID: "skip", Type: TaskTypeInterval, Interval: "1h", Status: TaskStatusActive, NextRunAt: &missed},
+		{ID: "once", Type: TaskTypeInterval, Interval: "1h", Status: TaskStatusActive, NextRunAt: &missed, CatchUp: CatchUpOnce},
+		{ID: "all", Type: TaskTypeInterval, Interval: "1h", Status: TaskStatusActive, NextRunAt: &missed, CatchUp: CatchUpAll},
+	} {
+		assert.NoError(t, store.AddTask(task))
+	}
+
+	engine.catchUp(now)
+
+	counts := map[string]int{}
+	deadline := time.After(2 * time.Second)
+	for counts["once"]+counts["all"] < 5 {
+		select {
+		case id := <-exec.runs:
+			counts[id]++
+		case <-deadline:
+			t.Fatalf("timed out waiting for catch-up runs, got %v", counts)
+		}
+	}
+	assert.Equal(t, 0, counts["skip"])
+	assert.Equal(t, 1, counts["once"])
+	assert.Equal(t, 4, counts["all"]) // missed at -3.5h, -2.5h, -1.5h and -0.5h
+
+	// Let the catch-up goroutines persist their next run before the store directory is removed.
+	for _, id := range []string{"once", "all"} {
+		for {
+			if _, running := engine.runningTasks.Load(id); !running {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	skipped, _ := store.GetTask("skip")
+	skipped.RLock()
+	defer skipped.RUnlock()
+	assert.True(t, skipped.NextRunAt.After(now))
+}
+
+func TestTruncateLogged_KeepsWholeRunes(t *testing.T) {
+	assert.Equal(t, "short", truncateLogged("short"))
+
+	body := "a" + strings.Repeat("é", maxLoggedBodyBytes)
+	got := truncateLogged(body)
+	assert.True(t, utf8.ValidString(got))
+	assert.True(t, strings.HasSuffix(got, "...(truncated)"))
+	assert.LessOrEqual(t, len(got), maxLoggedBodyBytes+len("...(truncated)"))
+}
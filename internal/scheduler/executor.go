@@ -3,14 +3,11 @@ package scheduler
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
 type LoopbackExecutor struct {
@@ -18,6 +15,7 @@ type LoopbackExecutor struct {
 	AuthToken     string // Optional: Bearer token if needed
 	LocalPassword string // Optional: X-Local-Password
 	store         *Store
+	credentials   CredentialManager
 }
 
 func NewLoopbackExecutor(baseURL, localPwd string, store *Store) *LoopbackExecutor {
@@ -28,36 +26,39 @@ func NewLoopbackExecutor(baseURL, localPwd string, store *Store) *LoopbackExecut
 	}
 }
 
-func (e *LoopbackExecutor) Execute(ctx context.Context, task *Task) (string, error) {
+// SetCredentialManager enables the credential_probe and credential_report task types.
+func (e *LoopbackExecutor) SetCredentialManager(m CredentialManager) {
+	e.credentials = m
+}
+
+// Execute renders the task into a request for its API surface, sends it to the local proxy and
+// returns the extracted answer together with the request and raw response.
+func (e *LoopbackExecutor) Execute(ctx context.Context, task *Task) (ExecutionResult, error) {
 	task.RLock()
 	taskType := task.Type
-	taskModel := task.Model
-	taskPrompt := task.Prompt
-	taskWebhookURL := task.WebhookURL
 	task.RUnlock()
 
-	if taskType == TaskTypeSystemReport {
-		return e.generateSystemReport(task)
-	}
-
-	// Construct generic chat completion request
-	// Assuming OpenAI compatible format for simplicity as it covers most
-	requestBody := map[string]interface{}{
-		"model": taskModel,
-		"messages": []map[string]string{
-			{"role": "user", "content": taskPrompt},
-		},
+	switch taskType {
+	case TaskTypeSystemReport:
+		report, err := e.generateSystemReport(task)
+		return ExecutionResult{Output: report}, err
+	case TaskTypeCredentialProbe:
+		return e.probeCredentials(ctx, task)
+	case TaskTypeCredentialReport:
+		return e.credentialReport(task)
 	}
 
-	bodyBytes, err := json.Marshal(requestBody)
+	spec := snapshotTaskRequest(task)
+	path, bodyBytes, err := spec.build(e.templateData(spec))
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return ExecutionResult{}, fmt.Errorf("failed to render request: %w", err)
 	}
+	result := ExecutionResult{Request: string(bodyBytes)}
 
-	url := fmt.Sprintf("%s/v1/chat/completions", e.BaseURL)
+	url := e.BaseURL + path
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(bodyBytes))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return result, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -68,10 +69,10 @@ func (e *LoopbackExecutor) Execute(ctx context.Context, task *Task) (string, err
 	// Add specific User-Agent to identify scheduler traffic
 	req.Header.Set("User-Agent", "CLIProxyAPI-Scheduler/1.0")
 
-	client := &http.Client{Timeout: 5 * time.Minute} // Long timeout for AI
+	client := &http.Client{} // The engine bounds each attempt with the task's timeout
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http request failed: %w", err)
+		return result, fmt.Errorf("http request failed: %w", err)
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -79,35 +80,39 @@ func (e *LoopbackExecutor) Execute(ctx context.Context, task *Task) (string, err
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return result, fmt.Errorf("failed to read response body: %w", err)
 	}
+	result.Response = string(respBody)
 
 	if resp.StatusCode >= 400 {
-		return string(respBody), fmt.Errorf("api returned error status: %d", resp.StatusCode)
-	}
-
-	// Parse response to extract just the content
-	var result map[string]interface{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return string(respBody), nil // Return raw body if parse fails
+		result.Output = string(respBody)
+		return result, fmt.Errorf("api returned error status: %d", resp.StatusCode)
 	}
 
-	// Try to extract content from standard OpenAI format
-	if choices, ok := result["choices"].([]interface{}); ok && len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]interface{}); ok {
-			if message, ok := choice["message"].(map[string]interface{}); ok {
-				if content, ok := message["content"].(string); ok {
-					// Trigger Webhook if configured
-					if taskWebhookURL != "" {
-						go e.triggerWebhook(task, content)
-					}
-					return content, nil
-				}
-			}
-		}
+	content, ok := extractOutput(spec.api, respBody)
+	if !ok {
+		result.Output = string(respBody) // Return raw body if nothing can be extracted
+		return result, nil
 	}
+	result.Output = content
+	return result, nil
+}
 
-	return string(respBody), nil
+// templateData collects the variables available to a task's templates at run time.
+func (e *LoopbackExecutor) templateData(spec taskRequest) TemplateData {
+	now := time.Now().In(spec.location)
+	data := TemplateData{
+		Now:      now,
+		Date:     now.Format("2006-01-02"),
+		Time:     now.Format("15:04"),
+		Weekday:  now.Weekday().String(),
+		Task:     TemplateTask{ID: spec.id, Name: spec.name, Model: spec.model},
+		Metadata: spec.metadata,
+	}
+	if e.store != nil {
+		data.PreviousOutput = e.store.LastOutput(spec.id)
+	}
+	return data
 }
 
 func (e *LoopbackExecutor) generateSystemReport(task *Task) (string, error) {
@@ -116,7 +121,6 @@ func (e *LoopbackExecutor) generateSystemReport(task *Task) (string, error) {
 	}
 
 	tasks := e.store.GetTasks()
-	logs := e.store.GetLogs()
 
 	totalTasks := len(tasks)
 	activeTasks := 0
@@ -134,12 +138,10 @@ func (e *LoopbackExecutor) generateSystemReport(task *Task) (string, error) {
 	recentLogs := 0
 	recentFailures := 0
 
-	for _, l := range logs {
-		if l.ExecutedAt.After(oneDayAgo) {
-			recentLogs++
-			if !l.Success {
-				recentFailures++
-			}
+	for _, l := range e.store.QueryLogs(LogFilter{Since: oneDayAgo}) {
+		recentLogs++
+		if !l.Success {
+			recentFailures++
 		}
 	}
 
@@ -159,96 +161,5 @@ func (e *LoopbackExecutor) generateSystemReport(task *Task) (string, error) {
 		totalTasks, activeTasks, recentLogs, recentFailures, successRate, now.Format(time.RFC3339),
 	)
 
-	if task.WebhookURL != "" {
-		go e.triggerWebhook(task, report)
-	}
-
 	return report, nil
 }
-
-func (e *LoopbackExecutor) triggerWebhook(task *Task, content string) {
-	var payload interface{}
-
-	// Detect WeCom Webhook
-	if strings.Contains(task.WebhookURL, "qyapi.weixin.qq.com") {
-		// Use Template Card for WeCom
-		// Format: https://developer.work.weixin.qq.com/document/path/91770
-
-		// Truncate content for desc if too long
-		desc := content
-		if len(desc) > 100 {
-			desc = desc[:97] + "..."
-		}
-
-		payload = map[string]interface{}{
-			"msgtype": "template_card",
-			"template_card": map[string]interface{}{
-				"card_type": "text_notice",
-				"source": map[string]string{
-					"icon_url": "https://wework.qpic.cn/wwpic/252813_jOfDHtcISzuay14_1628280241/0",
-					"desc":     desc,
-				},
-				"main_title": map[string]string{
-					"title": fmt.Sprintf("Task: %s", task.Name),
-					"desc":  "Task Execution Result",
-				},
-				"emphasis_content": map[string]string{
-					"title": "Success",
-					"desc":  "Status",
-				},
-				"sub_title_text": fmt.Sprintf("Executed at: %s", time.Now().Format("2006-01-02 15:04:05")),
-				"horizontal_content_list": []map[string]string{
-					{
-						"keyname": "Task Type",
-						"value":   string(task.Type),
-					},
-					{
-						"keyname": "Model",
-						"value":   task.Model,
-					},
-				},
-				"card_action": map[string]string{
-					"type": "url",
-					"url":  "http://localhost:21301/management.html", // Ideally configurable
-				},
-				// Use quote_area for the main content
-				"quote_area": map[string]string{
-					"type":       "text",
-					"quote_text": content,
-				},
-			},
-		}
-
-		// Adjust for System Report
-		if task.Type == TaskTypeSystemReport {
-			// Parse simple key-values from markdown report for visual presentation?
-			// For now, just put the report in quote_text
-			payload.(map[string]interface{})["template_card"].(map[string]interface{})["main_title"].(map[string]string)["title"] = "System Report"
-		}
-
-	} else {
-		// Default JSON payload
-		payload = map[string]interface{}{
-			"task_id":     task.ID,
-			"task_name":   task.Name,
-			"executed_at": time.Now(),
-			"content":     content,
-		}
-	}
-
-	data, _ := json.Marshal(payload)
-	resp, err := http.Post(task.WebhookURL, "application/json", bytes.NewBuffer(data))
-	if err != nil {
-		log.Warnf("Webhook failed for task %s: %v", task.ID, err)
-		return
-	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	// Read response for debugging if needed
-	// body, _ := io.ReadAll(resp.Body)
-	// log.Infof("Webhook response: %s", string(body))
-
-	log.Infof("Webhook dispatched for task %s, status: %d", task.ID, resp.StatusCode)
-}
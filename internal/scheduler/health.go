@@ -0,0 +1,386 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cliproxy/internal/registry"
+	"cliproxy/internal/usage"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	sdktranslator "cliproxy/sdk/translator"
+)
+
+const (
+	probeConcurrency    = 4
+	probeTimeout        = 30 * time.Second
+	defaultReportWindow = 24 * time.Hour
+)
+
+// CredentialFilter selects the credentials a credential_probe or credential_report task covers.
+// Empty fields match every credential.
+type CredentialFilter struct {
+	Providers []string `json:"providers,omitempty"`
+	Tags      []string `json:"tags,omitempty"` // Credentials must carry every tag
+}
+
+// CredentialManager is the part of the auth manager used by credential health tasks.
+type CredentialManager interface {
+	List() []*coreauth.Auth
+	ExecuteWithAuth(ctx context.Context, authID string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// ProbeResult is the outcome of probing one credential.
+type ProbeResult struct {
+	AuthID    string `json:"auth_id"`
+	AuthIndex string `json:"auth_index"`
+	Label     string `json:"label,omitempty"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latency_ms"`
+	Status    int    `json:"status,omitempty"` // Upstream HTTP status on failure, if known
+	Error     string `json:"error,omitempty"`
+}
+
+// CredentialUsage summarises one credential's traffic over a report window together with its
+// current availability.
+type CredentialUsage struct {
+	AuthID         string  `json:"auth_id"`
+	AuthIndex      string  `json:"auth_index"`
+	Label          string  `json:"label,omitempty"`
+	Provider       string  `json:"provider"`
+	Status         string  `json:"status"`
+	Disabled       bool    `json:"disabled,omitempty"`
+	CoolingModels  int     `json:"cooling_models,omitempty"` // Models currently blocked for retries
+	QuotaExceeded  bool    `json:"quota_exceeded,omitempty"`
+	Requests       int64   `json:"requests"`
+	Failures       int64   `json:"failures"`
+	SuccessRate    float64 `json:"success_rate"` // Percent, 100 when there was no traffic
+	InputTokens    int64   `json:"input_tokens"`
+	OutputTokens   int64   `json:"output_tokens"`
+	TotalTokens    int64   `json:"total_tokens"`
+	LastError      string  `json:"last_error,omitempty"`
+	AvgLatencyMs   int64   `json:"avg_latency_ms,omitempty"`
+	latencyTotal   int64
+	latencySamples int64
+}
+
+// isCredentialTask reports whether a task type runs against credentials rather than a prompt.
+func isCredentialTask(t TaskType) bool {
+	return t == TaskTypeCredentialProbe || t == TaskTypeCredentialReport
+}
+
+// selectCredentials returns the enabled credentials matching the filter, ordered by provider and ID.
+func selectCredentials(auths []*coreauth.Auth, filter *CredentialFilter) []*coreauth.Auth {
+	var providers, tags []string
+	if filter != nil {
+		for _, p := range filter.Providers {
+			providers = append(providers, strings.ToLower(strings.TrimSpace(p)))
+		}
+		tags = coreauth.NormalizeTags(filter.Tags)
+	}
+	var out []*coreauth.Auth
+	for _, a := range auths {
+		if a == nil || a.Disabled {
+			continue
+		}
+		if len(providers) > 0 && !containsString(providers, strings.ToLower(a.Provider)) {
+			continue
+		}
+		if !hasAllTags(a.Tags(), tags) {
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, tag := range want {
+		if !containsString(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// probeModel picks the model used to probe a credential: the task's model when the credential
+// serves it (or when nothing is registered for it), otherwise its first registered model.
+func probeModel(auth *coreauth.Auth, preferred string) string {
+	models := registry.GetGlobalRegistry().GetModelsForClient(auth.ID)
+	if preferred != "" {
+		if len(models) == 0 {
+			return preferred
+		}
+		for _, m := range models {
+			if m != nil && m.ID == preferred {
+				return preferred
+			}
+		}
+	}
+	for _, m := range models {
+		if m != nil && m.ID != "" {
+			return m.ID
+		}
+	}
+	return preferred
+}
+
+// probeCredentials sends a minimal one-token chat request through each selected credential.
+// Results are recorded by the auth manager, so failing credentials are cooled down before real
+// traffic reaches them. The run fails when any credential fails.
+func (e *LoopbackExecutor) probeCredentials(ctx context.Context, task *Task) (ExecutionResult, error) {
+	if e.credentials == nil {
+		return ExecutionResult{}, fmt.Errorf("credential manager not configured")
+	}
+	task.RLock()
+	filter, preferred := task.Credentials, task.Model
+	task.RUnlock()
+
+	auths := selectCredentials(e.credentials.List(), filter)
+	if len(auths) == 0 {
+		return ExecutionResult{Output: "No credentials matched the filter."}, nil
+	}
+
+	results := make([]ProbeResult, len(auths))
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for i, auth := range auths {
+		wg.Add(1)
+		go func(i int, auth *coreauth.Auth) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = e.probeCredential(ctx, auth, probeModel(auth, preferred))
+		}(i, auth)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, r := range results {
+		if !r.Success {
+			failed = append(failed, fmt.Sprintf("%s (%s)", credentialName(r.Label, r.AuthID), r.Error))
+		}
+	}
+	result := ExecutionResult{Output: formatProbeResults(results), Probes: results}
+	if data, err := json.Marshal(results); err == nil {
+		result.Response = string(data)
+	}
+	if len(failed) > 0 {
+		return result, fmt.Errorf("%d of %d credentials failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return result, nil
+}
+
+func (e *LoopbackExecutor) probeCredential(ctx context.Context, auth *coreauth.Auth, model string) ProbeResult {
+	r := ProbeResult{AuthID: auth.ID, AuthIndex: auth.EnsureIndex(), Label: auth.Label, Provider: auth.Provider, Model: model}
+	if model == "" {
+		r.Error = "no model registered for credential"
+		return r
+	}
+	payload, _ := json.Marshal(map[string]any{
+		"model":      model,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+		"max_tokens": 1,
+		"stream":     false,
+	})
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := e.credentials.ExecuteWithAuth(probeCtx, auth.ID,
+		cliproxyexecutor.Request{Model: model, Payload: payload},
+		cliproxyexecutor.Options{OriginalRequest: payload, SourceFormat: sdktranslator.FromString("openai")},
+	)
+	r.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		r.Error = truncateRunes(err.Error(), 300)
+		var se cliproxyexecutor.StatusError
+		if errors.As(err, &se) && se != nil {
+			r.Status = se.StatusCode()
+		}
+		return r
+	}
+	r.Success = true
+	return r
+}
+
+func credentialName(label, id string) string {
+	if label != "" {
+		return label
+	}
+	return id
+}
+
+func formatProbeResults(results []ProbeResult) string {
+	healthy := 0
+	var b strings.Builder
+	for _, r := range results {
+		if r.Success {
+			healthy++
+		}
+	}
+	fmt.Fprintf(&b, "**Credential Health**: %d/%d healthy\n\n", healthy, len(results))
+	b.WriteString("| Credential | Provider | Model | Result | Latency |\n|---|---|---|---|---|\n")
+	for _, r := range results {
+		outcome := "ok"
+		if !r.Success {
+			outcome = "FAIL: " + strings.ReplaceAll(truncateRunes(r.Error, 120), "|", "/")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %dms |\n", credentialName(r.Label, r.AuthID), r.Provider, r.Model, outcome, r.LatencyMs)
+	}
+	return b.String()
+}
+
+// credentialReport summarises per-credential success rate and token use from the request
+// statistics over the task's report window, together with each credential's current state.
+func (e *LoopbackExecutor) credentialReport(task *Task) (ExecutionResult, error) {
+	if e.credentials == nil {
+		return ExecutionResult{}, fmt.Errorf("credential manager not configured")
+	}
+	task.RLock()
+	filter, windowStr := task.Credentials, task.ReportWindow
+	task.RUnlock()
+	window := defaultReportWindow
+	if d, err := time.ParseDuration(windowStr); err == nil && d > 0 {
+		window = d
+	}
+
+	now := time.Now()
+	rows := buildCredentialUsage(selectCredentials(e.credentials.List(), filter), usage.GetRequestStatistics().Snapshot(), now.Add(-window), now)
+	result := ExecutionResult{Output: formatCredentialUsage(rows, window, now)}
+	if data, err := json.Marshal(rows); err == nil {
+		result.Response = string(data)
+	}
+	return result, nil
+}
+
+func buildCredentialUsage(auths []*coreauth.Auth, snapshot usage.StatisticsSnapshot, since, now time.Time) []*CredentialUsage {
+	rows := make([]*CredentialUsage, 0, len(auths))
+	byIndex := make(map[string]*CredentialUsage, len(auths))
+	for _, a := range auths {
+		row := &CredentialUsage{
+			AuthID:    a.ID,
+			AuthIndex: a.EnsureIndex(),
+			Label:     a.Label,
+			Provider:  a.Provider,
+			Status:    string(a.Status),
+			Disabled:  a.Disabled,
+		}
+		for _, state := range a.ModelStates {
+			if state == nil {
+				continue
+			}
+			if state.Unavailable && state.NextRetryAfter.After(now) {
+				row.CoolingModels++
+			}
+			if state.Quota.Exceeded && state.Quota.NextRecoverAt.After(now) {
+				row.QuotaExceeded = true
+			}
+		}
+		if a.Quota.Exceeded && a.Quota.NextRecoverAt.After(now) {
+			row.QuotaExceeded = true
+		}
+		if a.LastError != nil {
+			row.LastError = a.LastError.Message
+		}
+		rows = append(rows, row)
+		byIndex[row.AuthIndex] = row
+	}
+
+	for _, api := range snapshot.APIs {
+		for _, model := range api.Models {
+			for _, d := range model.Details {
+				row, ok := byIndex[d.AuthIndex]
+				if !ok || d.Timestamp.Before(since) {
+					continue
+				}
+				row.Requests++
+				if d.Failed {
+					row.Failures++
+				}
+				row.InputTokens += d.Tokens.InputTokens
+				row.OutputTokens += d.Tokens.OutputTokens
+				row.TotalTokens += d.Tokens.TotalTokens
+				if d.LatencyMs > 0 {
+					row.latencyTotal += d.LatencyMs
+					row.latencySamples++
+				}
+			}
+		}
+	}
+	for _, row := range rows {
+		row.SuccessRate = 100
+		if row.Requests > 0 {
+			row.SuccessRate = float64(row.Requests-row.Failures) / float64(row.Requests) * 100
+		}
+		if row.latencySamples > 0 {
+			row.AvgLatencyMs = row.latencyTotal / row.latencySamples
+		}
+	}
+	return rows
+}
+
+func formatCredentialUsage(rows []*CredentialUsage, window time.Duration, now time.Time) string {
+	var b strings.Builder
+	var requests, failures, tokens int64
+	for _, r := range rows {
+		requests += r.Requests
+		failures += r.Failures
+		tokens += r.TotalTokens
+	}
+	fmt.Fprintf(&b, "**Credential Report** (last %s)\n\n", window)
+	fmt.Fprintf(&b, "- Credentials: %d\n- Requests: %d\n- Failures: %d\n- Tokens: %d\n- Generated At: %s\n\n",
+		len(rows), requests, failures, tokens, now.Format(time.RFC3339))
+	b.WriteString("| Credential | Provider | State | Requests | Success | Tokens (in/out) |\n|---|---|---|---|---|---|\n")
+	for _, r := range rows {
+		state := r.Status
+		switch {
+		case r.QuotaExceeded:
+			state += ", quota exceeded"
+		case r.CoolingModels > 0:
+			state += fmt.Sprintf(", %d model(s) cooling down", r.CoolingModels)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %.1f%% | %d (%d/%d) |\n",
+			credentialName(r.Label, r.AuthID), r.Provider, state, r.Requests, r.SuccessRate, r.TotalTokens, r.InputTokens, r.OutputTokens)
+	}
+	return b.String()
+}
+
+// ValidateCredentialTask checks the schedule and report window of a credential task.
+func ValidateCredentialTask(task *Task) error {
+	if task.Interval == "" && task.Cron == "" && task.DailyTime == "" {
+		return fmt.Errorf("interval, cron or daily_time is required for %s tasks", task.Type)
+	}
+	if _, err := nextOccurrence(task, time.Now()); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if task.ReportWindow != "" {
+		if d, err := time.ParseDuration(task.ReportWindow); err != nil || d <= 0 {
+			return fmt.Errorf("invalid report_window %q", task.ReportWindow)
+		}
+	}
+	return nil
+}
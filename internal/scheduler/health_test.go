@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cliproxy/internal/usage"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredentials struct {
+	mu     sync.Mutex
+	auths  []*coreauth.Auth
+	fail   map[string]error
+	probed map[string]string // auth ID -> model
+}
+
+func (f *fakeCredentials) List() []*coreauth.Auth { return f.auths }
+
+func (f *fakeCredentials) ExecuteWithAuth(_ context.Context, authID string, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.probed == nil {
+		f.probed = map[string]string{}
+	}
+	f.probed[authID] = req.Model
+	if err := f.fail[authID]; err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{}`)}, nil
+}
+
+func testAuths() []*coreauth.Auth {
+	return []*coreauth.Auth{
+		{ID: "g1", Provider: "gemini", Label: "gemini-team", Attributes: map[string]string{"tags": "team-a"}},
+		{ID: "c1", Provider: "claude", Attributes: map[string]string{"tags": "team-a,prod"}},
+		{ID: "c2", Provider: "claude", Disabled: true},
+		{ID: "c3", Provider: "claude"},
+	}
+}
+
+func TestSelectCredentials(t *testing.T) {
+	ids := func(auths []*coreauth.Auth) []string {
+		var out []string
+		for _, a := range auths {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"c1", "c3", "g1"}, ids(selectCredentials(testAuths(), nil)))
+	assert.Equal(t, []string{"c1", "c3"}, ids(selectCredentials(testAuths(), &CredentialFilter{Providers: []string{"Claude"}})))
+	assert.Equal(t, []string{"c1", "g1"}, ids(selectCredentials(testAuths(), &CredentialFilter{Tags: []string{"team-a"}})))
+	assert.Equal(t, []string{"c1"}, ids(selectCredentials(testAuths(), &CredentialFilter{Tags: []string{"team-a", "prod"}})))
+}
+
+func TestLoopbackExecutor_ProbeCredentials(t *testing.T) {
+	creds := &fakeCredentials{auths: testAuths(), fail: map[string]error{"c3": errors.New("401 unauthorized")}}
+	executor := NewLoopbackExecutor("http://127.0.0.1:0", "", nil)
+	executor.SetCredentialManager(creds)
+
+	task := &Task{ID: "probe", Type: TaskTypeCredentialProbe, Model: "probe-model", Credentials: &CredentialFilter{Providers: []string{"claude"}}}
+	result, err := executor.Execute(context.Background(), task)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 2 credentials failed")
+	assert.Contains(t, err.Error(), "c3 (401 unauthorized)")
+
+	require.Len(t, result.Probes, 2)
+	assert.True(t, result.Probes[0].Success)
+	assert.Equal(t, "c1", result.Probes[0].AuthID)
+	assert.False(t, result.Probes[1].Success)
+	assert.Equal(t, "401 unauthorized", result.Probes[1].Error)
+	assert.Equal(t, map[string]string{"c1": "probe-model", "c3": "probe-model"}, creds.probed)
+	assert.Contains(t, result.Output, "1/2 healthy")
+}
+
+func TestEngine_RecordsProbesInLog(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	executor := NewLoopbackExecutor("http://127.0.0.1:0", "", store)
+	executor.SetCredentialManager(&fakeCredentials{auths: testAuths()})
+	engine := NewEngine(store, executor)
+
+	task := &Task{ID: "probe", Name: "Probe", Type: TaskTypeCredentialProbe, Interval: "10m", Model: "m", Status: TaskStatusActive}
+	require.NoError(t, store.AddTask(task))
+	engine.runTask(task)
+
+	logs := store.GetLogs()
+	require.Len(t, logs, 1)
+	assert.True(t, logs[0].Success)
+	assert.Len(t, logs[0].Probes, 3)
+}
+
+func TestBuildCredentialUsage(t *testing.T) {
+	now := time.Now()
+	auths := selectCredentials(testAuths(), &CredentialFilter{Providers: []string{"claude"}})
+	auths[1].ModelStates = map[string]*coreauth.ModelState{
+		"m": {Unavailable: true, NextRetryAfter: now.Add(time.Minute), Quota: coreauth.QuotaState{Exceeded: true, NextRecoverAt: now.Add(time.Minute)}},
+	}
+	c1, c3 := auths[0].EnsureIndex(), auths[1].EnsureIndex()
+
+	snapshot := usage.StatisticsSnapshot{APIs: map[string]usage.APISnapshot{
+		"key": {Models: map[string]usage.ModelSnapshot{
+			"m": {Details: []usage.RequestDetail{
+				{Timestamp: now.Add(-time.Hour), AuthIndex: c1, Tokens: usage.TokenStats{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, LatencyMs: 200},
+				{Timestamp: now.Add(-time.Hour), AuthIndex: c1, Failed: true, LatencyMs: 400},
+				{Timestamp: now.Add(-48 * time.Hour), AuthIndex: c1, Tokens: usage.TokenStats{TotalTokens: 1000}},
+				{Timestamp: now.Add(-time.Minute), AuthIndex: c3, Failed: true},
+			}},
+		}},
+	}}
+
+	rows := buildCredentialUsage(auths, snapshot, now.Add(-24*time.Hour), now)
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(2), rows[0].Requests)
+	assert.Equal(t, int64(1), rows[0].Failures)
+	assert.Equal(t, 50.0, rows[0].SuccessRate)
+	assert.Equal(t, int64(15), rows[0].TotalTokens)
+	assert.Equal(t, int64(300), rows[0].AvgLatencyMs)
+	assert.Equal(t, 0.0, rows[1].SuccessRate)
+	assert.Equal(t, 1, rows[1].CoolingModels)
+	assert.True(t, rows[1].QuotaExceeded)
+
+	report := formatCredentialUsage(rows, 24*time.Hour, now)
+	assert.Contains(t, report, "| c1 | claude |")
+	assert.Contains(t, report, "quota exceeded")
+}
+
+func TestValidateCredentialTask(t *testing.T) {
+	assert.NoError(t, ValidateCredentialTask(&Task{Type: TaskTypeCredentialProbe, Interval: "15m"}))
+	assert.NoError(t, ValidateCredentialTask(&Task{Type: TaskTypeCredentialReport, DailyTime: "09:00", ReportWindow: "168h"}))
+	assert.Error(t, ValidateCredentialTask(&Task{Type: TaskTypeCredentialProbe}))
+	assert.Error(t, ValidateCredentialTask(&Task{Type: TaskTypeCredentialProbe, Cron: "bad"}))
+	assert.Error(t, ValidateCredentialTask(&Task{Type: TaskTypeCredentialReport, Interval: "1h", ReportWindow: "week"}))
+}
@@ -0,0 +1,397 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+func init() {
+	RegisterNotifier(NotifierWebhook, webhookNotifier{})
+	RegisterNotifier(NotifierWeCom, weComNotifier{})
+	RegisterNotifier(NotifierSlack, slackNotifier{})
+	RegisterNotifier(NotifierFeishu, feishuNotifier{})
+	RegisterNotifier(NotifierDingTalk, dingTalkNotifier{})
+	RegisterNotifier(NotifierTelegram, telegramNotifier{})
+	RegisterNotifier(NotifierDiscord, discordNotifier{})
+	RegisterNotifier(NotifierEmail, emailNotifier{})
+}
+
+func requireURL(ch NotificationChannel) error {
+	u, err := url.Parse(strings.TrimSpace(ch.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s: url must be an http(s) URL", ch.Type)
+	}
+	return nil
+}
+
+// postJSON posts a JSON payload and returns the response status and body. 5xx and 429 responses
+// are returned as retryable errors, other 4xx responses as permanent ones.
+func postJSON(ctx context.Context, endpoint string, headers map[string]string, payload any) (int, []byte, error) {
+	data, ok := payload.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return 0, nil, permanent(err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Drop the endpoint from the error: webhook URLs and the Telegram API path carry
+		// credentials, and the error ends up in delivery statuses and logs.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return 0, nil, fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+		}
+		return 0, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return resp.StatusCode, body, fmt.Errorf("status %d: %s", resp.StatusCode, truncateRunes(string(body), 200))
+	case resp.StatusCode >= 400:
+		return resp.StatusCode, body, permanent(fmt.Errorf("status %d: %s", resp.StatusCode, truncateRunes(string(body), 200)))
+	}
+	return resp.StatusCode, body, nil
+}
+
+// checkAPICode reports a non-zero application error code in a 200 response, as returned by the
+// WeCom, Feishu and DingTalk bot APIs.
+func checkAPICode(body []byte, codeField, msgField string) error {
+	code := gjson.GetBytes(body, codeField)
+	if code.Exists() && code.Int() != 0 {
+		return permanent(fmt.Errorf("%s %d: %s", codeField, code.Int(), gjson.GetBytes(body, msgField).String()))
+	}
+	return nil
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max(n-3, 0)]) + "..."
+}
+
+// webhookNotifier posts generic JSON. Without a body template the payload is the notification
+// itself plus a "content" field holding the rendered text; with one, the template's output is
+// sent as-is and must be valid JSON.
+type webhookNotifier struct{}
+
+func (webhookNotifier) Validate(ch NotificationChannel) error { return requireURL(ch) }
+
+func (webhookNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	var payload any
+	if ch.BodyTemplate != "" {
+		body, err := executeNotificationTemplate("body_template", ch.BodyTemplate, "", msg)
+		if err != nil {
+			return 0, permanent(err)
+		}
+		if !json.Valid([]byte(body)) {
+			return 0, permanent(fmt.Errorf("body_template did not produce valid JSON"))
+		}
+		payload = []byte(body)
+	} else {
+		payload = struct {
+			Notification
+			Content string `json:"content"`
+		}{msg.Notification, msg.Text}
+	}
+	status, _, err := postJSON(ctx, ch.URL, ch.Headers, payload)
+	return status, err
+}
+
+// weComNotifier posts to a WeCom group bot: a text notice card for results and markdown for
+// failures. See https://developer.work.weixin.qq.com/document/path/91770.
+type weComNotifier struct{}
+
+func (weComNotifier) Validate(ch NotificationChannel) error { return requireURL(ch) }
+
+func (weComNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	var payload map[string]any
+	if msg.Success {
+		title := msg.Title
+		if msg.TaskType == TaskTypeSystemReport && ch.Title == "" {
+			title = "System Report"
+		}
+		payload = map[string]any{
+			"msgtype": "template_card",
+			"template_card": map[string]any{
+				"card_type": "text_notice",
+				"source": map[string]string{
+					"icon_url": "https://wework.qpic.cn/wwpic/252813_jOfDHtcISzuay14_1628280241/0",
+					"desc":     truncateRunes(msg.Output, 100),
+				},
+				"main_title": map[string]string{
+					"title": title,
+					"desc":  "Task Execution Result",
+				},
+				"emphasis_content": map[string]string{
+					"title": "Success",
+					"desc":  "Status",
+				},
+				"sub_title_text": fmt.Sprintf("Executed at: %s", msg.ExecutedAt.Format("2006-01-02 15:04:05")),
+				"horizontal_content_list": []map[string]string{
+					{"keyname": "Task Type", "value": string(msg.TaskType)},
+					{"keyname": "Model", "value": msg.Model},
+				},
+				"card_action": map[string]string{
+					"type": "url",
+					"url":  "http://localhost:21301/management.html",
+				},
+				"quote_area": map[string]string{
+					"type":       "text",
+					"quote_text": msg.Text,
+				},
+			},
+		}
+	} else {
+		payload = map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": "**" + msg.Title + "**\n" + msg.Text},
+		}
+	}
+	status, body, err := postJSON(ctx, ch.URL, nil, payload)
+	if err == nil {
+		err = checkAPICode(body, "errcode", "errmsg")
+	}
+	return status, err
+}
+
+// slackNotifier posts to a Slack incoming webhook.
+type slackNotifier struct{}
+
+func (slackNotifier) Validate(ch NotificationChannel) error { return requireURL(ch) }
+
+func (slackNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	status, _, err := postJSON(ctx, ch.URL, nil, map[string]any{
+		"text": fmt.Sprintf("*%s*\n%s", msg.Title, msg.Text),
+	})
+	return status, err
+}
+
+// discordNotifier posts to a Discord webhook. Discord caps message content at 2000 characters.
+type discordNotifier struct{}
+
+func (discordNotifier) Validate(ch NotificationChannel) error { return requireURL(ch) }
+
+func (discordNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	status, _, err := postJSON(ctx, ch.URL, nil, map[string]any{
+		"content": truncateRunes(fmt.Sprintf("**%s**\n%s", msg.Title, msg.Text), 2000),
+	})
+	return status, err
+}
+
+// feishuNotifier posts to a Feishu/Lark custom bot, signing the request when a secret is set.
+type feishuNotifier struct{}
+
+func (feishuNotifier) Validate(ch NotificationChannel) error { return requireURL(ch) }
+
+func (feishuNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	payload := map[string]any{
+		"msg_type": "text",
+		"content":  map[string]string{"text": msg.Title + "\n" + msg.Text},
+	}
+	if ch.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = timestamp
+		payload["sign"] = feishuSign(timestamp, ch.Secret)
+	}
+	status, body, err := postJSON(ctx, ch.URL, nil, payload)
+	if err == nil {
+		err = checkAPICode(body, "code", "msg")
+	}
+	return status, err
+}
+
+// feishuSign computes the Feishu bot signature: HMAC-SHA256 keyed with "timestamp\nsecret" over
+// an empty message, base64 encoded.
+func feishuSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// dingTalkNotifier posts markdown to a DingTalk custom robot, signing the URL when a secret is set.
+type dingTalkNotifier struct{}
+
+func (dingTalkNotifier) Validate(ch NotificationChannel) error { return requireURL(ch) }
+
+func (dingTalkNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	endpoint := ch.URL
+	if ch.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		sep := "?"
+		if strings.Contains(endpoint, "?") {
+			sep = "&"
+		}
+		endpoint += sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(dingTalkSign(timestamp, ch.Secret))
+	}
+	status, body, err := postJSON(ctx, endpoint, nil, map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  "### " + msg.Title + "\n\n" + msg.Text,
+		},
+	})
+	if err == nil {
+		err = checkAPICode(body, "errcode", "errmsg")
+	}
+	return status, err
+}
+
+// dingTalkSign computes the DingTalk robot signature: HMAC-SHA256 keyed with the secret over
+// "timestamp\nsecret", base64 encoded.
+func dingTalkSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// telegramNotifier sends a message through the Telegram Bot API. URL optionally overrides the
+// API base (default https://api.telegram.org) for self-hosted Bot API servers.
+type telegramNotifier struct{}
+
+func (telegramNotifier) Validate(ch NotificationChannel) error {
+	if strings.TrimSpace(ch.BotToken) == "" || strings.TrimSpace(ch.ChatID) == "" {
+		return fmt.Errorf("telegram: bot_token and chat_id are required")
+	}
+	if ch.URL != "" {
+		return requireURL(ch)
+	}
+	return nil
+}
+
+func (telegramNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	base := strings.TrimRight(ch.URL, "/")
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	status, _, err := postJSON(ctx, base+"/bot"+ch.BotToken+"/sendMessage", nil, map[string]any{
+		"chat_id": ch.ChatID,
+		"text":    truncateRunes(msg.Title+"\n\n"+msg.Text, 4096),
+	})
+	return status, err
+}
+
+// emailNotifier sends a plain-text email over SMTP. Port 465 uses implicit TLS; other ports
+// upgrade with STARTTLS when the server offers it.
+type emailNotifier struct{}
+
+func (emailNotifier) Validate(ch NotificationChannel) error {
+	if strings.TrimSpace(ch.SMTPHost) == "" || strings.TrimSpace(ch.From) == "" || len(ch.To) == 0 {
+		return fmt.Errorf("email: smtp_host, from and to are required")
+	}
+	if ch.SMTPPort < 0 || ch.SMTPPort > 65535 {
+		return fmt.Errorf("email: invalid smtp_port %d", ch.SMTPPort)
+	}
+	return nil
+}
+
+func (emailNotifier) Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error) {
+	port := ch.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(ch.SMTPHost, strconv.Itoa(port))
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: ch.SMTPHost}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, ch.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return 0, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err = client.StartTLS(&tls.Config{ServerName: ch.SMTPHost}); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if ch.SMTPUsername != "" {
+		if err = client.Auth(smtp.PlainAuth("", ch.SMTPUsername, ch.SMTPPassword, ch.SMTPHost)); err != nil {
+			return 0, permanent(err)
+		}
+	}
+	if err = client.Mail(ch.From); err != nil {
+		return 0, err
+	}
+	for _, to := range ch.To {
+		if err = client.Rcpt(to); err != nil {
+			return 0, err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = w.Write(buildEmail(ch.From, ch.To, msg.Title, msg.Text)); err != nil {
+		return 0, err
+	}
+	if err = w.Close(); err != nil {
+		return 0, err
+	}
+	return 0, client.Quit()
+}
+
+func buildEmail(from string, to []string, subject, text string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: =?UTF-8?B?" + base64.StdEncoding.EncodeToString([]byte(subject)) + "?=\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,377 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NotificationEvent is the outcome of a run that a channel can be notified about.
+type NotificationEvent string
+
+const (
+	NotifyOnSuccess NotificationEvent = "success"
+	NotifyOnFailure NotificationEvent = "failure"
+)
+
+// NotifierType names a registered notification channel implementation.
+type NotifierType string
+
+const (
+	NotifierWebhook  NotifierType = "webhook" // Generic JSON, optionally shaped by a body template
+	NotifierWeCom    NotifierType = "wecom"
+	NotifierSlack    NotifierType = "slack"
+	NotifierFeishu   NotifierType = "feishu" // Also Lark
+	NotifierDingTalk NotifierType = "dingtalk"
+	NotifierTelegram NotifierType = "telegram"
+	NotifierDiscord  NotifierType = "discord"
+	NotifierEmail    NotifierType = "email"
+)
+
+const (
+	defaultDeliveryRetries = 2
+	maxDeliveryRetries     = 5
+	deliveryTimeout        = 30 * time.Second
+)
+
+// deliveryBackoff is the wait before the first delivery retry, doubled per retry.
+var deliveryBackoff = 2 * time.Second
+
+// NotificationChannel is one destination for a task's run results. Which fields apply depends on
+// Type; see the notifier implementations.
+type NotificationChannel struct {
+	Type NotifierType        `json:"type"`
+	Name string              `json:"name,omitempty"` // Shown in delivery status, defaults to Type
+	On   []NotificationEvent `json:"on,omitempty"`   // Events to deliver, default both
+
+	URL     string            `json:"url,omitempty"`     // Incoming webhook URL (Telegram: optional API base)
+	Secret  string            `json:"secret,omitempty"`  // Feishu and DingTalk signing secret
+	Headers map[string]string `json:"headers,omitempty"` // Extra headers for generic webhooks
+
+	BotToken string `json:"bot_token,omitempty"` // Telegram
+	ChatID   string `json:"chat_id,omitempty"`   // Telegram
+
+	SMTPHost     string   `json:"smtp_host,omitempty"`
+	SMTPPort     int      `json:"smtp_port,omitempty"` // 465 uses implicit TLS, others STARTTLS when offered
+	SMTPUsername string   `json:"smtp_username,omitempty"`
+	SMTPPassword string   `json:"smtp_password,omitempty"`
+	From         string   `json:"from,omitempty"`
+	To           []string `json:"to,omitempty"`
+
+	Title        string `json:"title,omitempty"`         // Go template for the title or email subject
+	Template     string `json:"template,omitempty"`      // Go template for the message text
+	BodyTemplate string `json:"body_template,omitempty"` // Go template for a generic webhook's JSON body
+
+	Retries *int `json:"retries,omitempty"` // Delivery retries after a failed attempt (default 2)
+}
+
+// RedactedSecret replaces channel credentials in API responses. Sending it back in an update
+// keeps the stored value (see RestoreSecrets).
+const RedactedSecret = "********"
+
+// Redacted returns a copy of the channel with its signing secret, bot token and SMTP password
+// masked, for API responses. Tasks are persisted unredacted.
+func (ch NotificationChannel) Redacted() NotificationChannel {
+	for _, field := range []*string{&ch.Secret, &ch.BotToken, &ch.SMTPPassword} {
+		if *field != "" {
+			*field = RedactedSecret
+		}
+	}
+	return ch
+}
+
+// RestoreSecrets replaces masked credentials in updated with the values of the channel at the
+// same position in previous, so a task read from the API can be sent back unchanged.
+func RestoreSecrets(updated, previous []NotificationChannel) {
+	for i := range updated {
+		if i >= len(previous) || updated[i].Type != previous[i].Type {
+			continue
+		}
+		if updated[i].Secret == RedactedSecret {
+			updated[i].Secret = previous[i].Secret
+		}
+		if updated[i].BotToken == RedactedSecret {
+			updated[i].BotToken = previous[i].BotToken
+		}
+		if updated[i].SMTPPassword == RedactedSecret {
+			updated[i].SMTPPassword = previous[i].SMTPPassword
+		}
+	}
+}
+
+// Notification describes a finished run. It is the data available to channel templates, e.g.
+// "{{.TaskName}}: {{if .Success}}{{.Output}}{{else}}failed: {{.Error}}{{end}}".
+type Notification struct {
+	Event               NotificationEvent `json:"event"`
+	TaskID              string            `json:"task_id"`
+	TaskName            string            `json:"task_name"`
+	TaskType            TaskType          `json:"task_type"`
+	Model               string            `json:"model"`
+	Success             bool              `json:"success"`
+	Output              string            `json:"output,omitempty"`
+	Error               string            `json:"error,omitempty"`
+	Attempts            int               `json:"attempts"`
+	ConsecutiveFailures int               `json:"consecutive_failures"`
+	Paused              bool              `json:"paused"`
+	ExecutedAt          time.Time         `json:"executed_at"`
+	DurationMs          int64             `json:"duration_ms"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+}
+
+// DeliveryStatus records the delivery of a notification to one channel.
+type DeliveryStatus struct {
+	Channel  string       `json:"channel"`
+	Type     NotifierType `json:"type"`
+	Success  bool         `json:"success"`
+	Status   int          `json:"status,omitempty"` // HTTP status of the last attempt, 0 for email or transport errors
+	Attempts int          `json:"attempts"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// Message is a notification rendered for a channel.
+type Message struct {
+	Title string
+	Text  string
+	Notification
+}
+
+// Notifier delivers messages to one kind of channel.
+type Notifier interface {
+	// Validate checks the channel's type-specific settings.
+	Validate(ch NotificationChannel) error
+	// Send delivers msg and returns the HTTP status (0 if not applicable). Errors wrapped with
+	// permanent are not retried.
+	Send(ctx context.Context, ch NotificationChannel, msg Message) (int, error)
+}
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = map[NotifierType]Notifier{}
+)
+
+// RegisterNotifier makes a notifier available to tasks under the given channel type, replacing
+// any existing registration.
+func RegisterNotifier(t NotifierType, n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[t] = n
+}
+
+func lookupNotifier(t NotifierType) (Notifier, bool) {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+	n, ok := notifiers[t]
+	return n, ok
+}
+
+// permanentError marks a delivery failure that retrying will not fix, such as a rejected payload.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error { return permanentError{err: err} }
+
+// ValidateNotifications checks a task's notification channels: known types, type-specific
+// settings, events, retry counts and template syntax.
+func ValidateNotifications(channels []NotificationChannel) error {
+	for i, ch := range channels {
+		n, ok := lookupNotifier(ch.Type)
+		if !ok {
+			return fmt.Errorf("notifications[%d]: unknown type %q", i, ch.Type)
+		}
+		if err := n.Validate(ch); err != nil {
+			return fmt.Errorf("notifications[%d]: %w", i, err)
+		}
+		for _, ev := range ch.On {
+			if ev != NotifyOnSuccess && ev != NotifyOnFailure {
+				return fmt.Errorf("notifications[%d]: invalid event %q (expected success or failure)", i, ev)
+			}
+		}
+		if ch.Retries != nil && (*ch.Retries < 0 || *ch.Retries > maxDeliveryRetries) {
+			return fmt.Errorf("notifications[%d]: retries must be between 0 and %d", i, maxDeliveryRetries)
+		}
+		for _, tmpl := range [][2]string{{"title", ch.Title}, {"template", ch.Template}, {"body_template", ch.BodyTemplate}} {
+			if _, err := parseNotificationTemplate(tmpl[0], tmpl[1]); err != nil {
+				return fmt.Errorf("notifications[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// notificationFuncs are available in channel templates. json renders a value as a JSON literal,
+// for use in body templates: {"text": {{json .Output}}}.
+var notificationFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"truncate": truncateRunes,
+}
+
+func parseNotificationTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(notificationFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func executeNotificationTemplate(name, text, fallback string, data any) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := parseNotificationTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+const (
+	defaultTitleTemplate = `{{if .Success}}Task: {{.TaskName}}{{else}}Task failed: {{.TaskName}}{{end}}`
+	defaultTextTemplate  = `{{if .Success}}{{.Output}}{{else}}Error: {{.Error}}
+Attempts: {{.Attempts}}
+Consecutive failures: {{.ConsecutiveFailures}}{{if .Paused}}
+The task has been paused.{{end}}{{end}}`
+)
+
+// render formats a notification for a channel using its title and message templates.
+func (ch NotificationChannel) render(n Notification) (Message, error) {
+	title, err := executeNotificationTemplate("title", ch.Title, defaultTitleTemplate, n)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := executeNotificationTemplate("template", ch.Template, defaultTextTemplate, n)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Title: strings.TrimSpace(title), Text: text, Notification: n}, nil
+}
+
+func (ch NotificationChannel) wants(event NotificationEvent) bool {
+	if len(ch.On) == 0 {
+		return true
+	}
+	for _, ev := range ch.On {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (ch NotificationChannel) label() string {
+	if ch.Name != "" {
+		return ch.Name
+	}
+	return string(ch.Type)
+}
+
+// taskChannels returns the task's notification channels, including the legacy webhook_url
+// (results) and failure_webhook_url (failures) fields.
+func taskChannels(task *Task) []NotificationChannel {
+	task.RLock()
+	defer task.RUnlock()
+	channels := append([]NotificationChannel(nil), task.Notifications...)
+	if url := strings.TrimSpace(task.WebhookURL); url != "" {
+		channels = append(channels, legacyWebhookChannel("webhook_url", url, NotifyOnSuccess))
+	}
+	if url := strings.TrimSpace(task.FailureWebhook); url != "" {
+		channels = append(channels, legacyWebhookChannel("failure_webhook_url", url, NotifyOnFailure))
+	}
+	return channels
+}
+
+func legacyWebhookChannel(name, url string, event NotificationEvent) NotificationChannel {
+	t := NotifierWebhook
+	if strings.Contains(url, "qyapi.weixin.qq.com") {
+		t = NotifierWeCom
+	}
+	return NotificationChannel{Type: t, Name: name, URL: url, On: []NotificationEvent{event}}
+}
+
+// notify delivers n to every channel subscribed to its event, concurrently, and returns the
+// delivery results in channel order.
+func (e *Engine) notify(channels []NotificationChannel, n Notification) []DeliveryStatus {
+	var targets []NotificationChannel
+	for _, ch := range channels {
+		if ch.wants(n.Event) {
+			targets = append(targets, ch)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	results := make([]DeliveryStatus, len(targets))
+	var wg sync.WaitGroup
+	for i, ch := range targets {
+		wg.Add(1)
+		go func(i int, ch NotificationChannel) {
+			defer wg.Done()
+			results[i] = e.deliver(ch, n)
+		}(i, ch)
+	}
+	wg.Wait()
+	return results
+}
+
+// deliver sends one notification, retrying transient failures with exponential backoff.
+func (e *Engine) deliver(ch NotificationChannel, n Notification) DeliveryStatus {
+	status := DeliveryStatus{Channel: ch.label(), Type: ch.Type}
+	notifier, ok := lookupNotifier(ch.Type)
+	if !ok {
+		status.Error = fmt.Sprintf("unknown notifier type %q", ch.Type)
+		return status
+	}
+	msg, err := ch.render(n)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	retries := defaultDeliveryRetries
+	if ch.Retries != nil {
+		retries = min(max(*ch.Retries, 0), maxDeliveryRetries)
+	}
+
+	delay := deliveryBackoff
+	for {
+		status.Attempts++
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		code, errSend := notifier.Send(ctx, ch, msg)
+		cancel()
+		status.Status = code
+		if errSend == nil {
+			status.Success, status.Error = true, ""
+			log.Infof("Notification %s delivered for task %s (status %d)", status.Channel, n.TaskID, code)
+			return status
+		}
+		status.Error = errSend.Error()
+		var perm permanentError
+		if status.Attempts > retries || errors.As(errSend, &perm) {
+			break
+		}
+		if !e.sleep(delay) {
+			break
+		}
+		delay *= 2
+	}
+	log.Warnf("Notification %s failed for task %s after %d attempt(s): %s", status.Channel, n.TaskID, status.Attempts, status.Error)
+	return status
+}
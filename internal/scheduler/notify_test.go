@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func init() {
+	deliveryBackoff = time.Millisecond
+}
+
+func TestValidateNotifications(t *testing.T) {
+	retries := 9
+	assert.NoError(t, ValidateNotifications([]NotificationChannel{
+		{Type: NotifierSlack, URL: "https://hooks.slack.com/services/x"},
+		{Type: NotifierTelegram, BotToken: "t", ChatID: "1"},
+		{Type: NotifierEmail, SMTPHost: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}},
+	}))
+	assert.Error(t, ValidateNotifications([]NotificationChannel{{Type: "pager"}}))
+	assert.Error(t, ValidateNotifications([]NotificationChannel{{Type: NotifierFeishu, URL: "not a url"}}))
+	assert.Error(t, ValidateNotifications([]NotificationChannel{{Type: NotifierTelegram, BotToken: "t"}}))
+	assert.Error(t, ValidateNotifications([]NotificationChannel{{Type: NotifierSlack, URL: "https://x", On: []NotificationEvent{"always"}}}))
+	assert.Error(t, ValidateNotifications([]NotificationChannel{{Type: NotifierSlack, URL: "https://x", Retries: &retries}}))
+	assert.Error(t, ValidateNotifications([]NotificationChannel{{Type: NotifierSlack, URL: "https://x", Template: "{{.Output"}}))
+}
+
+func TestEngine_NotifyFansOutToChannels(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+		if r.URL.Path == "/feishu" || r.URL.Path == "/dingtalk" {
+			_, _ = w.Write([]byte(`{"code":0,"errcode":0}`))
+		}
+	}))
+	defer server.Close()
+
+	engine := NewEngine(nil, nil)
+	channels := []NotificationChannel{
+		{Type: NotifierSlack, URL: server.URL + "/slack", Template: "{{.TaskName}} says {{.Output}} ({{.Metadata.team}})"},
+		{Type: NotifierFeishu, Name: "ops", URL: server.URL + "/feishu", Secret: "s3cret"},
+		{Type: NotifierDingTalk, URL: server.URL + "/dingtalk", On: []NotificationEvent{NotifyOnFailure}},
+		{Type: NotifierWebhook, URL: server.URL + "/generic", BodyTemplate: `{"msg": {{json .Text}}, "ok": {{.Success}}}`},
+	}
+	results := engine.notify(channels, Notification{
+		Event: NotifyOnSuccess, TaskID: "t1", TaskName: "Digest", Success: true, Output: "hello",
+		Metadata: map[string]string{"team": "infra"},
+	})
+
+	require.Len(t, results, 3, "the failure-only channel is skipped")
+	for _, r := range results {
+		assert.True(t, r.Success, r.Channel)
+		assert.Equal(t, http.StatusOK, r.Status)
+		assert.Equal(t, 1, r.Attempts)
+	}
+	assert.Equal(t, "ops", results[1].Channel)
+
+	assert.Equal(t, "*Task: Digest*\nDigest says hello (infra)", gjson.GetBytes(bodies["/slack"], "text").String())
+	feishu := gjson.ParseBytes(bodies["/feishu"])
+	assert.Equal(t, "text", feishu.Get("msg_type").String())
+	assert.Equal(t, feishuSign(feishu.Get("timestamp").String(), "s3cret"), feishu.Get("sign").String())
+	assert.Equal(t, "hello", gjson.GetBytes(bodies["/generic"], "msg").String())
+	assert.True(t, gjson.GetBytes(bodies["/generic"], "ok").Bool())
+	assert.NotContains(t, bodies, "/dingtalk")
+}
+
+func TestEngine_DeliverRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/rejected"):
+			w.WriteHeader(http.StatusBadRequest)
+		case calls.Add(1) < 3:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	engine := NewEngine(nil, nil)
+	n := Notification{Event: NotifyOnFailure, TaskID: "t1", Error: "boom"}
+
+	status := engine.deliver(NotificationChannel{Type: NotifierDiscord, URL: server.URL + "/flaky"}, n)
+	assert.True(t, status.Success)
+	assert.Equal(t, 3, status.Attempts)
+
+	status = engine.deliver(NotificationChannel{Type: NotifierDiscord, URL: server.URL + "/rejected"}, n)
+	assert.False(t, status.Success)
+	assert.Equal(t, 1, status.Attempts, "4xx responses are not retried")
+	assert.Equal(t, http.StatusBadRequest, status.Status)
+}
+
+func TestDingTalkNotifier_SignsURL(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	defer server.Close()
+
+	status := NewEngine(nil, nil).deliver(NotificationChannel{Type: NotifierDingTalk, URL: server.URL + "/robot/send?access_token=x", Secret: "sec"}, Notification{Success: true})
+	require.Contains(t, query, "sign")
+	assert.Equal(t, dingTalkSign(query["timestamp"][0], "sec"), query["sign"][0])
+	assert.Equal(t, []string{"x"}, query["access_token"])
+	assert.False(t, status.Success, "a non-zero errcode is a failed delivery")
+	assert.Contains(t, status.Error, "sign not match")
+}
+
+func TestTelegramNotifier_ErrorOmitsBotToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	base := server.URL
+	server.Close()
+
+	retries := 0
+	status := NewEngine(nil, nil).deliver(NotificationChannel{Type: NotifierTelegram, URL: base, BotToken: "123:secret-token", ChatID: "1", Retries: &retries}, Notification{Success: true})
+	assert.False(t, status.Success)
+	assert.NotEmpty(t, status.Error)
+	assert.NotContains(t, status.Error, "secret-token")
+}
+
+func TestNotificationChannel_RedactedAndRestored(t *testing.T) {
+	stored := []NotificationChannel{
+		{Type: NotifierTelegram, BotToken: "bot", ChatID: "1"},
+		{Type: NotifierEmail, SMTPHost: "smtp.example.com", SMTPPassword: "pw"},
+		{Type: NotifierFeishu, URL: "https://open.feishu.cn/x", Secret: "sec"},
+	}
+	var shown []NotificationChannel
+	for _, ch := range stored {
+		shown = append(shown, ch.Redacted())
+	}
+	assert.Equal(t, RedactedSecret, shown[0].BotToken)
+	assert.Equal(t, RedactedSecret, shown[1].SMTPPassword)
+	assert.Equal(t, RedactedSecret, shown[2].Secret)
+	assert.Equal(t, "bot", stored[0].BotToken, "redaction must not touch the stored channel")
+
+	shown[2].Secret = "rotated"
+	RestoreSecrets(shown, stored)
+	assert.Equal(t, "bot", shown[0].BotToken)
+	assert.Equal(t, "pw", shown[1].SMTPPassword)
+	assert.Equal(t, "rotated", shown[2].Secret)
+}
+
+func TestTaskChannels_IncludesLegacyWebhooks(t *testing.T) {
+	channels := taskChannels(&Task{
+		Notifications:  []NotificationChannel{{Type: NotifierSlack, URL: "https://hooks.slack.com/x"}},
+		WebhookURL:     "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=k",
+		FailureWebhook: "https://example.com/alerts",
+	})
+	require.Len(t, channels, 3)
+	assert.Equal(t, NotifierWeCom, channels[1].Type)
+	assert.True(t, channels[1].wants(NotifyOnSuccess))
+	assert.False(t, channels[1].wants(NotifyOnFailure))
+	assert.Equal(t, NotifierWebhook, channels[2].Type)
+	assert.False(t, channels[2].wants(NotifyOnSuccess))
+}
@@ -0,0 +1,362 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// structuredOutputName names the JSON schema (and, for the messages API, the forced tool) used
+// for structured output.
+const structuredOutputName = "task_output"
+
+// TemplateData is the data available to task prompt and message templates, e.g.
+// "Summarise activity for {{.Date}}. Yesterday you wrote: {{.PreviousOutput}}".
+type TemplateData struct {
+	Now            time.Time         // Run time in the task's time zone
+	Date           string            // Run date, 2006-01-02
+	Time           string            // Run time, 15:04
+	Weekday        string            // e.g. "Monday"
+	PreviousOutput string            // Output of the last successful run, if any
+	Task           TemplateTask      // Task identity
+	Metadata       map[string]string // Task metadata
+}
+
+// TemplateTask describes the running task to templates.
+type TemplateTask struct {
+	ID    string
+	Name  string
+	Model string
+}
+
+// taskRequest is a snapshot of the request-shaping fields of a task, taken under its lock.
+type taskRequest struct {
+	id, name, model string
+	api             APISurface
+	systemPrompt    string
+	messages        []MessageTemplate
+	prompt          string
+	params          *GenerationParams
+	tools           json.RawMessage
+	schema          json.RawMessage
+	metadata        map[string]string
+	location        *time.Location
+}
+
+func snapshotTaskRequest(task *Task) taskRequest {
+	task.RLock()
+	defer task.RUnlock()
+	loc, err := task.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	return taskRequest{
+		id:           task.ID,
+		name:         task.Name,
+		model:        task.Model,
+		api:          task.API,
+		systemPrompt: task.SystemPrompt,
+		messages:     append([]MessageTemplate(nil), task.Messages...),
+		prompt:       task.Prompt,
+		params:       task.Parameters,
+		tools:        task.Tools,
+		schema:       task.ResponseSchema,
+		metadata:     task.Metadata,
+		location:     loc,
+	}
+}
+
+// ValidateRequestFields checks the request-shaping fields of a task: the API surface, template
+// syntax, message roles and that tools and the response schema are JSON.
+func ValidateRequestFields(task *Task) error {
+	switch task.API {
+	case "", APIChat, APIResponses, APIMessages:
+	default:
+		return fmt.Errorf("invalid api %q (expected chat, responses or messages)", task.API)
+	}
+	if _, err := renderTemplate("system_prompt", task.SystemPrompt, TemplateData{}); err != nil {
+		return err
+	}
+	if _, err := renderTemplate("prompt", task.Prompt, TemplateData{}); err != nil {
+		return err
+	}
+	for i, m := range task.Messages {
+		switch m.Role {
+		case "system", "user", "assistant":
+		default:
+			return fmt.Errorf("messages[%d]: invalid role %q", i, m.Role)
+		}
+		if _, err := renderTemplate(fmt.Sprintf("messages[%d]", i), m.Content, TemplateData{}); err != nil {
+			return err
+		}
+	}
+	if hasJSON(task.Tools) && !gjson.ParseBytes(task.Tools).IsArray() {
+		return fmt.Errorf("tools must be a JSON array")
+	}
+	if hasJSON(task.ResponseSchema) && !gjson.ParseBytes(task.ResponseSchema).IsObject() {
+		return fmt.Errorf("response_schema must be a JSON object")
+	}
+	return nil
+}
+
+func renderTemplate(name, text string, data TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+type renderedMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// render expands the system prompt, message templates and prompt. The prompt, when set, is sent
+// as the final user message.
+func (r taskRequest) render(data TemplateData) (system string, messages []renderedMessage, err error) {
+	if system, err = renderTemplate("system_prompt", r.systemPrompt, data); err != nil {
+		return "", nil, err
+	}
+	for i, m := range r.messages {
+		content, errRender := renderTemplate(fmt.Sprintf("messages[%d]", i), m.Content, data)
+		if errRender != nil {
+			return "", nil, errRender
+		}
+		messages = append(messages, renderedMessage{Role: m.Role, Content: content})
+	}
+	if strings.TrimSpace(r.prompt) != "" {
+		prompt, errRender := renderTemplate("prompt", r.prompt, data)
+		if errRender != nil {
+			return "", nil, errRender
+		}
+		messages = append(messages, renderedMessage{Role: "user", Content: prompt})
+	}
+	return system, messages, nil
+}
+
+// hasJSON reports whether an optional raw JSON field is set.
+func hasJSON(raw json.RawMessage) bool {
+	return len(raw) > 0 && !bytes.Equal(raw, []byte("null"))
+}
+
+// build renders the task into a request body for its API surface and returns the endpoint path.
+func (r taskRequest) build(vars TemplateData) (string, []byte, error) {
+	system, messages, err := r.render(vars)
+	if err != nil {
+		return "", nil, err
+	}
+	params := r.params
+	if params == nil {
+		params = &GenerationParams{}
+	}
+
+	body := map[string]any{"model": r.model}
+	if params.Temperature != nil {
+		body["temperature"] = *params.Temperature
+	}
+	if params.TopP != nil {
+		body["top_p"] = *params.TopP
+	}
+
+	var path string
+	switch r.api {
+	case APIResponses:
+		path = "/v1/responses"
+		if system != "" {
+			body["instructions"] = system
+		}
+		body["input"] = messages
+		if params.MaxTokens > 0 {
+			body["max_output_tokens"] = params.MaxTokens
+		}
+		if hasJSON(r.tools) {
+			var tools []map[string]any
+			gjson.ParseBytes(r.tools).ForEach(func(_, tool gjson.Result) bool {
+				fn := tool.Get("function")
+				if !fn.Exists() {
+					fn = tool
+				}
+				tools = append(tools, map[string]any{
+					"type":        "function",
+					"name":        fn.Get("name").String(),
+					"description": fn.Get("description").String(),
+					"parameters":  json.RawMessage(rawOrEmptyObject(fn.Get("parameters"))),
+				})
+				return true
+			})
+			body["tools"] = tools
+		}
+		if hasJSON(r.schema) {
+			body["text"] = map[string]any{"format": map[string]any{
+				"type": "json_schema", "name": structuredOutputName, "schema": r.schema, "strict": true,
+			}}
+		}
+
+	case APIMessages:
+		path = "/v1/messages"
+		// The messages API takes the system prompt separately, so system turns are folded into it.
+		var turns []renderedMessage
+		var systemParts []string
+		if system != "" {
+			systemParts = append(systemParts, system)
+		}
+		for _, m := range messages {
+			if m.Role == "system" {
+				systemParts = append(systemParts, m.Content)
+				continue
+			}
+			turns = append(turns, m)
+		}
+		if len(systemParts) > 0 {
+			body["system"] = strings.Join(systemParts, "\n\n")
+		}
+		body["messages"] = turns
+		maxTokens := params.MaxTokens
+		if maxTokens <= 0 {
+			maxTokens = 4096
+		}
+		body["max_tokens"] = maxTokens
+		if len(params.Stop) > 0 {
+			body["stop_sequences"] = params.Stop
+		}
+		var tools []map[string]any
+		if hasJSON(r.tools) {
+			gjson.ParseBytes(r.tools).ForEach(func(_, tool gjson.Result) bool {
+				fn := tool.Get("function")
+				if !fn.Exists() {
+					fn = tool
+				}
+				tools = append(tools, map[string]any{
+					"name":         fn.Get("name").String(),
+					"description":  fn.Get("description").String(),
+					"input_schema": json.RawMessage(rawOrEmptyObject(fn.Get("parameters"))),
+				})
+				return true
+			})
+		}
+		if hasJSON(r.schema) {
+			// Claude has no response_format; forcing a tool whose input is the schema is the
+			// documented way to get structured output.
+			tools = append(tools, map[string]any{
+				"name":         structuredOutputName,
+				"description":  "Return the final answer in this structure.",
+				"input_schema": r.schema,
+			})
+			body["tool_choice"] = map[string]any{"type": "tool", "name": structuredOutputName}
+		}
+		if len(tools) > 0 {
+			body["tools"] = tools
+		}
+
+	default:
+		path = "/v1/chat/completions"
+		chat := make([]renderedMessage, 0, len(messages)+1)
+		if system != "" {
+			chat = append(chat, renderedMessage{Role: "system", Content: system})
+		}
+		body["messages"] = append(chat, messages...)
+		if params.MaxTokens > 0 {
+			body["max_tokens"] = params.MaxTokens
+		}
+		if len(params.Stop) > 0 {
+			body["stop"] = params.Stop
+		}
+		if hasJSON(r.tools) {
+			body["tools"] = r.tools
+		}
+		if hasJSON(r.schema) {
+			body["response_format"] = map[string]any{"type": "json_schema", "json_schema": map[string]any{
+				"name": structuredOutputName, "schema": r.schema, "strict": true,
+			}}
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return path, data, nil
+}
+
+func rawOrEmptyObject(v gjson.Result) string {
+	if v.Exists() && v.IsObject() {
+		return v.Raw
+	}
+	return `{"type":"object","properties":{}}`
+}
+
+// extractOutput pulls the model's answer out of a response body for the given API surface.
+// Tool calls are returned as JSON; structured output forced through a tool returns its input.
+func extractOutput(api APISurface, body []byte) (string, bool) {
+	root := gjson.ParseBytes(body)
+	switch api {
+	case APIResponses:
+		var text []string
+		var calls []string
+		root.Get("output").ForEach(func(_, item gjson.Result) bool {
+			switch item.Get("type").String() {
+			case "message":
+				item.Get("content").ForEach(func(_, part gjson.Result) bool {
+					if part.Get("type").String() == "output_text" {
+						text = append(text, part.Get("text").String())
+					}
+					return true
+				})
+			case "function_call":
+				calls = append(calls, item.Raw)
+			}
+			return true
+		})
+		if len(text) > 0 {
+			return strings.Join(text, ""), true
+		}
+		if len(calls) > 0 {
+			return "[" + strings.Join(calls, ",") + "]", true
+		}
+	case APIMessages:
+		var text []string
+		var calls []string
+		for _, block := range root.Get("content").Array() {
+			switch block.Get("type").String() {
+			case "text":
+				text = append(text, block.Get("text").String())
+			case "tool_use":
+				if block.Get("name").String() == structuredOutputName {
+					return block.Get("input").Raw, true
+				}
+				calls = append(calls, block.Raw)
+			}
+		}
+		if len(text) > 0 {
+			return strings.Join(text, ""), true
+		}
+		if len(calls) > 0 {
+			return "[" + strings.Join(calls, ",") + "]", true
+		}
+	default:
+		message := root.Get("choices.0.message")
+		if content := message.Get("content"); content.Type == gjson.String && content.String() != "" {
+			return content.String(), true
+		}
+		if calls := message.Get("tool_calls"); calls.IsArray() && len(calls.Array()) > 0 {
+			return calls.Raw, true
+		}
+		if content := message.Get("content"); content.Exists() {
+			return content.String(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func richTask(api APISurface) *Task {
+	temp := 0.2
+	return &Task{
+		ID:           "report",
+		Name:         "Weekday report",
+		Model:        "gpt-4o",
+		API:          api,
+		SystemPrompt: "You write reports for {{.Metadata.team}}.",
+		Messages: []MessageTemplate{
+			{Role: "user", Content: "Previous report: {{.PreviousOutput}}"},
+			{Role: "assistant", Content: "Noted."},
+		},
+		Prompt:         "Write the report for {{.Date}} ({{.Task.Name}}).",
+		Parameters:     &GenerationParams{Temperature: &temp, MaxTokens: 256, Stop: []string{"END"}},
+		Tools:          json.RawMessage(`[{"type":"function","function":{"name":"lookup","description":"d","parameters":{"type":"object"}}}]`),
+		ResponseSchema: json.RawMessage(`{"type":"object","properties":{"summary":{"type":"string"}}}`),
+		Metadata:       map[string]string{"team": "platform"},
+	}
+}
+
+func testTemplateData(spec taskRequest) TemplateData {
+	now := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	return TemplateData{
+		Now: now, Date: now.Format("2006-01-02"), Time: now.Format("15:04"), Weekday: now.Weekday().String(),
+		PreviousOutput: "all quiet",
+		Task:           TemplateTask{ID: spec.id, Name: spec.name, Model: spec.model},
+		Metadata:       spec.metadata,
+	}
+}
+
+func TestTaskRequest_BuildChat(t *testing.T) {
+	spec := snapshotTaskRequest(richTask(""))
+	path, body, err := spec.build(testTemplateData(spec))
+	require.NoError(t, err)
+
+	root := gjson.ParseBytes(body)
+	assert.Equal(t, "/v1/chat/completions", path)
+	assert.Equal(t, "system", root.Get("messages.0.role").String())
+	assert.Equal(t, "You write reports for platform.", root.Get("messages.0.content").String())
+	assert.Equal(t, "Previous report: all quiet", root.Get("messages.1.content").String())
+	assert.Equal(t, "Write the report for 2025-01-06 (Weekday report).", root.Get("messages.3.content").String())
+	assert.Equal(t, 0.2, root.Get("temperature").Float())
+	assert.Equal(t, int64(256), root.Get("max_tokens").Int())
+	assert.Equal(t, "lookup", root.Get("tools.0.function.name").String())
+	assert.Equal(t, "json_schema", root.Get("response_format.type").String())
+}
+
+func TestTaskRequest_BuildResponses(t *testing.T) {
+	spec := snapshotTaskRequest(richTask(APIResponses))
+	path, body, err := spec.build(testTemplateData(spec))
+	require.NoError(t, err)
+
+	root := gjson.ParseBytes(body)
+	assert.Equal(t, "/v1/responses", path)
+	assert.Equal(t, "You write reports for platform.", root.Get("instructions").String())
+	assert.Len(t, root.Get("input").Array(), 3)
+	assert.Equal(t, int64(256), root.Get("max_output_tokens").Int())
+	assert.Equal(t, "lookup", root.Get("tools.0.name").String())
+	assert.Equal(t, "json_schema", root.Get("text.format.type").String())
+}
+
+func TestTaskRequest_BuildMessages(t *testing.T) {
+	task := richTask(APIMessages)
+	task.Messages = append([]MessageTemplate{{Role: "system", Content: "Be brief."}}, task.Messages...)
+	spec := snapshotTaskRequest(task)
+	path, body, err := spec.build(testTemplateData(spec))
+	require.NoError(t, err)
+
+	root := gjson.ParseBytes(body)
+	assert.Equal(t, "/v1/messages", path)
+	assert.Equal(t, "You write reports for platform.\n\nBe brief.", root.Get("system").String())
+	assert.Equal(t, "user", root.Get("messages.0.role").String())
+	assert.Equal(t, []any{"END"}, root.Get("stop_sequences").Value())
+	assert.Equal(t, "lookup", root.Get("tools.0.name").String())
+	assert.Equal(t, structuredOutputName, root.Get("tools.1.name").String())
+	assert.Equal(t, structuredOutputName, root.Get("tool_choice.name").String())
+}
+
+func TestExtractOutput(t *testing.T) {
+	out, ok := extractOutput(APIMessages, []byte(`{"content":[{"type":"tool_use","name":"task_output","input":{"summary":"ok"}}]}`))
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"summary":"ok"}`, out)
+
+	out, ok = extractOutput(APIResponses, []byte(`{"output":[{"type":"reasoning"},{"type":"message","content":[{"type":"output_text","text":"hi"}]}]}`))
+	assert.True(t, ok)
+	assert.Equal(t, "hi", out)
+
+	out, ok = extractOutput(APIChat, []byte(`{"choices":[{"message":{"content":null,"tool_calls":[{"id":"1"}]}}]}`))
+	assert.True(t, ok)
+	assert.JSONEq(t, `[{"id":"1"}]`, out)
+}
+
+func TestValidateRequestFields(t *testing.T) {
+	assert.NoError(t, ValidateRequestFields(richTask(APIResponses)))
+	assert.Error(t, ValidateRequestFields(&Task{API: "graphql"}))
+	assert.Error(t, ValidateRequestFields(&Task{Prompt: "{{.Date"}))
+	assert.Error(t, ValidateRequestFields(&Task{Messages: []MessageTemplate{{Role: "tool", Content: "x"}}}))
+	assert.Error(t, ValidateRequestFields(&Task{ResponseSchema: json.RawMessage(`[]`)}))
+}
+
+func TestLoopbackExecutor_RecordsRequestAndPreviousOutput(t *testing.T) {
+	var captured []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"new report"}}]}`))
+	}))
+	defer server.Close()
+
+	dir, err := os.MkdirTemp("", "scheduler_rich_*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.AddLog(&ExecutionLog{TaskID: "t1", Success: true, Output: "old report"}))
+
+	executor := NewLoopbackExecutor(server.URL, "", store)
+	result, err := executor.Execute(context.Background(), &Task{ID: "t1", Model: "m", Prompt: "Last time: {{.PreviousOutput}}"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "new report", result.Output)
+	assert.Equal(t, string(captured), result.Request)
+	assert.Equal(t, "Last time: old report", gjson.GetBytes(captured, "messages.0.content").String())
+	assert.Contains(t, result.Response, "new report")
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultAttemptTimeout = 5 * time.Minute
+	defaultRetryBackoff   = 30 * time.Second
+	maxRetryBackoff       = 10 * time.Minute
+	maxTaskRetries        = 10
+)
+
+// retryPolicy is the parsed retry, timeout and pause settings of a task.
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	timeout    time.Duration
+	pauseAfter int
+}
+
+// retryPolicyFor snapshots a task's retry settings. Invalid durations fall back to defaults;
+// ValidateRetryFields rejects them at the API.
+func retryPolicyFor(task *Task) retryPolicy {
+	task.RLock()
+	defer task.RUnlock()
+	p := retryPolicy{
+		attempts:   1 + min(max(task.MaxRetries, 0), maxTaskRetries),
+		backoff:    defaultRetryBackoff,
+		timeout:    defaultAttemptTimeout,
+		pauseAfter: task.PauseAfter,
+	}
+	if d, err := time.ParseDuration(task.RetryBackoff); err == nil && d >= 0 {
+		p.backoff = d
+	}
+	if d, err := time.ParseDuration(task.Timeout); err == nil && d > 0 {
+		p.timeout = d
+	}
+	return p
+}
+
+// delay returns the wait before the given retry (1 for the first retry), doubling each time.
+func (p retryPolicy) delay(retry int) time.Duration {
+	d := p.backoff
+	for i := 1; i < retry && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// ValidateRetryFields checks a task's retry, timeout and auto-pause settings.
+func ValidateRetryFields(task *Task) error {
+	if task.MaxRetries < 0 || task.MaxRetries > maxTaskRetries {
+		return fmt.Errorf("max_retries must be between 0 and %d", maxTaskRetries)
+	}
+	if task.RetryBackoff != "" {
+		if d, err := time.ParseDuration(task.RetryBackoff); err != nil || d < 0 {
+			return fmt.Errorf("invalid retry_backoff %q", task.RetryBackoff)
+		}
+	}
+	if task.Timeout != "" {
+		if d, err := time.ParseDuration(task.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", task.Timeout)
+		}
+	}
+	if task.PauseAfter < 0 {
+		return fmt.Errorf("pause_after_failures must not be negative")
+	}
+	return nil
+}
+
+// sleep waits for d or until the engine stops, reporting whether the full wait elapsed.
+func (e *Engine) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-e.stopChan:
+		return false
+	}
+}
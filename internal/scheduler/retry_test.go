@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+// flakyExecutor fails the first failures calls, then succeeds.
+type flakyExecutor struct {
+	failures int32
+	calls    atomic.Int32
+}
+
+func (e *flakyExecutor) Execute(ctx context.Context, task *Task) (ExecutionResult, error) {
+	if e.calls.Add(1) <= e.failures {
+		return ExecutionResult{}, errors.New("upstream unavailable")
+	}
+	return ExecutionResult{Output: "done"}, nil
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := retryPolicyFor(&Task{MaxRetries: 3, RetryBackoff: "10s", Timeout: "1m"})
+	assert.Equal(t, 4, p.attempts)
+	assert.Equal(t, time.Minute, p.timeout)
+	assert.Equal(t, 10*time.Second, p.delay(1))
+	assert.Equal(t, 40*time.Second, p.delay(3))
+	assert.Equal(t, maxRetryBackoff, p.delay(20))
+}
+
+func TestValidateRetryFields(t *testing.T) {
+	assert.NoError(t, ValidateRetryFields(&Task{MaxRetries: 2, RetryBackoff: "5s", Timeout: "30s", PauseAfter: 3}))
+	assert.Error(t, ValidateRetryFields(&Task{MaxRetries: -1}))
+	assert.Error(t, ValidateRetryFields(&Task{MaxRetries: maxTaskRetries + 1}))
+	assert.Error(t, ValidateRetryFields(&Task{RetryBackoff: "soon"}))
+	assert.Error(t, ValidateRetryFields(&Task{Timeout: "0s"}))
+	assert.Error(t, ValidateRetryFields(&Task{PauseAfter: -1}))
+}
+
+func TestEngine_RetriesRecordEachAttempt(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	executor := &flakyExecutor{failures: 1}
+	engine := NewEngine(store, executor)
+
+	task := &Task{ID: "flaky", Name: "Flaky", Type: TaskTypeInterval, Status: TaskStatusActive, MaxRetries: 2, RetryBackoff: "1ms"}
+	require.NoError(t, store.AddTask(task))
+	engine.runTask(task)
+
+	logs := store.GetLogs()
+	require.Len(t, logs, 2)
+	byAttempt := map[int]*ExecutionLog{}
+	for _, l := range logs {
+		byAttempt[l.Attempt] = l
+	}
+	require.Contains(t, byAttempt, 1)
+	require.Contains(t, byAttempt, 2)
+	assert.Equal(t, byAttempt[1].RunID, byAttempt[2].RunID)
+	assert.False(t, byAttempt[1].Success)
+	assert.True(t, byAttempt[1].WillRetry)
+	assert.True(t, byAttempt[2].Success)
+	assert.False(t, byAttempt[2].WillRetry)
+	assert.Equal(t, 0, task.FailureCount)
+}
+
+func TestEngine_PausesAndAlertsAfterConsecutiveFailures(t *testing.T) {
+	var alerts atomic.Int32
+	var lastAlert []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastAlert, _ = io.ReadAll(r.Body)
+		alerts.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	engine := NewEngine(store, &flakyExecutor{failures: 100})
+
+	task := &Task{
+		ID: "broken", Name: "Broken", Type: TaskTypeInterval, Status: TaskStatusActive,
+		MaxRetries: 1, RetryBackoff: "1ms", PauseAfter: 2, FailureWebhook: server.URL,
+	}
+	require.NoError(t, store.AddTask(task))
+
+	engine.runTask(task)
+	assert.Equal(t, TaskStatusActive, task.Status)
+	assert.Equal(t, 1, task.FailureCount)
+
+	engine.runTask(task)
+	assert.Equal(t, TaskStatusPaused, task.Status)
+	assert.Equal(t, 2, task.FailureCount)
+
+	// Alerts go out once per failed run, not per attempt.
+	assert.Equal(t, int32(2), alerts.Load())
+	assert.Equal(t, "failure", gjson.GetBytes(lastAlert, "event").String())
+	assert.Equal(t, int64(2), gjson.GetBytes(lastAlert, "attempts").Int())
+	assert.True(t, gjson.GetBytes(lastAlert, "paused").Bool())
+
+	var finalStatuses []int
+	for _, l := range store.GetLogs() {
+		if !l.WillRetry {
+			finalStatuses = append(finalStatuses, l.WebhookStatus)
+		}
+	}
+	assert.Equal(t, []int{http.StatusAccepted, http.StatusAccepted}, finalStatuses)
+}
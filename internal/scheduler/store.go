@@ -1,23 +1,52 @@
 package scheduler
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// Store handles persistence of scheduled tasks and execution logs.
+// Store handles persistence of scheduled tasks and execution logs. Tasks are always held in
+// memory. The file backend (NewStore) also keeps the logs in memory, rewrites the tasks file on
+// every task change and batches log writes (see logFlushDelay); the SQLite backend
+// (NewSQLiteStore) writes each task and log as its own row and answers log queries from the
+// database.
 type Store struct {
 	mu        sync.RWMutex
 	tasksPath string
 	logsPath  string
+	db        *sql.DB
 	Tasks     map[string]*Task
 	Logs      []*ExecutionLog
 	maxLogs   int
+
+	flushMu    sync.Mutex
+	flushTimer *time.Timer
+}
+
+// logFlushDelay is how long the file backend waits after a new log before rewriting the logs
+// file, so a burst of executions costs one write instead of one per log.
+const logFlushDelay = 2 * time.Second
+
+// LogFilter selects execution logs in QueryLogs. Zero fields match everything.
+type LogFilter struct {
+	TaskID string
+	Since  time.Time
+	// Limit caps the number of logs returned, newest first.
+	Limit int
+}
+
+func (f LogFilter) match(entry *ExecutionLog) bool {
+	if f.TaskID != "" && entry.TaskID != f.TaskID {
+		return false
+	}
+	return f.Since.IsZero() || !entry.ExecutedAt.Before(f.Since)
 }
 
 // NewStore initializes a new Store instance and loads data from disk.
@@ -66,38 +95,104 @@ func (s *Store) load() error {
 	return nil
 }
 
-// Save persists the current state to disk.
+// Save persists the current state, including logs still waiting for a batched write. The
+// SQLite backend rewrites only the task rows, since logs are written as they are added.
 func (s *Store) Save() error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	if s.db != nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.saveTasksSQLite()
+	}
+	if err := s.saveTasks(); err != nil {
+		return err
+	}
+	return s.flushLogs()
+}
 
-	// Save Tasks
+func (s *Store) saveTasks() error {
+	s.mu.RLock()
 	data, err := json.MarshalIndent(s.Tasks, "", "  ")
+	s.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal tasks: %w", err)
 	}
-	if err := os.WriteFile(s.tasksPath, data, 0644); err != nil {
+	if err := writeFileAtomic(s.tasksPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write tasks file: %w", err)
 	}
+	return nil
+}
+
+// flushLogs cancels any pending batched write and rewrites the logs file now.
+func (s *Store) flushLogs() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
 
-	// Save Logs
-	data, err = json.MarshalIndent(s.Logs, "", "  ")
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s.Logs, "", "  ")
+	s.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal logs: %w", err)
 	}
-	if err := os.WriteFile(s.logsPath, data, 0644); err != nil {
+	if err := writeFileAtomic(s.logsPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write logs file: %w", err)
 	}
-
 	return nil
 }
 
+// scheduleLogFlush arranges for the logs file to be rewritten after logFlushDelay unless a
+// write is already pending.
+func (s *Store) scheduleLogFlush() {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	if s.flushTimer != nil {
+		return
+	}
+	s.flushTimer = time.AfterFunc(logFlushDelay, func() {
+		if err := s.flushLogs(); err != nil {
+			log.Warnf("failed to save scheduler logs: %v", err)
+		}
+	})
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over
+// path, so a crash mid-write leaves the previous contents intact instead of a truncated file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+	}
+	return err
+}
+
 // AddTask adds or updates a task and saves the store.
 func (s *Store) AddTask(task *Task) error {
 	s.mu.Lock()
 	s.Tasks[task.ID] = task
 	s.mu.Unlock()
-	return s.Save()
+	if s.db != nil {
+		return s.putTaskSQLite(task)
+	}
+	return s.saveTasks()
 }
 
 // DeleteTask removes a task and saves the store.
@@ -105,15 +200,20 @@ func (s *Store) DeleteTask(id string) error {
 	s.mu.Lock()
 	delete(s.Tasks, id)
 	s.mu.Unlock()
-	return s.Save()
+	if s.db != nil {
+		return s.deleteTaskSQLite(id)
+	}
+	return s.saveTasks()
 }
 
-// AddLog appends a new execution log, enforcing the max limit, and saves.
+// AddLog appends a new execution log, enforcing the max limit. The file backend writes the
+// logs file after logFlushDelay, or earlier on Save or Close.
 func (s *Store) AddLog(entry *ExecutionLog) error {
+	if s.db != nil {
+		return s.addLogSQLite(entry)
+	}
 	s.mu.Lock()
-	// Prepend for newer-first order logic, or append and sort?
-	// Let's prepend to keep list sorted by time descending implicitly if used that way.
-	// Actually, appending is faster, we can reverse on read.
+	// Appending is faster; readers walk the slice backwards for newest-first order.
 	s.Logs = append(s.Logs, entry)
 
 	// Trim if needed
@@ -123,8 +223,8 @@ func (s *Store) AddLog(entry *ExecutionLog) error {
 	}
 	s.mu.Unlock()
 
-	// Debounce save? For simplicity, save immediately for now.
-	return s.Save()
+	s.scheduleLogFlush()
+	return nil
 }
 
 // GetTasks returns a list of all tasks.
@@ -146,24 +246,75 @@ func (s *Store) GetTask(id string) (*Task, bool) {
 	return t, ok
 }
 
-// GetLogs returns all logs (optionally filtered could be added later).
+// GetLogs returns all logs, newest first.
 func (s *Store) GetLogs() []*ExecutionLog {
+	return s.QueryLogs(LogFilter{})
+}
+
+// QueryLogs returns the logs matching filter, newest first.
+func (s *Store) QueryLogs(filter LogFilter) []*ExecutionLog {
+	if s.db != nil {
+		logs, err := s.queryLogsSQLite(filter)
+		if err != nil {
+			log.Warnf("failed to query scheduler logs: %v", err)
+		}
+		return logs
+	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	// Return copy
-	logs := make([]*ExecutionLog, len(s.Logs))
-	copy(logs, s.Logs)
-	// Reverse to show newest first
-	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
-		logs[i], logs[j] = logs[j], logs[i]
+	logs := make([]*ExecutionLog, 0, len(s.Logs))
+	for i := len(s.Logs) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(logs) >= filter.Limit {
+			break
+		}
+		if entry := s.Logs[i]; filter.match(entry) {
+			logs = append(logs, entry)
+		}
 	}
 	return logs
 }
 
+// LastOutput returns the output of the task's most recent successful run, or "".
+func (s *Store) LastOutput(taskID string) string {
+	if s.db != nil {
+		output, err := s.lastOutputSQLite(taskID)
+		if err != nil {
+			log.Warnf("failed to read last output of task %s: %v", taskID, err)
+		}
+		return output
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.Logs) - 1; i >= 0; i-- {
+		if entry := s.Logs[i]; entry.TaskID == taskID && entry.Success {
+			return entry.Output
+		}
+	}
+	return ""
+}
+
 // ClearLogs removes all execution logs and saves.
 func (s *Store) ClearLogs() error {
+	if s.db != nil {
+		return s.clearLogsSQLite()
+	}
 	s.mu.Lock()
 	s.Logs = make([]*ExecutionLog, 0)
 	s.mu.Unlock()
-	return s.Save()
+	return s.flushLogs()
+}
+
+// Close releases the database of the SQLite backend. The file backend writes any logs still
+// waiting for a batched write.
+func (s *Store) Close() error {
+	if s.db == nil {
+		s.flushMu.Lock()
+		pending := s.flushTimer != nil
+		s.flushMu.Unlock()
+		if !pending {
+			return nil
+		}
+		return s.flushLogs()
+	}
+	return s.db.Close()
 }
@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cliproxy/internal/sqlitedb"
+	log "github.com/sirupsen/logrus"
+)
+
+// sqliteMaxLogs is the log retention of the SQLite backend. Logs are indexed rows rather than
+// one JSON document, so it keeps a longer history than the file backend.
+const sqliteMaxLogs = 10000
+
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS scheduler_tasks (
+		id   TEXT PRIMARY KEY,
+		data TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS scheduler_logs (
+		seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+		id          TEXT NOT NULL,
+		task_id     TEXT NOT NULL,
+		executed_at INTEGER NOT NULL,
+		success     INTEGER NOT NULL,
+		data        TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS scheduler_logs_task ON scheduler_logs (task_id, seq)`,
+	`CREATE INDEX IF NOT EXISTS scheduler_logs_executed_at ON scheduler_logs (executed_at)`,
+}
+
+// NewSQLiteStore opens the scheduler tables in the SQLite database at path
+// (sqlitedb.DefaultPath when empty) and loads the tasks.
+func NewSQLiteStore(path string) (*Store, error) {
+	db, err := sqlitedb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range sqliteSchema {
+		if _, err = db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create scheduler tables: %w", err)
+		}
+	}
+
+	s := &Store{
+		db:      db,
+		Tasks:   make(map[string]*Task),
+		Logs:    make([]*ExecutionLog, 0),
+		maxLogs: sqliteMaxLogs,
+	}
+	rows, err := db.Query(`SELECT id, data FROM scheduler_tasks`)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id, data string
+		if err = rows.Scan(&id, &data); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to read tasks: %w", err)
+		}
+		task := &Task{}
+		if errUnmarshal := json.Unmarshal([]byte(data), task); errUnmarshal != nil {
+			log.Warnf("failed to unmarshal scheduler task %s: %v", id, errUnmarshal)
+			continue
+		}
+		s.Tasks[id] = task
+	}
+	if err = rows.Err(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Store) putTaskSQLite(task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO scheduler_tasks (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`, task.ID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write task: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) deleteTaskSQLite(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM scheduler_tasks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return nil
+}
+
+// saveTasksSQLite rewrites every task row in one transaction. The caller holds s.mu.
+func (s *Store) saveTasksSQLite() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for id, task := range s.Tasks {
+		data, errMarshal := json.Marshal(task)
+		if errMarshal != nil {
+			return fmt.Errorf("failed to marshal task: %w", errMarshal)
+		}
+		if _, err = tx.Exec(`INSERT INTO scheduler_tasks (id, data) VALUES (?, ?)
+			ON CONFLICT(id) DO UPDATE SET data = excluded.data`, id, string(data)); err != nil {
+			return fmt.Errorf("failed to write tasks: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+	return nil
+}
+
+// addLogSQLite inserts entry and drops the oldest rows beyond the retention limit.
+func (s *Store) addLogSQLite(entry *ExecutionLog) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log: %w", err)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write log: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err = tx.Exec(`INSERT INTO scheduler_logs (id, task_id, executed_at, success, data) VALUES (?, ?, ?, ?, ?)`,
+		entry.ID, entry.TaskID, entry.ExecutedAt.UnixMilli(), entry.Success, string(data)); err != nil {
+		return fmt.Errorf("failed to write log: %w", err)
+	}
+	if _, err = tx.Exec(`DELETE FROM scheduler_logs WHERE seq <= (
+		SELECT seq FROM scheduler_logs ORDER BY seq DESC LIMIT 1 OFFSET ?)`, s.maxLogs); err != nil {
+		return fmt.Errorf("failed to trim logs: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write log: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) queryLogsSQLite(filter LogFilter) ([]*ExecutionLog, error) {
+	var where []string
+	var args []any
+	if filter.TaskID != "" {
+		where = append(where, "task_id = ?")
+		args = append(args, filter.TaskID)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, filter.Since.UnixMilli())
+	}
+	query := `SELECT data FROM scheduler_logs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY seq DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	logs := make([]*ExecutionLog, 0)
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return logs, err
+		}
+		entry := &ExecutionLog{}
+		if errUnmarshal := json.Unmarshal([]byte(data), entry); errUnmarshal != nil {
+			continue
+		}
+		logs = append(logs, entry)
+	}
+	return logs, rows.Err()
+}
+
+func (s *Store) lastOutputSQLite(taskID string) (string, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM scheduler_logs WHERE task_id = ? AND success = 1 ORDER BY seq DESC LIMIT 1`, taskID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var entry ExecutionLog
+	if err = json.Unmarshal([]byte(data), &entry); err != nil {
+		return "", err
+	}
+	return entry.Output, nil
+}
+
+func (s *Store) clearLogsSQLite() error {
+	if _, err := s.db.Exec(`DELETE FROM scheduler_logs`); err != nil {
+		return fmt.Errorf("failed to clear logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_SaveIsAtomicAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.AddTask(&Task{ID: "t1", Name: "ping", Type: TaskTypeInterval, Interval: "1h"}))
+	assert.NoError(t, store.AddLog(&ExecutionLog{ID: "l1", TaskID: "t1"}))
+	_, err = os.Stat(filepath.Join(dir, "scheduler_logs.json"))
+	assert.True(t, os.IsNotExist(err), "logs are written in batches, not on every AddLog")
+	assert.NoError(t, store.Close())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"scheduler_tasks.json", "scheduler_logs.json"}, names, "temporary files must not be left behind")
+
+	reloaded, err := NewStore(dir)
+	assert.NoError(t, err)
+	task, ok := reloaded.GetTask("t1")
+	assert.True(t, ok)
+	assert.Equal(t, "ping", task.Name)
+	assert.Len(t, reloaded.GetLogs(), 1)
+}
+
+func TestSQLiteStore_PersistsRowsAndQueriesLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cliproxy.db")
+	store, err := NewSQLiteStore(path)
+	assert.NoError(t, err)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.AddTask(&Task{ID: "t1", Name: "ping", Type: TaskTypeInterval, Interval: "1h"}))
+	assert.NoError(t, store.AddTask(&Task{ID: "t2", Name: "pong", Type: TaskTypeInterval, Interval: "2h"}))
+	assert.NoError(t, store.DeleteTask("t2"))
+	for i := 0; i < 4; i++ {
+		taskID := "t1"
+		if i%2 == 1 {
+			taskID = "t9"
+		}
+		assert.NoError(t, store.AddLog(&ExecutionLog{
+			ID:         fmt.Sprintf("l%d", i),
+			TaskID:     taskID,
+			ExecutedAt: base.Add(time.Duration(i) * time.Hour),
+			Success:    i < 2,
+			Output:     fmt.Sprintf("out-%d", i),
+		}))
+	}
+	assert.NoError(t, store.Close())
+
+	reloaded, err := NewSQLiteStore(path)
+	assert.NoError(t, err)
+	defer func() { _ = reloaded.Close() }()
+	task, ok := reloaded.GetTask("t1")
+	assert.True(t, ok)
+	assert.Equal(t, "ping", task.Name)
+	_, ok = reloaded.GetTask("t2")
+	assert.False(t, ok)
+
+	logs := reloaded.GetLogs()
+	assert.Len(t, logs, 4)
+	assert.Equal(t, "l3", logs[0].ID, "logs are returned newest first")
+	assert.Len(t, reloaded.QueryLogs(LogFilter{TaskID: "t1"}), 2)
+	assert.Len(t, reloaded.QueryLogs(LogFilter{Since: base.Add(2 * time.Hour)}), 2)
+	assert.Len(t, reloaded.QueryLogs(LogFilter{Limit: 1}), 1)
+	assert.Equal(t, "out-0", reloaded.LastOutput("t1"))
+
+	reloaded.maxLogs = 2
+	assert.NoError(t, reloaded.AddLog(&ExecutionLog{ID: "l4", TaskID: "t1", ExecutedAt: base.Add(4 * time.Hour)}))
+	logs = reloaded.GetLogs()
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "l4", logs[0].ID)
+
+	assert.NoError(t, reloaded.ClearLogs())
+	assert.Empty(t, reloaded.GetLogs())
+}
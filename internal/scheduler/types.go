@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,8 +15,46 @@ const (
 	TaskTypeFixedTime    TaskType = "fixed_time"
 	TaskTypeDaily        TaskType = "daily"
 	TaskTypeSystemReport TaskType = "system_report"
+	TaskTypeCron         TaskType = "cron"
+
+	// Credential health tasks run on Interval, Cron or DailyTime, whichever is set.
+	TaskTypeCredentialProbe  TaskType = "credential_probe"  // Probe credentials with a minimal request
+	TaskTypeCredentialReport TaskType = "credential_report" // Summarise per-credential traffic and state
+)
+
+// CatchUpPolicy decides what happens at startup to runs missed while the process was down.
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip CatchUpPolicy = "skip" // Drop missed runs and wait for the next one (default)
+	CatchUpOnce CatchUpPolicy = "once" // Run once for all missed runs
+	CatchUpAll  CatchUpPolicy = "all"  // Run every missed run, up to maxCatchUpRuns
+)
+
+// APISurface selects the loopback endpoint a task calls.
+type APISurface string
+
+const (
+	APIChat      APISurface = "chat"      // /v1/chat/completions (default)
+	APIResponses APISurface = "responses" // /v1/responses
+	APIMessages  APISurface = "messages"  // /v1/messages
 )
 
+// MessageTemplate is one turn of a task's conversation. Content is a Go text/template that may
+// reference the variables described by TemplateData.
+type MessageTemplate struct {
+	Role    string `json:"role"` // system, user or assistant
+	Content string `json:"content"`
+}
+
+// GenerationParams holds optional sampling parameters sent with a task's request.
+type GenerationParams struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
 // TaskStatus defines the operational status of a task.
 type TaskStatus string
 
@@ -27,21 +66,47 @@ const (
 
 // Task represents a scheduled AI job.
 type Task struct {
-	mu           sync.RWMutex
-	ID           string     `json:"id"`
-	Name         string     `json:"name"`
-	Type         TaskType   `json:"type"`       // "interval" or "fixed_time"
-	Interval     string     `json:"interval"`   // e.g. "30m", "1h" (only for TaskTypeInterval)
-	FixedTime    *time.Time `json:"fixed_time"` // ISO timestamp (only for TaskTypeFixedTime)
-	DailyTime    string     `json:"daily_time"` // e.g. "09:00,18:00" (only for TaskTypeDaily)
-	Prompt       string     `json:"prompt"`
-	Model        string     `json:"model"`
-	WebhookURL   string     `json:"webhook_url"`
-	Status       TaskStatus `json:"status"`
-	CreatedAt    time.Time  `json:"created_at"`
-	LastRunAt    *time.Time `json:"last_run_at"`
-	NextRunAt    *time.Time `json:"next_run_at"`
-	FailureCount int        `json:"failure_count"`
+	mu             sync.RWMutex
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Type           TaskType              `json:"type"`       // interval, fixed_time, daily, cron, system_report, credential_probe or credential_report
+	Interval       string                `json:"interval"`   // e.g. "30m", "1h" (only for TaskTypeInterval)
+	FixedTime      *time.Time            `json:"fixed_time"` // ISO timestamp (only for TaskTypeFixedTime)
+	DailyTime      string                `json:"daily_time"` // e.g. "09:00,18:00" (only for TaskTypeDaily)
+	Cron           string                `json:"cron"`       // e.g. "0 9 * * 1-5" (only for TaskTypeCron)
+	Timezone       string                `json:"timezone"`   // IANA zone for daily and cron tasks, e.g. "Europe/Berlin"
+	CatchUp        CatchUpPolicy         `json:"catch_up"`   // skip (default), once or all
+	Prompt         string                `json:"prompt"`     // Final user message, rendered as a template
+	Model          string                `json:"model"`
+	API            APISurface            `json:"api"`                  // chat (default), responses or messages
+	SystemPrompt   string                `json:"system_prompt"`        // Rendered as a template
+	Messages       []MessageTemplate     `json:"messages"`             // Turns sent before Prompt
+	Parameters     *GenerationParams     `json:"parameters"`           // Optional sampling parameters
+	Tools          json.RawMessage       `json:"tools"`                // OpenAI chat tool definitions
+	ResponseSchema json.RawMessage       `json:"response_schema"`      // JSON schema for structured output
+	Metadata       map[string]string     `json:"metadata"`             // Exposed to templates as .Metadata
+	Credentials    *CredentialFilter     `json:"credentials"`          // Credentials covered by credential tasks
+	ReportWindow   string                `json:"report_window"`        // credential_report window, e.g. "24h" (default)
+	WebhookURL     string                `json:"webhook_url"`          // Legacy: receives successful results
+	Notifications  []NotificationChannel `json:"notifications"`        // Channels notified of results and failures
+	MaxRetries     int                   `json:"max_retries"`          // Extra attempts after a failed one
+	RetryBackoff   string                `json:"retry_backoff"`        // First retry delay, doubled per retry, e.g. "30s"
+	Timeout        string                `json:"timeout"`              // Per-attempt timeout, e.g. "2m" (default 5m)
+	PauseAfter     int                   `json:"pause_after_failures"` // Pause after N consecutive failed runs (0 = never)
+	FailureWebhook string                `json:"failure_webhook_url"`  // Legacy: notified when a run fails after all attempts
+	Status         TaskStatus            `json:"status"`
+	CreatedAt      time.Time             `json:"created_at"`
+	LastRunAt      *time.Time            `json:"last_run_at"`
+	NextRunAt      *time.Time            `json:"next_run_at"`
+	FailureCount   int                   `json:"failure_count"`
+}
+
+// Location returns the task's time zone, defaulting to the server's local zone.
+func (t *Task) Location() (*time.Location, error) {
+	if strings.TrimSpace(t.Timezone) == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(strings.TrimSpace(t.Timezone))
 }
 
 func (t *Task) Lock()    { t.mu.Lock() }
@@ -64,12 +129,27 @@ func (t *Task) MarshalJSON() ([]byte, error) {
 
 // ExecutionLog records the result of a task run.
 type ExecutionLog struct {
-	ID            string    `json:"id"`
-	TaskID        string    `json:"task_id"`
-	TaskName      string    `json:"task_name"`
-	ExecutedAt    time.Time `json:"executed_at"`
-	DurationMs    int64     `json:"duration_ms"`
-	Success       bool      `json:"success"`
-	Output        string    `json:"output"`         // AI response or error message
-	WebhookStatus int       `json:"webhook_status"` // HTTP status code of webhook, 0 if not sent
+	ID            string           `json:"id"`
+	RunID         string           `json:"run_id"`     // Shared by all attempts of one run
+	Attempt       int              `json:"attempt"`    // 1-based attempt number within the run
+	WillRetry     bool             `json:"will_retry"` // A failed attempt that will be retried
+	TaskID        string           `json:"task_id"`
+	TaskName      string           `json:"task_name"`
+	ExecutedAt    time.Time        `json:"executed_at"`
+	DurationMs    int64            `json:"duration_ms"`
+	Success       bool             `json:"success"`
+	Output        string           `json:"output"`                  // AI response or error message
+	WebhookStatus int              `json:"webhook_status"`          // HTTP status of the first notification delivery, 0 if none
+	Notifications []DeliveryStatus `json:"notifications,omitempty"` // Delivery result per notified channel
+	Probes        []ProbeResult    `json:"probes,omitempty"`        // Per-credential results of credential_probe runs
+	Request       string           `json:"request,omitempty"`       // Rendered request body sent upstream
+	Response      string           `json:"response,omitempty"`      // Raw response body
+}
+
+// ExecutionResult is what an Executor returns for one run.
+type ExecutionResult struct {
+	Output   string
+	Request  string
+	Response string
+	Probes   []ProbeResult
 }
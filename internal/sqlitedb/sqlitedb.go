@@ -0,0 +1,49 @@
+// Package sqlitedb opens the SQLite database used by the "sqlite" storage backend.
+// It uses the pure-Go modernc.org/sqlite driver, so builds stay free of cgo.
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cliproxy/internal/util"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultPath is the database file used when storage.sqlite-path is empty:
+// data/cliproxy.db under WRITABLE_PATH when set, else under the working directory.
+func DefaultPath() string {
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "data", "cliproxy.db")
+	}
+	return filepath.Join("data", "cliproxy.db")
+}
+
+// Open opens (creating if needed) the database at path in WAL mode with a busy timeout, so the
+// server and one-off commands such as --login can share the file. A single connection is used
+// per process; SQLite serialises writers anyway and this avoids SQLITE_BUSY between them.
+func Open(path string) (*sql.DB, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = DefaultPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite: create directory failed: %w", err)
+	}
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: open %s failed: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite: open %s failed: %w", path, err)
+	}
+	return db, nil
+}
@@ -126,6 +126,9 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// response_format -> request.generationConfig.responseMimeType / responseJsonSchema
+	out = common.ApplyResponseFormat(out, "request.generationConfig", rawJSON)
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -0,0 +1,102 @@
+// Package common provides helpers shared by the translators that target the Claude API.
+package common
+
+import (
+	"cliproxy/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StructuredOutputToolName is the tool Claude is forced to call when an OpenAI client asks for
+// JSON output. Claude has no JSON mode, so the requested schema becomes the tool's input schema
+// and the tool input is returned to the client as the message content.
+const StructuredOutputToolName = "structured_output"
+
+// structuredOutputValueKey holds the answer when a non-object schema is wrapped in an object,
+// since Claude tool inputs must be objects.
+const structuredOutputValueKey = "value"
+
+const structuredOutputDescription = "Return the final answer by calling this tool. Its input is delivered to the user verbatim as a JSON document, so put the complete answer in it and do not answer in plain text."
+
+// ApplyStructuredOutput adds the structured output tool to a Claude request when the OpenAI
+// request openAIRequest carries a response_format or text.format asking for JSON. Claude is
+// forced to call the tool, or to call any tool when the client supplied tools of its own so
+// function calling keeps working unless the client disabled it. The request is returned
+// unchanged when a client tool already uses the reserved name.
+func ApplyStructuredOutput(out string, openAIRequest []byte) string {
+	format, ok := util.StructuredOutputFormat(openAIRequest)
+	if !ok {
+		return out
+	}
+	tools := gjson.Get(out, "tools").Array()
+	for _, tool := range tools {
+		if tool.Get("name").String() == StructuredOutputToolName {
+			return out
+		}
+	}
+
+	schema := format.Schema
+	switch {
+	case schema == "":
+		// json_object requests have no schema at all.
+		schema = `{"type":"object"}`
+	case structuredOutputWrapped(schema):
+		// Tool inputs are always objects; UnwrapStructuredOutput strips the wrapper again.
+		wrapped := `{"type":"object","properties":{},"required":[]}`
+		wrapped, _ = sjson.SetRaw(wrapped, "properties."+structuredOutputValueKey, schema)
+		wrapped, _ = sjson.Set(wrapped, "required.-1", structuredOutputValueKey)
+		schema = wrapped
+	}
+	description := structuredOutputDescription
+	if format.Description != "" {
+		description += " " + format.Description
+	}
+	tool := `{"name":"","description":"","input_schema":{}}`
+	tool, _ = sjson.Set(tool, "name", StructuredOutputToolName)
+	tool, _ = sjson.Set(tool, "description", description)
+	tool, _ = sjson.SetRaw(tool, "input_schema", schema)
+	if !gjson.Get(out, "tools").IsArray() {
+		out, _ = sjson.SetRaw(out, "tools", `[]`)
+	}
+	out, _ = sjson.SetRaw(out, "tools.-1", tool)
+
+	switch {
+	case gjson.Get(out, "tool_choice.type").String() == "tool":
+		// The client forced one of its own tools; honour that for this turn.
+	case len(tools) > 0 && gjson.GetBytes(openAIRequest, "tool_choice").String() != "none":
+		out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"any"}`)
+	default:
+		out, _ = sjson.Set(out, "tool_choice", map[string]string{"type": "tool", "name": StructuredOutputToolName})
+	}
+	return out
+}
+
+// IsStructuredOutputTool reports whether a Claude tool_use block named name carries the
+// structured output requested by openAIRequest rather than a real function call.
+func IsStructuredOutputTool(name string, openAIRequest []byte) bool {
+	if name != StructuredOutputToolName {
+		return false
+	}
+	_, ok := util.StructuredOutputFormat(openAIRequest)
+	return ok
+}
+
+// StructuredOutputWrapped reports whether the schema requested by openAIRequest is not an
+// object and was therefore wrapped by ApplyStructuredOutput.
+func StructuredOutputWrapped(openAIRequest []byte) bool {
+	format, ok := util.StructuredOutputFormat(openAIRequest)
+	return ok && structuredOutputWrapped(format.Schema)
+}
+
+// UnwrapStructuredOutput returns the answer held in a complete structured output tool input.
+// Inputs for object schemas are returned unchanged.
+func UnwrapStructuredOutput(input string, openAIRequest []byte) string {
+	if !StructuredOutputWrapped(openAIRequest) {
+		return input
+	}
+	return gjson.Get(input, structuredOutputValueKey).Raw
+}
+
+func structuredOutputWrapped(schema string) bool {
+	return schema != "" && gjson.Get(schema, "type").String() != "object"
+}
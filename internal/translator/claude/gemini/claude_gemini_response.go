@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -284,10 +285,17 @@ func convertArrayToJSON(arr []interface{}) string {
 	return result
 }
 
-// convertMapToJSON converts map[string]interface{} to JSON object string
+// convertMapToJSON converts map[string]interface{} to JSON object string, with keys in sorted
+// order so the output is stable.
 func convertMapToJSON(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	result := "{}"
-	for key, value := range m {
+	for _, key := range keys {
+		value := m[key]
 		switch val := value.(type) {
 		case map[string]interface{}:
 			nestedJSON := convertMapToJSON(val)
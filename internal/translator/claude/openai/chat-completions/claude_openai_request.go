@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"cliproxy/internal/translator/claude/common"
 	"cliproxy/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -346,5 +347,8 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// response_format / text.format -> forced structured output tool
+	out = common.ApplyStructuredOutput(out, rawJSON)
+
 	return []byte(out)
 }
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"cliproxy/internal/translator/claude/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -27,6 +28,13 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	FinishReason string
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// StructuredOutputBlocks marks content blocks that carry response_format output
+	StructuredOutputBlocks map[int]bool
+	// StructuredOutputInputs buffers wrapped structured output until its block completes
+	StructuredOutputInputs map[int]*strings.Builder
+	// StructuredOutputSent and ToolCallsSent decide how a tool_use stop reason is reported
+	StructuredOutputSent bool
+	ToolCallsSent        bool
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -114,6 +122,15 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				toolName := contentBlock.Get("name").String()
 				index := int(root.Get("index").Int())
 
+				if common.IsStructuredOutputTool(toolName, originalRequestRawJSON) {
+					// Structured output is streamed as message content, not as a tool call
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputBlocks == nil {
+						(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputBlocks = make(map[int]bool)
+					}
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputBlocks[index] = true
+					return []string{}
+				}
+
 				if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
 					(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 				}
@@ -152,6 +169,27 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				// Tool use input delta - accumulate arguments for tool calls
 				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 					index := int(root.Get("index").Int())
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputBlocks[index] {
+						if partialJSON.String() == "" {
+							return []string{}
+						}
+						if common.StructuredOutputWrapped(originalRequestRawJSON) {
+							// The answer can only be unwrapped once the whole input is known
+							if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputInputs == nil {
+								(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputInputs = make(map[int]*strings.Builder)
+							}
+							input := (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputInputs[index]
+							if input == nil {
+								input = &strings.Builder{}
+								(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputInputs[index] = input
+							}
+							input.WriteString(partialJSON.String())
+							return []string{}
+						}
+						(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputSent = true
+						template, _ = sjson.Set(template, "choices.0.delta.content", partialJSON.String())
+						return []string{template}
+					}
 					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
@@ -171,6 +209,21 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	case "content_block_stop":
 		// End of content block - output complete tool call if it's a tool_use block
 		index := int(root.Get("index").Int())
+		if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputBlocks[index] {
+			delete((*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputBlocks, index)
+			input, buffered := (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputInputs[index]
+			if !buffered {
+				return []string{}
+			}
+			delete((*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputInputs, index)
+			value := common.UnwrapStructuredOutput(input.String(), originalRequestRawJSON)
+			if value == "" {
+				return []string{}
+			}
+			(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputSent = true
+			template, _ = sjson.Set(template, "choices.0.delta.content", value)
+			return []string{template}
+		}
 		if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 			if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
 				// Build complete tool call with accumulated arguments
@@ -193,6 +246,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 				// Clean up the accumulator for this index
 				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsSent = true
 
 				return []string{template}
 			}
@@ -204,6 +258,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
 				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				// A forced structured output call ends the turn like a plain answer
+				if stopReason.String() == "tool_use" && (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutputSent && !(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsSent {
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = "stop"
+				}
 				template, _ = sjson.Set(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
 			}
 		}
@@ -302,6 +360,10 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	toolCallsMap := make(map[int]map[string]interface{})
 	// Track tool call arguments accumulation
 	toolCallArgsMap := make(map[int]strings.Builder)
+	// Track blocks carrying response_format output, which become message content
+	structuredOutputBlocks := make(map[int]bool)
+	structuredOutputInputs := make(map[int]*strings.Builder)
+	structuredOutputWrapped := common.StructuredOutputWrapped(originalRequestRawJSON)
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -329,6 +391,10 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				} else if blockType == "tool_use" {
 					// Initialize tool call tracking for this index
 					index := int(root.Get("index").Int())
+					if common.IsStructuredOutputTool(contentBlock.Get("name").String(), originalRequestRawJSON) {
+						structuredOutputBlocks[index] = true
+						continue
+					}
 					toolCallsMap[index] = map[string]interface{}{
 						"id":   contentBlock.Get("id").String(),
 						"type": "function",
@@ -361,6 +427,18 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					// Accumulate tool call arguments
 					if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 						index := int(root.Get("index").Int())
+						if structuredOutputBlocks[index] {
+							if !structuredOutputWrapped {
+								contentParts = append(contentParts, partialJSON.String())
+							} else if input, exists := structuredOutputInputs[index]; exists {
+								input.WriteString(partialJSON.String())
+							} else {
+								input = &strings.Builder{}
+								input.WriteString(partialJSON.String())
+								structuredOutputInputs[index] = input
+							}
+							continue
+						}
 						if builder, exists := toolCallArgsMap[index]; exists {
 							builder.WriteString(partialJSON.String())
 							toolCallArgsMap[index] = builder
@@ -372,6 +450,10 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		case "content_block_stop":
 			// Finalize tool call arguments for this index when content block ends
 			index := int(root.Get("index").Int())
+			if input, exists := structuredOutputInputs[index]; exists {
+				contentParts = append(contentParts, common.UnwrapStructuredOutput(input.String(), originalRequestRawJSON))
+				delete(structuredOutputInputs, index)
+			}
 			if toolCall, exists := toolCallsMap[index]; exists {
 				if builder, argsExists := toolCallArgsMap[index]; argsExists {
 					// Set the accumulated arguments for the tool call
@@ -439,6 +521,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		} else {
 			out, _ = sjson.Set(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
 		}
+	} else if stopReason == "tool_use" && len(structuredOutputBlocks) > 0 {
+		// A forced structured output call ends the turn like a plain answer
+		out, _ = sjson.Set(out, "choices.0.finish_reason", "stop")
 	} else {
 		out, _ = sjson.Set(out, "choices.0.finish_reason", mapAnthropicStopReasonToOpenAI(stopReason))
 	}
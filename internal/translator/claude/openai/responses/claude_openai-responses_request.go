@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"cliproxy/internal/translator/claude/common"
 	"cliproxy/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -325,5 +326,8 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		}
 	}
 
+	// response_format / text.format -> forced structured output tool
+	out = common.ApplyStructuredOutput(out, rawJSON)
+
 	return []byte(out)
 }
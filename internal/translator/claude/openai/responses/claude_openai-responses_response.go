@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"cliproxy/internal/translator/claude/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -26,6 +27,10 @@ type claudeToResponsesState struct {
 	FuncCallIDs map[int]string // index -> call id
 	// message text aggregation
 	TextBuf strings.Builder
+	// tool_use blocks carrying response_format output, delivered as message text
+	StructuredBlocks map[int]bool
+	// wrapped structured output, held back until its block completes
+	StructuredBufs map[int]*strings.Builder
 	// reasoning state
 	ReasoningActive    bool
 	ReasoningItemID    string
@@ -47,7 +52,7 @@ func emitEvent(event string, payload string) string {
 // ConvertClaudeResponseToOpenAIResponses converts Claude SSE to OpenAI Responses SSE events.
 func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	if *param == nil {
-		*param = &claudeToResponsesState{FuncArgsBuf: make(map[int]*strings.Builder), FuncNames: make(map[int]string), FuncCallIDs: make(map[int]string)}
+		*param = &claudeToResponsesState{FuncArgsBuf: make(map[int]*strings.Builder), FuncNames: make(map[int]string), FuncCallIDs: make(map[int]string), StructuredBlocks: make(map[int]bool)}
 	}
 	st := (*param).(*claudeToResponsesState)
 
@@ -81,6 +86,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			st.FuncArgsBuf = make(map[int]*strings.Builder)
 			st.FuncNames = make(map[int]string)
 			st.FuncCallIDs = make(map[int]string)
+			st.StructuredBlocks = make(map[int]bool)
 			st.InputTokens = 0
 			st.OutputTokens = 0
 			st.UsageSeen = false
@@ -114,6 +120,11 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		}
 		idx := int(root.Get("index").Int())
 		typ := cb.Get("type").String()
+		if typ == "tool_use" && common.IsStructuredOutputTool(cb.Get("name").String(), originalRequestRawJSON) {
+			// Structured output is delivered as message text, not as a function call
+			st.StructuredBlocks[idx] = true
+			typ = "text"
+		}
 		switch typ {
 		case "text":
 			// open message item + content part
@@ -183,7 +194,22 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			}
 		case "input_json_delta":
 			idx := int(root.Get("index").Int())
-			if pj := d.Get("partial_json"); pj.Exists() {
+			if pj := d.Get("partial_json"); pj.Exists() && st.StructuredBlocks[idx] && common.StructuredOutputWrapped(originalRequestRawJSON) {
+				if st.StructuredBufs == nil {
+					st.StructuredBufs = make(map[int]*strings.Builder)
+				}
+				if st.StructuredBufs[idx] == nil {
+					st.StructuredBufs[idx] = &strings.Builder{}
+				}
+				st.StructuredBufs[idx].WriteString(pj.String())
+			} else if pj.Exists() && st.StructuredBlocks[idx] {
+				msg := `{"type":"response.output_text.delta","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"delta":"","logprobs":[]}`
+				msg, _ = sjson.Set(msg, "sequence_number", nextSeq())
+				msg, _ = sjson.Set(msg, "item_id", st.CurrentMsgID)
+				msg, _ = sjson.Set(msg, "delta", pj.String())
+				out = append(out, emitEvent("response.output_text.delta", msg))
+				st.TextBuf.WriteString(pj.String())
+			} else if pj.Exists() {
 				if st.FuncArgsBuf[idx] == nil {
 					st.FuncArgsBuf[idx] = &strings.Builder{}
 				}
@@ -210,6 +236,17 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		}
 	case "content_block_stop":
 		idx := int(root.Get("index").Int())
+		if buf, ok := st.StructuredBufs[idx]; ok {
+			delete(st.StructuredBufs, idx)
+			if value := common.UnwrapStructuredOutput(buf.String(), originalRequestRawJSON); value != "" {
+				msg := `{"type":"response.output_text.delta","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"delta":"","logprobs":[]}`
+				msg, _ = sjson.Set(msg, "sequence_number", nextSeq())
+				msg, _ = sjson.Set(msg, "item_id", st.CurrentMsgID)
+				msg, _ = sjson.Set(msg, "delta", value)
+				out = append(out, emitEvent("response.output_text.delta", msg))
+				st.TextBuf.WriteString(value)
+			}
+		}
 		if st.InTextBlock {
 			done := `{"type":"response.output_text.done","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"text":"","logprobs":[]}`
 			done, _ = sjson.Set(done, "sequence_number", nextSeq())
@@ -482,6 +519,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		args strings.Builder
 	}
 	toolCalls := make(map[int]*toolState)
+	// tool_use blocks carrying response_format output, delivered as message text
+	structuredBlocks := make(map[int]bool)
+	structuredBufs := make(map[int]*strings.Builder)
+	structuredWrapped := common.StructuredOutputWrapped(originalRequestRawJSON)
 
 	// Walk through SSE chunks to fill state
 	for _, ch := range chunks {
@@ -505,6 +546,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			}
 			idx := int(root.Get("index").Int())
 			typ := cb.Get("type").String()
+			if typ == "tool_use" && common.IsStructuredOutputTool(cb.Get("name").String(), originalRequestRawJSON) {
+				structuredBlocks[idx] = true
+				typ = "text"
+			}
 			switch typ {
 			case "text":
 				currentMsgID = "msg_" + responseID + "_0"
@@ -536,6 +581,17 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			case "input_json_delta":
 				if pj := d.Get("partial_json"); pj.Exists() {
 					idx := int(root.Get("index").Int())
+					if structuredBlocks[idx] && structuredWrapped {
+						if structuredBufs[idx] == nil {
+							structuredBufs[idx] = &strings.Builder{}
+						}
+						structuredBufs[idx].WriteString(pj.String())
+						continue
+					}
+					if structuredBlocks[idx] {
+						textBuf.WriteString(pj.String())
+						continue
+					}
 					if toolCalls[idx] == nil {
 						toolCalls[idx] = &toolState{}
 					}
@@ -550,8 +606,11 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			}
 
 		case "content_block_stop":
-			// Nothing special to finalize for non-stream aggregation
-			_ = root
+			idx := int(root.Get("index").Int())
+			if buf, ok := structuredBufs[idx]; ok {
+				textBuf.WriteString(common.UnwrapStructuredOutput(buf.String(), originalRequestRawJSON))
+				delete(structuredBufs, idx)
+			}
 
 		case "message_delta":
 			if usage := root.Get("usage"); usage.Exists() {
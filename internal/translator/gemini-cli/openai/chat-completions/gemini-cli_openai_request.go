@@ -109,6 +109,9 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		}
 	}
 
+	// response_format -> request.generationConfig.responseMimeType / responseJsonSchema
+	out = common.ApplyResponseFormat(out, "request.generationConfig", rawJSON)
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"cliproxy/internal/util"
+	"github.com/tidwall/sjson"
+)
+
+// ApplyResponseFormat maps an OpenAI response_format or Responses text.format found in
+// openAIRequest onto the Gemini generation config at configPath (e.g. "generationConfig" or
+// "request.generationConfig"). json_object selects JSON output; json_schema additionally sets
+// responseJsonSchema to the schema cleaned of keywords Gemini rejects.
+func ApplyResponseFormat(out []byte, configPath string, openAIRequest []byte) []byte {
+	format, ok := util.StructuredOutputFormat(openAIRequest)
+	if !ok {
+		return out
+	}
+	out, _ = sjson.SetBytes(out, configPath+".responseMimeType", "application/json")
+	if format.Schema != "" {
+		schema := util.CleanJSONSchemaForGemini(format.Schema)
+		out, _ = sjson.SetRawBytes(out, configPath+".responseJsonSchema", []byte(schema))
+	}
+	return out
+}
@@ -111,6 +111,9 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// response_format -> generationConfig.responseMimeType / responseJsonSchema
+	out = common.ApplyResponseFormat(out, "generationConfig", rawJSON)
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
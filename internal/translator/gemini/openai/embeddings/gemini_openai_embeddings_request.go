@@ -0,0 +1,49 @@
+// Package embeddings provides translation between OpenAI embeddings requests and the Gemini
+// batchEmbedContents API.
+package embeddings
+
+import (
+	"bytes"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingRequestToGemini converts an OpenAI embeddings request into a Gemini
+// batchEmbedContents request with one entry per input string. The "dimensions" parameter maps
+// to outputDimensionality. Token-array inputs have no Gemini equivalent and are rejected by the
+// executors before translation.
+//
+// Parameters:
+//   - modelName: The upstream model name
+//   - inputRawJSON: The raw JSON of the OpenAI embeddings request
+//   - stream: Unused; embeddings are never streamed
+//
+// Returns:
+//   - []byte: The Gemini batchEmbedContents request JSON
+func ConvertOpenAIEmbeddingRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
+	rawJSON := bytes.Clone(inputRawJSON)
+	out := []byte(`{"requests":[]}`)
+
+	var texts []string
+	input := gjson.GetBytes(rawJSON, "input")
+	if input.IsArray() {
+		for _, item := range input.Array() {
+			texts = append(texts, item.String())
+		}
+	} else if input.Exists() {
+		texts = append(texts, input.String())
+	}
+
+	dimensions := gjson.GetBytes(rawJSON, "dimensions")
+	for _, text := range texts {
+		request := []byte(`{"model":"","content":{"parts":[{"text":""}]}}`)
+		request, _ = sjson.SetBytes(request, "model", "models/"+modelName)
+		request, _ = sjson.SetBytes(request, "content.parts.0.text", text)
+		if dimensions.Exists() && dimensions.Int() > 0 {
+			request, _ = sjson.SetBytes(request, "outputDimensionality", dimensions.Int())
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", request)
+	}
+	return out
+}
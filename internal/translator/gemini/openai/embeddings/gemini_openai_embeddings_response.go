@@ -0,0 +1,59 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingResponseToOpenAI converts a Gemini batchEmbedContents response into an
+// OpenAI embeddings list. When the original request asked for encoding_format "base64", vectors
+// are returned as base64 of little-endian float32 values, as OpenAI does. Prompt tokens come from
+// usageMetadata.promptTokenCount, which the executors fill in.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The model name reported to the client
+//   - originalRequestRawJSON: The original OpenAI embeddings request
+//   - requestRawJSON: The translated Gemini request
+//   - rawJSON: The Gemini response
+//   - param: Unused
+//
+// Returns:
+//   - string: The OpenAI embeddings response JSON
+func ConvertGeminiEmbeddingResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, _, rawJSON []byte, _ *any) string {
+	useBase64 := gjson.GetBytes(originalRequestRawJSON, "encoding_format").String() == "base64"
+
+	out := `{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`
+	out, _ = sjson.Set(out, "model", modelName)
+	gjson.GetBytes(rawJSON, "embeddings").ForEach(func(key, value gjson.Result) bool {
+		item := `{"object":"embedding","index":0,"embedding":[]}`
+		item, _ = sjson.Set(item, "index", key.Int())
+		values := value.Get("values")
+		if useBase64 {
+			item, _ = sjson.Set(item, "embedding", encodeFloat32Base64(values))
+		} else if values.IsArray() {
+			item, _ = sjson.SetRaw(item, "embedding", values.Raw)
+		}
+		out, _ = sjson.SetRaw(out, "data.-1", item)
+		return true
+	})
+
+	tokens := gjson.GetBytes(rawJSON, "usageMetadata.promptTokenCount").Int()
+	out, _ = sjson.Set(out, "usage.prompt_tokens", tokens)
+	out, _ = sjson.Set(out, "usage.total_tokens", tokens)
+	return out
+}
+
+func encodeFloat32Base64(values gjson.Result) string {
+	items := values.Array()
+	buf := make([]byte, 4*len(items))
+	for i, v := range items {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(v.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,18 @@
+package embeddings
+
+import (
+	"cliproxy/internal/constant"
+	"cliproxy/internal/interfaces"
+	"cliproxy/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		constant.OpenAIEmbedding,
+		constant.GeminiEmbedding,
+		ConvertOpenAIEmbeddingRequestToGemini,
+		interfaces.TranslateResponse{
+			NonStream: ConvertGeminiEmbeddingResponseToOpenAI,
+		},
+	)
+}
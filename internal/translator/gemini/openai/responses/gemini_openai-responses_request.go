@@ -418,6 +418,8 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 	}
 
 	result := []byte(out)
+	// text.format -> generationConfig.responseMimeType / responseJsonSchema
+	result = common.ApplyResponseFormat(result, "generationConfig", rawJSON)
 	result = common.AttachDefaultSafetySettings(result, "safetySettings")
 	return result
 }
@@ -21,10 +21,12 @@ import (
 	_ "cliproxy/internal/translator/gemini/gemini"
 	_ "cliproxy/internal/translator/gemini/gemini-cli"
 	_ "cliproxy/internal/translator/gemini/openai/chat-completions"
+	_ "cliproxy/internal/translator/gemini/openai/embeddings"
 	_ "cliproxy/internal/translator/gemini/openai/responses"
 
 	_ "cliproxy/internal/translator/openai/claude"
 	_ "cliproxy/internal/translator/openai/gemini"
+	_ "cliproxy/internal/translator/openai/gemini/embeddings"
 	_ "cliproxy/internal/translator/openai/gemini-cli"
 	_ "cliproxy/internal/translator/openai/openai/chat-completions"
 	_ "cliproxy/internal/translator/openai/openai/responses"
@@ -33,4 +35,7 @@ import (
 	_ "cliproxy/internal/translator/antigravity/gemini"
 	_ "cliproxy/internal/translator/antigravity/openai/chat-completions"
 	_ "cliproxy/internal/translator/antigravity/openai/responses"
+
+	_ "cliproxy/internal/translator/kiro/claude"
+	_ "cliproxy/internal/translator/kiro/openai"
 )
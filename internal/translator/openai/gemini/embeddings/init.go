@@ -0,0 +1,18 @@
+package embeddings
+
+import (
+	"cliproxy/internal/constant"
+	"cliproxy/internal/interfaces"
+	"cliproxy/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		constant.GeminiEmbedding,
+		constant.OpenAIEmbedding,
+		ConvertGeminiEmbeddingRequestToOpenAI,
+		interfaces.TranslateResponse{
+			NonStream: ConvertOpenAIEmbeddingResponseToGemini,
+		},
+	)
+}
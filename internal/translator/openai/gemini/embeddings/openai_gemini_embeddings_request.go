@@ -0,0 +1,46 @@
+// Package embeddings provides translation between Gemini batchEmbedContents requests and the
+// OpenAI embeddings API.
+package embeddings
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingRequestToOpenAI converts a Gemini batchEmbedContents request into an
+// OpenAI embeddings request. Each entry becomes one input, with the text of multi-part contents
+// joined by newlines. outputDimensionality of the first entry maps to "dimensions"; Gemini task
+// types and titles have no OpenAI equivalent and are dropped.
+//
+// Parameters:
+//   - modelName: The upstream model name
+//   - inputRawJSON: The raw JSON of the Gemini batchEmbedContents request
+//   - stream: Unused; embeddings are never streamed
+//
+// Returns:
+//   - []byte: The OpenAI embeddings request JSON
+func ConvertGeminiEmbeddingRequestToOpenAI(modelName string, inputRawJSON []byte, _ bool) []byte {
+	rawJSON := bytes.Clone(inputRawJSON)
+	out := []byte(`{"model":"","input":[]}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+
+	requests := gjson.GetBytes(rawJSON, "requests").Array()
+	for _, request := range requests {
+		var parts []string
+		for _, part := range request.Get("content.parts").Array() {
+			if text := part.Get("text"); text.Exists() {
+				parts = append(parts, text.String())
+			}
+		}
+		out, _ = sjson.SetBytes(out, "input.-1", strings.Join(parts, "\n"))
+	}
+	if len(requests) > 0 {
+		if dimensions := requests[0].Get("outputDimensionality"); dimensions.Exists() && dimensions.Int() > 0 {
+			out, _ = sjson.SetBytes(out, "dimensions", dimensions.Int())
+		}
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package embeddings
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingResponseToGemini converts an OpenAI embeddings list into a Gemini
+// batchEmbedContents response, ordering vectors by their index. Prompt tokens are reported in
+// usageMetadata.promptTokenCount.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The model name reported to the client
+//   - originalRequestRawJSON: The original Gemini request
+//   - requestRawJSON: The translated OpenAI request
+//   - rawJSON: The OpenAI response
+//   - param: Unused
+//
+// Returns:
+//   - string: The Gemini batchEmbedContents response JSON
+func ConvertOpenAIEmbeddingResponseToGemini(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) string {
+	data := gjson.GetBytes(rawJSON, "data").Array()
+	ordered := make([]string, len(data))
+	for i, item := range data {
+		idx := i
+		if index := item.Get("index"); index.Exists() && int(index.Int()) >= 0 && int(index.Int()) < len(data) {
+			idx = int(index.Int())
+		}
+		ordered[idx] = item.Get("embedding").Raw
+	}
+
+	out := `{"embeddings":[]}`
+	for _, values := range ordered {
+		entry := `{"values":[]}`
+		if values != "" {
+			entry, _ = sjson.SetRaw(entry, "values", values)
+		}
+		out, _ = sjson.SetRaw(out, "embeddings.-1", entry)
+	}
+	if tokens := gjson.GetBytes(rawJSON, "usage.prompt_tokens"); tokens.Exists() {
+		out, _ = sjson.Set(out, "usageMetadata.promptTokenCount", tokens.Int())
+	}
+	return out
+}
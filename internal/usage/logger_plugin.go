@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -90,13 +91,35 @@ type modelStats struct {
 	Details       []RequestDetail
 }
 
-// RequestDetail stores the timestamp and token usage for a single request.
+// RequestDetail stores the timestamp, token usage and timing for a single request.
 type RequestDetail struct {
 	Timestamp time.Time  `json:"timestamp"`
 	Source    string     `json:"source"`
 	AuthIndex string     `json:"auth_index"`
+	Provider  string     `json:"provider,omitempty"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+
+	// LatencyMs is the total upstream request duration in milliseconds.
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+	// TTFTMs is the time to the first streamed chunk in milliseconds (streaming only).
+	TTFTMs int64 `json:"ttft_ms,omitempty"`
+	// TokensPerSecond is the output generation rate measured after the first chunk
+	// for streams, or across the whole request otherwise.
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+}
+
+// PerformanceStats summarises request timing across successful requests.
+type PerformanceStats struct {
+	Samples            int     `json:"samples"`
+	LatencyP50Ms       int64   `json:"latency_p50_ms"`
+	LatencyP90Ms       int64   `json:"latency_p90_ms"`
+	LatencyP99Ms       int64   `json:"latency_p99_ms"`
+	TTFTP50Ms          int64   `json:"ttft_p50_ms,omitempty"`
+	TTFTP90Ms          int64   `json:"ttft_p90_ms,omitempty"`
+	TTFTP99Ms          int64   `json:"ttft_p99_ms,omitempty"`
+	TokensPerSecondP50 float64 `json:"tokens_per_second_p50,omitempty"`
+	TokensPerSecondAvg float64 `json:"tokens_per_second_avg,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -121,6 +144,10 @@ type StatisticsSnapshot struct {
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
 	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+
+	// Performance groups timing percentiles by model and then by provider so providers
+	// serving the same model can be compared.
+	Performance map[string]map[string]PerformanceStats `json:"performance,omitempty"`
 }
 
 // APISnapshot summarises metrics for a single API key.
@@ -132,9 +159,10 @@ type APISnapshot struct {
 
 // ModelSnapshot summarises metrics for a specific model.
 type ModelSnapshot struct {
-	TotalRequests int64           `json:"total_requests"`
-	TotalTokens   int64           `json:"total_tokens"`
-	Details       []RequestDetail `json:"details"`
+	TotalRequests int64             `json:"total_requests"`
+	TotalTokens   int64             `json:"total_tokens"`
+	Details       []RequestDetail   `json:"details"`
+	Performance   *PerformanceStats `json:"performance,omitempty"`
 }
 
 var defaultRequestStatistics = NewRequestStatistics()
@@ -199,13 +227,16 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		stats = &apiStats{Models: make(map[string]*modelStats)}
 		s.apis[statsKey] = stats
 	}
-	s.updateAPIStats(stats, modelName, RequestDetail{
+	requestDetail := RequestDetail{
 		Timestamp: timestamp,
 		Source:    record.Source,
 		AuthIndex: record.AuthIndex,
+		Provider:  record.Provider,
 		Tokens:    detail,
 		Failed:    failed,
-	})
+	}
+	applyTiming(&requestDetail, record)
+	s.updateAPIStats(stats, modelName, requestDetail)
 
 	s.requestsByDay[dayKey]++
 	s.requestsByHour[hourKey]++
@@ -242,6 +273,7 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.TotalTokens = s.totalTokens
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
+	byProvider := make(map[string]map[string][]RequestDetail)
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
 			TotalRequests: stats.TotalRequests,
@@ -251,15 +283,45 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		for modelName, modelStatsValue := range stats.Models {
 			requestDetails := make([]RequestDetail, len(modelStatsValue.Details))
 			copy(requestDetails, modelStatsValue.Details)
-			apiSnapshot.Models[modelName] = ModelSnapshot{
+			modelSnapshot := ModelSnapshot{
 				TotalRequests: modelStatsValue.TotalRequests,
 				TotalTokens:   modelStatsValue.TotalTokens,
 				Details:       requestDetails,
 			}
+			if perf, ok := summarisePerformance(requestDetails); ok {
+				modelSnapshot.Performance = &perf
+			}
+			apiSnapshot.Models[modelName] = modelSnapshot
+			for _, detail := range requestDetails {
+				provider := detail.Provider
+				if provider == "" {
+					provider = "unknown"
+				}
+				if byProvider[modelName] == nil {
+					byProvider[modelName] = make(map[string][]RequestDetail)
+				}
+				byProvider[modelName][provider] = append(byProvider[modelName][provider], detail)
+			}
 		}
 		result.APIs[apiName] = apiSnapshot
 	}
 
+	for modelName, providers := range byProvider {
+		for provider, details := range providers {
+			perf, ok := summarisePerformance(details)
+			if !ok {
+				continue
+			}
+			if result.Performance == nil {
+				result.Performance = make(map[string]map[string]PerformanceStats)
+			}
+			if result.Performance[modelName] == nil {
+				result.Performance[modelName] = make(map[string]PerformanceStats)
+			}
+			result.Performance[modelName][provider] = perf
+		}
+	}
+
 	result.RequestsByDay = make(map[string]int64, len(s.requestsByDay))
 	for k, v := range s.requestsByDay {
 		result.RequestsByDay[k] = v
@@ -397,6 +459,10 @@ func dedupKey(apiName, modelName string, detail RequestDetail) string {
 	)
 }
 
+// resolveAPIIdentifier names the statistics bucket of a request made without a client API key:
+// "METHOD /route" when the Gin context is available, the provider otherwise. Statistics saved
+// before handlers exposed the Gin context under the "gin" key grouped these requests by provider,
+// so reloaded snapshots keep those buckets alongside the route-based ones.
 func resolveAPIIdentifier(ctx context.Context, record coreusage.Record) string {
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
@@ -466,6 +532,79 @@ func normaliseTokenStats(tokens TokenStats) TokenStats {
 	return tokens
 }
 
+// applyTiming derives latency, TTFT and output throughput from the record timestamps.
+func applyTiming(detail *RequestDetail, record coreusage.Record) {
+	latency := record.Latency()
+	if latency <= 0 {
+		return
+	}
+	detail.LatencyMs = latency.Milliseconds()
+	generation := latency
+	if ttft := record.TimeToFirstChunk(); ttft > 0 {
+		detail.TTFTMs = ttft.Milliseconds()
+		if remaining := latency - ttft; remaining > 0 {
+			generation = remaining
+		}
+	}
+	output := detail.Tokens.OutputTokens + detail.Tokens.ReasoningTokens
+	if output > 0 && generation > 0 {
+		detail.TokensPerSecond = float64(output) / generation.Seconds()
+	}
+}
+
+// summarisePerformance computes timing percentiles over successful requests that carry
+// timing data. ok is false when no such request exists.
+func summarisePerformance(details []RequestDetail) (PerformanceStats, bool) {
+	var latencies, ttfts []int64
+	var rates []float64
+	rateSum := 0.0
+	for _, detail := range details {
+		if detail.Failed || detail.LatencyMs <= 0 {
+			continue
+		}
+		latencies = append(latencies, detail.LatencyMs)
+		if detail.TTFTMs > 0 {
+			ttfts = append(ttfts, detail.TTFTMs)
+		}
+		if detail.TokensPerSecond > 0 {
+			rates = append(rates, detail.TokensPerSecond)
+			rateSum += detail.TokensPerSecond
+		}
+	}
+	if len(latencies) == 0 {
+		return PerformanceStats{}, false
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	sort.Slice(ttfts, func(i, j int) bool { return ttfts[i] < ttfts[j] })
+	sort.Float64s(rates)
+	stats := PerformanceStats{
+		Samples:      len(latencies),
+		LatencyP50Ms: percentile(latencies, 50),
+		LatencyP90Ms: percentile(latencies, 90),
+		LatencyP99Ms: percentile(latencies, 99),
+		TTFTP50Ms:    percentile(ttfts, 50),
+		TTFTP90Ms:    percentile(ttfts, 90),
+		TTFTP99Ms:    percentile(ttfts, 99),
+	}
+	if len(rates) > 0 {
+		stats.TokensPerSecondP50 = rates[(len(rates)-1)/2]
+		stats.TokensPerSecondAvg = rateSum / float64(len(rates))
+	}
+	return stats, true
+}
+
+// percentile returns the nearest-rank percentile p of sorted values, or zero when empty.
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
 func formatHour(hour int) string {
 	if hour < 0 {
 		hour = 0
@@ -75,3 +75,45 @@ func TestMemoryMode_PersistenceDisabled(t *testing.T) {
 		t.Errorf("Load should not error on missing file: %v", err)
 	}
 }
+
+func TestUsageTimingPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage_timing.json")
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s1 := NewRequestStatistics()
+	for i, provider := range []string{"gemini", "gemini", "antigravity"} {
+		s1.Record(context.Background(), coreusage.Record{
+			RequestedAt:  start.Add(time.Duration(i) * time.Minute),
+			FirstChunkAt: start.Add(time.Duration(i)*time.Minute + time.Duration(i+1)*100*time.Millisecond),
+			CompletedAt:  start.Add(time.Duration(i)*time.Minute + time.Duration(i+1)*100*time.Millisecond + 2*time.Second),
+			Provider:     provider,
+			APIKey:       "sk-test",
+			Model:        "gemini-2.5-pro",
+			Detail:       coreusage.Detail{InputTokens: 10, OutputTokens: 100},
+		})
+	}
+
+	snap := s1.Snapshot()
+	detail := snap.APIs["sk-test"].Models["gemini-2.5-pro"].Details[0]
+	if detail.LatencyMs != 2100 || detail.TTFTMs != 100 || detail.TokensPerSecond != 50 {
+		t.Fatalf("detail timing = latency %d ttft %d tps %v", detail.LatencyMs, detail.TTFTMs, detail.TokensPerSecond)
+	}
+	gemini := snap.Performance["gemini-2.5-pro"]["gemini"]
+	if gemini.Samples != 2 || gemini.TTFTP50Ms != 100 || gemini.TTFTP99Ms != 200 || gemini.LatencyP90Ms != 2200 {
+		t.Fatalf("gemini performance = %+v", gemini)
+	}
+	if perf := snap.Performance["gemini-2.5-pro"]["antigravity"]; perf.Samples != 1 || perf.TTFTP50Ms != 300 {
+		t.Fatalf("antigravity performance = %+v", perf)
+	}
+
+	if _, err := s1.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	s2 := NewRequestStatistics()
+	if err := s2.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(snap, s2.Snapshot()) {
+		t.Fatalf("timing data not preserved across Save/Load")
+	}
+}
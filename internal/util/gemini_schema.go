@@ -16,6 +16,18 @@ var gjsonPathKeyReplacer = strings.NewReplacer(".", "\\.", "*", "\\*", "?", "\\?
 // It handles unsupported keywords, type flattening, and schema simplification while preserving
 // semantic information as description hints.
 func CleanJSONSchemaForAntigravity(jsonStr string) string {
+	jsonStr = CleanJSONSchemaForGemini(jsonStr)
+
+	// Phase 4: Add placeholder for empty object schemas (Claude VALIDATED mode requirement)
+	jsonStr = addEmptySchemaPlaceholder(jsonStr)
+
+	return jsonStr
+}
+
+// CleanJSONSchemaForGemini rewrites a JSON schema into the subset Gemini accepts. Unlike
+// CleanJSONSchemaForAntigravity it adds no placeholder properties, so it is also suitable
+// for response schemas where every property ends up in the model's output.
+func CleanJSONSchemaForGemini(jsonStr string) string {
 	// Phase 1: Convert and add hints
 	jsonStr = convertRefsToHints(jsonStr)
 	jsonStr = convertConstToEnum(jsonStr)
@@ -32,9 +44,6 @@ func CleanJSONSchemaForAntigravity(jsonStr string) string {
 	jsonStr = removeUnsupportedKeywords(jsonStr)
 	jsonStr = cleanupRequiredFields(jsonStr)
 
-	// Phase 4: Add placeholder for empty object schemas (Claude VALIDATED mode requirement)
-	jsonStr = addEmptySchemaPlaceholder(jsonStr)
-
 	return jsonStr
 }
 
@@ -236,7 +236,7 @@ func MaskSensitiveQuery(raw string) string {
 		if err != nil {
 			decodedKey = keyPart
 		}
-		if !shouldMaskQueryParam(decodedKey) {
+		if !IsSensitiveQueryParam(decodedKey) {
 			continue
 		}
 		decodedValue, err := url.QueryUnescape(valuePart)
@@ -253,7 +253,8 @@ func MaskSensitiveQuery(raw string) string {
 	return strings.Join(parts, "&")
 }
 
-func shouldMaskQueryParam(key string) bool {
+// IsSensitiveQueryParam reports whether a query parameter carries a credential.
+func IsSensitiveQueryParam(key string) bool {
 	key = strings.ToLower(strings.TrimSpace(key))
 	if key == "" {
 		return false
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// ResponseFormat describes the structured output an OpenAI client asked for.
+type ResponseFormat struct {
+	// Name is the json_schema name, empty for json_object.
+	Name string
+	// Description is the optional json_schema description.
+	Description string
+	// Schema is the raw JSON schema, empty for json_object.
+	Schema string
+	// Strict reports whether the client asked for strict schema adherence.
+	Strict bool
+}
+
+// StructuredOutputFormat extracts the structured output request from an OpenAI Chat Completions
+// response_format or a Responses text.format. It returns false when the client asked for plain
+// text or set no format at all.
+func StructuredOutputFormat(rawJSON []byte) (ResponseFormat, bool) {
+	format := gjson.GetBytes(rawJSON, "response_format")
+	if !format.Exists() {
+		format = gjson.GetBytes(rawJSON, "text.format")
+	}
+	switch format.Get("type").String() {
+	case "json_object":
+		return ResponseFormat{}, true
+	case "json_schema":
+		// Chat Completions nests the schema under json_schema; Responses keeps it flat.
+		spec := format
+		if nested := format.Get("json_schema"); nested.IsObject() {
+			spec = nested
+		}
+		rf := ResponseFormat{
+			Name:        spec.Get("name").String(),
+			Description: spec.Get("description").String(),
+			Strict:      spec.Get("strict").Bool(),
+		}
+		if schema := spec.Get("schema"); schema.IsObject() {
+			rf.Schema = schema.Raw
+		}
+		return rf, true
+	}
+	return ResponseFormat{}, false
+}
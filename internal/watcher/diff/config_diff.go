@@ -63,12 +63,33 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
 
+	if oldCfg.ResponseCache != newCfg.ResponseCache {
+		changes = append(changes, fmt.Sprintf("response-cache: enable=%t backend=%s ttl-seconds=%d max-entries=%d", newCfg.ResponseCache.Enable, newCfg.ResponseCache.Backend, newCfg.ResponseCache.TTLSeconds, newCfg.ResponseCache.MaxEntries))
+	}
+
+	if oldCfg.Scheduling.Strategy != newCfg.Scheduling.Strategy {
+		changes = append(changes, fmt.Sprintf("scheduling.strategy: %s -> %s", oldCfg.Scheduling.Strategy, newCfg.Scheduling.Strategy))
+	}
+	if !reflect.DeepEqual(oldCfg.Providers, newCfg.Providers) {
+		changes = append(changes, fmt.Sprintf("providers: updated (%d -> %d entries)", len(oldCfg.Providers), len(newCfg.Providers)))
+	}
+	if oldCfg.Scheduling.Sticky != newCfg.Scheduling.Sticky {
+		n := newCfg.Scheduling.Sticky
+		changes = append(changes, fmt.Sprintf("scheduling.sticky: ttl-seconds=%d max-entries=%d rebalance-factor=%g persist-file=%s", n.TTLSeconds, n.MaxEntries, n.RebalanceFactor, n.PersistFile))
+	}
+
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
 		changes = append(changes, fmt.Sprintf("api-keys count: %d -> %d", len(oldCfg.APIKeys), len(newCfg.APIKeys)))
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if !reflect.DeepEqual(oldCfg.APIKeyPolicies, newCfg.APIKeyPolicies) {
+		changes = append(changes, fmt.Sprintf("api-key-policies: updated (%d -> %d entries)", len(oldCfg.APIKeyPolicies), len(newCfg.APIKeyPolicies)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks, newCfg.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks: updated (%d -> %d models)", len(oldCfg.ModelFallbacks), len(newCfg.ModelFallbacks)))
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -249,10 +249,11 @@ func normalizeAuth(a *coreauth.Auth) *coreauth.Auth {
 	return clone
 }
 
-func snapshotCoreAuths(cfg *config.Config, authDir string) []*coreauth.Auth {
+func snapshotCoreAuths(cfg *config.Config, authDir string, records []*coreauth.Auth) []*coreauth.Auth {
 	ctx := &synthesizer.SynthesisContext{
 		Config:      cfg,
 		AuthDir:     authDir,
+		Records:     records,
 		Now:         time.Now(),
 		IDGenerator: synthesizer.NewStableIDGenerator(),
 	}
@@ -264,6 +265,13 @@ func snapshotCoreAuths(cfg *config.Config, authDir string) []*coreauth.Auth {
 		out = append(out, auths...)
 	}
 
+	// Stored records come before auth files so a file dropped into auth-dir wins over the
+	// copy of it already persisted in the store.
+	storeSynth := synthesizer.NewStoreSynthesizer()
+	if auths, err := storeSynth.Synthesize(ctx); err == nil {
+		out = append(out, auths...)
+	}
+
 	fileSynth := synthesizer.NewFileSynthesizer()
 	if auths, err := fileSynth.Synthesize(ctx); err == nil {
 		out = append(out, auths...)
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"cliproxy/internal/config"
+	coreauth "cliproxy/sdk/cliproxy/auth"
 )
 
 // SynthesisContext provides the context needed for auth synthesis.
@@ -12,6 +13,8 @@ type SynthesisContext struct {
 	Config *config.Config
 	// AuthDir is the directory containing auth files
 	AuthDir string
+	// Records are the credentials held by a token store other than auth-dir
+	Records []*coreauth.Auth
 	// Now is the current time for timestamps
 	Now time.Time
 	// IDGenerator generates stable IDs for auth entries
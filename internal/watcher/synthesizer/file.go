@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"cliproxy/internal/authcrypt"
 	"cliproxy/internal/runtime/geminicli"
 	coreauth "cliproxy/sdk/cliproxy/auth"
 )
@@ -34,9 +35,6 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		return out, nil
 	}
 
-	now := ctx.Now
-	cfg := ctx.Config
-
 	for _, e := range entries {
 		if e.IsDir() {
 			continue
@@ -46,7 +44,7 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			continue
 		}
 		full := filepath.Join(ctx.AuthDir, name)
-		data, errRead := os.ReadFile(full)
+		data, errRead := authcrypt.ReadFile(full)
 		if errRead != nil || len(data) == 0 {
 			continue
 		}
@@ -54,67 +52,75 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil {
 			continue
 		}
-		t, _ := metadata["type"].(string)
-		if t == "" {
-			continue
-		}
-		provider := strings.ToLower(t)
-		if provider == "gemini" {
-			provider = "gemini-cli"
-		}
-		label := provider
-		if email, _ := metadata["email"].(string); email != "" {
-			label = email
-		}
 		// Use relative path under authDir as ID to stay consistent with the file-based token store
 		id := full
 		if rel, errRel := filepath.Rel(ctx.AuthDir, full); errRel == nil && rel != "" {
 			id = rel
 		}
+		out = append(out, synthesizeMetadataAuths(ctx, id, full, full, metadata)...)
+	}
+	return out, nil
+}
 
-		proxyURL := ""
-		if p, ok := metadata["proxy_url"].(string); ok {
-			proxyURL = p
-		}
+// synthesizeMetadataAuths builds the auth entries for one stored credential document: the
+// credential itself plus, for multi-project Gemini credentials, one virtual auth per project.
+// path is the credential's file, empty when it is not stored on disk.
+func synthesizeMetadataAuths(ctx *SynthesisContext, id, source, path string, metadata map[string]any) []*coreauth.Auth {
+	t, _ := metadata["type"].(string)
+	if t == "" {
+		return nil
+	}
+	now := ctx.Now
+	cfg := ctx.Config
+	provider := strings.ToLower(t)
+	if provider == "gemini" {
+		provider = "gemini-cli"
+	}
+	label := provider
+	if email, _ := metadata["email"].(string); email != "" {
+		label = email
+	}
 
-		prefix := ""
-		if rawPrefix, ok := metadata["prefix"].(string); ok {
-			trimmed := strings.TrimSpace(rawPrefix)
-			trimmed = strings.Trim(trimmed, "/")
-			if trimmed != "" && !strings.Contains(trimmed, "/") {
-				prefix = trimmed
-			}
-		}
+	proxyURL := ""
+	if p, ok := metadata["proxy_url"].(string); ok {
+		proxyURL = p
+	}
 
-		a := &coreauth.Auth{
-			ID:       id,
-			Provider: provider,
-			Label:    label,
-			Prefix:   prefix,
-			Status:   coreauth.StatusActive,
-			Attributes: map[string]string{
-				"source": full,
-				"path":   full,
-			},
-			ProxyURL:  proxyURL,
-			Metadata:  metadata,
-			CreatedAt: now,
-			UpdatedAt: now,
+	prefix := ""
+	if rawPrefix, ok := metadata["prefix"].(string); ok {
+		trimmed := strings.TrimSpace(rawPrefix)
+		trimmed = strings.Trim(trimmed, "/")
+		if trimmed != "" && !strings.Contains(trimmed, "/") {
+			prefix = trimmed
 		}
-		ApplyAuthExcludedModelsMeta(a, cfg, nil, "oauth")
-		if provider == "gemini-cli" {
-			if virtuals := SynthesizeGeminiVirtualAuths(a, metadata, now); len(virtuals) > 0 {
-				for _, v := range virtuals {
-					ApplyAuthExcludedModelsMeta(v, cfg, nil, "oauth")
-				}
-				out = append(out, a)
-				out = append(out, virtuals...)
-				continue
+	}
+
+	attrs := map[string]string{"source": source}
+	if path != "" {
+		attrs["path"] = path
+	}
+	a := &coreauth.Auth{
+		ID:         id,
+		Provider:   provider,
+		Label:      label,
+		Prefix:     prefix,
+		Status:     coreauth.StatusActive,
+		Attributes: attrs,
+		ProxyURL:   proxyURL,
+		Metadata:   metadata,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	ApplyAuthExcludedModelsMeta(a, cfg, nil, "oauth")
+	if provider == "gemini-cli" {
+		if virtuals := SynthesizeGeminiVirtualAuths(a, metadata, now); len(virtuals) > 0 {
+			for _, v := range virtuals {
+				ApplyAuthExcludedModelsMeta(v, cfg, nil, "oauth")
 			}
+			return append([]*coreauth.Auth{a}, virtuals...)
 		}
-		out = append(out, a)
 	}
-	return out, nil
+	return []*coreauth.Auth{a}
 }
 
 // SynthesizeGeminiVirtualAuths creates virtual Auth entries for multi-project Gemini credentials.
@@ -2,6 +2,7 @@
 // It implements the Strategy pattern to support multiple auth sources:
 // - ConfigSynthesizer: generates Auth entries from config API keys
 // - FileSynthesizer: generates Auth entries from OAuth JSON files
+// - StoreSynthesizer: generates Auth entries from credentials held in a token store
 package synthesizer
 
 import (
@@ -0,0 +1,124 @@
+package synthesizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cliproxy/internal/config"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// synthesizeUnifiedProviders creates Auth entries for the unified providers: block.
+func (s *ConfigSynthesizer) synthesizeUnifiedProviders(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+
+	out := make([]*coreauth.Auth, 0, len(cfg.Providers))
+	for i := range cfg.Providers {
+		p := &cfg.Providers[i]
+		if !p.IsEnabled() {
+			continue
+		}
+		providerType := config.NormalizeProviderType(p.Type)
+		required, supported := config.RequiredProviderCredentials(providerType)
+		if !supported {
+			log.Warnf("providers[%s]: unsupported type %q, skipping", p.ID, p.Type)
+			continue
+		}
+		missing := false
+		for _, key := range required {
+			if p.Credential(key) == "" {
+				log.Warnf("providers[%s]: missing credential %q, skipping", p.ID, key)
+				missing = true
+			}
+		}
+		if missing {
+			continue
+		}
+
+		key := p.Credential("api_key")
+		base := p.Credential("base_url")
+		attrs := map[string]string{
+			"source":           fmt.Sprintf("config:providers[%s]", p.ID),
+			"unified_provider": p.ID,
+		}
+		if key != "" {
+			attrs["api_key"] = key
+		}
+		if base != "" {
+			attrs["base_url"] = base
+		}
+		if tags := coreauth.NormalizeTags(p.Tags); len(tags) > 0 {
+			attrs["tags"] = strings.Join(tags, ",")
+		}
+		if hash := unifiedModelsHash(p.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		for client, upstream := range p.Models.Alias {
+			client = strings.ToLower(strings.TrimSpace(client))
+			upstream = strings.TrimSpace(upstream)
+			if client != "" && upstream != "" && !strings.EqualFold(client, upstream) {
+				attrs[coreauth.ModelAliasAttrPrefix+client] = upstream
+			}
+		}
+
+		providerName := providerType
+		label := providerType + "-provider"
+		switch providerType {
+		case "vertex":
+			attrs["provider_key"] = "vertex"
+		case "openai-compatibility":
+			name := p.Credential("name")
+			if name == "" {
+				name = p.ID
+			}
+			providerName = strings.ToLower(name)
+			label = name
+			attrs["compat_name"] = name
+			attrs["provider_key"] = providerName
+		}
+
+		a := &coreauth.Auth{
+			ID:         "provider:" + p.ID,
+			Provider:   providerName,
+			Label:      label,
+			Prefix:     p.Prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   p.ProxyURL,
+			Priority:   p.Priority,
+			Weight:     p.Weight,
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, p.Models.Exclude, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// unifiedModelsHash fingerprints a provider's model rules so edits trigger re-registration.
+func unifiedModelsHash(models config.ProviderModelConfig) string {
+	if len(models.Include) == 0 && len(models.Exclude) == 0 && len(models.Alias) == 0 {
+		return ""
+	}
+	include := append([]string(nil), models.Include...)
+	exclude := append([]string(nil), models.Exclude...)
+	sort.Strings(include)
+	sort.Strings(exclude)
+	data, err := json.Marshal(struct {
+		Include []string          `json:"include"`
+		Exclude []string          `json:"exclude"`
+		Alias   map[string]string `json:"alias"`
+	}{include, exclude, models.Alias})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,51 @@
+package synthesizer
+
+import (
+	"testing"
+	"time"
+
+	"cliproxy/internal/config"
+)
+
+func TestConfigSynthesizer_UnifiedProviders(t *testing.T) {
+	disabled := false
+	cfg := &config.Config{
+		Providers: []config.UnifiedProvider{
+			{ID: "gem", Type: "gemini", Priority: 1, Weight: 50, Tags: []string{"Production"}, Prefix: "gem",
+				Credentials: map[string]string{"api_key": "g-key"},
+				Models:      config.ProviderModelConfig{Alias: map[string]string{"fast": "gemini-2.5-flash"}}},
+			{ID: "router", Type: "openai", Credentials: map[string]string{"api-key": "or-key", "base_url": "https://openrouter.ai/api/v1", "name": "OpenRouter"}},
+			{ID: "off", Type: "claude", Enabled: &disabled, Credentials: map[string]string{"api_key": "c-key"}},
+			{ID: "no-key", Type: "claude"},
+			{ID: "unknown", Type: "qwen", Credentials: map[string]string{"api_key": "q"}},
+		},
+	}
+	cfg.SanitizeProviders()
+
+	auths, err := NewConfigSynthesizer().Synthesize(&SynthesisContext{Config: cfg, Now: time.Now(), IDGenerator: NewStableIDGenerator()})
+	if err != nil {
+		t.Fatalf("Synthesize error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+
+	gem := auths[0]
+	if gem.ID != "provider:gem" || gem.Provider != "gemini" || gem.Priority != 1 || gem.Weight != 50 || gem.Prefix != "gem" {
+		t.Fatalf("unexpected gemini auth: %+v", gem)
+	}
+	if gem.Attributes["api_key"] != "g-key" || gem.Attributes["tags"] != "production" || gem.Attributes["unified_provider"] != "gem" {
+		t.Fatalf("unexpected gemini attributes: %v", gem.Attributes)
+	}
+	if gem.Attributes["model_alias:fast"] != "gemini-2.5-flash" || gem.Attributes["models_hash"] == "" {
+		t.Fatalf("alias attributes missing: %v", gem.Attributes)
+	}
+
+	router := auths[1]
+	if router.Provider != "openrouter" || router.Attributes["compat_name"] != "OpenRouter" || router.Attributes["api_key"] != "or-key" {
+		t.Fatalf("unexpected compat auth: %+v", router)
+	}
+	if router.Priority != 10 || router.Weight != 100 {
+		t.Fatalf("defaults not applied: priority=%d weight=%d", router.Priority, router.Weight)
+	}
+}
@@ -0,0 +1,33 @@
+package synthesizer
+
+import (
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+// StoreSynthesizer generates Auth entries from credentials kept in a token store instead of
+// auth-dir, such as the SQLite store. Records are expanded exactly like auth files.
+type StoreSynthesizer struct{}
+
+// NewStoreSynthesizer creates a new StoreSynthesizer instance.
+func NewStoreSynthesizer() *StoreSynthesizer {
+	return &StoreSynthesizer{}
+}
+
+// Synthesize generates Auth entries from ctx.Records.
+func (s *StoreSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, error) {
+	if ctx == nil {
+		return nil, nil
+	}
+	out := make([]*coreauth.Auth, 0, len(ctx.Records))
+	for _, record := range ctx.Records {
+		if record == nil || record.ID == "" || record.Metadata == nil {
+			continue
+		}
+		source := record.Attributes["source"]
+		if source == "" {
+			source = record.ID
+		}
+		out = append(out, synthesizeMetadataAuths(ctx, record.ID, source, "", record.Metadata)...)
+	}
+	return out, nil
+}
@@ -0,0 +1,41 @@
+package synthesizer
+
+import (
+	"testing"
+	"time"
+
+	"cliproxy/internal/config"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+func TestStoreSynthesizer_ExpandsRecordsLikeFiles(t *testing.T) {
+	ctx := &SynthesisContext{
+		Config:      &config.Config{},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+		Records: []*coreauth.Auth{
+			{ID: "claude.json", Attributes: map[string]string{"source": "sqlite:claude.json"}, Metadata: map[string]any{"type": "claude", "email": "a@example.com", "prefix": "team"}},
+			{ID: "gemini.json", Metadata: map[string]any{"type": "gemini", "email": "b@example.com", "project_id": "p1,p2"}},
+			{ID: "untyped.json", Metadata: map[string]any{"email": "c@example.com"}},
+			{ID: "runtime-only"},
+		},
+	}
+
+	auths, err := NewStoreSynthesizer().Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 4 {
+		t.Fatalf("expected claude, gemini primary and two virtual auths, got %d", len(auths))
+	}
+	claude := auths[0]
+	if claude.Provider != "claude" || claude.Label != "a@example.com" || claude.Prefix != "team" || claude.Attributes["source"] != "sqlite:claude.json" {
+		t.Fatalf("unexpected claude auth: %+v", claude)
+	}
+	if _, hasPath := claude.Attributes["path"]; hasPath {
+		t.Fatal("stored records must not claim a file path")
+	}
+	if primary := auths[1]; primary.Provider != "gemini-cli" || !primary.Disabled || auths[2].Attributes["gemini_virtual_parent"] != "gemini.json" {
+		t.Fatalf("gemini record not expanded into virtual auths: %+v", primary)
+	}
+}
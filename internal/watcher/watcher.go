@@ -9,6 +9,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"cliproxy/internal/config"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
 	coreauth "cliproxy/sdk/cliproxy/auth"
@@ -37,6 +38,7 @@ type Watcher struct {
 	dispatchCancel    context.CancelFunc
 	mirroredAuthDir   string
 	oldConfigYaml     []byte
+	recordStore       coreauth.Store
 }
 
 // AuthUpdateAction represents the type of change detected in auth sources.
@@ -112,10 +114,27 @@ func (w *Watcher) DispatchRuntimeAuthUpdate(update AuthUpdate) bool {
 	return w.dispatchRuntimeAuthUpdate(update)
 }
 
+// SetRecordStore makes snapshots include the credentials held by store, for token stores that
+// keep credentials outside auth-dir. Files in auth-dir are still watched.
+func (w *Watcher) SetRecordStore(store coreauth.Store) {
+	w.clientsMutex.Lock()
+	w.recordStore = store
+	w.clientsMutex.Unlock()
+}
+
 // SnapshotCoreAuths converts current clients snapshot into core auth entries.
 func (w *Watcher) SnapshotCoreAuths() []*coreauth.Auth {
 	w.clientsMutex.RLock()
 	cfg := w.config
+	store := w.recordStore
 	w.clientsMutex.RUnlock()
-	return snapshotCoreAuths(cfg, w.authDir)
+	var records []*coreauth.Auth
+	if store != nil {
+		list, err := store.List(context.Background())
+		if err != nil {
+			log.Warnf("failed to list stored credentials: %v", err)
+		}
+		records = list
+	}
+	return snapshotCoreAuths(cfg, w.authDir, records)
 }
@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"cliproxy/internal/authcrypt"
 	"cliproxy/internal/config"
 	"cliproxy/internal/watcher/diff"
 	"cliproxy/internal/watcher/synthesizer"
@@ -498,6 +499,36 @@ func TestAuthFileUnchangedUsesHash(t *testing.T) {
 	}
 }
 
+func TestAuthFileUnchangedIgnoresReencryption(t *testing.T) {
+	c, err := authcrypt.NewCipher([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	authcrypt.SetDefault(c)
+	t.Cleanup(func() { authcrypt.SetDefault(nil) })
+
+	authFile := filepath.Join(t.TempDir(), "sealed.json")
+	content := []byte(`{"type":"demo"}`)
+	if err = authcrypt.WriteFile(authFile, content, 0o600); err != nil {
+		t.Fatalf("failed to write auth file: %v", err)
+	}
+	w := &Watcher{lastAuthHashes: make(map[string]string)}
+	sum := sha256.Sum256(content)
+	w.lastAuthHashes[w.normalizeAuthPath(authFile)] = hexString(sum[:])
+
+	// A fresh nonce changes the ciphertext but not the credential.
+	if err = authcrypt.WriteFile(authFile, content, 0o600); err != nil {
+		t.Fatalf("failed to rewrite auth file: %v", err)
+	}
+	unchanged, err := w.authFileUnchanged(authFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !unchanged {
+		t.Fatal("expected re-encrypted file with identical plaintext to report unchanged")
+	}
+}
+
 func TestAuthFileUnchangedEmptyAndMissing(t *testing.T) {
 	tmpDir := t.TempDir()
 	emptyFile := filepath.Join(tmpDir, "empty.json")
@@ -1,15 +1,82 @@
 import { apiClient as api } from './client';
 
+export type NotificationEvent = 'success' | 'failure';
+
+export interface NotificationChannel {
+  type: 'webhook' | 'wecom' | 'slack' | 'feishu' | 'dingtalk' | 'telegram' | 'discord' | 'email';
+  name?: string;
+  on?: NotificationEvent[];
+  url?: string;
+  secret?: string;
+  headers?: Record<string, string>;
+  bot_token?: string;
+  chat_id?: string;
+  smtp_host?: string;
+  smtp_port?: number;
+  smtp_username?: string;
+  smtp_password?: string;
+  from?: string;
+  to?: string[];
+  title?: string;
+  template?: string;
+  body_template?: string;
+  retries?: number;
+}
+
+export interface DeliveryStatus {
+  channel: string;
+  type: NotificationChannel['type'];
+  success: boolean;
+  status?: number;
+  attempts: number;
+  error?: string;
+}
+
+export interface CredentialFilter {
+  providers?: string[];
+  tags?: string[];
+}
+
+export interface ProbeResult {
+  auth_id: string;
+  auth_index: string;
+  label?: string;
+  provider: string;
+  model?: string;
+  success: boolean;
+  latency_ms: number;
+  status?: number;
+  error?: string;
+}
+
 export interface SchedulerTask {
   id: string;
   name: string;
-  type: 'interval' | 'fixed_time' | 'daily';
+  type: 'interval' | 'fixed_time' | 'daily' | 'cron' | 'system_report' | 'credential_probe' | 'credential_report';
   interval?: string;
   fixed_time?: string;
   daily_time?: string;
+  cron?: string;
+  timezone?: string;
+  catch_up?: 'skip' | 'once' | 'all';
   prompt: string;
   model: string;
+  api?: 'chat' | 'responses' | 'messages';
+  system_prompt?: string;
+  messages?: { role: 'system' | 'user' | 'assistant'; content: string }[];
+  parameters?: { temperature?: number; top_p?: number; max_tokens?: number; stop?: string[] };
+  tools?: unknown[];
+  response_schema?: Record<string, unknown>;
+  metadata?: Record<string, string>;
+  credentials?: CredentialFilter;
+  report_window?: string;
   webhook_url?: string;
+  notifications?: NotificationChannel[];
+  max_retries?: number;
+  retry_backoff?: string;
+  timeout?: string;
+  pause_after_failures?: number;
+  failure_webhook_url?: string;
   status: 'active' | 'paused' | 'finished';
   created_at: string;
   last_run_at?: string;
@@ -19,6 +86,9 @@ export interface SchedulerTask {
 
 export interface SchedulerLog {
   id: string;
+  run_id?: string;
+  attempt?: number;
+  will_retry?: boolean;
   task_id: string;
   task_name: string;
   executed_at: string;
@@ -26,6 +96,10 @@ export interface SchedulerLog {
   success: boolean;
   output: string;
   webhook_status: number;
+  notifications?: DeliveryStatus[];
+  probes?: ProbeResult[];
+  request?: string;
+  response?: string;
 }
 
 export const schedulerApi = {
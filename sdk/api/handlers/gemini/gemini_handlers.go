@@ -1,6 +1,6 @@
 // Package gemini provides HTTP handlers for Gemini API endpoints.
 // This package implements handlers for managing Gemini model operations including
-// model listing, content generation, streaming content generation, and token counting.
+// model listing, content generation, streaming content generation, token counting and embeddings.
 // It serves as a proxy layer between clients and the Gemini backend service,
 // handling request translation, client management, and response processing.
 package gemini
@@ -17,6 +17,8 @@ import (
 	"cliproxy/internal/interfaces"
 	"cliproxy/internal/registry"
 	"cliproxy/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // GeminiAPIHandler contains the handlers for Gemini API endpoints.
@@ -211,6 +213,10 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 		h.handleStreamGenerateContent(c, action[0], rawJSON)
 	case "countTokens":
 		h.handleCountTokens(c, action[0], rawJSON)
+	case "embedContent":
+		h.handleEmbedContent(c, action[0], rawJSON, false)
+	case "batchEmbedContents":
+		h.handleEmbedContent(c, action[0], rawJSON, true)
 	}
 }
 
@@ -273,6 +279,42 @@ func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, modelName string, r
 	cliCancel()
 }
 
+// handleEmbedContent handles embedContent and batchEmbedContents requests. A single
+// embedContent request is sent as a one-element batch and its vector unwrapped from the
+// batch response, so providers only deal with the batch format.
+//
+// Parameters:
+//   - c: The Gin context for the request
+//   - modelName: The name of the embedding model
+//   - rawJSON: The raw JSON request body
+//   - batch: Whether the request is a batchEmbedContents request
+func (h *GeminiAPIHandler) handleEmbedContent(c *gin.Context, modelName string, rawJSON []byte, batch bool) {
+	c.Header("Content-Type", "application/json")
+	payload := rawJSON
+	if !batch {
+		payload, _ = sjson.SetRawBytes([]byte(`{"requests":[]}`), "requests.-1", rawJSON)
+	}
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteEmbedWithAuthManager(cliCtx, constant.GeminiEmbedding, modelName, payload)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	if !batch {
+		out := []byte(`{"embedding":{"values":[]}}`)
+		if embedding := gjson.GetBytes(resp, "embeddings.0"); embedding.Exists() {
+			out, _ = sjson.SetRawBytes(out, "embedding", []byte(embedding.Raw))
+		}
+		if tokens := gjson.GetBytes(resp, "usageMetadata"); tokens.Exists() {
+			out, _ = sjson.SetRawBytes(out, "usageMetadata", []byte(tokens.Raw))
+		}
+		resp = out
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
 // handleGenerateContent handles non-streaming content generation requests for Gemini models.
 // This function processes the request synchronously and returns the complete generated
 // response in a single API call. It supports various generation parameters and
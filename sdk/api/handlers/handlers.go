@@ -7,12 +7,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	keypolicy "cliproxy/internal/access/key_policy"
 	"cliproxy/internal/interfaces"
+	"cliproxy/internal/responsecache"
+	"cliproxy/internal/responsestore"
 	"cliproxy/internal/router"
 	"cliproxy/internal/util"
 	coreauth "cliproxy/sdk/cliproxy/auth"
@@ -53,6 +57,12 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// ResponseCache serves repeated identical completion requests without calling upstream.
+	ResponseCache *responsecache.Cache
+
+	// ResponseStore keeps /v1/responses results for previous_response_id and retrieval.
+	ResponseStore *responsestore.Store
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -65,10 +75,15 @@ type BaseAPIHandler struct {
 // Returns:
 //   - *BaseAPIHandler: A new API handlers instance
 func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager, r *router.Router) *BaseAPIHandler {
+	if cfg != nil {
+		keypolicy.Default().SetPolicies(cfg.APIKeyPolicies)
+	}
 	return &BaseAPIHandler{
-		Cfg:         cfg,
-		AuthManager: authManager,
-		Router:      r,
+		Cfg:           cfg,
+		AuthManager:   authManager,
+		Router:        r,
+		ResponseCache: responsecache.New(cfg),
+		ResponseStore: responsestore.New(cfg),
 	}
 }
 
@@ -78,7 +93,14 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager, r
 // Parameters:
 //   - clients: The new slice of AI service clients
 //   - cfg: The new application configuration
-func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) { h.Cfg = cfg }
+func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) {
+	h.Cfg = cfg
+	if cfg != nil {
+		keypolicy.Default().SetPolicies(cfg.APIKeyPolicies)
+	}
+	h.ResponseCache.Configure(cfg)
+	h.ResponseStore.Configure(cfg)
+}
 
 // GetAlt extracts the 'alt' parameter from the request query string.
 // It checks both 'alt' and '$alt' parameters and returns the appropriate value.
@@ -104,10 +126,11 @@ func (h *BaseAPIHandler) GetAlt(c *gin.Context) string {
 // contextKey defines a custom type for context keys to avoid collisions.
 type contextKey string
 
-const (
-	ginContextKey     contextKey = "gin"
-	handlerContextKey contextKey = "handler"
-)
+// ginContextKey is deliberately an untyped string: executors and the usage plugin look the Gin
+// context up with ctx.Value("gin").
+const ginContextKey = "gin"
+
+const handlerContextKey contextKey = "handler"
 
 // GetContextWithCancel creates a new context with cancellation capabilities.
 // It embeds the Gin context and the API handler into the new context for later use.
@@ -191,6 +214,9 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	if errMsg := checkKeyPolicy(ctx, modelName, true); errMsg != nil {
+		return nil, errMsg
+	}
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
@@ -211,7 +237,19 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	if cloned := cloneMetadata(metadata); cloned != nil {
 		opts.Metadata = cloned
 	}
-	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	cacheKey := h.responseCacheKey(ctx, handlerType, modelName, rawJSON, false)
+	if cacheKey != "" {
+		if entry, ok := h.ResponseCache.Get(cacheKey); ok {
+			if payload, okPayload := entry.NonStreamPayload(ctx, opts.SourceFormat, normalizedModel, rawJSON); okPayload {
+				setCacheStatus(ctx, "HIT")
+				setServedModelHeader(ctx, entry.ServedModel)
+				return payload, nil
+			}
+		}
+		setCacheStatus(ctx, "MISS")
+	}
+	servedModel := ""
+	resp, err := h.AuthManager.Execute(withServedModelHeader(ctx, &servedModel), providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -227,12 +265,18 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	if cacheKey != "" {
+		h.ResponseCache.Put(cacheKey, &responsecache.Entry{Format: handlerType, Model: normalizedModel, ServedModel: servedModel, Payload: cloneBytes(resp.Payload)})
+	}
 	return cloneBytes(resp.Payload), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	if errMsg := checkKeyPolicy(ctx, modelName, false); errMsg != nil {
+		return nil, errMsg
+	}
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
@@ -253,7 +297,51 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if cloned := cloneMetadata(metadata); cloned != nil {
 		opts.Metadata = cloned
 	}
-	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	resp, err := h.AuthManager.ExecuteCount(withServedModelHeader(ctx, nil), providers, req, opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+			if code := se.StatusCode(); code > 0 {
+				status = code
+			}
+		}
+		var addon http.Header
+		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+			if hdr := he.Headers(); hdr != nil {
+				addon = hdr.Clone()
+			}
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+	}
+	return cloneBytes(resp.Payload), nil
+}
+
+// ExecuteEmbedWithAuthManager executes an embedding request via the core auth manager.
+// handlerType is one of the embedding formats, e.g. "openai-embedding".
+func (h *BaseAPIHandler) ExecuteEmbedWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if errMsg := checkKeyPolicy(ctx, modelName, true); errMsg != nil {
+		return nil, errMsg
+	}
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	req := coreexecutor.Request{
+		Model:   normalizedModel,
+		Payload: cloneBytes(rawJSON),
+	}
+	if cloned := cloneMetadata(metadata); cloned != nil {
+		req.Metadata = cloned
+	}
+	opts := coreexecutor.Options{
+		Stream:          false,
+		OriginalRequest: cloneBytes(rawJSON),
+		SourceFormat:    sdktranslator.FromString(handlerType),
+	}
+	if cloned := cloneMetadata(metadata); cloned != nil {
+		opts.Metadata = cloned
+	}
+	resp, err := h.AuthManager.ExecuteEmbed(withServedModelHeader(ctx, nil), providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -275,6 +363,12 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	if errMsg := checkKeyPolicy(ctx, modelName, true); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -298,7 +392,19 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	if cloned := cloneMetadata(metadata); cloned != nil {
 		opts.Metadata = cloned
 	}
-	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	cacheKey := h.responseCacheKey(ctx, handlerType, modelName, rawJSON, true)
+	if cacheKey != "" {
+		if entry, ok := h.ResponseCache.Get(cacheKey); ok {
+			if cached, okChunks := entry.StreamChunks(ctx, opts.SourceFormat, normalizedModel, rawJSON); okChunks {
+				setCacheStatus(ctx, "HIT")
+				setServedModelHeader(ctx, entry.ServedModel)
+				return replayCachedStream(ctx, cached)
+			}
+		}
+		setCacheStatus(ctx, "MISS")
+	}
+	servedModel := ""
+	chunks, err := h.AuthManager.ExecuteStream(withServedModelHeader(ctx, &servedModel), providers, req, opts)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -322,6 +428,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		var recorded [][]byte
 		for chunk := range chunks {
 			if chunk.Err != nil {
 				status := http.StatusInternalServerError
@@ -340,13 +447,67 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				return
 			}
 			if len(chunk.Payload) > 0 {
+				if cacheKey != "" {
+					recorded = append(recorded, cloneBytes(chunk.Payload))
+				}
 				dataChan <- cloneBytes(chunk.Payload)
 			}
 		}
+		if cacheKey != "" && len(recorded) > 0 && ctx.Err() == nil {
+			h.ResponseCache.Put(cacheKey, &responsecache.Entry{Format: handlerType, Model: normalizedModel, ServedModel: servedModel, Stream: true, Chunks: recorded})
+		}
+	}()
+	return dataChan, errChan
+}
+
+// replayCachedStream emits cached chunks through the same channel pair a live stream uses.
+func replayCachedStream(ctx context.Context, cached [][]byte) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		for _, chunk := range cached {
+			select {
+			case dataChan <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
 	}()
 	return dataChan, errChan
 }
 
+// checkKeyPolicy applies the configured API key policy for the client that issued the request.
+// When admit is false only the model allowlist is checked and no rate-limit capacity is consumed.
+func checkKeyPolicy(ctx context.Context, modelName string, admit bool) *interfaces.ErrorMessage {
+	if ctx == nil {
+		return nil
+	}
+	c, ok := ctx.Value(ginContextKey).(*gin.Context)
+	if !ok || c == nil {
+		return nil
+	}
+	apiKey := c.GetString("apiKey")
+	if apiKey == "" {
+		return nil
+	}
+	var err error
+	if admit {
+		err = keypolicy.Default().Admit(apiKey, modelName)
+	} else {
+		err = keypolicy.Default().CheckModel(apiKey, modelName)
+	}
+	if err == nil {
+		return nil
+	}
+	var perr *keypolicy.Error
+	if errors.As(err, &perr) {
+		return &interfaces.ErrorMessage{StatusCode: perr.StatusCode(), Error: perr, Addon: perr.Headers()}
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: err}
+}
+
 func (h *BaseAPIHandler) getRequestDetails(ctx context.Context, modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
 	// Resolve "auto" model to an actual available model first
 	resolvedModelName := util.ResolveAutoModel(modelName)
@@ -361,10 +522,12 @@ func (h *BaseAPIHandler) getRequestDetails(ctx context.Context, modelName string
 
 	// 1. Resolve through the intelligent router
 	var finalModelID string
+	var ruleTags []string
 	if h.Router != nil {
 		res, _ := h.Router.Resolve(ctx, resolvedModelName, userAgent)
 		providers = res.Providers
 		finalModelID = res.ModelID
+		ruleTags = res.Tags
 	} else {
 		// Fallback for when router isn't initialized
 		providers = util.GetProviderName(resolvedModelName)
@@ -393,10 +556,28 @@ func (h *BaseAPIHandler) getRequestDetails(ctx context.Context, modelName string
 		}
 	}
 
+	if target, ok := executionTargetFrom(ctx); ok && target.Provider != "" {
+		providers = []string{target.Provider}
+	}
+
 	if len(providers) == 0 {
 		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown provider for model %s", modelName)}
 	}
 
+	if tags := requestTags(ctx, ruleTags); len(tags) > 0 {
+		if metadata == nil {
+			metadata = make(map[string]any, 1)
+		}
+		metadata[coreauth.RequiredTagsMetadataKey] = tags
+	}
+
+	if target, ok := executionTargetFrom(ctx); ok && target.AuthID != "" {
+		if metadata == nil {
+			metadata = make(map[string]any, 1)
+		}
+		metadata[coreauth.PinnedAuthMetadataKey] = target.AuthID
+	}
+
 	return providers, normalizedModel, metadata, nil
 }
 
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	keypolicy "cliproxy/internal/access/key_policy"
+	internalconfig "cliproxy/internal/config"
+	"cliproxy/internal/registry"
+	"cliproxy/internal/runtime/executor"
+	_ "cliproxy/internal/translator"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	coreexecutor "cliproxy/sdk/cliproxy/executor"
+	"cliproxy/sdk/config"
+)
+
+// modelEchoExecutor answers every request with the model it was asked to serve.
+type modelEchoExecutor struct{ provider string }
+
+func (e modelEchoExecutor) Identifier() string { return e.provider }
+
+func (modelEchoExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: []byte(fmt.Sprintf(`{"id":"r1","model":%q}`, req.Model))}, nil
+}
+
+func (modelEchoExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (modelEchoExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (modelEchoExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+// requestContext returns a handler context for a request made with apiKey and its recorder.
+func requestContext(apiKey string) (context.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), ginContextKey, c), rec
+}
+
+func TestExecute_FallbackRespectsKeyPolicyAndCache(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("handler-fallback-a", "handler-fallback", []*registry.ModelInfo{{ID: "hfb-opus"}})
+	reg.RegisterClient("handler-fallback-b", "handler-fallback", []*registry.ModelInfo{{ID: "hfb-sonnet"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("handler-fallback-a")
+		reg.UnregisterClient("handler-fallback-b")
+	})
+
+	m := coreauth.NewManager(nil, &coreauth.FillFirstSelector{}, nil)
+	m.RegisterExecutor(modelEchoExecutor{provider: "handler-fallback"})
+	m.SetModelFallbacks(map[string][]string{"hfb-opus": {"hfb-sonnet"}})
+	cooling := map[string]*coreauth.ModelState{"hfb-opus": {
+		Unavailable:    true,
+		NextRetryAfter: time.Now().Add(time.Minute),
+		Quota:          coreauth.QuotaState{Exceeded: true},
+	}}
+	if _, err := m.Register(context.Background(), &coreauth.Auth{ID: "handler-fallback-a", Provider: "handler-fallback", Status: coreauth.StatusActive, ModelStates: cooling}); err != nil {
+		t.Fatalf("register a: %v", err)
+	}
+	if _, err := m.Register(context.Background(), &coreauth.Auth{ID: "handler-fallback-b", Provider: "handler-fallback", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("register b: %v", err)
+	}
+
+	cfg := &config.SDKConfig{
+		APIKeyPolicies: []config.APIKeyPolicy{{APIKey: "opus-only", AllowedModels: []string{"hfb-opus"}}},
+		ResponseCache:  config.ResponseCacheConfig{Enable: true},
+	}
+	h := NewBaseAPIHandlers(cfg, m, nil)
+	t.Cleanup(func() { NewBaseAPIHandlers(&config.SDKConfig{}, nil, nil) })
+	raw := []byte(`{"model":"hfb-opus","messages":[{"role":"user","content":"hi"}]}`)
+
+	ctx, rec := requestContext("opus-only")
+	if _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "hfb-opus", raw, ""); errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("restricted key should not be served by a fallback model, got %+v", errMsg)
+	}
+	if served := rec.Header().Get(servedModelHeader); served != "" {
+		t.Fatalf("restricted key got %s = %s", servedModelHeader, served)
+	}
+
+	for _, want := range []string{"MISS", "HIT"} {
+		ctx, rec = requestContext("open")
+		payload, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "hfb-opus", raw, "")
+		if errMsg != nil {
+			t.Fatalf("request error: %v", errMsg.Error)
+		}
+		if string(payload) != `{"id":"r1","model":"hfb-opus"}` {
+			t.Fatalf("payload = %s", payload)
+		}
+		if got := rec.Header().Get(cacheStatusHeader); got != want {
+			t.Fatalf("%s = %q, want %s", cacheStatusHeader, got, want)
+		}
+		if served := rec.Header().Get(servedModelHeader); served != "hfb-sonnet" {
+			t.Fatalf("%s on %s = %q, want hfb-sonnet", servedModelHeader, want, served)
+		}
+	}
+}
+
+func TestExecute_KeyBudgetAccruesAndRejects(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"hb-model","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":40,"completion_tokens":20,"total_tokens":60}}`))
+	}))
+	defer upstream.Close()
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("handler-budget", "handler-budget", []*registry.ModelInfo{{ID: "hb-model"}})
+	t.Cleanup(func() { reg.UnregisterClient("handler-budget") })
+
+	m := coreauth.NewManager(nil, &coreauth.FillFirstSelector{}, nil)
+	m.RegisterExecutor(executor.NewOpenAICompatExecutor("handler-budget", &internalconfig.Config{}))
+	auth := &coreauth.Auth{ID: "handler-budget", Provider: "handler-budget", Status: coreauth.StatusActive, Attributes: map[string]string{"base_url": upstream.URL}}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	cfg := &config.SDKConfig{APIKeyPolicies: []config.APIKeyPolicy{{APIKey: "budgeted", TokensPerDay: 100}}}
+	h := NewBaseAPIHandlers(cfg, m, nil)
+	t.Cleanup(func() { NewBaseAPIHandlers(&config.SDKConfig{}, nil, nil) })
+	raw := []byte(`{"model":"hb-model","messages":[{"role":"user","content":"hi"}]}`)
+
+	tokensToday := func() int64 {
+		for _, usage := range keypolicy.Default().Snapshot() {
+			return usage.TokensToday
+		}
+		return 0
+	}
+	for i, want := range []int64{60, 120} {
+		ctx, _ := requestContext("budgeted")
+		if _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "hb-model", raw, ""); errMsg != nil {
+			t.Fatalf("request %d error: %v", i+1, errMsg.Error)
+		}
+		// Usage reaches the key policy through the asynchronous usage plugin pipeline.
+		deadline := time.Now().Add(2 * time.Second)
+		for tokensToday() < want && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := tokensToday(); got != want {
+			t.Fatalf("tokens today after request %d = %d, want %d", i+1, got, want)
+		}
+	}
+
+	ctx, _ := requestContext("budgeted")
+	_, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "hb-model", raw, "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("key over its daily budget should be rejected, got %+v", errMsg)
+	}
+	if errMsg.Addon.Get("Retry-After") == "" {
+		t.Fatalf("budget rejection should carry Retry-After")
+	}
+}
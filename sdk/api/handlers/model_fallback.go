@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	keypolicy "cliproxy/internal/access/key_policy"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+// servedModelHeader names the model that actually served a request after a model fallback.
+const servedModelHeader = "X-Served-Model"
+
+// withServedModelHeader arranges for fallback models chosen by the auth manager to be
+// reported to the client through the served-model response header and, when served is not
+// nil, recorded there. Fallback models the client API key may not use are skipped.
+func withServedModelHeader(ctx context.Context, served *string) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	c, ok := ctx.Value(ginContextKey).(*gin.Context)
+	if !ok || c == nil {
+		return ctx
+	}
+	if apiKey := c.GetString("apiKey"); apiKey != "" {
+		ctx = coreauth.WithModelAllowedFunc(ctx, func(model string) bool {
+			return keypolicy.Default().CheckModel(apiKey, model) == nil
+		})
+	}
+	return coreauth.WithServedModelFunc(ctx, func(model string) {
+		if served != nil {
+			*served = model
+		}
+		c.Header(servedModelHeader, model)
+	})
+}
+
+// setServedModelHeader repeats the served-model header recorded with a cached response.
+func setServedModelHeader(ctx context.Context, model string) {
+	if ctx == nil || model == "" {
+		return
+	}
+	if c, ok := ctx.Value(ginContextKey).(*gin.Context); ok && c != nil {
+		c.Header(servedModelHeader, model)
+	}
+}
@@ -0,0 +1,76 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/constant"
+	"cliproxy/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// Embeddings handles the /v1/embeddings endpoint.
+// It accepts an OpenAI embeddings request (input as a string, an array of strings or token
+// arrays) and routes it through the auth manager to any provider that supports embeddings.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if errValidate := validateEmbeddingRequest(rawJSON); errValidate != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: errValidate.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteEmbedWithAuthManager(cliCtx, constant.OpenAIEmbedding, modelName, rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func validateEmbeddingRequest(rawJSON []byte) error {
+	if !gjson.ValidBytes(rawJSON) {
+		return fmt.Errorf("request body must be valid JSON")
+	}
+	if gjson.GetBytes(rawJSON, "model").String() == "" {
+		return fmt.Errorf("model is required")
+	}
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case !input.Exists():
+		return fmt.Errorf("input is required")
+	case input.IsArray() && len(input.Array()) == 0:
+		return fmt.Errorf("input must not be empty")
+	case input.Type != gjson.String && !input.IsArray():
+		return fmt.Errorf("input must be a string or an array")
+	}
+	switch format := gjson.GetBytes(rawJSON, "encoding_format").String(); format {
+	case "", "float", "base64":
+	default:
+		return fmt.Errorf("unsupported encoding_format %q", format)
+	}
+	return nil
+}
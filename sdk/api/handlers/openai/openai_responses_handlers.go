@@ -17,6 +17,7 @@ import (
 	"cliproxy/internal/constant"
 	"cliproxy/internal/interfaces"
 	"cliproxy/internal/registry"
+	"cliproxy/internal/responsestore"
 	"cliproxy/sdk/api/handlers"
 	"github.com/tidwall/gjson"
 )
@@ -79,15 +80,34 @@ func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
 				Type:    "invalid_request_error",
 			},
 		})
+		return
+	}
 
+	// Continue a stored conversation: previous_response_id is expanded into the full input
+	// here, so every backend receives the complete history. The request as sent is what gets
+	// stored with the response.
+	request := rawJSON
+	if prevID := gjson.GetBytes(rawJSON, "previous_response_id").String(); prevID != "" && h.ResponseStore.Enabled() {
+		chain, ok := h.ResponseStore.Conversation(c.GetString("apiKey"), prevID)
+		if !ok {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: fmt.Sprintf("Previous response with id '%s' not found.", prevID),
+					Type:    "invalid_request_error",
+					Code:    "previous_response_not_found",
+				},
+			})
+			return
+		}
+		rawJSON = responsestore.ExpandRequest(chain, rawJSON)
 	}
 
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	if streamResult.Type == gjson.True {
-		h.handleStreamingResponse(c, rawJSON)
+		h.handleStreamingResponse(c, rawJSON, request)
 	} else {
-		h.handleNonStreamingResponse(c, rawJSON)
+		h.handleNonStreamingResponse(c, rawJSON, request)
 	}
 
 }
@@ -99,7 +119,8 @@ func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 //   - rawJSON: The raw JSON bytes of the OpenAIResponses-compatible request
-func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []byte) {
+//   - request: The request as the client sent it, stored with the response
+func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON, request []byte) {
 	c.Header("Content-Type", "application/json")
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
@@ -111,8 +132,9 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
-
+		return
 	}
+	h.storeResponse(c, request, resp)
 	_, _ = c.Writer.Write(resp)
 
 	// no legacy fallback
@@ -126,7 +148,8 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 //   - rawJSON: The raw JSON bytes of the OpenAIResponses-compatible request
-func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byte) {
+//   - request: The request as the client sent it, stored with the response
+func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON, request []byte) {
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -141,31 +164,37 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 				Type:    "server_error",
 			},
 		})
-
+		return
 	}
 
 	// New core execution path
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
-	h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+	h.forwardResponsesStream(c, flusher, request, func(err error) { cliCancel(err) }, dataChan, errChan)
 
 }
 
-func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, request []byte, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+	var collected streamedResponse
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			cancel(c.Request.Context().Err())
+			return
 
 		case chunk, ok := <-data:
 			if !ok {
 				_, _ = c.Writer.Write([]byte("\n"))
 				flusher.Flush()
+				if resp := collected.response(); resp != nil {
+					h.storeResponse(c, request, resp)
+				}
 				cancel(nil)
-
+				return
 			}
 
+			collected.observe(chunk)
 			if bytes.HasPrefix(chunk, []byte("event:")) {
 				_, _ = c.Writer.Write([]byte("\n"))
 			}
@@ -186,7 +215,7 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flush
 				execErr = errMsg.Error
 			}
 			cancel(execErr)
-
+			return
 		case <-time.After(500 * time.Millisecond):
 		}
 	}
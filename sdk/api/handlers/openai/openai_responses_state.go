@@ -0,0 +1,169 @@
+package openai
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/responsestore"
+	"cliproxy/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// storeResponse saves a completed response and the input of its turn for previous_response_id,
+// unless the store is disabled or the request set "store": false. rawJSON is the request as the
+// client sent it, before previous_response_id was expanded.
+func (h *OpenAIResponsesAPIHandler) storeResponse(c *gin.Context, rawJSON, response []byte) {
+	if !h.ResponseStore.Enabled() || !responsestore.ShouldStore(rawJSON) {
+		return
+	}
+	if record := responsestore.NewRecord(c.GetString("apiKey"), rawJSON, response); record != nil {
+		h.ResponseStore.Put(record)
+	}
+}
+
+// streamedResponse reassembles the final response object from a Responses SSE stream.
+type streamedResponse struct {
+	completed []byte
+	items     []string
+}
+
+// observe inspects one stream chunk for finished output items and the completion event.
+func (s *streamedResponse) observe(chunk []byte) {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		payload, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		event := gjson.ParseBytes(bytes.TrimSpace(payload))
+		switch event.Get("type").String() {
+		case "response.output_item.done":
+			if item := event.Get("item"); item.IsObject() {
+				s.items = append(s.items, item.Raw)
+			}
+		case "response.completed", "response.incomplete":
+			if resp := event.Get("response"); resp.IsObject() {
+				s.completed = []byte(resp.Raw)
+			}
+		}
+	}
+}
+
+// response returns the final response object, or nil when the stream did not complete. Output
+// items are filled in from the stream when the completion event omits them.
+func (s *streamedResponse) response() []byte {
+	if s.completed == nil {
+		return nil
+	}
+	if len(gjson.GetBytes(s.completed, "output").Array()) == 0 && len(s.items) > 0 {
+		out, err := sjson.SetRawBytes(s.completed, "output", []byte("["+strings.Join(s.items, ",")+"]"))
+		if err == nil {
+			return out
+		}
+	}
+	return s.completed
+}
+
+// storedResponse looks up the response named in the URL for the calling API key and writes a
+// 404 when it is unknown.
+func (h *OpenAIResponsesAPIHandler) storedResponse(c *gin.Context) (*responsestore.Record, bool) {
+	id := c.Param("id")
+	record, ok := h.ResponseStore.Get(c.GetString("apiKey"), id)
+	if !ok {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Response with id '%s' not found.", id),
+				Type:    "invalid_request_error",
+			},
+		})
+		return nil, false
+	}
+	return record, true
+}
+
+// GetResponse handles GET /v1/responses/:id.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	record, ok := h.storedResponse(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", record.Response)
+}
+
+// DeleteResponse handles DELETE /v1/responses/:id.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	record, ok := h.storedResponse(c)
+	if !ok {
+		return
+	}
+	h.ResponseStore.Delete(c.GetString("apiKey"), record.ID)
+	c.JSON(http.StatusOK, gin.H{"id": record.ID, "object": "response", "deleted": true})
+}
+
+// ListResponseInputItems handles GET /v1/responses/:id/input_items, including the items
+// inherited through previous_response_id. It supports OpenAI's limit, order and after parameters.
+func (h *OpenAIResponsesAPIHandler) ListResponseInputItems(c *gin.Context) {
+	record, ok := h.storedResponse(c)
+	if !ok {
+		return
+	}
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{Message: "limit must be between 1 and 100.", Type: "invalid_request_error"},
+			})
+			return
+		}
+		limit = n
+	}
+	order := c.DefaultQuery("order", "desc")
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{Message: "order must be 'asc' or 'desc'.", Type: "invalid_request_error"},
+		})
+		return
+	}
+
+	chain, ok := h.ResponseStore.Conversation(c.GetString("apiKey"), record.ID)
+	if !ok {
+		chain = []*responsestore.Record{record}
+	}
+	var items []gjson.Result
+	for _, item := range responsestore.ConversationInput(chain) {
+		items = append(items, gjson.Parse(item))
+	}
+	if order == "desc" {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if after := c.Query("after"); after != "" {
+		for i, item := range items {
+			if item.Get("id").String() == after {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	out := []byte(`{"object":"list","data":[],"first_id":null,"last_id":null,"has_more":false}`)
+	for _, item := range items {
+		out, _ = sjson.SetRawBytes(out, "data.-1", []byte(item.Raw))
+	}
+	if len(items) > 0 {
+		out, _ = sjson.SetBytes(out, "first_id", items[0].Get("id").String())
+		out, _ = sjson.SetBytes(out, "last_id", items[len(items)-1].Get("id").String())
+	}
+	out, _ = sjson.SetBytes(out, "has_more", hasMore)
+	c.Data(http.StatusOK, "application/json", out)
+}
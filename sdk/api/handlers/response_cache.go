@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	sdktranslator "cliproxy/sdk/translator"
+)
+
+// cacheStatusHeader tells clients whether a response was served from the response cache.
+const cacheStatusHeader = "X-Cache"
+
+// responseCacheKey returns the response cache key for the request, or an empty string
+// when caching is disabled or the client opted out with Cache-Control: no-cache/no-store.
+func (h *BaseAPIHandler) responseCacheKey(ctx context.Context, handlerType, modelName string, rawJSON []byte, stream bool) string {
+	if ctx == nil || !h.ResponseCache.Enabled() {
+		return ""
+	}
+	apiKey := ""
+	if c, ok := ctx.Value(ginContextKey).(*gin.Context); ok && c != nil {
+		cacheControl := strings.ToLower(c.GetHeader("Cache-Control"))
+		if strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "no-store") {
+			return ""
+		}
+		apiKey = c.GetString("apiKey")
+	}
+	return h.ResponseCache.Key(apiKey, sdktranslator.FromString(handlerType), modelName, rawJSON, stream)
+}
+
+// setCacheStatus records the cache outcome on the client response headers.
+func setCacheStatus(ctx context.Context, status string) {
+	if ctx == nil {
+		return
+	}
+	if c, ok := ctx.Value(ginContextKey).(*gin.Context); ok && c != nil {
+		c.Header(cacheStatusHeader, status)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	keypolicy "cliproxy/internal/access/key_policy"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+// proxyTagsHeader lets clients select a credential pool by tag, e.g. "X-Proxy-Tags: production".
+const proxyTagsHeader = "X-Proxy-Tags"
+
+// requestTags combines the credential tags demanded by the request header, the client's API key
+// policy and any matching routing rules. Credentials must carry every returned tag.
+func requestTags(ctx context.Context, ruleTags []string) []string {
+	var headerTags, policyTags []string
+	if ctx != nil {
+		if c, ok := ctx.Value(ginContextKey).(*gin.Context); ok && c != nil {
+			headerTags = coreauth.ParseTags(c.GetHeader(proxyTagsHeader))
+			if apiKey := c.GetString("apiKey"); apiKey != "" {
+				policyTags = keypolicy.Default().Tags(apiKey)
+			}
+		}
+	}
+	return coreauth.NormalizeTags(headerTags, policyTags, ruleTags)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// executionTargetKey is the Gin context key holding an ExecutionTarget.
+const executionTargetKey = "EXECUTION_TARGET"
+
+// ExecutionTarget pins the requests handled for one Gin context to a provider and, optionally,
+// a single credential instead of the ones model routing would choose.
+type ExecutionTarget struct {
+	// Provider replaces the providers resolved for the model.
+	Provider string
+	// AuthID restricts credential selection to this auth.
+	AuthID string
+}
+
+// SetExecutionTarget pins the requests handled for c to target. Request replay uses it to
+// re-run a captured call against a chosen provider or credential.
+func SetExecutionTarget(c *gin.Context, target ExecutionTarget) {
+	if c == nil {
+		return
+	}
+	target.Provider = strings.TrimSpace(target.Provider)
+	target.AuthID = strings.TrimSpace(target.AuthID)
+	c.Set(executionTargetKey, target)
+}
+
+func executionTargetFrom(ctx context.Context) (ExecutionTarget, bool) {
+	if ctx == nil {
+		return ExecutionTarget{}, false
+	}
+	c, ok := ctx.Value(ginContextKey).(*gin.Context)
+	if !ok || c == nil {
+		return ExecutionTarget{}, false
+	}
+	value, exists := c.Get(executionTargetKey)
+	if !exists {
+		return ExecutionTarget{}, false
+	}
+	target, ok := value.(ExecutionTarget)
+	return target, ok
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"cliproxy/internal/runtime/executor"
+	sdktranslator "cliproxy/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Tokenize counts the prompt tokens of a chat request locally, without contacting any provider.
+// The body is an OpenAI chat, OpenAI Responses, Claude messages or Gemini generateContent request;
+// the format is taken from the "format" query parameter or detected from the body.
+func (h *BaseAPIHandler) Tokenize(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		if err == nil {
+			err = fmt.Errorf("body is not valid JSON")
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	format := detectTokenizeFormat(c.Query("format"), c.GetHeader("anthropic-version"), rawJSON)
+	model := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if model == "" {
+		model = strings.TrimSpace(c.Query("model"))
+	}
+
+	count, err := executor.CountTokensLocally(format, model, rawJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("token counting failed: %v", err),
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"model": model, "format": format.String(), "tokens": count})
+}
+
+// detectTokenizeFormat resolves the request format for Tokenize. An explicit format wins;
+// otherwise Gemini is recognised by "contents", Responses by "input" or "instructions", and
+// Claude by a top-level "system", an anthropic-version header or Claude-only content blocks.
+func detectTokenizeFormat(explicit, anthropicVersion string, rawJSON []byte) sdktranslator.Format {
+	switch strings.ToLower(strings.TrimSpace(explicit)) {
+	case "claude", "anthropic":
+		return sdktranslator.FormatClaude
+	case "gemini":
+		return sdktranslator.FormatGemini
+	case "gemini-cli":
+		return sdktranslator.FormatGeminiCLI
+	case "responses", "openai-response", "openai-responses":
+		return sdktranslator.FormatOpenAIResponse
+	case "openai", "chat":
+		return sdktranslator.FormatOpenAI
+	}
+
+	root := gjson.ParseBytes(rawJSON)
+	switch {
+	case root.Get("contents").Exists() || root.Get("request.contents").Exists():
+		return sdktranslator.FormatGemini
+	case root.Get("input").Exists() || root.Get("instructions").Exists():
+		return sdktranslator.FormatOpenAIResponse
+	case strings.TrimSpace(anthropicVersion) != "" || root.Get("system").Exists():
+		return sdktranslator.FormatClaude
+	}
+	isClaude := false
+	root.Get("messages.#.content").ForEach(func(_, blocks gjson.Result) bool {
+		blocks.ForEach(func(_, block gjson.Result) bool {
+			switch block.Get("type").String() {
+			case "tool_use", "tool_result", "thinking":
+				isClaude = true
+			case "image":
+				isClaude = block.Get("source").Exists()
+			}
+			return !isClaude
+		})
+		return !isClaude
+	})
+	if isClaude {
+		return sdktranslator.FormatClaude
+	}
+	return sdktranslator.FormatOpenAI
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -11,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	baseauth "cliproxy/internal/auth"
+	"cliproxy/internal/authcrypt"
 	cliproxyauth "cliproxy/sdk/cliproxy/auth"
 )
 
@@ -62,6 +65,10 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 	}
 
 	switch {
+	case auth.Storage != nil && authcrypt.Enabled():
+		if err = s.saveEncryptedStorage(auth.Storage, path); err != nil {
+			return "", err
+		}
 	case auth.Storage != nil:
 		if err = auth.Storage.SaveTokenToFile(path); err != nil {
 			return "", err
@@ -85,15 +92,19 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 		if errMarshal != nil {
 			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
 		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
+		if existing, errRead := authcrypt.ReadFile(path); errRead == nil {
 			if jsonEqual(existing, raw) {
 				return path, nil
 			}
-		} else if !os.IsNotExist(errRead) {
+		} else if !os.IsNotExist(errRead) && !errors.Is(errRead, authcrypt.ErrNoKey) {
 			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
 		}
+		encoded, errEncode := authcrypt.Encode(raw)
+		if errEncode != nil {
+			return "", fmt.Errorf("auth filestore: encrypt failed: %w", errEncode)
+		}
 		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		if errWrite := os.WriteFile(tmp, encoded, 0o600); errWrite != nil {
 			return "", fmt.Errorf("auth filestore: write temp failed: %w", errWrite)
 		}
 		if errRename := os.Rename(tmp, path); errRename != nil {
@@ -115,6 +126,31 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 	return path, nil
 }
 
+// saveEncryptedStorage serialises the token storage in memory and atomically replaces path with
+// the encrypted document, so the plaintext tokens never reach the disk.
+func (s *FileTokenStore) saveEncryptedStorage(storage baseauth.TokenStorage, path string) error {
+	raw, err := marshalTokenStorage(storage)
+	if err != nil {
+		return fmt.Errorf("auth filestore: marshal storage failed: %w", err)
+	}
+	if existing, errRead := authcrypt.ReadFile(path); errRead == nil && jsonEqual(existing, raw) {
+		return nil
+	}
+	if err = authcrypt.WriteFile(path, raw, 0o600); err != nil {
+		return fmt.Errorf("auth filestore: write encrypted failed: %w", err)
+	}
+	return nil
+}
+
+// marshalTokenStorage returns the JSON document SaveTokenToFile would write. Storages that do not
+// implement baseauth.TokenMarshaler are encoded with encoding/json.
+func marshalTokenStorage(storage baseauth.TokenStorage) ([]byte, error) {
+	if marshaler, ok := storage.(baseauth.TokenMarshaler); ok {
+		return marshaler.MarshalToken()
+	}
+	return json.Marshal(storage)
+}
+
 // List enumerates all auth JSON files under the configured directory.
 func (s *FileTokenStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error) {
 	dir := s.baseDirSnapshot()
@@ -175,7 +211,7 @@ func (s *FileTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -211,7 +247,7 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 		ID:               id,
 		Provider:         provider,
 		FileName:         id,
-		Label:            s.labelFor(metadata),
+		Label:            labelFor(metadata),
 		Status:           status,
 		StatusMessage:    statusMsg,
 		Attributes:       map[string]string{"path": path},
@@ -269,7 +305,7 @@ func (s *FileTokenStore) resolveAuthPath(auth *cliproxyauth.Auth) (string, error
 	return filepath.Join(dir, auth.ID), nil
 }
 
-func (s *FileTokenStore) labelFor(metadata map[string]any) string {
+func labelFor(metadata map[string]any) string {
 	if metadata == nil {
 		return ""
 	}
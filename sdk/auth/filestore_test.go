@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cliproxy/internal/authcrypt"
+	cliproxyauth "cliproxy/sdk/cliproxy/auth"
+)
+
+type jsonFileStorage struct{ body string }
+
+func (s jsonFileStorage) SaveTokenToFile(path string) error {
+	return os.WriteFile(path, []byte(s.body), 0o600)
+}
+
+func (s jsonFileStorage) MarshalToken() ([]byte, error) { return []byte(s.body), nil }
+
+// plainOnlyStorage fails the test if the encrypted store lets it write plaintext to disk.
+type plainOnlyStorage struct {
+	t    *testing.T
+	Type string `json:"type"`
+	Key  string `json:"api_key"`
+}
+
+func (s plainOnlyStorage) SaveTokenToFile(path string) error {
+	s.t.Errorf("plaintext written to %s", path)
+	return os.WriteFile(path, []byte(`{}`), 0o600)
+}
+
+func TestFileTokenStore_EncryptsAtRest(t *testing.T) {
+	c, err := authcrypt.NewCipher(bytes.Repeat([]byte{5}, 32))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	authcrypt.SetDefault(c)
+	t.Cleanup(func() { authcrypt.SetDefault(nil) })
+
+	dir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	ctx := context.Background()
+
+	meta := &cliproxyauth.Auth{ID: "claude.json", Metadata: map[string]any{"type": "claude", "refresh_token": "secret-meta"}}
+	if _, err = store.Save(ctx, meta); err != nil {
+		t.Fatalf("Save metadata: %v", err)
+	}
+	storage := &cliproxyauth.Auth{ID: "codex.json", Storage: jsonFileStorage{body: `{"type":"codex","refresh_token":"secret-storage"}`}}
+	if _, err = store.Save(ctx, storage); err != nil {
+		t.Fatalf("Save storage: %v", err)
+	}
+	plain := &cliproxyauth.Auth{ID: "qwen.json", Storage: plainOnlyStorage{t: t, Type: "qwen", Key: "secret-plain"}}
+	if _, err = store.Save(ctx, plain); err != nil {
+		t.Fatalf("Save plain storage: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Fatalf("expected only the three auth files, got %d entries", len(entries))
+	}
+	for _, name := range []string{"claude.json", "codex.json", "qwen.json"} {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		if !authcrypt.IsEncrypted(data) || bytes.Contains(data, []byte("secret")) {
+			t.Fatalf("%s stored in plaintext: %s", name, data)
+		}
+	}
+
+	before, _ := os.ReadFile(filepath.Join(dir, "claude.json"))
+	if _, err = store.Save(ctx, meta); err != nil {
+		t.Fatalf("re-Save: %v", err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "claude.json")); !bytes.Equal(before, after) {
+		t.Fatal("unchanged metadata should not be re-encrypted")
+	}
+
+	list, err := store.List(ctx)
+	if err != nil || len(list) != 3 {
+		t.Fatalf("List = %d entries, %v", len(list), err)
+	}
+	for _, a := range list {
+		if a.Provider != "claude" && a.Provider != "codex" && a.Provider != "qwen" {
+			t.Fatalf("unexpected provider %q for %s", a.Provider, a.ID)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cliproxy/internal/authcrypt"
+	"cliproxy/internal/sqlitedb"
+	cliproxyauth "cliproxy/sdk/cliproxy/auth"
+)
+
+const sqliteAuthSchema = `CREATE TABLE IF NOT EXISTS auth_records (
+	id         TEXT PRIMARY KEY,
+	provider   TEXT NOT NULL,
+	content    BLOB NOT NULL,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+)`
+
+// SQLiteTokenStore persists auth records in a SQLite database, one row per credential. Each row
+// holds the JSON document an auth file in auth-dir would contain, encrypted when auth file
+// encryption is enabled, so saving one credential never rewrites the others.
+type SQLiteTokenStore struct {
+	db *sql.DB
+
+	dirLock sync.RWMutex
+	baseDir string
+}
+
+// NewSQLiteTokenStore opens the database at path (sqlitedb.DefaultPath when empty) and creates
+// the auth table if needed.
+func NewSQLiteTokenStore(path string) (*SQLiteTokenStore, error) {
+	db, err := sqlitedb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(sqliteAuthSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("auth sqlitestore: create schema failed: %w", err)
+	}
+	return &SQLiteTokenStore{db: db}, nil
+}
+
+// SetBaseDir records the auth directory so callers that address records by file path, such as
+// the management API, resolve to the same IDs as the file store.
+func (s *SQLiteTokenStore) SetBaseDir(dir string) {
+	s.dirLock.Lock()
+	s.baseDir = strings.TrimSpace(dir)
+	s.dirLock.Unlock()
+}
+
+// Close releases the database handle.
+func (s *SQLiteTokenStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Save upserts the record. Records carrying a token storage are stored as the storage's JSON
+// document, and auth.Metadata is replaced with that document so the caller can register the
+// record directly; there is no file for the watcher to pick up.
+func (s *SQLiteTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
+	if auth == nil {
+		return "", fmt.Errorf("auth sqlitestore: auth is nil")
+	}
+	id := s.idFor(auth.ID)
+	if id == "" {
+		id = s.idFor(auth.FileName)
+	}
+	if id == "" {
+		return "", fmt.Errorf("auth sqlitestore: missing id")
+	}
+
+	var doc map[string]any
+	switch {
+	case auth.Storage != nil:
+		raw, err := marshalTokenStorage(auth.Storage)
+		if err != nil {
+			return "", fmt.Errorf("auth sqlitestore: marshal storage failed: %w", err)
+		}
+		if err = json.Unmarshal(raw, &doc); err != nil {
+			return "", fmt.Errorf("auth sqlitestore: decode storage failed: %w", err)
+		}
+		for k, v := range auth.Metadata {
+			if _, exists := doc[k]; !exists {
+				doc[k] = v
+			}
+		}
+		auth.Metadata = doc
+	case auth.Metadata != nil:
+		doc = make(map[string]any, len(auth.Metadata)+2)
+		for k, v := range auth.Metadata {
+			doc[k] = v
+		}
+		if auth.Status != "" {
+			doc["status"] = auth.Status
+		}
+		if auth.StatusMessage != "" {
+			doc["status_message"] = auth.StatusMessage
+		}
+	default:
+		return "", fmt.Errorf("auth sqlitestore: nothing to persist for %s", auth.ID)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("auth sqlitestore: marshal metadata failed: %w", err)
+	}
+	content, err := authcrypt.Encode(raw)
+	if err != nil {
+		return "", fmt.Errorf("auth sqlitestore: encrypt failed: %w", err)
+	}
+	provider, _ := doc["type"].(string)
+	if provider == "" {
+		provider = auth.Provider
+	}
+	now := time.Now().UnixMilli()
+	_, err = s.db.ExecContext(ctx, `INSERT INTO auth_records (id, provider, content, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET provider = excluded.provider, content = excluded.content, updated_at = excluded.updated_at`,
+		id, provider, content, now, now)
+	if err != nil {
+		return "", fmt.Errorf("auth sqlitestore: save failed: %w", err)
+	}
+	if strings.TrimSpace(auth.FileName) == "" {
+		auth.FileName = id
+	}
+	return "sqlite:" + id, nil
+}
+
+// List returns every stored record, shaped like the records the file store reads from auth-dir.
+func (s *SQLiteTokenStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, content, created_at, updated_at FROM auth_records ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("auth sqlitestore: list failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []*cliproxyauth.Auth
+	for rows.Next() {
+		var id string
+		var content []byte
+		var createdAt, updatedAt int64
+		if err = rows.Scan(&id, &content, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("auth sqlitestore: list failed: %w", err)
+		}
+		auth, errDecode := decodeSQLiteRecord(id, content, time.UnixMilli(createdAt), time.UnixMilli(updatedAt))
+		if errDecode != nil {
+			// Mirror the file store, which skips unreadable files instead of failing the listing.
+			continue
+		}
+		entries = append(entries, auth)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("auth sqlitestore: list failed: %w", err)
+	}
+	return entries, nil
+}
+
+// Delete removes the record identified by id, which may also be its path under auth-dir.
+func (s *SQLiteTokenStore) Delete(ctx context.Context, id string) error {
+	id = s.idFor(id)
+	if id == "" {
+		return fmt.Errorf("auth sqlitestore: id is empty")
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM auth_records WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("auth sqlitestore: delete failed: %w", err)
+	}
+	return nil
+}
+
+// idFor maps an auth ID or a path under auth-dir to the stored ID.
+func (s *SQLiteTokenStore) idFor(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || !filepath.IsAbs(id) {
+		return id
+	}
+	s.dirLock.RLock()
+	baseDir := s.baseDir
+	s.dirLock.RUnlock()
+	if baseDir != "" {
+		if rel, err := filepath.Rel(baseDir, id); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return id
+}
+
+func decodeSQLiteRecord(id string, content []byte, createdAt, updatedAt time.Time) (*cliproxyauth.Auth, error) {
+	raw, err := authcrypt.Decode(content)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]any)
+	if err = json.Unmarshal(raw, &metadata); err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		return nil, errors.New("empty record")
+	}
+	provider, _ := metadata["type"].(string)
+	if provider == "" {
+		provider = "unknown"
+	}
+	status := cliproxyauth.StatusActive
+	var statusMsg string
+	if v, ok := metadata["status"].(string); ok && v != "" {
+		status = cliproxyauth.Status(v)
+	}
+	if msg, ok := metadata["status_message"].(string); ok {
+		statusMsg = msg
+	}
+	auth := &cliproxyauth.Auth{
+		ID:            id,
+		Provider:      provider,
+		FileName:      id,
+		Label:         labelFor(metadata),
+		Status:        status,
+		StatusMessage: statusMsg,
+		Attributes:    map[string]string{"source": "sqlite:" + id},
+		Metadata:      metadata,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+	}
+	if email, ok := metadata["email"].(string); ok && email != "" {
+		auth.Attributes["email"] = email
+	}
+	return auth, nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"cliproxy/internal/authcrypt"
+	cliproxyauth "cliproxy/sdk/cliproxy/auth"
+)
+
+func TestSQLiteTokenStore_SaveListDelete(t *testing.T) {
+	c, err := authcrypt.NewCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	authcrypt.SetDefault(c)
+	t.Cleanup(func() { authcrypt.SetDefault(nil) })
+
+	authDir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "cliproxy.db")
+	store, err := NewSQLiteTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteTokenStore: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	store.SetBaseDir(authDir)
+	ctx := context.Background()
+
+	meta := &cliproxyauth.Auth{ID: "claude.json", Status: cliproxyauth.StatusDisabled, Metadata: map[string]any{"type": "claude", "email": "a@example.com", "refresh_token": "secret-meta"}}
+	if _, err = store.Save(ctx, meta); err != nil {
+		t.Fatalf("Save metadata: %v", err)
+	}
+	storage := &cliproxyauth.Auth{ID: "codex.json", Storage: jsonFileStorage{body: `{"type":"codex","refresh_token":"secret-storage"}`}, Metadata: map[string]any{"email": "b@example.com"}}
+	if _, err = store.Save(ctx, storage); err != nil {
+		t.Fatalf("Save storage: %v", err)
+	}
+	if storage.Metadata["refresh_token"] != "secret-storage" || storage.Metadata["email"] != "b@example.com" {
+		t.Fatalf("storage metadata not filled in: %v", storage.Metadata)
+	}
+	meta.Metadata["refresh_token"] = "rotated"
+	if _, err = store.Save(ctx, meta); err != nil {
+		t.Fatalf("re-Save: %v", err)
+	}
+
+	var raw []byte
+	if err = store.db.QueryRow(`SELECT content FROM auth_records WHERE id = ?`, "claude.json").Scan(&raw); err != nil {
+		t.Fatalf("read row: %v", err)
+	}
+	if !authcrypt.IsEncrypted(raw) || bytes.Contains(raw, []byte("rotated")) {
+		t.Fatalf("record stored in plaintext: %s", raw)
+	}
+
+	list, err := store.List(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List = %d entries, %v", len(list), err)
+	}
+	if list[0].ID != "claude.json" || list[0].Provider != "claude" || list[0].Status != cliproxyauth.StatusDisabled || list[0].Metadata["refresh_token"] != "rotated" || list[0].Label != "a@example.com" {
+		t.Fatalf("unexpected claude record: %+v", list[0])
+	}
+	if list[1].ID != "codex.json" || list[1].Provider != "codex" {
+		t.Fatalf("unexpected codex record: %+v", list[1])
+	}
+
+	if err = store.Delete(ctx, filepath.Join(authDir, "codex.json")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if list, _ = store.List(ctx); len(list) != 1 || list[0].ID != "claude.json" {
+		t.Fatalf("Delete by path left %d records", len(list))
+	}
+}
@@ -114,6 +114,9 @@ type Manager struct {
 	// modelNameMappings stores global model name alias mappings (alias -> upstream name) keyed by channel.
 	modelNameMappings atomic.Value
 
+	// modelFallbacks stores the compiled model fallback chains.
+	modelFallbacks atomic.Value
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
@@ -139,6 +142,16 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 	}
 }
 
+// Selector returns the selector currently used to pick credentials.
+func (m *Manager) Selector() Selector {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.selector
+}
+
 func (m *Manager) SetSelector(selector Selector) {
 	if m == nil {
 		return
@@ -260,86 +273,60 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When every credential for the model is cooling down, configured fallback models are tried once.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
-	normalized := m.normalizeProviders(providers)
-	if len(normalized) == 0 {
-		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
-	}
-	rotated := m.rotateProviders(req.Model, normalized)
-
-	retryTimes, maxWait := m.retrySettings()
-	attempts := retryTimes + 1
-	if attempts < 1 {
-		attempts = 1
-	}
-
-	var lastErr error
-	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+	resp, fellBack, err := executeWithRetry(ctx, m, providers, req, opts, true, func(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return m.executeProvidersOnce(ctx, providers, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
 			return m.executeWithProvider(execCtx, provider, req, opts)
 		})
-		if errExec == nil {
-			return resp, nil
-		}
-		lastErr = errExec
-		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, attempts, rotated, req.Model, maxWait)
-		if !shouldRetry {
-			break
-		}
-		if errWait := waitForCooldown(ctx, wait); errWait != nil {
-			return cliproxyexecutor.Response{}, errWait
-		}
+	})
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
 	}
-	if lastErr != nil {
-		return cliproxyexecutor.Response{}, lastErr
+	if fellBack {
+		resp.Payload = rewriteResponseModel(resp.Payload, req.Model)
 	}
-	return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
+	return resp, nil
 }
 
 // ExecuteCount performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// Token counts describe the requested model, so fallback models are never tried.
 func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
-	normalized := m.normalizeProviders(providers)
-	if len(normalized) == 0 {
-		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
-	}
-	rotated := m.rotateProviders(req.Model, normalized)
-
-	retryTimes, maxWait := m.retrySettings()
-	attempts := retryTimes + 1
-	if attempts < 1 {
-		attempts = 1
-	}
-
-	var lastErr error
-	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+	resp, _, err := executeWithRetry(ctx, m, providers, req, opts, false, func(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return m.executeProvidersOnce(ctx, providers, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
 			return m.executeCountWithProvider(execCtx, provider, req, opts)
 		})
-		if errExec == nil {
-			return resp, nil
-		}
-		lastErr = errExec
-		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, attempts, rotated, req.Model, maxWait)
-		if !shouldRetry {
-			break
-		}
-		if errWait := waitForCooldown(ctx, wait); errWait != nil {
-			return cliproxyexecutor.Response{}, errWait
-		}
-	}
-	if lastErr != nil {
-		return cliproxyexecutor.Response{}, lastErr
-	}
-	return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
+	})
+	return resp, err
 }
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	chunks, fellBack, err := executeWithRetry(ctx, m, providers, req, opts, true, func(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+		return m.executeStreamProvidersOnce(ctx, providers, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
+			return m.executeStreamWithProvider(execCtx, provider, req, opts)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fellBack {
+		return rewriteStreamModel(chunks, req.Model), nil
+	}
+	return chunks, nil
+}
+
+// executeWithRetry runs one pass over the rotated providers with run, repeating it per the
+// configured retry settings while credentials cool down. When allowFallback is set and the
+// model stays unavailable, the configured fallback models are tried once; fellBack reports
+// that a fallback model served the request.
+func executeWithRetry[T any](ctx context.Context, m *Manager, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, allowFallback bool, run func(context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error)) (out T, fellBack bool, err error) {
+	var zero T
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
-		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
+		return zero, false, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 	rotated := m.rotateProviders(req.Model, normalized)
 
@@ -351,25 +338,30 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
-		chunks, errStream := m.executeStreamProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
-			return m.executeStreamWithProvider(execCtx, provider, req, opts)
-		})
-		if errStream == nil {
-			return chunks, nil
+		result, errExec := run(ctx, rotated, req, opts)
+		if errExec == nil {
+			return result, false, nil
 		}
-		lastErr = errStream
-		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, attempts, rotated, req.Model, maxWait)
+		lastErr = errExec
+		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, attempts, rotated, req.Model, maxWait)
 		if !shouldRetry {
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
-			return nil, errWait
+			return zero, false, errWait
 		}
 	}
-	if lastErr != nil {
-		return nil, lastErr
+	if lastErr == nil {
+		return zero, false, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
+	if !allowFallback {
+		return zero, false, lastErr
+	}
+	result, errFallback := executeFallbacks(ctx, m, req, opts, lastErr, run)
+	if errFallback != nil {
+		return zero, false, errFallback
+	}
+	return result, true, nil
 }
 
 func (m *Manager) executeWithProvider(ctx context.Context, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
@@ -414,6 +406,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execReq.Model, execReq.Metadata = applyAuthModelAlias(auth, execReq.Model, execReq.Metadata)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
@@ -477,6 +470,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execReq.Model, execReq.Metadata = applyAuthModelAlias(auth, execReq.Model, execReq.Metadata)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
@@ -540,41 +534,67 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execReq.Model, execReq.Metadata = applyAuthModelAlias(auth, execReq.Model, execReq.Metadata)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
-		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
-		if errStream != nil {
-			rerr := &Error{Message: errStream.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errStream, &se) && se != nil {
-				rerr.HTTPStatus = se.StatusCode()
+		streamCtx, cancelStream := context.WithCancel(execCtx)
+		chunks, errStream := executor.ExecuteStream(streamCtx, auth, execReq, opts)
+		if errStream == nil {
+			// Hold back output until the first real payload so an upstream that accepts the
+			// connection but fails immediately can still fail over to the next credential.
+			buffered, open, errFirst := awaitFirstStreamPayload(streamCtx, chunks)
+			if errFirst == nil {
+				out := make(chan cliproxyexecutor.StreamChunk)
+				go m.forwardStream(streamCtx, cancelStream, out, auth.Clone(), provider, routeModel, buffered, chunks, open)
+				return out, nil
 			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
-			result.RetryAfter = retryAfterFromError(errStream)
-			m.MarkResult(execCtx, result)
-			lastErr = errStream
-			continue
+			if open {
+				go drainStreamChunks(chunks)
+			}
+			if ctx.Err() != nil {
+				cancelStream()
+				return nil, ctx.Err()
+			}
+			entry.Debugf("Stream for model %s failed before first payload, trying next credential: %v", req.Model, errFirst)
+			errStream = errFirst
 		}
-		out := make(chan cliproxyexecutor.StreamChunk)
-		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
-			defer close(out)
-			var failed bool
-			for chunk := range streamChunks {
-				if chunk.Err != nil && !failed {
-					failed = true
-					rerr := &Error{Message: chunk.Err.Error()}
-					var se cliproxyexecutor.StatusError
-					if errors.As(chunk.Err, &se) && se != nil {
-						rerr.HTTPStatus = se.StatusCode()
-					}
-					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+		cancelStream()
+		rerr := &Error{Message: errStream.Error()}
+		var se cliproxyexecutor.StatusError
+		if errors.As(errStream, &se) && se != nil {
+			rerr.HTTPStatus = se.StatusCode()
+		}
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+		result.RetryAfter = retryAfterFromError(errStream)
+		m.MarkResult(execCtx, result)
+		lastErr = errStream
+	}
+}
+
+// forwardStream relays buffered chunks followed by the remaining upstream chunks and
+// records the final execution result once the stream ends.
+func (m *Manager) forwardStream(streamCtx context.Context, cancel context.CancelFunc, out chan<- cliproxyexecutor.StreamChunk, streamAuth *Auth, streamProvider, routeModel string, buffered []cliproxyexecutor.StreamChunk, streamChunks <-chan cliproxyexecutor.StreamChunk, open bool) {
+	defer close(out)
+	defer cancel()
+	for _, chunk := range buffered {
+		out <- chunk
+	}
+	var failed bool
+	if open {
+		for chunk := range streamChunks {
+			if chunk.Err != nil && !failed {
+				failed = true
+				rerr := &Error{Message: chunk.Err.Error()}
+				var se cliproxyexecutor.StatusError
+				if errors.As(chunk.Err, &se) && se != nil {
+					rerr.HTTPStatus = se.StatusCode()
 				}
-				out <- chunk
-			}
-			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
 			}
-		}(execCtx, auth.Clone(), provider, chunks)
-		return out, nil
+			out <- chunk
+		}
+	}
+	if !failed {
+		m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 	}
 }
 
@@ -1204,6 +1224,19 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		}
 		candidates = append(candidates, candidate)
 	}
+	if required := requiredTags(opts); len(required) > 0 && len(candidates) > 0 {
+		// Tag filtering narrows the pool before any selection strategy runs.
+		if candidates = filterByTags(candidates, required); len(candidates) == 0 {
+			m.mu.RUnlock()
+			return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available with tags " + strings.Join(required, ","), HTTPStatus: http.StatusServiceUnavailable}
+		}
+	}
+	if pinned := pinnedAuthID(opts); pinned != "" && len(candidates) > 0 {
+		if candidates = filterByID(candidates, pinned); len(candidates) == 0 {
+			m.mu.RUnlock()
+			return nil, nil, &Error{Code: "auth_not_found", Message: "pinned auth " + pinned + " is unavailable for this model", HTTPStatus: http.StatusServiceUnavailable}
+		}
+	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
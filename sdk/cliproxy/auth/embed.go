@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"cliproxy/internal/util"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+// EmbeddingExecutor is implemented by provider executors that can create embeddings.
+// Providers whose executor does not implement it are skipped for embedding requests.
+type EmbeddingExecutor interface {
+	// Embed creates embeddings for the inputs in req and returns the provider response
+	// translated back to the request's source format.
+	Embed(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// ExecuteEmbed performs an embedding request using the configured selector and executor,
+// with the same retry and cooldown handling as chat requests. Embeddings from another model
+// are not interchangeable, so fallback models are never tried.
+func (m *Manager) ExecuteEmbed(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, _, err := executeWithRetry(ctx, m, providers, req, opts, false, func(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return m.executeProvidersOnce(ctx, providers, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+			return m.executeEmbedWithProvider(execCtx, provider, req, opts)
+		})
+	})
+	return resp, err
+}
+
+func (m *Manager) executeEmbedWithProvider(ctx context.Context, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if provider == "" {
+		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
+	}
+	if _, ok := m.executorFor(provider).(EmbeddingExecutor); !ok {
+		return cliproxyexecutor.Response{}, &Error{
+			Code:       "embeddings_not_supported",
+			Message:    fmt.Sprintf("provider %s does not support embeddings", provider),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	routeModel := req.Model
+	tried := make(map[string]struct{})
+	var lastErr error
+	for {
+		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		if errPick != nil {
+			if lastErr != nil {
+				return cliproxyexecutor.Response{}, lastErr
+			}
+			return cliproxyexecutor.Response{}, errPick
+		}
+		embedder, ok := executor.(EmbeddingExecutor)
+		if !ok {
+			tried[auth.ID] = struct{}{}
+			continue
+		}
+
+		accountType, accountInfo := auth.AccountInfo()
+		entry := logEntryWithRequestID(ctx)
+		switch accountType {
+		case "api_key":
+			entry.Debugf("Use API key %s for embedding model %s", util.HideAPIKey(accountInfo), req.Model)
+		case "oauth":
+			entry.Debugf("Use OAuth %s for embedding model %s", accountInfo, req.Model)
+		}
+
+		tried[auth.ID] = struct{}{}
+		execCtx := ctx
+		if rt := m.roundTripperFor(auth); rt != nil {
+			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+		}
+		execReq := req
+		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execReq.Model, execReq.Metadata = applyAuthModelAlias(auth, execReq.Model, execReq.Metadata)
+		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+		resp, errExec := embedder.Embed(execCtx, auth, execReq, opts)
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		if errExec != nil {
+			result.Error = &Error{Message: errExec.Error()}
+			var se cliproxyexecutor.StatusError
+			if errors.As(errExec, &se) && se != nil {
+				result.Error.HTTPStatus = se.StatusCode()
+			}
+			if ra := retryAfterFromError(errExec); ra != nil {
+				result.RetryAfter = ra
+			}
+			m.MarkResult(execCtx, result)
+			lastErr = errExec
+			continue
+		}
+		m.MarkResult(execCtx, result)
+		return resp, nil
+	}
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"cliproxy/internal/registry"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+type embedExecutor struct {
+	modelEchoExecutor
+	calls []string
+}
+
+func (e *embedExecutor) Identifier() string { return "embed-test" }
+
+func (e *embedExecutor) Embed(_ context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.calls = append(e.calls, auth.ID)
+	return cliproxyexecutor.Response{Payload: []byte(fmt.Sprintf(`{"object":"list","model":%q}`, req.Model))}, nil
+}
+
+func TestExecuteEmbed_SkipsProvidersWithoutEmbeddings(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("embed-a", "embed-test", []*registry.ModelInfo{{ID: "emb-1"}})
+	t.Cleanup(func() { reg.UnregisterClient("embed-a") })
+
+	exec := &embedExecutor{}
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(exec)
+	m.RegisterExecutor(modelEchoExecutor{})
+	ctx := context.Background()
+	if _, err := m.Register(ctx, &Auth{ID: "embed-a", Provider: "embed-test", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	req := cliproxyexecutor.Request{Model: "emb-1", Payload: []byte(`{"input":"hi"}`)}
+	resp, err := m.ExecuteEmbed(ctx, []string{"fallback-test", "embed-test"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("ExecuteEmbed error: %v", err)
+	}
+	if got := string(resp.Payload); got != `{"object":"list","model":"emb-1"}` {
+		t.Fatalf("payload = %s", got)
+	}
+	if len(exec.calls) != 1 || exec.calls[0] != "embed-a" {
+		t.Fatalf("calls = %v, want [embed-a]", exec.calls)
+	}
+
+	_, err = m.ExecuteEmbed(ctx, []string{"fallback-test"}, req, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "embeddings_not_supported" || authErr.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("err = %v, want embeddings_not_supported (400)", err)
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"strings"
+
+	"cliproxy/internal/util"
+)
+
+// ModelAliasAttrPrefix prefixes auth attributes mapping a client-facing model name (lowercase)
+// to the upstream model that serves it, e.g. "model_alias:kimi-k2" -> "moonshotai/kimi-k2:free".
+const ModelAliasAttrPrefix = "model_alias:"
+
+// applyAuthModelAlias resolves a per-credential model alias. Like OAuth model mappings, the
+// requested name is kept in metadata so responses are reported under the client-facing model.
+func applyAuthModelAlias(auth *Auth, requestedModel string, metadata map[string]any) (string, map[string]any) {
+	if auth == nil || len(auth.Attributes) == 0 {
+		return requestedModel, metadata
+	}
+	upstream := strings.TrimSpace(auth.Attributes[ModelAliasAttrPrefix+strings.ToLower(strings.TrimSpace(requestedModel))])
+	if upstream == "" || strings.EqualFold(upstream, requestedModel) {
+		return requestedModel, metadata
+	}
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[util.ModelMappingOriginalModelMetadataKey] = requestedModel
+	return upstream, out
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"testing"
+
+	"cliproxy/internal/util"
+)
+
+func TestApplyAuthModelAlias(t *testing.T) {
+	a := &Auth{Attributes: map[string]string{ModelAliasAttrPrefix + "kimi-k2": "moonshotai/kimi-k2:free"}}
+	model, meta := applyAuthModelAlias(a, "Kimi-K2", map[string]any{"k": "v"})
+	if model != "moonshotai/kimi-k2:free" {
+		t.Fatalf("model = %q", model)
+	}
+	if meta[util.ModelMappingOriginalModelMetadataKey] != "Kimi-K2" || meta["k"] != "v" {
+		t.Fatalf("metadata = %v", meta)
+	}
+	if model, _ = applyAuthModelAlias(a, "other", nil); model != "other" {
+		t.Fatalf("unaliased model rewritten to %q", model)
+	}
+}
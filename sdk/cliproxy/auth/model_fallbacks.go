@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"cliproxy/internal/util"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// modelFallbackTable maps a requested model (lower) to the ordered models tried once every
+// credential able to serve it is cooling down.
+type modelFallbackTable struct {
+	chains map[string][]string
+}
+
+func compileModelFallbackTable(fallbacks map[string][]string) *modelFallbackTable {
+	out := &modelFallbackTable{}
+	for rawModel, rawChain := range fallbacks {
+		model := strings.TrimSpace(rawModel)
+		if model == "" || len(rawChain) == 0 {
+			continue
+		}
+		seen := map[string]struct{}{strings.ToLower(model): {}}
+		chain := make([]string, 0, len(rawChain))
+		for _, candidate := range rawChain {
+			candidate = strings.TrimSpace(candidate)
+			key := strings.ToLower(candidate)
+			if candidate == "" {
+				continue
+			}
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			chain = append(chain, candidate)
+		}
+		if len(chain) == 0 {
+			continue
+		}
+		if out.chains == nil {
+			out.chains = make(map[string][]string, len(fallbacks))
+		}
+		out.chains[strings.ToLower(model)] = chain
+	}
+	return out
+}
+
+// SetModelFallbacks updates the model fallback chains evaluated after provider rotation for a
+// model is exhausted because all of its credentials are cooling down.
+func (m *Manager) SetModelFallbacks(fallbacks map[string][]string) {
+	if m == nil {
+		return
+	}
+	m.modelFallbacks.Store(compileModelFallbackTable(fallbacks))
+}
+
+// fallbackModels returns the configured fallback chain for the requested model.
+func (m *Manager) fallbackModels(model string) []string {
+	if m == nil {
+		return nil
+	}
+	table, _ := m.modelFallbacks.Load().(*modelFallbackTable)
+	if table == nil || table.chains == nil {
+		return nil
+	}
+	return table.chains[strings.ToLower(strings.TrimSpace(model))]
+}
+
+// ServedModelFunc receives the model that actually served a request when a fallback was used.
+type ServedModelFunc func(model string)
+
+type servedModelKey struct{}
+
+// WithServedModelFunc returns a context that reports fallback models to fn. The callback runs
+// synchronously before Execute or ExecuteStream returns, so callers may still set
+// response headers from it.
+func WithServedModelFunc(ctx context.Context, fn ServedModelFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, servedModelKey{}, fn)
+}
+
+func reportServedModel(ctx context.Context, model string) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(servedModelKey{}).(ServedModelFunc); ok && fn != nil {
+		fn(model)
+	}
+}
+
+// ModelAllowedFunc reports whether the client that issued a request may be served by model.
+type ModelAllowedFunc func(model string) bool
+
+type modelAllowedKey struct{}
+
+// WithModelAllowedFunc returns a context whose fallback models are limited to those fn
+// allows, so a client restricted to some models is never answered by another one.
+func WithModelAllowedFunc(ctx context.Context, fn ModelAllowedFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, modelAllowedKey{}, fn)
+}
+
+func modelAllowed(ctx context.Context, model string) bool {
+	if ctx == nil {
+		return true
+	}
+	if fn, ok := ctx.Value(modelAllowedKey{}).(ModelAllowedFunc); ok && fn != nil {
+		return fn(model)
+	}
+	return true
+}
+
+// shouldFallback reports whether err means the requested model has no usable credential left.
+func shouldFallback(err error) bool {
+	if err == nil {
+		return false
+	}
+	var cooldownErr *modelCooldownError
+	if errors.As(err, &cooldownErr) {
+		return true
+	}
+	return statusCodeFromError(err) == http.StatusTooManyRequests
+}
+
+// fallbackRequest prepares the request, options and provider rotation used to try model.
+func (m *Manager) fallbackRequest(model string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Request, cliproxyexecutor.Options, []string) {
+	baseModel, metadata := util.NormalizeThinkingModel(model)
+	providers := m.normalizeProviders(util.GetProviderName(baseModel))
+	if len(providers) == 0 && baseModel != model {
+		baseModel, metadata = model, nil
+		providers = m.normalizeProviders(util.GetProviderName(baseModel))
+	}
+	if len(providers) == 0 {
+		return req, opts, nil
+	}
+	req.Model = baseModel
+	req.Metadata = fallbackMetadata(req.Metadata, metadata)
+	opts.Metadata = fallbackMetadata(opts.Metadata, metadata)
+	return req, opts, m.rotateProviders(baseModel, providers)
+}
+
+// modelMetadataKeys are the metadata entries describing the requested model rather than the
+// request, so they are replaced when a fallback model is tried.
+var modelMetadataKeys = []string{
+	util.ThinkingBudgetMetadataKey,
+	util.ThinkingIncludeThoughtsMetadataKey,
+	util.ReasoningEffortMetadataKey,
+	util.ThinkingOriginalModelMetadataKey,
+	util.ModelMappingOriginalModelMetadataKey,
+}
+
+// fallbackMetadata copies base, keeping request-scoped entries such as required tags and the
+// pinned auth, and swaps its model-specific entries for those parsed from the fallback model.
+func fallbackMetadata(base, model map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(model))
+	for k, v := range base {
+		out[k] = v
+	}
+	for _, k := range modelMetadataKeys {
+		delete(out, k)
+	}
+	for k, v := range model {
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// executeFallbacks runs one pass over the fallback chain of req.Model using fn and returns the
+// first successful result. The original lastErr is returned when
+// no fallback succeeds so clients still see why the requested model was unavailable.
+func executeFallbacks[T any](ctx context.Context, m *Manager, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, lastErr error, fn func(context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error)) (T, error) {
+	var zero T
+	if !shouldFallback(lastErr) {
+		return zero, lastErr
+	}
+	for _, model := range m.fallbackModels(req.Model) {
+		fbReq, fbOpts, providers := m.fallbackRequest(model, req, opts)
+		if len(providers) == 0 {
+			continue
+		}
+		if !modelAllowed(ctx, fbReq.Model) {
+			logEntryWithRequestID(ctx).Debugf("fallback model %s for %s is not allowed for this client", fbReq.Model, req.Model)
+			continue
+		}
+		out, errExec := fn(ctx, providers, fbReq, fbOpts)
+		if errExec == nil {
+			logEntryWithRequestID(ctx).Infof("model %s unavailable, served by fallback model %s", req.Model, fbReq.Model)
+			reportServedModel(ctx, fbReq.Model)
+			return out, nil
+		}
+		logEntryWithRequestID(ctx).Debugf("fallback model %s for %s failed: %v", fbReq.Model, req.Model, errExec)
+	}
+	return zero, lastErr
+}
+
+// fallbackModelPaths lists the JSON paths where response formats carry the model name.
+var fallbackModelPaths = []string{"model", "modelVersion", "response.model", "response.modelVersion", "message.model"}
+
+// rewriteResponseModel replaces the served model name with the requested one in a JSON
+// payload or in the data lines of an SSE chunk.
+func rewriteResponseModel(payload []byte, model string) []byte {
+	if model == "" || len(payload) == 0 {
+		return payload
+	}
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return rewriteModelFields(payload, model)
+	}
+	lines := bytes.Split(payload, []byte("\n"))
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if len(data) == 0 || data[0] != '{' {
+			continue
+		}
+		lines[i] = append([]byte("data: "), rewriteModelFields(data, model)...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func rewriteModelFields(data []byte, model string) []byte {
+	for _, path := range fallbackModelPaths {
+		if gjson.GetBytes(data, path).Exists() {
+			if updated, err := sjson.SetBytes(data, path, model); err == nil {
+				data = updated
+			}
+		}
+	}
+	return data
+}
+
+// rewriteStreamModel relays chunks with the served model name replaced by the requested one.
+func rewriteStreamModel(in <-chan cliproxyexecutor.StreamChunk, model string) <-chan cliproxyexecutor.StreamChunk {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		for chunk := range in {
+			chunk.Payload = rewriteResponseModel(chunk.Payload, model)
+			out <- chunk
+		}
+	}()
+	return out
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cliproxy/internal/registry"
+	"cliproxy/internal/util"
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+type modelEchoExecutor struct{}
+
+func (modelEchoExecutor) Identifier() string { return "fallback-test" }
+
+func (modelEchoExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(fmt.Sprintf(`{"id":"r1","model":%q}`, req.Model))}, nil
+}
+
+func (modelEchoExecutor) ExecuteStream(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	out := make(chan cliproxyexecutor.StreamChunk, 2)
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("event: message_start\ndata: " + fmt.Sprintf(`{"type":"message_start","message":{"model":%q}}`, req.Model) + "\n")}
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("data: [DONE]\n")}
+	close(out)
+	return out, nil
+}
+
+func (modelEchoExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (modelEchoExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+// embedEchoExecutor is modelEchoExecutor with embedding support.
+type embedEchoExecutor struct{ modelEchoExecutor }
+
+func (embedEchoExecutor) Embed(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(fmt.Sprintf(`{"object":"list","model":%q}`, req.Model))}, nil
+}
+
+func TestExecute_FallsBackWhenModelIsCoolingDown(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("fallback-a", "fallback-test", []*registry.ModelInfo{{ID: "fb-opus"}})
+	reg.RegisterClient("fallback-b", "fallback-test", []*registry.ModelInfo{{ID: "fb-sonnet"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("fallback-a")
+		reg.UnregisterClient("fallback-b")
+	})
+
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(embedEchoExecutor{})
+	m.SetModelFallbacks(map[string][]string{"FB-Opus": {"fb-missing", "fb-sonnet"}})
+	ctx := context.Background()
+	cooling := map[string]*ModelState{"fb-opus": {
+		Unavailable:    true,
+		NextRetryAfter: time.Now().Add(time.Minute),
+		Quota:          QuotaState{Exceeded: true},
+	}}
+	if _, err := m.Register(ctx, &Auth{ID: "fallback-a", Provider: "fallback-test", Status: StatusActive, ModelStates: cooling}); err != nil {
+		t.Fatalf("register a: %v", err)
+	}
+	if _, err := m.Register(ctx, &Auth{ID: "fallback-b", Provider: "fallback-test", Status: StatusActive}); err != nil {
+		t.Fatalf("register b: %v", err)
+	}
+
+	var served []string
+	ctx = WithServedModelFunc(ctx, func(model string) { served = append(served, model) })
+	req := cliproxyexecutor.Request{Model: "fb-opus"}
+
+	resp, err := m.Execute(ctx, []string{"fallback-test"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := string(resp.Payload); got != `{"id":"r1","model":"fb-opus"}` {
+		t.Fatalf("payload = %s", got)
+	}
+
+	chunks, err := m.ExecuteStream(ctx, []string{"fallback-test"}, req, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var stream strings.Builder
+	for chunk := range chunks {
+		stream.Write(chunk.Payload)
+	}
+	if !strings.Contains(stream.String(), `"model":"fb-opus"`) || strings.Contains(stream.String(), "fb-sonnet") {
+		t.Fatalf("stream model not rewritten:\n%s", stream.String())
+	}
+	if len(served) != 2 || served[0] != "fb-sonnet" || served[1] != "fb-sonnet" {
+		t.Fatalf("served models = %v", served)
+	}
+
+	if _, err = m.ExecuteCount(ctx, []string{"fallback-test"}, req, cliproxyexecutor.Options{}); err == nil || statusCodeFromError(err) != 429 {
+		t.Fatalf("expected token counting to skip fallbacks, got %v", err)
+	}
+	if _, err = m.ExecuteEmbed(ctx, []string{"fallback-test"}, req, cliproxyexecutor.Options{}); err == nil || statusCodeFromError(err) != 429 {
+		t.Fatalf("expected embeddings to skip fallbacks, got %v", err)
+	}
+	if len(served) != 2 {
+		t.Fatalf("served models after count and embed = %v", served)
+	}
+
+	restricted := WithModelAllowedFunc(ctx, func(model string) bool { return model != "fb-sonnet" })
+	if _, err = m.Execute(restricted, []string{"fallback-test"}, req, cliproxyexecutor.Options{}); err == nil || statusCodeFromError(err) != 429 {
+		t.Fatalf("expected a disallowed fallback model to be skipped, got %v", err)
+	}
+	if len(served) != 2 {
+		t.Fatalf("served models after disallowed fallback = %v", served)
+	}
+
+	m.SetModelFallbacks(nil)
+	if _, err = m.Execute(ctx, []string{"fallback-test"}, req, cliproxyexecutor.Options{}); err == nil || statusCodeFromError(err) != 429 {
+		t.Fatalf("expected cooldown error without fallbacks, got %v", err)
+	}
+}
+
+type authEchoExecutor struct{ modelEchoExecutor }
+
+func (authEchoExecutor) Identifier() string { return "fallback-tags" }
+
+func (authEchoExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func TestExecute_FallbackKeepsRequiredTags(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("fallback-tagged", "fallback-tags", []*registry.ModelInfo{{ID: "fbt-opus"}, {ID: "fbt-sonnet"}})
+	reg.RegisterClient("fallback-untagged", "fallback-tags", []*registry.ModelInfo{{ID: "fbt-sonnet"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("fallback-tagged")
+		reg.UnregisterClient("fallback-untagged")
+	})
+
+	m := NewManager(nil, &RoundRobinSelector{}, nil)
+	m.RegisterExecutor(authEchoExecutor{})
+	m.SetModelFallbacks(map[string][]string{"fbt-opus": {"fbt-sonnet(high)"}})
+	ctx := context.Background()
+	cooling := map[string]*ModelState{"fbt-opus": {
+		Unavailable:    true,
+		NextRetryAfter: time.Now().Add(time.Minute),
+		Quota:          QuotaState{Exceeded: true},
+	}}
+	if _, err := m.Register(ctx, &Auth{ID: "fallback-tagged", Provider: "fallback-tags", Status: StatusActive, ModelStates: cooling, Attributes: map[string]string{"tags": "production"}}); err != nil {
+		t.Fatalf("register tagged: %v", err)
+	}
+	if _, err := m.Register(ctx, &Auth{ID: "fallback-untagged", Provider: "fallback-tags", Status: StatusActive}); err != nil {
+		t.Fatalf("register untagged: %v", err)
+	}
+
+	var served []string
+	ctx = WithServedModelFunc(ctx, func(model string) { served = append(served, model) })
+	req := cliproxyexecutor.Request{Model: "fbt-opus"}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{RequiredTagsMetadataKey: []string{"production"}}}
+	for i := 0; i < 4; i++ {
+		resp, err := m.Execute(ctx, []string{"fallback-tags"}, req, opts)
+		if err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		if got := string(resp.Payload); got != "fallback-tagged" {
+			t.Fatalf("fallback served by %s, want fallback-tagged", got)
+		}
+	}
+	if len(served) != 4 || served[0] != "fbt-sonnet" {
+		t.Fatalf("served models = %v", served)
+	}
+	if _, ok := opts.Metadata[util.ReasoningEffortMetadataKey]; ok {
+		t.Fatal("fallback metadata leaked into the caller's options")
+	}
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"strings"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+// PinnedAuthMetadataKey is the options metadata key naming the only auth, by ID, allowed to
+// serve a request. The value is a string. Request replay uses it to reproduce a call on a
+// chosen credential.
+const PinnedAuthMetadataKey = "pinned_auth_id"
+
+// pinnedAuthID extracts the pinned auth ID from the options metadata.
+func pinnedAuthID(opts cliproxyexecutor.Options) string {
+	if opts.Metadata == nil {
+		return ""
+	}
+	id, _ := opts.Metadata[PinnedAuthMetadataKey].(string)
+	return strings.TrimSpace(id)
+}
+
+// filterByID keeps the candidate with the given ID.
+func filterByID(candidates []*Auth, id string) []*Auth {
+	for _, candidate := range candidates {
+		if candidate.ID == id {
+			return []*Auth{candidate}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+// ExecuteWithAuth runs a non-streaming request against one specific auth, bypassing selection,
+// retries and fallbacks. The result is recorded through MarkResult like regular traffic, so a
+// failing credential is cooled down for the model and a healthy one is resumed. It is intended
+// for health probes.
+func (m *Manager) ExecuteWithAuth(ctx context.Context, authID string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	m.mu.RLock()
+	auth, ok := m.auths[authID]
+	if ok && auth != nil {
+		auth = auth.Clone()
+	}
+	m.mu.RUnlock()
+	if !ok || auth == nil {
+		return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	if auth.Disabled {
+		return cliproxyexecutor.Response{}, &Error{Code: "auth_disabled", Message: "auth is disabled"}
+	}
+	executor := m.executorFor(auth.Provider)
+	if executor == nil {
+		return cliproxyexecutor.Response{}, &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+
+	execCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	routeModel := req.Model
+	execReq := req
+	execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+	execReq.Model, execReq.Metadata = applyAuthModelAlias(auth, execReq.Model, execReq.Metadata)
+	execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+	resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+	result := Result{AuthID: auth.ID, Provider: auth.Provider, Model: routeModel, Success: errExec == nil}
+	if errExec != nil {
+		result.Error = &Error{Message: errExec.Error()}
+		var se cliproxyexecutor.StatusError
+		if errors.As(errExec, &se) && se != nil {
+			result.Error.HTTPStatus = se.StatusCode()
+		}
+		if ra := retryAfterFromError(errExec); ra != nil {
+			result.RetryAfter = ra
+		}
+	}
+	m.MarkResult(execCtx, result)
+	return resp, errExec
+}
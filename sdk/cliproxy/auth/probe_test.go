@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+type statusErr struct{ code int }
+
+func (e statusErr) Error() string   { return "upstream rejected the request" }
+func (e statusErr) StatusCode() int { return e.code }
+
+type probeExecutor struct {
+	failFor map[string]int
+	calls   []string
+}
+
+func (e *probeExecutor) Identifier() string { return "test" }
+
+func (e *probeExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.calls = append(e.calls, auth.ID)
+	if code, ok := e.failFor[auth.ID]; ok {
+		return cliproxyexecutor.Response{}, statusErr{code: code}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{}`)}, nil
+}
+
+func (e *probeExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *probeExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *probeExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestExecuteWithAuth_TargetsAuthAndRecordsResult(t *testing.T) {
+	exec := &probeExecutor{failFor: map[string]int{"dead": 401}}
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(exec)
+	ctx := context.Background()
+	for _, id := range []string{"live", "dead"} {
+		if _, err := m.Register(ctx, &Auth{ID: id, Provider: "test", Status: StatusActive}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+
+	if _, err := m.ExecuteWithAuth(ctx, "dead", cliproxyexecutor.Request{Model: "m1"}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("expected error for dead auth")
+	}
+	if _, err := m.ExecuteWithAuth(ctx, "live", cliproxyexecutor.Request{Model: "m1"}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("live auth: %v", err)
+	}
+	if len(exec.calls) != 2 || exec.calls[0] != "dead" || exec.calls[1] != "live" {
+		t.Fatalf("calls = %v, want [dead live]", exec.calls)
+	}
+
+	dead, _ := m.GetByID("dead")
+	state := dead.ModelStates["m1"]
+	if state == nil || !state.Unavailable || !state.NextRetryAfter.After(time.Now()) {
+		t.Fatalf("dead auth model state = %+v, want cooled down", state)
+	}
+	if state.LastError == nil || state.LastError.HTTPStatus != 401 {
+		t.Fatalf("dead auth last error = %+v, want HTTP 401", state.LastError)
+	}
+	live, _ := m.GetByID("live")
+	if s := live.ModelStates["m1"]; s == nil || s.Unavailable {
+		t.Fatalf("live auth model state = %+v, want available", s)
+	}
+
+	if _, err := m.ExecuteWithAuth(ctx, "missing", cliproxyexecutor.Request{Model: "m1"}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("expected error for unknown auth")
+	}
+}
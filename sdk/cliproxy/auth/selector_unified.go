@@ -17,10 +17,10 @@ func init() {
 // UnifiedSelector implements a comprehensive selection strategy supporting
 // Priority, Weighted Load Balancing, Round Robin, and Sticky sessions.
 type UnifiedSelector struct {
-	mu           sync.Mutex
-	cursors      map[string]int // For Round Robin
-	stickyRoutes map[string]string
-	strategy     string // Default strategy
+	mu       sync.Mutex
+	cursors  map[string]int // For Round Robin
+	sticky   *stickyTable
+	strategy string // Default strategy
 }
 
 // NewUnifiedSelector creates a new selector with the given default strategy.
@@ -29,12 +29,72 @@ func NewUnifiedSelector(strategy string) *UnifiedSelector {
 		strategy = "priority"
 	}
 	return &UnifiedSelector{
-		cursors:      make(map[string]int),
-		stickyRoutes: make(map[string]string),
-		strategy:     strategy,
+		cursors:  make(map[string]int),
+		sticky:   newStickyTable(StickyOptions{}),
+		strategy: strategy,
 	}
 }
 
+// Strategy returns the active selection strategy.
+func (s *UnifiedSelector) Strategy() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.strategy
+}
+
+// SetStickyOptions updates the bounds of the sticky session table at runtime.
+func (s *UnifiedSelector) SetStickyOptions(opts StickyOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sticky.setOptions(opts, time.Now())
+}
+
+// StickyBindings returns the live session bindings, most recently used first.
+func (s *UnifiedSelector) StickyBindings() []StickyBinding {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sticky.snapshot(time.Now())
+}
+
+// ClearStickyBindings removes session bindings and returns how many were removed.
+// Non-empty authID or session restrict removal to matching bindings; session accepts
+// either the raw identifier or the digest reported by StickyBindings.
+func (s *UnifiedSelector) ClearStickyBindings(authID, session string) int {
+	digest := ""
+	if session != "" {
+		digest = sessionDigest(session)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sticky.clear(func(b *StickyBinding) bool {
+		if authID != "" && b.AuthID != authID {
+			return false
+		}
+		if session != "" && b.Session != session && b.Session != digest {
+			return false
+		}
+		return true
+	})
+}
+
+// SaveStickyBindings writes the live session bindings to path.
+func (s *UnifiedSelector) SaveStickyBindings(path string) error {
+	return writeStickyFile(path, s.StickyBindings())
+}
+
+// LoadStickyBindings restores session bindings saved by SaveStickyBindings.
+// A missing file is not an error; expired bindings are dropped.
+func (s *UnifiedSelector) LoadStickyBindings(path string) error {
+	bindings, err := readStickyFile(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sticky.restore(bindings, time.Now())
+	return nil
+}
+
 // SetStrategy updates the selection strategy at runtime.
 func (s *UnifiedSelector) SetStrategy(strategy string) {
 	s.mu.Lock()
@@ -181,8 +241,9 @@ func (s *UnifiedSelector) pickRoundRobin(provider, model string, candidates []*A
 }
 
 // pickSticky binds a session to a specific auth for consistent routing.
-// If a session already has a bound auth and it's still available, use it.
-// Otherwise, select a new auth using priority strategy and bind it to the session.
+// A bound auth is reused while it is still available; it is replaced when it is blocked
+// (for example cooling down) or holds too large a share of the bindings. New sessions go to
+// the least-bound auth among those with the best priority.
 func (s *UnifiedSelector) pickSticky(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, candidates []*Auth) (*Auth, error) {
 	if len(candidates) == 1 {
 		return candidates[0], nil
@@ -196,35 +257,51 @@ func (s *UnifiedSelector) pickSticky(ctx context.Context, provider, model string
 		return s.pickPriority(provider, model, candidates)
 	}
 
-	key := provider + ":" + model + ":" + sessionID
+	binding := &StickyBinding{Provider: provider, Model: model, Session: sessionDigest(sessionID)}
+	scope := binding.scope()
+	now := time.Now()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Check if session already has a binding
-	boundID, exists := s.stickyRoutes[key]
-	if exists {
-		// Find the bound auth in available candidates
+	if bound, ok := s.sticky.lookup(binding.key(), now); ok {
 		for _, c := range candidates {
-			if c.ID == boundID {
+			if c.ID == bound.AuthID && !s.sticky.overloaded(scope, c.ID, candidates) {
+				bound.LastUsedAt = now
+				bound.Hits++
 				return c, nil
 			}
 		}
-		// Bound auth is not available anymore, fall through to rebind
+		// Bound auth is unavailable or overloaded, fall through to rebind
 	}
 
-	// Select a new auth using priority strategy
-	selected, err := s.pickPriority(provider, model, candidates)
-	if err != nil {
-		return nil, err
-	}
-
-	// Bind session to selected auth
-	s.stickyRoutes[key] = selected.ID
+	selected := s.leastBound(scope, candidates)
+	binding.AuthID = selected.ID
+	binding.CreatedAt = now
+	binding.LastUsedAt = now
+	binding.Hits = 1
+	s.sticky.bind(binding, now)
 
 	return selected, nil
 }
 
+// leastBound returns the best-priority candidate holding the fewest sticky bindings.
+// The caller must hold s.mu.
+func (s *UnifiedSelector) leastBound(scope string, candidates []*Auth) *Auth {
+	var selected *Auth
+	selectedLoad := 0
+	for _, c := range candidates {
+		load := s.sticky.load(scope, c.ID)
+		if selected == nil || c.Priority < selected.Priority ||
+			(c.Priority == selected.Priority && (load < selectedLoad || (load == selectedLoad && c.ID < selected.ID))) {
+			selected = c
+			selectedLoad = load
+		}
+	}
+	return selected
+}
+
 // extractSessionID attempts to extract a session identifier from the request context.
 // It checks context values, HTTP headers, and metadata fields.
 func (s *UnifiedSelector) extractSessionID(ctx context.Context, opts cliproxyexecutor.Options) string {
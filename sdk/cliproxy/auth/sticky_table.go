@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	defaultStickyTTL        = time.Hour
+	defaultStickyMaxEntries = 10000
+)
+
+// StickyOptions bounds the session bindings kept by the sticky strategy.
+type StickyOptions struct {
+	// TTL expires bindings that have not been used for this long. Zero uses one hour.
+	TTL time.Duration
+	// MaxEntries caps the number of bindings; the least recently used are evicted first.
+	// Zero uses 10000.
+	MaxEntries int
+	// RebalanceFactor moves a session to another credential once its bound credential holds
+	// more than this multiple of its fair share of the bindings for the same provider and
+	// model. Zero disables rebalancing.
+	RebalanceFactor float64
+}
+
+func (o StickyOptions) normalized() StickyOptions {
+	if o.TTL <= 0 {
+		o.TTL = defaultStickyTTL
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = defaultStickyMaxEntries
+	}
+	if o.RebalanceFactor < 0 {
+		o.RebalanceFactor = 0
+	}
+	return o
+}
+
+// StickyBinding describes one session bound to a credential. Session holds a digest of the
+// session identifier, never the raw value, because identifiers may be client secrets.
+type StickyBinding struct {
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Session    string    `json:"session"`
+	AuthID     string    `json:"auth_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Hits       int64     `json:"hits"`
+}
+
+func (b *StickyBinding) scope() string { return b.Provider + ":" + b.Model }
+
+func (b *StickyBinding) key() string { return b.scope() + ":" + b.Session }
+
+// sessionDigest hashes a session identifier for use as a table key.
+func sessionDigest(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// stickyTable is a TTL- and LRU-bounded map of session bindings. It also tracks how many
+// bindings point at each credential per provider and model so callers can judge load.
+// It is not safe for concurrent use; UnifiedSelector guards it with its mutex.
+type stickyTable struct {
+	opts    StickyOptions
+	ll      *list.List
+	items   map[string]*list.Element
+	perAuth map[string]int
+	perPool map[string]int
+}
+
+func newStickyTable(opts StickyOptions) *stickyTable {
+	return &stickyTable{
+		opts:    opts.normalized(),
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		perAuth: make(map[string]int),
+		perPool: make(map[string]int),
+	}
+}
+
+func (t *stickyTable) setOptions(opts StickyOptions, now time.Time) {
+	t.opts = opts.normalized()
+	t.evict(now)
+}
+
+// lookup returns the live binding for key and marks it as recently used.
+func (t *stickyTable) lookup(key string, now time.Time) (*StickyBinding, bool) {
+	el, ok := t.items[key]
+	if !ok {
+		return nil, false
+	}
+	b := el.Value.(*StickyBinding)
+	if now.Sub(b.LastUsedAt) > t.opts.TTL {
+		t.removeElement(el)
+		return nil, false
+	}
+	t.ll.MoveToFront(el)
+	return b, true
+}
+
+// bind stores b, replacing any binding with the same key, and enforces the bounds.
+func (t *stickyTable) bind(b *StickyBinding, now time.Time) {
+	if el, ok := t.items[b.key()]; ok {
+		t.removeElement(el)
+	}
+	t.items[b.key()] = t.ll.PushFront(b)
+	t.perAuth[b.scope()+"\x00"+b.AuthID]++
+	t.perPool[b.scope()]++
+	t.evict(now)
+}
+
+// evict drops expired bindings and then the least recently used ones above MaxEntries.
+func (t *stickyTable) evict(now time.Time) {
+	for el := t.ll.Back(); el != nil; {
+		prev := el.Prev()
+		b := el.Value.(*StickyBinding)
+		if now.Sub(b.LastUsedAt) <= t.opts.TTL && t.ll.Len() <= t.opts.MaxEntries {
+			break
+		}
+		t.removeElement(el)
+		el = prev
+	}
+}
+
+func (t *stickyTable) removeElement(el *list.Element) {
+	b := el.Value.(*StickyBinding)
+	t.ll.Remove(el)
+	delete(t.items, b.key())
+	authKey := b.scope() + "\x00" + b.AuthID
+	if t.perAuth[authKey]--; t.perAuth[authKey] <= 0 {
+		delete(t.perAuth, authKey)
+	}
+	if t.perPool[b.scope()]--; t.perPool[b.scope()] <= 0 {
+		delete(t.perPool, b.scope())
+	}
+}
+
+// load returns the number of bindings for authID within scope.
+func (t *stickyTable) load(scope, authID string) int {
+	return t.perAuth[scope+"\x00"+authID]
+}
+
+// overloaded reports whether authID holds more than RebalanceFactor times its fair share of
+// the bindings in scope while another candidate sits below the fair share.
+func (t *stickyTable) overloaded(scope, authID string, candidates []*Auth) bool {
+	if t.opts.RebalanceFactor <= 0 || len(candidates) < 2 {
+		return false
+	}
+	count := t.load(scope, authID)
+	fair := float64(t.perPool[scope]) / float64(len(candidates))
+	if count <= 1 || float64(count) <= t.opts.RebalanceFactor*fair {
+		return false
+	}
+	for _, c := range candidates {
+		if c.ID != authID && float64(t.load(scope, c.ID)) < fair {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns copies of the live bindings, most recently used first.
+func (t *stickyTable) snapshot(now time.Time) []StickyBinding {
+	t.evict(now)
+	out := make([]StickyBinding, 0, t.ll.Len())
+	for el := t.ll.Front(); el != nil; el = el.Next() {
+		out = append(out, *el.Value.(*StickyBinding))
+	}
+	return out
+}
+
+// clear removes the bindings for which match returns true and reports how many were removed.
+func (t *stickyTable) clear(match func(*StickyBinding) bool) int {
+	removed := 0
+	for el := t.ll.Front(); el != nil; {
+		next := el.Next()
+		if match(el.Value.(*StickyBinding)) {
+			t.removeElement(el)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+// restore loads bindings, oldest first so the LRU order is preserved, skipping expired ones.
+func (t *stickyTable) restore(bindings []StickyBinding, now time.Time) {
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].LastUsedAt.Before(bindings[j].LastUsedAt) })
+	for i := range bindings {
+		b := bindings[i]
+		if b.AuthID == "" || b.Session == "" || now.Sub(b.LastUsedAt) > t.opts.TTL {
+			continue
+		}
+		t.bind(&b, now)
+	}
+}
+
+func writeStickyFile(path string, bindings []StickyBinding) error {
+	data, err := json.Marshal(bindings)
+	if err != nil {
+		return fmt.Errorf("sticky bindings: marshal: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("sticky bindings: create dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("sticky bindings: write: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("sticky bindings: rename: %w", err)
+	}
+	return nil
+}
+
+func readStickyFile(path string) ([]StickyBinding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("sticky bindings: read: %w", err)
+	}
+	var bindings []StickyBinding
+	if err = json.Unmarshal(data, &bindings); err != nil {
+		return nil, fmt.Errorf("sticky bindings: decode: %w", err)
+	}
+	return bindings, nil
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+func stickyCtx(session string) context.Context {
+	return context.WithValue(context.Background(), "session-id", session)
+}
+
+func TestStickyTable_ExpiresAndEvicts(t *testing.T) {
+	table := newStickyTable(StickyOptions{TTL: time.Minute, MaxEntries: 2})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		table.bind(&StickyBinding{Provider: "p", Model: "m", Session: fmt.Sprintf("s%d", i), AuthID: "a", LastUsedAt: now}, now)
+	}
+	if table.ll.Len() != 2 {
+		t.Fatalf("entries = %d, want 2", table.ll.Len())
+	}
+	if _, ok := table.lookup("p:m:s0", now); ok {
+		t.Fatal("least recently used binding was not evicted")
+	}
+	if got := table.load("p:m", "a"); got != 2 {
+		t.Fatalf("load = %d, want 2", got)
+	}
+	if _, ok := table.lookup("p:m:s2", now.Add(2*time.Minute)); ok {
+		t.Fatal("expired binding still returned")
+	}
+	if got := table.load("p:m", "a"); got != 1 {
+		t.Fatalf("load after expiry = %d, want 1", got)
+	}
+}
+
+func TestUnifiedSelector_StickyRebindsUnavailableAuth(t *testing.T) {
+	s := NewUnifiedSelector("sticky")
+	a := &Auth{ID: "a", Priority: 1}
+	b := &Auth{ID: "b", Priority: 1}
+
+	first, _ := s.pickSticky(stickyCtx("sess"), "p", "m", cliproxyexecutor.Options{}, []*Auth{a, b})
+	again, _ := s.pickSticky(stickyCtx("sess"), "p", "m", cliproxyexecutor.Options{}, []*Auth{a, b})
+	if first.ID != again.ID {
+		t.Fatalf("session moved from %s to %s", first.ID, again.ID)
+	}
+
+	other := a
+	if first.ID == "a" {
+		other = b
+	}
+	moved, _ := s.pickSticky(stickyCtx("sess"), "p", "m", cliproxyexecutor.Options{}, []*Auth{other, {ID: "c", Priority: 5}})
+	if moved.ID != other.ID {
+		t.Fatalf("expected rebind to %s, got %s", other.ID, moved.ID)
+	}
+	bindings := s.StickyBindings()
+	if len(bindings) != 1 || bindings[0].AuthID != other.ID || bindings[0].Session == "sess" {
+		t.Fatalf("unexpected bindings %+v", bindings)
+	}
+}
+
+func TestUnifiedSelector_StickySpreadsAndRebalances(t *testing.T) {
+	s := NewUnifiedSelector("sticky")
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	counts := map[string]int{}
+	for i := 0; i < 4; i++ {
+		got, _ := s.pickSticky(stickyCtx(fmt.Sprintf("s%d", i)), "p", "m", cliproxyexecutor.Options{}, auths)
+		counts[got.ID]++
+	}
+	if counts["a"] != 2 || counts["b"] != 2 {
+		t.Fatalf("new sessions not spread evenly: %v", counts)
+	}
+
+	// All sessions pile onto "a" while "b" is unavailable, then "b" comes back.
+	s.SetStickyOptions(StickyOptions{RebalanceFactor: 1.5})
+	for i := 0; i < 4; i++ {
+		_, _ = s.pickSticky(stickyCtx(fmt.Sprintf("s%d", i)), "p", "m", cliproxyexecutor.Options{}, []*Auth{auths[0], {ID: "c", Priority: 9}})
+	}
+	got, _ := s.pickSticky(stickyCtx("s0"), "p", "m", cliproxyexecutor.Options{}, auths)
+	if got.ID != "b" {
+		t.Fatalf("overloaded binding kept on %s", got.ID)
+	}
+}
+
+func TestUnifiedSelector_StickyPersistAndClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sticky.json")
+	s := NewUnifiedSelector("sticky")
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	_, _ = s.pickSticky(stickyCtx("s1"), "p", "m", cliproxyexecutor.Options{}, auths)
+	_, _ = s.pickSticky(stickyCtx("s2"), "p", "m", cliproxyexecutor.Options{}, auths)
+	if err := s.SaveStickyBindings(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	restored := NewUnifiedSelector("sticky")
+	if err := restored.LoadStickyBindings(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := len(restored.StickyBindings()); got != 2 {
+		t.Fatalf("restored %d bindings, want 2", got)
+	}
+	if removed := restored.ClearStickyBindings("", "s1"); removed != 1 {
+		t.Fatalf("cleared %d bindings by session, want 1", removed)
+	}
+	if removed := restored.ClearStickyBindings("", ""); removed != 1 {
+		t.Fatalf("cleared %d remaining bindings, want 1", removed)
+	}
+	if err := restored.LoadStickyBindings(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("missing file should not fail: %v", err)
+	}
+}
+
+func TestUnifiedSelector_StickyTiedPriorityDoesNotDeadlock(t *testing.T) {
+	s := NewUnifiedSelector("sticky")
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	done := make(chan struct{})
+	go func() {
+		_, _ = s.Pick(stickyCtx("sess"), "p", "m", cliproxyexecutor.Options{}, auths)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Pick blocked")
+	}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// awaitFirstStreamPayload holds back stream chunks until the first chunk that carries
+// client-visible content arrives. It returns the buffered chunks (including that first
+// content chunk), whether the upstream channel is still open, and an error when the
+// stream failed before any content was produced. Failing here lets the manager retry
+// another credential before a single byte reaches the client.
+func awaitFirstStreamPayload(ctx context.Context, chunks <-chan cliproxyexecutor.StreamChunk) ([]cliproxyexecutor.StreamChunk, bool, error) {
+	var buffered []cliproxyexecutor.StreamChunk
+	for {
+		select {
+		case <-ctx.Done():
+			return buffered, true, ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				return buffered, false, nil
+			}
+			if chunk.Err != nil {
+				return buffered, true, chunk.Err
+			}
+			buffered = append(buffered, chunk)
+			hasContent, errPayload := classifyStreamChunk(chunk.Payload)
+			if errPayload != nil {
+				return buffered, true, errPayload
+			}
+			if hasContent {
+				return buffered, true, nil
+			}
+		}
+	}
+}
+
+// drainStreamChunks discards the remaining chunks of an abandoned stream so the
+// executor goroutine producing them can exit.
+func drainStreamChunks(chunks <-chan cliproxyexecutor.StreamChunk) {
+	for range chunks {
+	}
+}
+
+// headerOnlyStreamEvents are event types that open or keep alive a stream without carrying
+// content, so a failure right after them can still fail over to another credential.
+var headerOnlyStreamEvents = map[string]bool{
+	"message_start":        true, // Claude
+	"ping":                 true, // Claude
+	"response.created":     true, // OpenAI Responses
+	"response.in_progress": true, // OpenAI Responses
+}
+
+// classifyStreamChunk reports whether payload contains data beyond SSE framing
+// (blank lines, comments, event/id/retry fields) and header-only events and, if that
+// data is an upstream error event, returns it as an *Error carrying the derived HTTP status.
+func classifyStreamChunk(payload []byte) (bool, error) {
+	hasContent := false
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == ':' {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("event:")) || bytes.HasPrefix(line, []byte("id:")) || bytes.HasPrefix(line, []byte("retry:")) {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("data:")) {
+			line = bytes.TrimSpace(line[len("data:"):])
+			if len(line) == 0 {
+				continue
+			}
+		}
+		if line[0] == '{' && headerOnlyStreamEvents[gjson.GetBytes(line, "type").String()] {
+			continue
+		}
+		hasContent = true
+		if status, ok := streamErrorStatus(line); ok {
+			return true, &Error{Message: string(line), HTTPStatus: status}
+		}
+	}
+	return hasContent, nil
+}
+
+// streamErrorStatus inspects a single JSON event and reports whether it describes an
+// upstream failure. Claude ("type":"error"), OpenAI Responses ("type":"error" and
+// "response.failed"), OpenAI chat and Gemini ({"error":{...}}) shapes are recognised.
+func streamErrorStatus(data []byte) (int, bool) {
+	if len(data) == 0 || data[0] != '{' || !gjson.ValidBytes(data) {
+		return 0, false
+	}
+	root := gjson.ParseBytes(data)
+	var node gjson.Result
+	switch root.Get("type").String() {
+	case "error":
+		node = root.Get("error")
+		if !node.Exists() {
+			node = root
+		}
+	case "response.failed":
+		node = root.Get("response.error")
+	case "":
+		node = root.Get("error")
+		if !node.IsObject() {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return statusFromStreamError(node), true
+}
+
+func statusFromStreamError(node gjson.Result) int {
+	if code := node.Get("code"); code.Type == gjson.Number {
+		if status := int(code.Int()); status >= 400 && status <= 599 {
+			return status
+		}
+	}
+	switch strings.ToUpper(node.Get("status").String()) {
+	case "RESOURCE_EXHAUSTED":
+		return http.StatusTooManyRequests
+	case "UNAVAILABLE":
+		return http.StatusServiceUnavailable
+	case "DEADLINE_EXCEEDED":
+		return http.StatusGatewayTimeout
+	case "UNAUTHENTICATED":
+		return http.StatusUnauthorized
+	case "PERMISSION_DENIED":
+		return http.StatusForbidden
+	case "NOT_FOUND":
+		return http.StatusNotFound
+	case "INVALID_ARGUMENT":
+		return http.StatusBadRequest
+	}
+	kind := strings.ToLower(node.Get("type").String() + " " + node.Get("code").String())
+	switch {
+	case strings.Contains(kind, "overloaded"):
+		return 529
+	case strings.Contains(kind, "rate_limit"), strings.Contains(kind, "insufficient_quota"):
+		return http.StatusTooManyRequests
+	case strings.Contains(kind, "authentication"):
+		return http.StatusUnauthorized
+	case strings.Contains(kind, "permission"):
+		return http.StatusForbidden
+	case strings.Contains(kind, "not_found"):
+		return http.StatusNotFound
+	case strings.Contains(kind, "invalid_request"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+type scriptedStreamExecutor struct {
+	mu      sync.Mutex
+	streams map[string][]cliproxyexecutor.StreamChunk
+	calls   []string
+}
+
+func (e *scriptedStreamExecutor) Identifier() string { return "test" }
+
+func (e *scriptedStreamExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *scriptedStreamExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, auth.ID)
+	script := e.streams[auth.ID]
+	e.mu.Unlock()
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		for _, chunk := range script {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (e *scriptedStreamExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *scriptedStreamExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestExecuteStream_FailsOverOnFirstChunkError(t *testing.T) {
+	exec := &scriptedStreamExecutor{streams: map[string][]cliproxyexecutor.StreamChunk{
+		"a": {
+			{Payload: []byte("event: message_start\n")},
+			{Payload: []byte(`data: {"type":"message_start","message":{"id":"msg_a"}}` + "\n\n")},
+			{Payload: []byte("event: ping\n" + `data: {"type":"ping"}` + "\n\n")},
+			{Payload: []byte("event: error\n")},
+			{Payload: []byte(`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}` + "\n")},
+		},
+		"b": {
+			{Payload: []byte("event: message_start\n")},
+			{Payload: []byte(`data: {"type":"message_start"}` + "\n")},
+			{Payload: []byte("\n")},
+		},
+	}}
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(exec)
+	ctx := context.Background()
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Register(ctx, &Auth{ID: id, Provider: "test", Status: StatusActive}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+
+	chunks, err := m.ExecuteStream(ctx, []string{"test"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var got string
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected chunk error: %v", chunk.Err)
+		}
+		got += string(chunk.Payload)
+	}
+	want := "event: message_start\n" + `data: {"type":"message_start"}` + "\n\n"
+	if got != want {
+		t.Fatalf("stream payload = %q, want %q", got, want)
+	}
+	if len(exec.calls) != 2 || exec.calls[0] != "a" || exec.calls[1] != "b" {
+		t.Fatalf("executor calls = %v, want [a b]", exec.calls)
+	}
+	failed, _ := m.GetByID("a")
+	if failed.LastError == nil || failed.LastError.HTTPStatus != 529 {
+		t.Fatalf("auth a last error = %+v, want status 529", failed.LastError)
+	}
+}
+
+func TestExecuteStream_ReturnsErrorWhenEveryStreamFails(t *testing.T) {
+	exec := &scriptedStreamExecutor{streams: map[string][]cliproxyexecutor.StreamChunk{
+		"a": {{Payload: []byte(`data: {"error":{"code":429,"message":"quota","status":"RESOURCE_EXHAUSTED"}}`)}},
+	}}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(exec)
+	ctx := context.Background()
+	if _, err := m.Register(ctx, &Auth{ID: "a", Provider: "test", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	_, err := m.ExecuteStream(ctx, []string{"test"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{Stream: true})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if status := statusCodeFromError(err); status != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+}
+
+func TestClassifyStreamChunk(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		wantContent bool
+		wantStatus  int
+	}{
+		{name: "event line", payload: "event: message_start\n"},
+		{name: "comment", payload: ": keep-alive\n\n"},
+		{name: "claude message start", payload: `data: {"type":"message_start","message":{"id":"msg_1"}}`},
+		{name: "claude ping", payload: "event: ping\ndata: {\"type\":\"ping\"}\n\n"},
+		{name: "responses created", payload: `data: {"type":"response.created","response":{"id":"resp_1"}}`},
+		{name: "claude content block", payload: `data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`, wantContent: true},
+		{name: "openai chunk", payload: `{"object":"chat.completion.chunk","choices":[]}`, wantContent: true},
+		{name: "claude overloaded", payload: `data: {"type":"error","error":{"type":"overloaded_error"}}`, wantContent: true, wantStatus: 529},
+		{name: "translated rate limit", payload: `{"error":{"message":"slow down","type":"rate_limit_error"}}`, wantContent: true, wantStatus: http.StatusTooManyRequests},
+		{name: "gemini unavailable", payload: `{"error":{"status":"UNAVAILABLE","message":"try later"}}`, wantContent: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "responses failed", payload: `data: {"type":"response.failed","response":{"error":{"code":"server_error"}}}`, wantContent: true, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasContent, err := classifyStreamChunk([]byte(tt.payload))
+			if hasContent != tt.wantContent {
+				t.Errorf("hasContent = %v, want %v", hasContent, tt.wantContent)
+			}
+			if status := statusCodeFromError(err); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
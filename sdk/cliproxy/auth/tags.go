@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+// RequiredTagsMetadataKey is the options metadata key carrying the tags a credential must have
+// to serve a request. The value is a []string.
+const RequiredTagsMetadataKey = "required_tags"
+
+// ParseTags splits a comma or whitespace separated tag list into lowercase, de-duplicated tags.
+func ParseTags(raw string) []string {
+	return NormalizeTags(strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}))
+}
+
+// NormalizeTags lowercases, trims and de-duplicates the given tag lists, returning them sorted.
+func NormalizeTags(lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" {
+				continue
+			}
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			out = append(out, tag)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Tags returns the routing tags of the auth, taken from the "tags" attribute (comma separated)
+// and the "tags" metadata field (list or comma separated string).
+func (a *Auth) Tags() []string {
+	if a == nil {
+		return nil
+	}
+	var lists [][]string
+	if a.Attributes != nil {
+		lists = append(lists, ParseTags(a.Attributes["tags"]))
+	}
+	if a.Metadata != nil {
+		switch v := a.Metadata["tags"].(type) {
+		case string:
+			lists = append(lists, ParseTags(v))
+		case []string:
+			lists = append(lists, v)
+		case []any:
+			list := make([]string, 0, len(v))
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					list = append(list, s)
+				}
+			}
+			lists = append(lists, list)
+		}
+	}
+	return NormalizeTags(lists...)
+}
+
+// requiredTags extracts the tags a request demands from its options metadata.
+func requiredTags(opts cliproxyexecutor.Options) []string {
+	if opts.Metadata == nil {
+		return nil
+	}
+	switch v := opts.Metadata[RequiredTagsMetadataKey].(type) {
+	case []string:
+		return NormalizeTags(v)
+	case string:
+		return ParseTags(v)
+	default:
+		return nil
+	}
+}
+
+// filterByTags keeps the candidates carrying every required tag.
+func filterByTags(candidates []*Auth, required []string) []*Auth {
+	if len(required) == 0 {
+		return candidates
+	}
+	filtered := candidates[:0]
+	for _, candidate := range candidates {
+		tags := candidate.Tags()
+		matched := true
+		for _, tag := range required {
+			idx := sort.SearchStrings(tags, tag)
+			if idx >= len(tags) || tags[idx] != tag {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filtered = append(filtered, candidate)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cliproxyexecutor "cliproxy/sdk/cliproxy/executor"
+)
+
+func TestAuthTags(t *testing.T) {
+	a := &Auth{
+		Attributes: map[string]string{"tags": "Production, paid"},
+		Metadata:   map[string]any{"tags": []any{"paid", "eu"}},
+	}
+	if got, want := a.Tags(), []string{"eu", "paid", "production"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tags() = %v, want %v", got, want)
+	}
+}
+
+func TestPickNext_FiltersByRequiredTags(t *testing.T) {
+	m := NewManager(nil, &RoundRobinSelector{}, nil)
+	m.RegisterExecutor(modelEchoExecutor{})
+	ctx := context.Background()
+	for _, a := range []*Auth{
+		{ID: "free", Provider: "fallback-test", Status: StatusActive, Attributes: map[string]string{"tags": "experimental"}},
+		{ID: "paid", Provider: "fallback-test", Status: StatusActive, Attributes: map[string]string{"tags": "production,paid"}},
+	} {
+		if _, err := m.Register(ctx, a); err != nil {
+			t.Fatalf("register %s: %v", a.ID, err)
+		}
+	}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{RequiredTagsMetadataKey: []string{"production"}}}
+	for i := 0; i < 3; i++ {
+		got, _, err := m.pickNext(ctx, "fallback-test", "", opts, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("pickNext: %v", err)
+		}
+		if got.ID != "paid" {
+			t.Fatalf("picked %s, want paid", got.ID)
+		}
+	}
+
+	opts.Metadata[RequiredTagsMetadataKey] = []string{"production", "experimental"}
+	_, _, err := m.pickNext(ctx, "fallback-test", "", opts, map[string]struct{}{})
+	if err == nil || statusCodeFromError(err) != 503 {
+		t.Fatalf("expected 503 when no auth carries all tags, got %v", err)
+	}
+}
+
+func TestPickNext_HonoursPinnedAuth(t *testing.T) {
+	m := NewManager(nil, &RoundRobinSelector{}, nil)
+	m.RegisterExecutor(modelEchoExecutor{})
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := m.Register(ctx, &Auth{ID: id, Provider: "fallback-test", Status: StatusActive}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{PinnedAuthMetadataKey: "b"}}
+	for i := 0; i < 3; i++ {
+		got, _, err := m.pickNext(ctx, "fallback-test", "", opts, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("pickNext: %v", err)
+		}
+		if got.ID != "b" {
+			t.Fatalf("picked %s, want b", got.ID)
+		}
+	}
+
+	_, _, err := m.pickNext(ctx, "fallback-test", "", opts, map[string]struct{}{"b": {}})
+	if err == nil || statusCodeFromError(err) != 503 {
+		t.Fatalf("expected 503 once the pinned auth was tried, got %v", err)
+	}
+}
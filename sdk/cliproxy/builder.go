@@ -5,7 +5,6 @@ package cliproxy
 
 import (
 	"fmt"
-	"strings"
 
 	"cliproxy/internal/api"
 	"cliproxy/internal/config"
@@ -200,23 +199,14 @@ func (b *Builder) Build() (*Service, error) {
 			dirSetter.SetBaseDir(b.cfg.AuthDir)
 		}
 
-		strategy := ""
-		if b.cfg != nil {
-			strategy = strings.ToLower(strings.TrimSpace(b.cfg.Routing.Strategy))
-		}
-		var selector coreauth.Selector
-		switch strategy {
-		case "fill-first", "fillfirst", "ff":
-			selector = &coreauth.FillFirstSelector{}
-		default:
-			selector = &coreauth.RoundRobinSelector{}
-		}
+		selector := newSelector(b.cfg)
 
 		coreManager = coreauth.NewManager(tokenStore, selector, nil)
 	}
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetOAuthModelMappings(b.cfg.OAuthModelMappings)
+	coreManager.SetModelFallbacks(b.cfg.ModelFallbacks)
 
 	service := &Service{
 		cfg:            b.cfg,
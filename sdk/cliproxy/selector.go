@@ -0,0 +1,120 @@
+package cliproxy
+
+import (
+	"strings"
+	"time"
+
+	"cliproxy/internal/config"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// selectorStrategy resolves the credential selection strategy. An explicit routing.strategy
+// wins; otherwise an explicit scheduling.strategy selects one of the unified strategies.
+// With neither set, credentials are rotated round-robin.
+func selectorStrategy(cfg *config.Config) string {
+	if cfg == nil {
+		return "round-robin"
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Routing.Strategy)) {
+	case "fill-first", "fillfirst", "ff":
+		return "fill-first"
+	case "":
+	default:
+		return "round-robin"
+	}
+	switch strategy := strings.ToLower(strings.TrimSpace(cfg.Scheduling.Strategy)); strategy {
+	case "priority", "load-balance", "weight", "sticky":
+		return strategy
+	default:
+		return "round-robin"
+	}
+}
+
+// stickyOptions converts the scheduling.sticky block into selector options.
+func stickyOptions(cfg *config.Config) coreauth.StickyOptions {
+	if cfg == nil {
+		return coreauth.StickyOptions{}
+	}
+	sticky := cfg.Scheduling.Sticky
+	return coreauth.StickyOptions{
+		TTL:             time.Duration(sticky.TTLSeconds) * time.Second,
+		MaxEntries:      sticky.MaxEntries,
+		RebalanceFactor: sticky.RebalanceFactor,
+	}
+}
+
+// newSelector builds the selector for the configured strategy.
+func newSelector(cfg *config.Config) coreauth.Selector {
+	switch strategy := selectorStrategy(cfg); strategy {
+	case "fill-first":
+		return &coreauth.FillFirstSelector{}
+	case "round-robin":
+		return &coreauth.RoundRobinSelector{}
+	default:
+		selector := coreauth.NewUnifiedSelector(strategy)
+		selector.SetStickyOptions(stickyOptions(cfg))
+		return selector
+	}
+}
+
+// applySelector updates the manager's selector after a config change. A unified selector is
+// updated in place so its sticky bindings and cursors survive reloads; other selectors are
+// only replaced when the resolved strategy changes.
+func applySelector(manager *coreauth.Manager, previous, next *config.Config) {
+	if manager == nil || next == nil {
+		return
+	}
+	strategy := selectorStrategy(next)
+	unifiedStrategy := strategy != "fill-first" && strategy != "round-robin"
+	if current := stickySelector(manager); current != nil && unifiedStrategy {
+		current.SetStickyOptions(stickyOptions(next))
+		if current.Strategy() != strategy {
+			current.SetStrategy(strategy)
+			log.Infof("routing strategy updated to %s", strategy)
+		}
+		return
+	}
+	if selectorStrategy(previous) == strategy {
+		return
+	}
+	manager.SetSelector(newSelector(next))
+	log.Infof("routing strategy updated to %s", strategy)
+}
+
+// stickySelector returns the manager's unified selector, if one is active.
+func stickySelector(manager *coreauth.Manager) *coreauth.UnifiedSelector {
+	if manager == nil {
+		return nil
+	}
+	selector, _ := manager.Selector().(*coreauth.UnifiedSelector)
+	return selector
+}
+
+// loadStickyBindings restores persisted sticky bindings when scheduling.sticky.persist-file is set.
+func (s *Service) loadStickyBindings() {
+	selector := stickySelector(s.coreManager)
+	if selector == nil || s.cfg == nil || s.cfg.Scheduling.Sticky.PersistFile == "" {
+		return
+	}
+	path := s.cfg.Scheduling.Sticky.PersistFile
+	if err := selector.LoadStickyBindings(path); err != nil {
+		log.Warnf("failed to restore sticky bindings from %s: %v", path, err)
+		return
+	}
+	log.Debugf("restored %d sticky bindings from %s", len(selector.StickyBindings()), path)
+}
+
+// saveStickyBindings persists sticky bindings when scheduling.sticky.persist-file is set.
+func (s *Service) saveStickyBindings() {
+	selector := stickySelector(s.coreManager)
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	if selector == nil || cfg == nil || cfg.Scheduling.Sticky.PersistFile == "" {
+		return
+	}
+	if err := selector.SaveStickyBindings(cfg.Scheduling.Sticky.PersistFile); err != nil {
+		log.Errorf("failed to persist sticky bindings: %v", err)
+	}
+}
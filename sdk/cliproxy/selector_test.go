@@ -0,0 +1,30 @@
+package cliproxy
+
+import (
+	"testing"
+
+	"cliproxy/internal/config"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+func TestNewSelectorDefaultsToRoundRobin(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SanitizeProviders()
+	if got := selectorStrategy(cfg); got != "round-robin" {
+		t.Fatalf("strategy = %q, want round-robin", got)
+	}
+	if _, ok := newSelector(cfg).(*coreauth.RoundRobinSelector); !ok {
+		t.Fatalf("selector = %T, want *RoundRobinSelector", newSelector(cfg))
+	}
+
+	cfg.Scheduling.Strategy = "priority"
+	cfg.SanitizeProviders()
+	if _, ok := newSelector(cfg).(*coreauth.UnifiedSelector); !ok {
+		t.Fatalf("explicit priority strategy built %T, want *UnifiedSelector", newSelector(cfg))
+	}
+
+	cfg.Routing.Strategy = "fill-first"
+	if _, ok := newSelector(cfg).(*coreauth.FillFirstSelector); !ok {
+		t.Fatalf("routing.strategy should win, got %T", newSelector(cfg))
+	}
+}
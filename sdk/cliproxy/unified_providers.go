@@ -0,0 +1,147 @@
+package cliproxy
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"cliproxy/internal/config"
+	"cliproxy/internal/registry"
+	coreauth "cliproxy/sdk/cliproxy/auth"
+)
+
+// resolveUnifiedProvider finds the providers: entry an auth was synthesized from.
+func (s *Service) resolveUnifiedProvider(providerID string) *config.UnifiedProvider {
+	if s.cfg == nil || providerID == "" {
+		return nil
+	}
+	for i := range s.cfg.Providers {
+		if s.cfg.Providers[i].ID == providerID {
+			return &s.cfg.Providers[i]
+		}
+	}
+	return nil
+}
+
+// registerUnifiedProviderModels registers the models a providers: entry exposes, honouring its
+// include/exclude patterns and aliases.
+func (s *Service) registerUnifiedProviderModels(a *coreauth.Auth, providerID string) {
+	p := s.resolveUnifiedProvider(providerID)
+	if p == nil {
+		GlobalModelRegistry().UnregisterClient(a.ID)
+		return
+	}
+	models := buildUnifiedProviderModels(p)
+	if len(models) == 0 {
+		GlobalModelRegistry().UnregisterClient(a.ID)
+		return
+	}
+	GlobalModelRegistry().RegisterClient(a.ID, strings.ToLower(strings.TrimSpace(a.Provider)), applyModelPrefixes(models, a.Prefix, s.cfg.ForceModelPrefix))
+}
+
+// unifiedProviderCatalog returns the built-in model list for a provider type. OpenAI-compatible
+// providers have no catalog; their models come from include and alias entries.
+func unifiedProviderCatalog(providerType string) (models []*ModelInfo, ownedBy, modelType string) {
+	switch providerType {
+	case "gemini":
+		return registry.GetGeminiModels(), "google", "gemini"
+	case "vertex":
+		return registry.GetGeminiVertexModels(), "google", "vertex"
+	case "claude":
+		return registry.GetClaudeModels(), "anthropic", "claude"
+	case "codex":
+		return registry.GetOpenAIModels(), "openai", "openai"
+	default:
+		return nil, providerType, "openai-compatibility"
+	}
+}
+
+// buildUnifiedProviderModels applies a provider's model rules to its catalog. Include patterns
+// narrow the catalog (literal names outside it are added), exclude patterns remove models, and
+// each alias adds a client-facing model served by its upstream model.
+func buildUnifiedProviderModels(p *config.UnifiedProvider) []*ModelInfo {
+	catalog, ownedBy, modelType := unifiedProviderCatalog(config.NormalizeProviderType(p.Type))
+	now := time.Now().Unix()
+	newModel := func(id string) *ModelInfo {
+		info := &ModelInfo{ID: id, Object: "model", Created: now, OwnedBy: ownedBy, Type: modelType, DisplayName: id}
+		if upstream := registry.LookupStaticModelInfo(id); upstream != nil {
+			info.Thinking = upstream.Thinking
+		}
+		return info
+	}
+
+	seen := make(map[string]struct{})
+	var models []*ModelInfo
+	include := trimmedLower(p.Models.Include)
+	for _, m := range catalog {
+		if m == nil {
+			continue
+		}
+		if len(include) > 0 && !matchesAny(include, strings.ToLower(m.ID)) {
+			continue
+		}
+		seen[strings.ToLower(m.ID)] = struct{}{}
+		models = append(models, m)
+	}
+	for _, raw := range p.Models.Include {
+		name := strings.TrimSpace(raw)
+		if name == "" || strings.Contains(name, "*") {
+			continue
+		}
+		if _, ok := seen[strings.ToLower(name)]; ok {
+			continue
+		}
+		seen[strings.ToLower(name)] = struct{}{}
+		models = append(models, newModel(name))
+	}
+	models = applyExcludedModels(models, p.Models.Exclude)
+
+	aliases := make([]string, 0, len(p.Models.Alias))
+	for client := range p.Models.Alias {
+		aliases = append(aliases, client)
+	}
+	sort.Strings(aliases)
+	for _, client := range aliases {
+		upstream := strings.TrimSpace(p.Models.Alias[client])
+		client = strings.TrimSpace(client)
+		if client == "" || upstream == "" {
+			continue
+		}
+		if _, ok := seen[strings.ToLower(client)]; ok {
+			continue
+		}
+		seen[strings.ToLower(client)] = struct{}{}
+		info := newModel(client)
+		for _, m := range catalog {
+			if m != nil && strings.EqualFold(m.ID, upstream) {
+				clone := *m
+				clone.ID = client
+				clone.DisplayName = client
+				clone.Name = rewriteModelInfoName(m.Name, m.ID, client)
+				info = &clone
+				break
+			}
+		}
+		models = append(models, info)
+	}
+	return models
+}
+
+func trimmedLower(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if trimmed := strings.ToLower(strings.TrimSpace(v)); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchWildcard(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
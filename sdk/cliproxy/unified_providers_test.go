@@ -0,0 +1,40 @@
+package cliproxy
+
+import (
+	"testing"
+
+	"cliproxy/internal/config"
+)
+
+func TestBuildUnifiedProviderModels(t *testing.T) {
+	p := &config.UnifiedProvider{
+		Type: "claude",
+		Models: config.ProviderModelConfig{
+			Include: []string{"claude-sonnet-*", "claude-custom"},
+			Exclude: []string{"claude-sonnet-4-20250514"},
+			Alias:   map[string]string{"sonnet": "claude-sonnet-4-5-20250929"},
+		},
+	}
+	models := buildUnifiedProviderModels(p)
+	ids := make(map[string]bool, len(models))
+	for _, m := range models {
+		ids[m.ID] = true
+		if m.ID != "claude-custom" && m.ID != "sonnet" && !matchWildcard("claude-sonnet-*", m.ID) {
+			t.Fatalf("model %s should not pass include filter", m.ID)
+		}
+	}
+	if ids["claude-sonnet-4-20250514"] {
+		t.Fatal("excluded model still registered")
+	}
+	if !ids["claude-custom"] || !ids["sonnet"] {
+		t.Fatalf("literal include or alias missing: %v", ids)
+	}
+
+	compat := buildUnifiedProviderModels(&config.UnifiedProvider{
+		Type:   "openai-compatibility",
+		Models: config.ProviderModelConfig{Alias: map[string]string{"kimi-k2": "moonshotai/kimi-k2:free"}},
+	})
+	if len(compat) != 1 || compat[0].ID != "kimi-k2" || compat[0].Type != "openai-compatibility" {
+		t.Fatalf("unexpected compat models: %+v", compat)
+	}
+}
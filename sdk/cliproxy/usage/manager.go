@@ -17,10 +17,30 @@ type Record struct {
 	AuthIndex   string
 	Source      string
 	RequestedAt time.Time
+	// FirstChunkAt is when the first response chunk was emitted; zero for non-streaming requests.
+	FirstChunkAt time.Time
+	// CompletedAt is when the upstream response finished.
+	CompletedAt time.Time
 	Failed      bool
 	Detail      Detail
 }
 
+// Latency returns the total request duration, or zero when timing was not captured.
+func (r Record) Latency() time.Duration {
+	if r.RequestedAt.IsZero() || r.CompletedAt.Before(r.RequestedAt) {
+		return 0
+	}
+	return r.CompletedAt.Sub(r.RequestedAt)
+}
+
+// TimeToFirstChunk returns the delay before the first streamed chunk, or zero when unknown.
+func (r Record) TimeToFirstChunk() time.Duration {
+	if r.RequestedAt.IsZero() || r.FirstChunkAt.Before(r.RequestedAt) {
+		return 0
+	}
+	return r.FirstChunkAt.Sub(r.RequestedAt)
+}
+
 // Detail holds the token usage breakdown.
 type Detail struct {
 	InputTokens     int64
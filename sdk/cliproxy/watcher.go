@@ -5,6 +5,7 @@ import (
 
 	"cliproxy/internal/config"
 	"cliproxy/internal/watcher"
+	sdkAuth "cliproxy/sdk/auth"
 	coreauth "cliproxy/sdk/cliproxy/auth"
 )
 
@@ -13,6 +14,9 @@ func defaultWatcherFactory(configPath, authDir string, reload func(*config.Confi
 	if err != nil {
 		return nil, err
 	}
+	if store, ok := sdkAuth.GetTokenStore().(*sdkAuth.SQLiteTokenStore); ok {
+		w.SetRecordStore(store)
+	}
 
 	return &WatcherWrapper{
 		start: func(ctx context.Context) error {
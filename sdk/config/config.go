@@ -20,6 +20,9 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// APIKeyPolicies attaches model allowlists, rate limits and token budgets to client API keys.
+	APIKeyPolicies []APIKeyPolicy `yaml:"api-key-policies,omitempty" json:"api-key-policies,omitempty"`
+
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
 
@@ -29,6 +32,10 @@ type SDKConfig struct {
 	// OAuthModelMappings maps model IDs to specific OAuth providers.
 	OAuthModelMappings map[string]map[string]ModelNameMapping `yaml:"oauth-model-mappings" json:"oauth-model-mappings"`
 
+	// ModelFallbacks maps a requested model to the models tried, in order, when every
+	// credential for it is cooling down.
+	ModelFallbacks map[string][]string `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
 	// GeminiKey defines Gemini API key configurations with optional routing overrides.
 	GeminiKey []GeminiKey `yaml:"gemini-api-key" json:"gemini-api-key"`
 
@@ -51,6 +58,12 @@ type SDKConfig struct {
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// ResponseCache configures caching of completion responses for identical requests.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+
+	// ResponsesStore configures server-side conversation state for the OpenAI Responses API.
+	ResponsesStore ResponsesStoreConfig `yaml:"responses-store,omitempty" json:"responses-store,omitempty"`
+
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
@@ -125,6 +138,77 @@ func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
 	return provider
 }
 
+// APIKeyPolicy restricts what a single client API key may do.
+// Zero values disable the corresponding limit.
+type APIKeyPolicy struct {
+	// APIKey is the client key the policy applies to. It must also be listed in api-keys.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// AllowedModels lists model name patterns the key may request ("*" wildcards supported).
+	// An empty list allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// RequestsPerMinute caps the number of requests accepted within a sliding one-minute window.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// TokensPerDay caps the total tokens consumed per calendar day.
+	TokensPerDay int64 `yaml:"tokens-per-day,omitempty" json:"tokens-per-day,omitempty"`
+
+	// TokensPerMonth caps the total tokens consumed per calendar month.
+	TokensPerMonth int64 `yaml:"tokens-per-month,omitempty" json:"tokens-per-month,omitempty"`
+
+	// Tags pins the key to credentials carrying all of these tags.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// ResponseCacheConfig controls the completion response cache.
+type ResponseCacheConfig struct {
+	// Enable turns the cache on.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend selects the storage backend: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Dir is the directory used by the disk backend. Defaults to data/response-cache under the working directory (or WRITABLE_PATH).
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// TTLSeconds is how long an entry stays valid. Defaults to 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the number of stored responses; the least recently used entries are evicted first.
+	// Defaults to 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// SharedAcrossKeys lets clients with different API keys share cached responses.
+	// By default entries are scoped to the client API key that produced them.
+	SharedAcrossKeys bool `yaml:"shared-across-keys,omitempty" json:"shared-across-keys,omitempty"`
+}
+
+// ResponsesStoreConfig controls how /v1/responses results are kept for previous_response_id,
+// GET /v1/responses/{id} and input item listing. Responses are stored unless the request sets
+// "store": false.
+type ResponsesStoreConfig struct {
+	// Disable turns the store off; previous_response_id is then passed through untouched.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// Backend selects the storage backend: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Dir is the directory used by the disk backend. Defaults to data/responses under the working directory (or WRITABLE_PATH).
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// TTLSeconds is how long a stored response can be retrieved or continued. Defaults to 30 days.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the number of stored responses; the least recently used are evicted first.
+	// Defaults to 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// MaxBytes caps the total size of the stored responses; the least recently used are evicted
+	// first and a single larger response is not stored. Defaults to 256 MiB.
+	MaxBytes int64 `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`
+}
+
 // ModelNameMapping defines a model ID mapping for a specific channel.
 type ModelNameMapping struct {
 	Name  string `yaml:"name" json:"name"`
@@ -159,6 +243,8 @@ type RoutingRule struct {
 	Model string `yaml:"model" json:"model"`
 	// Priority defines the preferred provider order for this rule.
 	Priority []string `yaml:"priority" json:"priority"`
+	// Tags restricts matching requests to credentials carrying all of these tags.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
 }
 
 // ClaudeKey represents the configuration for a Claude API key.
@@ -9,4 +9,9 @@ const (
 	FormatGeminiCLI      Format = "gemini-cli"
 	FormatCodex          Format = "codex"
 	FormatAntigravity    Format = "antigravity"
+
+	// Embedding formats. Gemini embeddings use the batchEmbedContents shape; single
+	// embedContent calls are wrapped into a one-element batch by the handler.
+	FormatOpenAIEmbedding Format = "openai-embedding"
+	FormatGeminiEmbedding Format = "gemini-embedding"
 )
@@ -2,6 +2,7 @@ package translator
 
 import (
 	"context"
+	"sort"
 	"sync"
 )
 
@@ -38,6 +39,45 @@ func (r *Registry) Register(from, to Format, request RequestTransform, response
 	r.responses[from][to] = response
 }
 
+// Pair describes the transforms registered from one format to another. From is the client
+// format and To the upstream format; response transforms convert To back into From.
+type Pair struct {
+	From       Format
+	To         Format
+	Request    bool
+	Stream     bool
+	NonStream  bool
+	TokenCount bool
+}
+
+// Pairs lists every registered format pair, sorted by source and target format.
+func (r *Registry) Pairs() []Pair {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pairs []Pair
+	for from, byTarget := range r.responses {
+		for to, response := range byTarget {
+			_, hasRequest := r.requests[from][to]
+			pairs = append(pairs, Pair{
+				From:       from,
+				To:         to,
+				Request:    hasRequest,
+				Stream:     response.Stream != nil,
+				NonStream:  response.NonStream != nil,
+				TokenCount: response.TokenCount != nil,
+			})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
+}
+
 // TranslateRequest converts a payload between schemas, returning the original payload
 // if no translator is registered.
 func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
@@ -116,6 +156,11 @@ func Register(from, to Format, request RequestTransform, response ResponseTransf
 	defaultRegistry.Register(from, to, request, response)
 }
 
+// Pairs lists the format pairs of the default registry.
+func Pairs() []Pair {
+	return defaultRegistry.Pairs()
+}
+
 // TranslateRequest is a helper on the default registry.
 func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
 	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	_ "cliproxy/internal/translator"
+
+	sdktranslator "cliproxy/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const structuredOutputSchema = `{"type":"object","properties":{"city":{"type":"string","minLength":1},"kind":{"const":"weather"}},"required":["city"],"additionalProperties":false}`
+
+func TestStructuredOutput_GeminiRequests(t *testing.T) {
+	chat := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"w","strict":true,"schema":` + structuredOutputSchema + `}}}`)
+	responses := []byte(`{"model":"m","input":"hi","text":{"format":{"type":"json_schema","name":"w","strict":true,"schema":` + structuredOutputSchema + `}}}`)
+
+	cases := []struct {
+		from, to string
+		raw      []byte
+		prefix   string
+	}{
+		{"openai", "gemini", chat, "generationConfig"},
+		{"openai", "gemini-cli", chat, "request.generationConfig"},
+		{"openai", "antigravity", chat, "request.generationConfig"},
+		{"openai-response", "gemini", responses, "generationConfig"},
+		{"openai-response", "gemini-cli", responses, "request.generationConfig"},
+		{"openai-response", "antigravity", responses, "request.generationConfig"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.from+"->"+tc.to, func(t *testing.T) {
+			body := sdktranslator.TranslateRequest(sdktranslator.FromString(tc.from), sdktranslator.FromString(tc.to), "gemini-2.5-pro", tc.raw, false)
+			cfg := gjson.GetBytes(body, tc.prefix)
+			if cfg.Get("responseMimeType").String() != "application/json" {
+				t.Fatalf("responseMimeType missing: %s", body)
+			}
+			schema := cfg.Get("responseJsonSchema")
+			if schema.Get("properties.city.type").String() != "string" || schema.Get("required.0").String() != "city" {
+				t.Fatalf("responseJsonSchema = %s", schema.Raw)
+			}
+			if schema.Get("additionalProperties").Exists() || schema.Get("properties.city.minLength").Exists() {
+				t.Fatalf("unsupported keywords not cleaned: %s", schema.Raw)
+			}
+			if schema.Get("properties.reason").Exists() || schema.Get("properties._").Exists() {
+				t.Fatalf("response schema must not get placeholder properties: %s", schema.Raw)
+			}
+		})
+	}
+
+	jsonObject := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_object"}}`)
+	body := sdktranslator.TranslateRequest(sdktranslator.FromString("openai"), sdktranslator.FromString("gemini"), "gemini-2.5-pro", jsonObject, false)
+	if gjson.GetBytes(body, "generationConfig.responseMimeType").String() != "application/json" || gjson.GetBytes(body, "generationConfig.responseJsonSchema").Exists() {
+		t.Fatalf("json_object translated to %s", body)
+	}
+}
+
+func TestStructuredOutput_ClaudeRequest(t *testing.T) {
+	raw := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"w","schema":` + structuredOutputSchema + `}}}`)
+	body := sdktranslator.TranslateRequest(sdktranslator.FromString("openai"), sdktranslator.FromString("claude"), "claude-sonnet-4-5", raw, false)
+
+	tool := gjson.GetBytes(body, "tools.0")
+	if tool.Get("name").String() != "structured_output" || tool.Get("input_schema.properties.city.type").String() != "string" {
+		t.Fatalf("structured output tool = %s", tool.Raw)
+	}
+	if gjson.GetBytes(body, "tool_choice.type").String() != "tool" || gjson.GetBytes(body, "tool_choice.name").String() != "structured_output" {
+		t.Fatalf("tool_choice = %s", gjson.GetBytes(body, "tool_choice").Raw)
+	}
+
+	withTools := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object"}}}],"response_format":{"type":"json_object"}}`)
+	body = sdktranslator.TranslateRequest(sdktranslator.FromString("openai"), sdktranslator.FromString("claude"), "claude-sonnet-4-5", withTools, false)
+	if n := len(gjson.GetBytes(body, "tools").Array()); n != 2 || gjson.GetBytes(body, "tool_choice.type").String() != "any" {
+		t.Fatalf("client tools should stay callable, got %s", body)
+	}
+}
+
+var structuredOutputClaudeStream = []string{
+	`data: {"type":"message_start","message":{"id":"msg_1","model":"claude","usage":{"input_tokens":5,"output_tokens":0}}}`,
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+	`data: {"type":"content_block_stop","index":0}`,
+	`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+	`data: {"type":"message_stop"}`,
+}
+
+func TestStructuredOutput_ClaudeChatResponse(t *testing.T) {
+	original := []byte(`{"model":"m","messages":[],"response_format":{"type":"json_object"}}`)
+	from, to := sdktranslator.FromString("claude"), sdktranslator.FromString("openai")
+
+	var param any
+	var content strings.Builder
+	finish := ""
+	for _, line := range structuredOutputClaudeStream {
+		for _, chunk := range sdktranslator.TranslateStream(context.Background(), from, to, "m", original, original, []byte(line), &param) {
+			if gjson.Get(chunk, "choices.0.delta.tool_calls").Exists() {
+				t.Fatalf("structured output leaked as a tool call: %s", chunk)
+			}
+			content.WriteString(gjson.Get(chunk, "choices.0.delta.content").String())
+			if r := gjson.Get(chunk, "choices.0.finish_reason").String(); r != "" {
+				finish = r
+			}
+		}
+	}
+	if content.String() != `{"city":"Paris"}` || finish != "stop" {
+		t.Fatalf("stream content = %q, finish_reason = %q", content.String(), finish)
+	}
+
+	out := sdktranslator.TranslateNonStream(context.Background(), from, to, "m", original, original, []byte(strings.Join(structuredOutputClaudeStream, "\n")), nil)
+	msg := gjson.Get(out, "choices.0.message")
+	if msg.Get("content").String() != `{"city":"Paris"}` || msg.Get("tool_calls").Exists() || gjson.Get(out, "choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("non-stream response = %s", out)
+	}
+}
+
+func TestStructuredOutput_ClaudeResponsesResponse(t *testing.T) {
+	original := []byte(`{"model":"m","input":"hi","text":{"format":{"type":"json_object"}}}`)
+	from, to := sdktranslator.FromString("claude"), sdktranslator.FromString("openai-response")
+
+	var param any
+	var completed string
+	for _, line := range structuredOutputClaudeStream {
+		for _, event := range sdktranslator.TranslateStream(context.Background(), from, to, "m", original, original, []byte(line), &param) {
+			if strings.Contains(event, "function_call") {
+				t.Fatalf("structured output leaked as a function call: %s", event)
+			}
+			if strings.HasPrefix(event, "event: response.completed") {
+				completed = event[strings.Index(event, "data: ")+len("data: "):]
+			}
+		}
+	}
+	if text := gjson.Get(completed, "response.output.0.content.0.text").String(); text != `{"city":"Paris"}` {
+		t.Fatalf("completed output = %s", gjson.Get(completed, "response.output").Raw)
+	}
+
+	out := sdktranslator.TranslateNonStream(context.Background(), from, to, "m", original, original, []byte(strings.Join(structuredOutputClaudeStream, "\n")), nil)
+	if item := gjson.Get(out, "output.0"); item.Get("type").String() != "message" || item.Get("content.0.text").String() != `{"city":"Paris"}` {
+		t.Fatalf("non-stream output = %s", gjson.Get(out, "output").Raw)
+	}
+}
+
+var wrappedStructuredOutputClaudeStream = []string{
+	`data: {"type":"message_start","message":{"id":"msg_2","model":"claude","usage":{"input_tokens":5,"output_tokens":0}}}`,
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_2","name":"structured_output","input":{}}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"value\":[\"Paris\","}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"Lyon\"]}"}}`,
+	`data: {"type":"content_block_stop","index":0}`,
+	`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+	`data: {"type":"message_stop"}`,
+}
+
+func TestStructuredOutput_ClaudeWrapsNonObjectSchema(t *testing.T) {
+	const listSchema = `{"type":"array","items":{"type":"string"}}`
+	chat := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"cities","schema":` + listSchema + `}}}`)
+	body := sdktranslator.TranslateRequest(sdktranslator.FromString("openai"), sdktranslator.FromString("claude"), "claude-sonnet-4-5", chat, false)
+	schema := gjson.GetBytes(body, "tools.0.input_schema")
+	if schema.Get("type").String() != "object" || schema.Get("properties.value.type").String() != "array" || schema.Get("required.0").String() != "value" {
+		t.Fatalf("wrapped input_schema = %s", schema.Raw)
+	}
+
+	claude := sdktranslator.FromString("claude")
+	var param any
+	var content strings.Builder
+	for _, line := range wrappedStructuredOutputClaudeStream {
+		for _, chunk := range sdktranslator.TranslateStream(context.Background(), claude, sdktranslator.FromString("openai"), "m", chat, chat, []byte(line), &param) {
+			content.WriteString(gjson.Get(chunk, "choices.0.delta.content").String())
+		}
+	}
+	if content.String() != `["Paris","Lyon"]` {
+		t.Fatalf("chat stream content = %q", content.String())
+	}
+	out := sdktranslator.TranslateNonStream(context.Background(), claude, sdktranslator.FromString("openai"), "m", chat, chat, []byte(strings.Join(wrappedStructuredOutputClaudeStream, "\n")), nil)
+	if got := gjson.Get(out, "choices.0.message.content").String(); got != `["Paris","Lyon"]` {
+		t.Fatalf("chat non-stream content = %q", got)
+	}
+
+	responses := []byte(`{"model":"m","input":"hi","text":{"format":{"type":"json_schema","name":"cities","schema":` + listSchema + `}}}`)
+	param = nil
+	var completed string
+	for _, line := range wrappedStructuredOutputClaudeStream {
+		for _, event := range sdktranslator.TranslateStream(context.Background(), claude, sdktranslator.FromString("openai-response"), "m", responses, responses, []byte(line), &param) {
+			if strings.HasPrefix(event, "event: response.completed") {
+				completed = event[strings.Index(event, "data: ")+len("data: "):]
+			}
+		}
+	}
+	if text := gjson.Get(completed, "response.output.0.content.0.text").String(); text != `["Paris","Lyon"]` {
+		t.Fatalf("responses completed output = %s", gjson.Get(completed, "response.output").Raw)
+	}
+	out = sdktranslator.TranslateNonStream(context.Background(), claude, sdktranslator.FromString("openai-response"), "m", responses, responses, []byte(strings.Join(wrappedStructuredOutputClaudeStream, "\n")), nil)
+	if text := gjson.Get(out, "output.0.content.0.text").String(); text != `["Paris","Lyon"]` {
+		t.Fatalf("responses non-stream output = %s", gjson.Get(out, "output").Raw)
+	}
+}
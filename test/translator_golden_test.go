@@ -0,0 +1,296 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "cliproxy/internal/translator"
+
+	"cliproxy/internal/registry"
+	sdktranslator "cliproxy/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Translator golden fixtures live under testdata/translator:
+//
+//	requests/<client format>/<case>.json       canonical client requests
+//	responses/<upstream format>/<case>.stream  upstream stream, one chunk per line as the executors feed it
+//	responses/<upstream format>/<case>.json    upstream non-stream response
+//	golden/<client format>/<upstream format>/  expected translator output for every pair
+//
+// Response cases run with the client request of the same name, or basic.json when there is none.
+// The Claude executor always streams from upstream for other client formats and hands the whole
+// SSE body to the non-stream translator, so those non-stream cases read the .stream fixtures.
+// Regenerate the golden files after an intended translator change with:
+//
+//	go test ./test -run TestTranslatorGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite translator golden fixtures")
+
+const (
+	goldenDir        = "testdata/translator"
+	goldenModel      = "golden-model"
+	goldenTokenCount = 42
+)
+
+var (
+	// Generated identifiers and timestamps differ per run; they are replaced before comparing.
+	goldenIDPattern       = regexp.MustCompile(`"(id|[A-Za-z_]+_id|[A-Za-z]+Id|[A-Za-z]+ID)"(\s*:\s*)"([^"\\]*)"`)
+	goldenTimePattern     = regexp.MustCompile(`"(created|created_at|createdAt)"(\s*:\s*)\d+`)
+	goldenDateTimePattern = regexp.MustCompile(`"(createTime)"(\s*:\s*)"[^"]*"`)
+)
+
+func TestTranslatorGolden(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	client := fmt.Sprintf("translator-golden-%d", time.Now().UnixNano())
+	reg.RegisterClient(client, "gemini", []*registry.ModelInfo{{
+		ID:       goldenModel,
+		Object:   "model",
+		OwnedBy:  "golden",
+		Thinking: &registry.ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: true, DynamicAllowed: true},
+	}})
+	defer reg.UnregisterClient(client)
+
+	pairs := sdktranslator.Pairs()
+	if len(pairs) == 0 {
+		t.Fatal("no translators registered")
+	}
+	registered := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		pair := pair
+		registered[filepath.Join(pair.From.String(), pair.To.String())] = true
+		t.Run(pair.From.String()+"->"+pair.To.String(), func(t *testing.T) {
+			runGoldenPair(t, pair)
+		})
+	}
+
+	// Golden directories of pairs that are no longer registered are stale.
+	dirs, _ := filepath.Glob(filepath.Join(goldenDir, "golden", "*", "*"))
+	for _, dir := range dirs {
+		rel, _ := filepath.Rel(filepath.Join(goldenDir, "golden"), dir)
+		if registered[rel] {
+			continue
+		}
+		if *updateGolden {
+			_ = os.RemoveAll(dir)
+			continue
+		}
+		t.Errorf("golden fixtures %s belong to a pair that is not registered", dir)
+	}
+}
+
+func runGoldenPair(t *testing.T, pair sdktranslator.Pair) {
+	// Executors pass the requested alt parameter, empty for SSE streams, to the Gemini translators.
+	ctx := context.WithValue(context.Background(), "alt", "")
+	requests := goldenInputs(t, filepath.Join(goldenDir, "requests", pair.From.String()), ".json")
+	if len(requests) == 0 {
+		t.Fatalf("no request fixtures for client format %s in %s", pair.From, filepath.Join(goldenDir, "requests", pair.From.String()))
+	}
+	if _, ok := requests["basic"]; !ok {
+		t.Fatalf("client format %s has no basic.json request fixture", pair.From)
+	}
+	out := filepath.Join(goldenDir, "golden", pair.From.String(), pair.To.String())
+	expected := map[string]bool{}
+	check := func(name, got string, inputs ...[]byte) {
+		expected[name] = true
+		compareGolden(t, filepath.Join(out, name), normalizeGolden(got, inputs...))
+	}
+
+	if pair.Request {
+		for _, name := range sortedKeys(requests) {
+			translated := sdktranslator.TranslateRequest(pair.From, pair.To, goldenModel, bytes.Clone(requests[name]), false)
+			check("request-"+name+".json", string(translated), requests[name])
+		}
+	}
+
+	// Response transforms are registered on the client format and convert from the upstream format.
+	upstreamStreams := pair.To.String() == "claude" && pair.From != pair.To
+	original := func(name string, stream bool) ([]byte, []byte) {
+		req, ok := requests[name]
+		if !ok {
+			req = requests["basic"]
+		}
+		return req, sdktranslator.TranslateRequest(pair.From, pair.To, goldenModel, bytes.Clone(req), stream)
+	}
+	if pair.Stream {
+		streams := goldenInputs(t, filepath.Join(goldenDir, "responses", pair.To.String()), ".stream")
+		if len(streams) == 0 {
+			t.Errorf("no stream fixtures for upstream format %s", pair.To)
+		}
+		for _, name := range sortedKeys(streams) {
+			req, translated := original(name, true)
+			var param any
+			var chunks []string
+			for _, line := range strings.Split(strings.TrimSuffix(string(streams[name]), "\n"), "\n") {
+				chunks = append(chunks, sdktranslator.TranslateStream(ctx, pair.To, pair.From, goldenModel, bytes.Clone(req), bytes.Clone(translated), []byte(line), &param)...)
+			}
+			var b strings.Builder
+			for _, chunk := range chunks {
+				b.WriteString(strings.TrimRight(chunk, "\n"))
+				b.WriteString("\n---\n")
+			}
+			check("stream-"+name+".txt", b.String(), req, streams[name])
+		}
+	}
+	if pair.NonStream {
+		ext := ".json"
+		if upstreamStreams {
+			ext = ".stream"
+		}
+		responses := goldenInputs(t, filepath.Join(goldenDir, "responses", pair.To.String()), ext)
+		if len(responses) == 0 {
+			t.Errorf("no non-stream fixtures for upstream format %s", pair.To)
+		}
+		for _, name := range sortedKeys(responses) {
+			req, translated := original(name, upstreamStreams)
+			var param any
+			got := sdktranslator.TranslateNonStream(ctx, pair.To, pair.From, goldenModel, bytes.Clone(req), translated, bytes.Clone(responses[name]), &param)
+			if path := goldenEmptyOutput(got); path != "" {
+				t.Errorf("nonstream-%s.json: translator produced no content at %s:\n%s", name, path, got)
+			}
+			check("nonstream-"+name+".json", got, req, responses[name])
+		}
+	}
+	if pair.TokenCount {
+		check("token-count.json", sdktranslator.TranslateTokenCount(ctx, pair.To, pair.From, goldenTokenCount, nil))
+	}
+
+	// Golden files no transform produces any more are stale.
+	files, _ := filepath.Glob(filepath.Join(out, "*"))
+	for _, file := range files {
+		if expected[filepath.Base(file)] {
+			continue
+		}
+		if *updateGolden {
+			_ = os.Remove(file)
+			continue
+		}
+		t.Errorf("stale golden fixture %s", file)
+	}
+}
+
+// goldenEmptyOutput returns the path of the choices, candidates or content in a non-stream
+// response that carries nothing, or "" when the response has output.
+func goldenEmptyOutput(out string) string {
+	root := gjson.Parse(out)
+	if root.Get("response.candidates").Exists() {
+		root = root.Get("response")
+	}
+	switch {
+	case root.Get("choices").Exists():
+		msg := root.Get("choices.0.message")
+		if msg.Get("content").String() == "" && !msg.Get("tool_calls").Exists() && msg.Get("reasoning_content").String() == "" {
+			return "choices.0.message"
+		}
+	case root.Get("candidates").Exists():
+		if len(root.Get("candidates.0.content.parts").Array()) == 0 {
+			return "candidates.0.content.parts"
+		}
+	case root.Get("object").String() == "response":
+		if len(root.Get("output").Array()) == 0 {
+			return "output"
+		}
+	case root.Get("content").IsArray():
+		if len(root.Get("content").Array()) == 0 {
+			return "content"
+		}
+	}
+	return ""
+}
+
+// goldenInputs reads the fixtures with ext in dir, keyed by case name.
+func goldenInputs(t *testing.T, dir, ext string) map[string][]byte {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		t.Fatalf("list %s: %v", dir, err)
+	}
+	inputs := make(map[string][]byte, len(files))
+	for _, file := range files {
+		data, errRead := os.ReadFile(file)
+		if errRead != nil {
+			t.Fatalf("read %s: %v", file, errRead)
+		}
+		inputs[strings.TrimSuffix(filepath.Base(file), ext)] = data
+	}
+	return inputs
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareGolden checks got against the golden file at path, or rewrites it with -update.
+func compareGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Errorf("missing golden fixture %s; run go test ./test -run TestTranslatorGolden -update", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if string(want) != got {
+		t.Errorf("%s differs from translator output:\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+// normalizeGolden indents JSON output and replaces identifiers the translators generate, keeping
+// identifiers copied from inputs, and timestamps with stable placeholders.
+func normalizeGolden(out string, inputs ...[]byte) string {
+	if trimmed := strings.TrimSpace(out); trimmed != "" && json.Valid([]byte(trimmed)) {
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(trimmed), "", "  ") == nil {
+			out = indented.String() + "\n"
+		}
+	}
+	generated := map[string]string{}
+	out = goldenIDPattern.ReplaceAllStringFunc(out, func(match string) string {
+		parts := goldenIDPattern.FindStringSubmatch(match)
+		value := parts[3]
+		if value == "" || goldenInputContains(inputs, value) {
+			return match
+		}
+		placeholder, ok := generated[value]
+		if !ok {
+			placeholder = fmt.Sprintf("<generated-%d>", len(generated)+1)
+			generated[value] = placeholder
+		}
+		return `"` + parts[1] + `"` + parts[2] + `"` + placeholder + `"`
+	})
+	out = goldenTimePattern.ReplaceAllString(out, `"$1"${2}0`)
+	return goldenDateTimePattern.ReplaceAllString(out, `"$1"${2}"<time>"`)
+}
+
+func goldenInputContains(inputs [][]byte, value string) bool {
+	for _, input := range inputs {
+		if bytes.Contains(input, []byte(`"`+value+`"`)) {
+			return true
+		}
+	}
+	return false
+}